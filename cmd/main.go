@@ -8,12 +8,23 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"go-form-hub/internal/api"
 	"go-form-hub/internal/config"
 	"go-form-hub/internal/database"
 	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/apitoken"
 	"go-form-hub/internal/services/form"
+	"go-form-hub/internal/services/live"
+	"go-form-hub/internal/services/mail"
+	"go-form-hub/internal/services/notification"
+	"go-form-hub/internal/services/oidc"
+	passageservice "go-form-hub/internal/services/passage"
+	"go-form-hub/internal/services/ratelimit"
+	"go-form-hub/internal/services/recipient"
+	"go-form-hub/internal/services/sessioncache"
+	"go-form-hub/internal/services/webhook"
 	"go-form-hub/microservices/auth/session"
 	passage "go-form-hub/microservices/passage/passage_client"
 	"go-form-hub/microservices/user/profile"
@@ -112,23 +123,64 @@ func main() {
 
 	userRepository := repository.NewUserDatabaseRepository(db, builder)
 	formRepository := repository.NewFormDatabaseRepository(db, builder)
-	sessionRepository := repository.NewSessionDatabaseRepository(db, builder)
 	questionRepository := repository.NewQuestionDatabaseRepository(db, builder)
 	answerRepository := repository.NewAnswerDatabaseRepository(db, builder)
+	webhookRepository := repository.NewWebhookDatabaseRepository(db, builder)
+	notificationRepository := repository.NewNotificationDatabaseRepository(db, builder)
+	recipientRepository := repository.NewRecipientDatabaseRepository(db, builder)
+	apiTokenRepository := repository.NewAPITokenDatabaseRepository(db, builder)
 
-	formService := form.NewFormService(formRepository, questionRepository, answerRepository, validate)
+	unlockLimiter := ratelimit.NewLimiter(form.UnlockMaxFailures, form.UnlockWindow, time.Now)
+	formService := form.NewFormService(formRepository, questionRepository, answerRepository, recipientRepository, tokenParser, unlockLimiter, validate)
 
 	responseEncoder := api.NewResponseEncoder()
 
-	formRouter := api.NewFormAPIController(formService, passageController, validate, responseEncoder)
-	authRouter := api.NewAuthAPIController(tokenParser, sessController, validate, cfg.CookieExpiration, responseEncoder)
-	userRouter := api.NewUserAPIController(userController, validate, responseEncoder)
+	backgroundCtx, stopBackground := context.WithCancel(context.Background())
+	defer stopBackground()
 
-	authMiddleware := api.AuthMiddleware(sessionRepository, userRepository, cfg.CookieExpiration, responseEncoder)
-	currentUserMiddleware := api.CurrentUserMiddleware(sessionRepository, userRepository, cfg.CookieExpiration)
+	liveHub := live.NewHub()
+	go liveHub.Listen(backgroundCtx, db, repository.FormPassageChannel)
+
+	webhookDispatcher := webhook.NewDispatcher(webhookRepository, webhook.NewClient(), time.Now)
+	go webhookDispatcher.Run(backgroundCtx)
+	webhookService := webhook.NewWebhookService(formRepository, webhookRepository, webhookDispatcher, validate)
+
+	mailSender, err := mail.NewSender(cfg)
+	if err != nil {
+		log.Error().Msgf("failed to create mail sender: %s", err)
+		return
+	}
+
+	notificationScheduler := notification.NewScheduler(notificationRepository, mailSender, cfg.AppURL, time.Now)
+	go notificationScheduler.Run(backgroundCtx, cfg.NotificationInterval)
+	notificationService := notification.NewNotificationService(notificationRepository, validate)
+	recipientService := recipient.NewRecipientService(formRepository, recipientRepository, mailSender, cfg.AppURL, time.Now, validate)
+	importService := passageservice.NewImportService(formRepository, time.Now, validate)
+	apiTokenService := apitoken.NewAPITokenService(apiTokenRepository, userRepository, time.Now, validate)
+
+	respondentIdentifier := api.NewRespondentIdentifier(tokenParser, cfg)
+	formRouter := api.NewFormAPIController(formService, passageController, liveHub, respondentIdentifier, validate, responseEncoder)
+	sessionCache := sessioncache.NewCache(sessController, cfg.SessionCacheTTL, time.Now)
+	authRouter := api.NewAuthAPIController(tokenParser, sessController, sessionCache, validate, cfg.CookieExpiration, responseEncoder)
+	userRouter := api.NewUserAPIController(userController, sessController, sessionCache, tokenParser, cfg.CookieExpiration, validate, responseEncoder)
+	webhookRouter := api.NewWebhookAPIController(webhookService, validate, responseEncoder)
+	notificationRouter := api.NewNotificationAPIController(notificationService, validate, responseEncoder)
+	recipientRouter := api.NewRecipientAPIController(recipientService, validate, responseEncoder)
+	importRouter := api.NewImportAPIController(importService, validate, responseEncoder)
+	tokenRouter := api.NewTokenAPIController(apiTokenService, validate, responseEncoder)
+
+	oidcClient := &http.Client{Timeout: oidc.RequestTimeout}
+	oidcProviders := make([]*oidc.Provider, 0, len(cfg.OIDCProviders))
+	for _, provider := range cfg.OIDCProviders {
+		oidcProviders = append(oidcProviders, oidc.NewProvider(provider, api.OIDCRedirectURL(cfg.AppURL, provider.Name), oidcClient, time.Now))
+	}
+	oidcRouter := api.NewOIDCAPIController(oidcProviders, sessController, tokenParser, cfg.CookieExpiration, cfg.AppURL, responseEncoder)
+
+	authMiddleware := api.AuthMiddleware(sessionCache, cfg.CookieExpiration, sessController, responseEncoder)
+	currentUserMiddleware := api.CurrentUserMiddleware(sessionCache, cfg.CookieExpiration, sessController, responseEncoder)
 	csrfMiddleware := api.CSRFMiddleware(tokenParser, responseEncoder)
 
-	r := api.NewRouter(cfg, authMiddleware, currentUserMiddleware, csrfMiddleware, formRouter, authRouter, userRouter)
+	r := api.NewRouter(cfg, authMiddleware, currentUserMiddleware, csrfMiddleware, formRouter, authRouter, userRouter, webhookRouter, notificationRouter, recipientRouter, importRouter, oidcRouter, tokenRouter)
 
 	server, err := StartServer(cfg, r)
 	if err != nil {
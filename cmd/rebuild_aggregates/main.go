@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"go-form-hub/internal/config"
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/repository"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Recounts the form result aggregates from the saved passages.
+// Run it once after the aggregate tables are created and whenever they are suspected to be out of sync.
+func main() {
+	cfg, err := config.NewConfig()
+	if err != nil {
+		log.Error().Msg(fmt.Sprintf("rebuild failed to start: %s", err))
+		return
+	}
+	zerolog.SetGlobalLevel(config.ZeroLogLevel(cfg.LogLevel))
+
+	db, err := database.ConnectDatabaseWithRetry(cfg)
+	if err != nil {
+		log.Error().Msgf("failed to connect database: %s", err)
+		return
+	}
+	defer db.Close()
+
+	builder := squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
+	_, err = database.Migrate(db, cfg, builder)
+	if err != nil {
+		log.Error().Msgf("failed to migrate database: %s", err)
+		return
+	}
+
+	formRepository := repository.NewFormDatabaseRepository(db, builder)
+	if err := formRepository.RebuildResultAggregates(context.Background()); err != nil {
+		log.Error().Msgf("failed to rebuild result aggregates: %s", err)
+		return
+	}
+
+	log.Info().Msg("Result aggregates rebuilt")
+}
@@ -0,0 +1,4 @@
+CREATE TABLE nofronts.form_result_aggregate (
+    form_id BIGINT PRIMARY KEY REFERENCES nofronts.form(id) ON DELETE CASCADE,
+    passage_count BIGINT NOT NULL DEFAULT 0
+);
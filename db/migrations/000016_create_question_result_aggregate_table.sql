@@ -0,0 +1,5 @@
+CREATE TABLE nofronts.question_result_aggregate (
+    question_id BIGINT PRIMARY KEY REFERENCES nofronts.question(id) ON DELETE CASCADE,
+    form_id BIGINT NOT NULL REFERENCES nofronts.form(id) ON DELETE CASCADE,
+    passage_count BIGINT NOT NULL DEFAULT 0
+);
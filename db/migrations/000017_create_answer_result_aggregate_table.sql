@@ -0,0 +1,6 @@
+CREATE TABLE nofronts.answer_result_aggregate (
+    question_id BIGINT NOT NULL REFERENCES nofronts.question(id) ON DELETE CASCADE,
+    answer_text TEXT NOT NULL,
+    selected_times BIGINT NOT NULL DEFAULT 0,
+    PRIMARY KEY (question_id, answer_text)
+);
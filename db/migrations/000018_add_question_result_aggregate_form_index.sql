@@ -0,0 +1 @@
+CREATE INDEX question_result_aggregate_form_id_idx ON nofronts.question_result_aggregate (form_id);
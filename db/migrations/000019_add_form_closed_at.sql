@@ -0,0 +1,2 @@
+ALTER TABLE nofronts.form
+ADD COLUMN closed_at TIMESTAMP;
@@ -0,0 +1,8 @@
+CREATE TABLE nofronts.webhook (
+    id BIGSERIAL PRIMARY KEY,
+    form_id BIGINT NOT NULL REFERENCES nofronts.form(id) ON DELETE CASCADE,
+    url TEXT NOT NULL,
+    secret TEXT NOT NULL,
+    events TEXT[] NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
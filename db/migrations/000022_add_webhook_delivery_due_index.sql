@@ -0,0 +1 @@
+CREATE INDEX webhook_delivery_due_idx ON nofronts.webhook_delivery (next_attempt_at) WHERE status = 'pending';
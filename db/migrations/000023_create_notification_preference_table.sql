@@ -0,0 +1,5 @@
+CREATE TABLE nofronts.notification_preference (
+    user_id BIGINT PRIMARY KEY REFERENCES nofronts.user(id) ON DELETE CASCADE,
+    mode VARCHAR(16) NOT NULL DEFAULT 'off',
+    notified_until TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC')
+);
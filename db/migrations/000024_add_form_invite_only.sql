@@ -0,0 +1,2 @@
+ALTER TABLE nofronts.form
+ADD COLUMN invite_only BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,13 @@
+CREATE TABLE nofronts.form_recipient (
+    id BIGSERIAL PRIMARY KEY,
+    form_id BIGINT NOT NULL REFERENCES nofronts.form(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    name VARCHAR(255) NOT NULL DEFAULT '',
+    token_hash VARCHAR(64) UNIQUE NOT NULL,
+    max_uses INT NOT NULL DEFAULT 1,
+    uses INT NOT NULL DEFAULT 0,
+    invited_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'UTC'),
+    reminded_at TIMESTAMP,
+    responded_at TIMESTAMP,
+    UNIQUE (form_id, email)
+);
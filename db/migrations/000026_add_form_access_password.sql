@@ -0,0 +1,2 @@
+ALTER TABLE nofronts.form
+ADD COLUMN access_password_hash VARCHAR(255);
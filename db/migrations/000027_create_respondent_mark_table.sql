@@ -0,0 +1,9 @@
+CREATE TABLE nofronts.respondent_mark (
+    id BIGSERIAL PRIMARY KEY,
+    form_id BIGINT NOT NULL REFERENCES nofronts.form(id) ON DELETE CASCADE,
+    kind VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    passages INT NOT NULL DEFAULT 0,
+    last_passage_at TIMESTAMP NOT NULL,
+    UNIQUE (form_id, kind, key_hash)
+);
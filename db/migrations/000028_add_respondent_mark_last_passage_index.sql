@@ -0,0 +1,2 @@
+CREATE INDEX respondent_mark_fingerprint_last_passage_at_idx
+ON nofronts.respondent_mark (last_passage_at) WHERE kind = 'fingerprint';
@@ -0,0 +1,2 @@
+CREATE UNIQUE INDEX form_passage_form_id_idempotency_key_idx
+ON nofronts.form_passage (form_id, idempotency_key) WHERE idempotency_key IS NOT NULL;
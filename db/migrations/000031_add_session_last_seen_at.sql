@@ -0,0 +1,4 @@
+ALTER TABLE nofronts.session
+ADD COLUMN last_seen_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc');
+UPDATE nofronts.session
+SET last_seen_at = created_at;
@@ -0,0 +1 @@
+DELETE FROM nofronts.session;
@@ -0,0 +1,3 @@
+ALTER TABLE nofronts.session
+ADD COLUMN user_agent TEXT NOT NULL DEFAULT '',
+ADD COLUMN ip VARCHAR(45) NOT NULL DEFAULT '';
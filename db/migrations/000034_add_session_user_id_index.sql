@@ -0,0 +1,2 @@
+CREATE INDEX session_user_id_idx
+ON nofronts.session (user_id);
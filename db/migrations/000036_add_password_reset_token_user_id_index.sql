@@ -0,0 +1,2 @@
+CREATE INDEX password_reset_token_user_id_idx
+ON nofronts.password_reset_token (user_id);
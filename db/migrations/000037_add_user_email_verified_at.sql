@@ -0,0 +1,2 @@
+ALTER TABLE nofronts.user
+ADD COLUMN email_verified_at TIMESTAMP;
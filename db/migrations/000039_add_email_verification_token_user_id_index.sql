@@ -0,0 +1,2 @@
+CREATE INDEX email_verification_token_user_id_idx
+ON nofronts.email_verification_token (user_id);
@@ -0,0 +1,2 @@
+ALTER TABLE nofronts.form
+ADD COLUMN verified_only BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,2 @@
+ALTER TABLE nofronts.session
+ADD COLUMN pending BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,7 @@
+CREATE TABLE nofronts.two_factor (
+    user_id BIGINT PRIMARY KEY REFERENCES nofronts.user(id) ON DELETE CASCADE,
+    secret VARCHAR(64) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT (NOW() AT TIME ZONE 'utc'),
+    confirmed_at TIMESTAMP,
+    last_used_step BIGINT NOT NULL DEFAULT 0
+);
@@ -0,0 +1,2 @@
+CREATE INDEX user_identity_user_id_idx
+ON nofronts.user_identity (user_id);
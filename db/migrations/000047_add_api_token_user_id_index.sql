@@ -0,0 +1,2 @@
+CREATE INDEX api_token_user_id_idx
+ON nofronts.api_token (user_id);
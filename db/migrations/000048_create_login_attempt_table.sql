@@ -0,0 +1,5 @@
+CREATE TABLE nofronts.login_attempt (
+    key VARCHAR(64) PRIMARY KEY,
+    failures INT NOT NULL,
+    last_failure_at TIMESTAMP NOT NULL
+);
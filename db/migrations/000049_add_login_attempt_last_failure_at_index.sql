@@ -0,0 +1,2 @@
+CREATE INDEX login_attempt_last_failure_at_idx
+ON nofronts.login_attempt (last_failure_at);
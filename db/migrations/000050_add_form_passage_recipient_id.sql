@@ -0,0 +1,2 @@
+ALTER TABLE nofronts.form_passage
+ADD COLUMN recipient_id BIGINT REFERENCES nofronts.form_recipient(id) ON DELETE SET NULL;
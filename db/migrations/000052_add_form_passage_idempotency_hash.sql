@@ -0,0 +1,2 @@
+ALTER TABLE nofronts.form_passage
+ADD COLUMN idempotency_hash VARCHAR(64);
@@ -0,0 +1,2 @@
+ALTER TABLE nofronts.login_attempt
+ADD COLUMN previous_failure_at TIMESTAMP;
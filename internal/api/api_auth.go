@@ -1,31 +1,42 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"go-form-hub/internal/model"
+	"go-form-hub/internal/services/authsession"
 	resp "go-form-hub/internal/services/service_response"
+	"go-form-hub/internal/services/sessioncache"
 	"go-form-hub/microservices/auth/session"
 
+	"github.com/go-chi/chi/v5"
 	validator "github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type AuthAPIController struct {
 	authService      session.AuthCheckerClient
+	sessions         *sessioncache.Cache
 	validator        *validator.Validate
 	cookieExpiration time.Duration
 	responseEncoder  ResponseEncoder
 	tokenParser      *HashToken
 }
 
-func NewAuthAPIController(tokenParser *HashToken, authService session.AuthCheckerClient, v *validator.Validate, cookieExpiration time.Duration, responseEncoder ResponseEncoder) Router {
+func NewAuthAPIController(tokenParser *HashToken, authService session.AuthCheckerClient, sessions *sessioncache.Cache, v *validator.Validate, cookieExpiration time.Duration, responseEncoder ResponseEncoder) Router {
 	return &AuthAPIController{
 		authService:      authService,
+		sessions:         sessions,
 		validator:        v,
 		cookieExpiration: cookieExpiration,
 		responseEncoder:  responseEncoder,
@@ -42,6 +53,13 @@ func (c *AuthAPIController) Routes() []Route {
 			Handler:      c.Login,
 			AuthRequired: false,
 		},
+		{
+			Name:         "LoginSecondFactor",
+			Method:       http.MethodPost,
+			Path:         "/login/2fa",
+			Handler:      c.LoginSecondFactor,
+			AuthRequired: false,
+		},
 		{
 			Name:         "Signup",
 			Method:       http.MethodPost,
@@ -56,6 +74,76 @@ func (c *AuthAPIController) Routes() []Route {
 			Handler:      c.Logout,
 			AuthRequired: true,
 		},
+		{
+			Name:         "LogoutOthers",
+			Method:       http.MethodPost,
+			Path:         "/logout/others",
+			Handler:      c.LogoutOthers,
+			AuthRequired: true,
+		},
+		{
+			Name:         "SessionList",
+			Method:       http.MethodGet,
+			Path:         "/sessions",
+			Handler:      c.SessionList,
+			AuthRequired: true,
+		},
+		{
+			Name:         "SessionRevoke",
+			Method:       http.MethodDelete,
+			Path:         "/sessions/{id}",
+			Handler:      c.SessionRevoke,
+			AuthRequired: true,
+		},
+		{
+			Name:         "PasswordForgot",
+			Method:       http.MethodPost,
+			Path:         "/password/forgot",
+			Handler:      c.PasswordForgot,
+			AuthRequired: false,
+		},
+		{
+			Name:         "PasswordReset",
+			Method:       http.MethodPost,
+			Path:         "/password/reset",
+			Handler:      c.PasswordReset,
+			AuthRequired: false,
+		},
+		{
+			Name:         "EmailVerify",
+			Method:       http.MethodPost,
+			Path:         "/email/verify",
+			Handler:      c.EmailVerify,
+			AuthRequired: false,
+		},
+		{
+			Name:         "EmailVerifyResend",
+			Method:       http.MethodPost,
+			Path:         "/email/verify/resend",
+			Handler:      c.EmailVerifyResend,
+			AuthRequired: true,
+		},
+		{
+			Name:         "TwoFactorEnroll",
+			Method:       http.MethodPost,
+			Path:         "/2fa/enroll",
+			Handler:      c.TwoFactorEnroll,
+			AuthRequired: true,
+		},
+		{
+			Name:         "TwoFactorConfirm",
+			Method:       http.MethodPost,
+			Path:         "/2fa/confirm",
+			Handler:      c.TwoFactorConfirm,
+			AuthRequired: true,
+		},
+		{
+			Name:         "TwoFactorDisable",
+			Method:       http.MethodPost,
+			Path:         "/2fa/disable",
+			Handler:      c.TwoFactorDisable,
+			AuthRequired: true,
+		},
 		{
 			Name:         "IsAuthorized",
 			Method:       http.MethodGet,
@@ -70,7 +158,7 @@ func (c *AuthAPIController) Routes() []Route {
 func (c *AuthAPIController) Login(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	cookieSession, err := r.Cookie("session_id")
+	cookieSession, err := r.Cookie(sessionCookieName)
 	if err == nil {
 		curSession := &session.Session{
 			Session: cookieSession.Value,
@@ -100,37 +188,70 @@ func (c *AuthAPIController) Login(w http.ResponseWriter, r *http.Request) {
 		c.responseEncoder.HandleError(ctx, w, err, nil)
 		return
 	}
+	user.UserAgent = r.UserAgent()
+	user.Ip = ClientAddress(r)
 
 	sessionInfo, err := c.authService.Login(ctx, &user)
 	if err != nil {
-		c.responseEncoder.HandleError(ctx, w, err, nil)
+		setRetryAfter(w, err)
+		c.responseEncoder.HandleError(ctx, w, err, grpcErrorResponse(err))
 		return
 	}
 
-	cookie := &http.Cookie{
-		Name:     "session_id",
-		HttpOnly: true,
-		Value:    sessionInfo.Session,
-		Expires:  time.Now().Add(c.cookieExpiration),
-		SameSite: http.SameSiteLaxMode,
+	// the password was right, the login completes at /login/2fa with the pending token
+	if sessionInfo.TwoFactorRequired {
+		challenge := &model.TwoFactorChallenge{
+			TwoFactorRequired: true,
+			PendingToken:      sessionInfo.Session,
+		}
+		c.responseEncoder.EncodeJSONResponse(ctx, challenge, http.StatusAccepted, w)
+		return
 	}
-	http.SetCookie(w, cookie)
 
-	csrfToken, err := c.tokenParser.Create(sessionInfo.Session, int64(c.cookieExpiration))
+	c.startSession(w, r, sessionInfo)
+}
+
+// LoginSecondFactor completes a login that needs a code from the authenticator app or a recovery
+// code.
+func (c *AuthAPIController) LoginSecondFactor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	requestJSON, err := io.ReadAll(r.Body)
+	defer func() {
+		_ = r.Body.Close()
+	}()
 	if err != nil {
 		c.responseEncoder.HandleError(ctx, w, err, nil)
 		return
 	}
 
-	csrfCookie := &http.Cookie{
-		Name:     csrfCookieName,
-		HttpOnly: true,
-		Value:    csrfToken,
-		Expires:  time.Now().Add(c.cookieExpiration),
-		SameSite: http.SameSiteLaxMode,
+	var secondFactor model.SecondFactor
+	if err = json.Unmarshal(requestJSON, &secondFactor); err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	sessionInfo, err := c.authService.LoginSecondFactor(ctx, &session.SecondFactor{
+		Session: secondFactor.PendingToken,
+		Code:    secondFactor.Code,
+	})
+	if err != nil {
+		log.Error().Msgf("api_auth login_second_factor err: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, grpcErrorResponse(err))
+		return
+	}
+
+	c.startSession(w, r, sessionInfo)
+}
+
+// startSession sets the cookies of a completed login and answers with its user.
+func (c *AuthAPIController) startSession(w http.ResponseWriter, r *http.Request, sessionInfo *session.SessionInfo) {
+	ctx := r.Context()
+
+	if err := setSessionCookies(w, c.tokenParser, sessionInfo.Session, c.cookieExpiration); err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
 	}
-	http.SetCookie(w, csrfCookie)
-	w.Header().Add("X-CSRF-Token", csrfToken)
 
 	curUser := model.UserGet{
 		ID:        sessionInfo.CurrentUser.Id,
@@ -148,7 +269,7 @@ func (c *AuthAPIController) Login(w http.ResponseWriter, r *http.Request) {
 func (c *AuthAPIController) Signup(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	cookieSession, err := r.Cookie("session_id")
+	cookieSession, err := r.Cookie(sessionCookieName)
 	if err == nil {
 		curSession := &session.Session{
 			Session: cookieSession.Value,
@@ -190,6 +311,8 @@ func (c *AuthAPIController) Signup(w http.ResponseWriter, r *http.Request) {
 		LastName:  user.LastName,
 		Password:  user.Password,
 		Email:     user.Email,
+		UserAgent: r.UserAgent(),
+		Ip:        ClientAddress(r),
 	}
 
 	sessionInfo, err := c.authService.Signup(ctx, userMsg)
@@ -199,31 +322,11 @@ func (c *AuthAPIController) Signup(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	cookie := &http.Cookie{
-		Name:     "session_id",
-		HttpOnly: true,
-		Value:    sessionInfo.Session,
-		Expires:  time.Now().Add(c.cookieExpiration),
-		SameSite: http.SameSiteLaxMode,
-	}
-	http.SetCookie(w, cookie)
-
-	csrfToken, err := c.tokenParser.Create(sessionInfo.Session, int64(c.cookieExpiration))
-	if err != nil {
+	if err = setSessionCookies(w, c.tokenParser, sessionInfo.Session, c.cookieExpiration); err != nil {
 		c.responseEncoder.HandleError(ctx, w, err, nil)
 		return
 	}
 
-	csrfCookie := &http.Cookie{
-		Name:     csrfCookieName,
-		HttpOnly: true,
-		Value:    csrfToken,
-		Expires:  time.Now().Add(c.cookieExpiration),
-		SameSite: http.SameSiteLaxMode,
-	}
-	http.SetCookie(w, csrfCookie)
-	w.Header().Add("X-CSRF-Token", csrfToken)
-
 	curUser := model.UserGet{
 		ID:        sessionInfo.CurrentUser.Id,
 		FirstName: sessionInfo.CurrentUser.FirstName,
@@ -239,27 +342,310 @@ func (c *AuthAPIController) Signup(w http.ResponseWriter, r *http.Request) {
 func (c *AuthAPIController) Logout(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	_, err := c.authService.Delete(ctx, &session.Session{})
+	cookieSession, err := r.Cookie(sessionCookieName)
 	if err != nil {
 		c.responseEncoder.HandleError(ctx, w, err, nil)
 		return
 	}
 
-	cookie := &http.Cookie{
-		Name:     "session_id",
-		HttpOnly: true,
-		Value:    "",
-		Expires:  time.Unix(0, 0),
-		MaxAge:   -1,
+	_, err = c.authService.Delete(ctx, &session.Session{Session: cookieSession.Value})
+	if err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
 	}
-	http.SetCookie(w, cookie)
+	c.sessions.Forget(cookieSession.Value)
+
+	http.SetCookie(w, createExpiredSessionCookie())
 
 	c.responseEncoder.EncodeJSONResponse(ctx, nil, http.StatusOK, w)
 }
 
+func (c *AuthAPIController) LogoutOthers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cookieSession, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	_, err = c.authService.RevokeOtherSessions(ctx, &session.Session{Session: cookieSession.Value})
+	if err != nil {
+		log.Error().Msgf("api_auth logout_others err: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, grpcErrorResponse(err))
+		return
+	}
+	c.forgetCurrentUser(ctx)
+
+	c.responseEncoder.EncodeJSONResponse(ctx, nil, http.StatusNoContent, w)
+}
+
+func (c *AuthAPIController) SessionList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cookieSession, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	sessions, err := c.authService.ListSessions(ctx, &session.Session{Session: cookieSession.Value})
+	if err != nil {
+		log.Error().Msgf("api_auth session_list err: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, grpcErrorResponse(err))
+		return
+	}
+
+	response := &model.SessionList{Sessions: make([]*model.SessionGet, 0, len(sessions.Sessions))}
+	for _, s := range sessions.Sessions {
+		response.Sessions = append(response.Sessions, &model.SessionGet{
+			ID:         s.Id,
+			CreatedAt:  time.Unix(s.CreatedAt, 0).UTC(),
+			LastSeenAt: time.Unix(s.LastSeenAt, 0).UTC(),
+			UserAgent:  s.UserAgent,
+			IP:         s.Ip,
+			Current:    s.Current,
+		})
+	}
+	response.Count = len(response.Sessions)
+
+	c.responseEncoder.EncodeJSONResponse(ctx, response, http.StatusOK, w)
+}
+
+func (c *AuthAPIController) SessionRevoke(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cookieSession, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	id := chi.URLParam(r, "id")
+	_, err = c.authService.RevokeSession(ctx, &session.SessionRevoke{Session: cookieSession.Value, Id: id})
+	if err != nil {
+		log.Error().Msgf("api_auth session_revoke err: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, grpcErrorResponse(err))
+		return
+	}
+	c.forgetCurrentUser(ctx)
+
+	if id == authsession.Hash(cookieSession.Value) {
+		http.SetCookie(w, createExpiredSessionCookie())
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, nil, http.StatusNoContent, w)
+}
+
+// forgetCurrentUser drops the cached sessions of the current user once some of them are revoked,
+// the cache does not know which.
+func (c *AuthAPIController) forgetCurrentUser(ctx context.Context) {
+	if currentUser, ok := ctx.Value(model.ContextCurrentUser).(*model.UserGet); ok {
+		c.sessions.ForgetUser(currentUser.ID)
+	}
+}
+
+func (c *AuthAPIController) PasswordForgot(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	requestJSON, err := io.ReadAll(r.Body)
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	if err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	var forgot model.PasswordForgot
+	if err = json.Unmarshal(requestJSON, &forgot); err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	_, err = c.authService.ForgotPassword(ctx, &session.PasswordForgot{Email: forgot.Email, Ip: ClientAddress(r)})
+	if err != nil {
+		log.Error().Msgf("api_auth password_forgot err: %v", err)
+		setRetryAfter(w, err)
+		c.responseEncoder.HandleError(ctx, w, err, grpcErrorResponse(err))
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, nil, http.StatusNoContent, w)
+}
+
+// PasswordReset sets a new password with the token from the emailed link. Every session of the user
+// ends, so the session cookie, if any, is cleared too.
+func (c *AuthAPIController) PasswordReset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	requestJSON, err := io.ReadAll(r.Body)
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	if err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	var reset model.PasswordReset
+	if err = json.Unmarshal(requestJSON, &reset); err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.authService.ResetPassword(ctx, &session.PasswordReset{Token: reset.Token, Password: reset.Password})
+	if err != nil {
+		log.Error().Msgf("api_auth password_reset err: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, grpcErrorResponse(err))
+		return
+	}
+	c.sessions.ForgetUser(result.GetUserId())
+
+	if _, err = r.Cookie(sessionCookieName); err == nil {
+		http.SetCookie(w, createExpiredSessionCookie())
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, nil, http.StatusNoContent, w)
+}
+
+func (c *AuthAPIController) EmailVerify(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	requestJSON, err := io.ReadAll(r.Body)
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	if err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	var verify model.EmailVerify
+	if err = json.Unmarshal(requestJSON, &verify); err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	_, err = c.authService.VerifyEmail(ctx, &session.EmailVerify{Token: verify.Token})
+	if err != nil {
+		log.Error().Msgf("api_auth email_verify err: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, grpcErrorResponse(err))
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, nil, http.StatusNoContent, w)
+}
+
+func (c *AuthAPIController) EmailVerifyResend(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cookieSession, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	_, err = c.authService.ResendVerification(ctx, &session.Session{Session: cookieSession.Value})
+	if err != nil {
+		log.Error().Msgf("api_auth email_verify_resend err: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, grpcErrorResponse(err))
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, nil, http.StatusNoContent, w)
+}
+
+func (c *AuthAPIController) TwoFactorEnroll(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cookieSession, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	setup, err := c.authService.EnrollTwoFactor(ctx, &session.Session{Session: cookieSession.Value})
+	if err != nil {
+		log.Error().Msgf("api_auth two_factor_enroll err: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, grpcErrorResponse(err))
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, &model.TwoFactorSetup{Secret: setup.Secret, URI: setup.Uri}, http.StatusOK, w)
+}
+
+func (c *AuthAPIController) TwoFactorConfirm(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cookieSession, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	requestJSON, err := io.ReadAll(r.Body)
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	if err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	var confirm model.TwoFactorConfirm
+	if err = json.Unmarshal(requestJSON, &confirm); err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	codes, err := c.authService.ConfirmTwoFactor(ctx, &session.TwoFactorConfirm{Session: cookieSession.Value, Code: confirm.Code})
+	if err != nil {
+		log.Error().Msgf("api_auth two_factor_confirm err: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, grpcErrorResponse(err))
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, &model.RecoveryCodes{Codes: codes.Codes}, http.StatusOK, w)
+}
+
+func (c *AuthAPIController) TwoFactorDisable(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	cookieSession, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	requestJSON, err := io.ReadAll(r.Body)
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	if err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	var disable model.TwoFactorDisable
+	if err = json.Unmarshal(requestJSON, &disable); err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	_, err = c.authService.DisableTwoFactor(ctx, &session.TwoFactorDisable{Session: cookieSession.Value, Password: disable.Password})
+	if err != nil {
+		log.Error().Msgf("api_auth two_factor_disable err: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, grpcErrorResponse(err))
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, nil, http.StatusNoContent, w)
+}
+
 func (c *AuthAPIController) IsAuthorized(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	cookieSession, err := r.Cookie("session_id")
+	cookieSession, err := r.Cookie(sessionCookieName)
 	if err != nil {
 		c.responseEncoder.HandleError(ctx, w, err, nil)
 		return
@@ -283,3 +669,57 @@ func (c *AuthAPIController) IsAuthorized(w http.ResponseWriter, r *http.Request)
 
 	c.responseEncoder.EncodeJSONResponse(r.Context(), nil, http.StatusOK, w)
 }
+
+// setSessionCookies sends the session cookie along with a CSRF token bound to the session.
+func setSessionCookies(w http.ResponseWriter, tokenParser *HashToken, sessionID string, expiration time.Duration) error {
+	http.SetCookie(w, createSessionCookie(sessionID, expiration))
+
+	csrfToken, err := tokenParser.Create(sessionID, int64(expiration))
+	if err != nil {
+		return err
+	}
+
+	csrfCookie := &http.Cookie{
+		Name:     csrfCookieName,
+		HttpOnly: true,
+		Value:    csrfToken,
+		Expires:  time.Now().Add(expiration),
+		SameSite: http.SameSiteLaxMode,
+	}
+	http.SetCookie(w, csrfCookie)
+	w.Header().Add("X-CSRF-Token", csrfToken)
+
+	return nil
+}
+
+// setRetryAfter sets the Retry-After header from the retry delay the auth microservice attached
+// to a throttled login.
+func setRetryAfter(w http.ResponseWriter, err error) {
+	for _, detail := range status.Convert(err).Details() {
+		if retryInfo, ok := detail.(*errdetails.RetryInfo); ok {
+			seconds := int64(math.Ceil(retryInfo.GetRetryDelay().AsDuration().Seconds()))
+			w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+			return
+		}
+	}
+}
+
+// grpcErrorResponse maps the status of an error of the auth microservice back to an HTTP status.
+func grpcErrorResponse(err error) *resp.Response {
+	switch status.Code(err) {
+	case codes.InvalidArgument:
+		return &resp.Response{StatusCode: http.StatusBadRequest}
+	case codes.Unauthenticated:
+		return &resp.Response{StatusCode: http.StatusUnauthorized}
+	case codes.PermissionDenied:
+		return &resp.Response{StatusCode: http.StatusForbidden}
+	case codes.NotFound:
+		return &resp.Response{StatusCode: http.StatusNotFound}
+	case codes.AlreadyExists:
+		return &resp.Response{StatusCode: http.StatusConflict}
+	case codes.ResourceExhausted:
+		return &resp.Response{StatusCode: http.StatusTooManyRequests}
+	default:
+		return &resp.Response{StatusCode: http.StatusInternalServerError}
+	}
+}
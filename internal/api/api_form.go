@@ -7,9 +7,12 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"go-form-hub/internal/model"
 	"go-form-hub/internal/services/form"
+	"go-form-hub/internal/services/live"
 	passage "go-form-hub/microservices/passage/passage_client"
 
 	"github.com/go-chi/chi/v5"
@@ -17,17 +20,30 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
+const (
+	liveHeartbeatInterval = 30 * time.Second
+	// AccessGrantHeader carries the grant returned by FormUnlock to FormGet.
+	AccessGrantHeader = "X-Form-Access-Grant"
+	// IdempotencyKeyHeader lets a client retry FormPass without saving the passage twice.
+	IdempotencyKeyHeader = "Idempotency-Key"
+)
+
 type FormAPIController struct {
 	service         form.Service
 	passageService  passage.FormPassageClient
+	liveResults     live.Subscriber
+	respondents     *RespondentIdentifier
 	validator       *validator.Validate
 	responseEncoder ResponseEncoder
 }
 
-func NewFormAPIController(service form.Service, passageService passage.FormPassageClient, v *validator.Validate, responseEncoder ResponseEncoder) Router {
+func NewFormAPIController(service form.Service, passageService passage.FormPassageClient, liveResults live.Subscriber, respondents *RespondentIdentifier,
+	v *validator.Validate, responseEncoder ResponseEncoder) Router {
 	return &FormAPIController{
 		service:         service,
 		passageService:  passageService,
+		liveResults:     liveResults,
+		respondents:     respondents,
 		validator:       v,
 		responseEncoder: responseEncoder,
 	}
@@ -41,6 +57,15 @@ func (c *FormAPIController) Routes() []Route {
 			Path:         "/forms/save",
 			Handler:      c.FormSave,
 			AuthRequired: true,
+			Scope:        model.ScopeFormsWrite,
+		},
+		{
+			Name:         "FormImport",
+			Method:       http.MethodPost,
+			Path:         "/forms/import",
+			Handler:      c.FormImport,
+			AuthRequired: true,
+			Scope:        model.ScopeFormsWrite,
 		},
 		{
 			Name:         "FormList",
@@ -48,6 +73,7 @@ func (c *FormAPIController) Routes() []Route {
 			Path:         "/forms",
 			Handler:      c.FormList,
 			AuthRequired: false,
+			Scope:        model.ScopeFormsRead,
 		},
 		{
 			Name:         "FormGet",
@@ -55,6 +81,14 @@ func (c *FormAPIController) Routes() []Route {
 			Path:         "/forms/{id}",
 			Handler:      c.FormGet,
 			AuthRequired: false,
+			Scope:        model.ScopeFormsRead,
+		},
+		{
+			Name:         "FormUnlock",
+			Method:       http.MethodPost,
+			Path:         "/forms/{id}/unlock",
+			Handler:      c.FormUnlock,
+			AuthRequired: false,
 		},
 		{
 			Name:         "FormDelete",
@@ -62,6 +96,23 @@ func (c *FormAPIController) Routes() []Route {
 			Path:         "/forms/{id}/delete",
 			Handler:      c.FormDelete,
 			AuthRequired: true,
+			Scope:        model.ScopeFormsWrite,
+		},
+		{
+			Name:         "FormClose",
+			Method:       http.MethodPost,
+			Path:         "/forms/{id}/close",
+			Handler:      c.FormClose,
+			AuthRequired: true,
+			Scope:        model.ScopeFormsWrite,
+		},
+		{
+			Name:         "FormPublish",
+			Method:       http.MethodPost,
+			Path:         "/forms/{id}/publish",
+			Handler:      c.FormPublish,
+			AuthRequired: true,
+			Scope:        model.ScopeFormsWrite,
 		},
 		{
 			Name:         "FormUpdate",
@@ -69,6 +120,7 @@ func (c *FormAPIController) Routes() []Route {
 			Path:         "/forms/{id}/update",
 			Handler:      c.FormUpdate,
 			AuthRequired: true,
+			Scope:        model.ScopeFormsWrite,
 		},
 		{
 			Name:         "FormSearch",
@@ -76,6 +128,7 @@ func (c *FormAPIController) Routes() []Route {
 			Path:         "/forms/search",
 			Handler:      c.FormSearch,
 			AuthRequired: true,
+			Scope:        model.ScopeFormsRead,
 		},
 		{
 			Name:         "FormResults",
@@ -83,6 +136,39 @@ func (c *FormAPIController) Routes() []Route {
 			Path:         "/forms/{id}/results",
 			Handler:      c.FormResults,
 			AuthRequired: true,
+			Scope:        model.ScopeResultsRead,
+		},
+		{
+			Name:         "FormResultsLive",
+			Method:       http.MethodGet,
+			Path:         "/forms/{id}/results/live",
+			Handler:      c.FormResultsLive,
+			AuthRequired: true,
+			Scope:        model.ScopeResultsRead,
+		},
+		{
+			Name:         "FormCrossTab",
+			Method:       http.MethodGet,
+			Path:         "/forms/{id}/results/crosstab",
+			Handler:      c.FormCrossTab,
+			AuthRequired: true,
+			Scope:        model.ScopeResultsRead,
+		},
+		{
+			Name:         "FormTimeline",
+			Method:       http.MethodGet,
+			Path:         "/forms/{id}/results/timeline",
+			Handler:      c.FormTimeline,
+			AuthRequired: true,
+			Scope:        model.ScopeResultsRead,
+		},
+		{
+			Name:         "FormTextAnalytics",
+			Method:       http.MethodGet,
+			Path:         "/forms/{id}/results/questions/{question_id}/text",
+			Handler:      c.FormTextAnalytics,
+			AuthRequired: true,
+			Scope:        model.ScopeResultsRead,
 		},
 		{
 			Name:         "FormPassage",
@@ -97,6 +183,7 @@ func (c *FormAPIController) Routes() []Route {
 			Path:         "/forms/{id}/results/csv",
 			Handler:      c.FormResultsCsv,
 			AuthRequired: true,
+			Scope:        model.ScopeResultsRead,
 		},
 		{
 			Name:         "FormResultsExel",
@@ -104,6 +191,7 @@ func (c *FormAPIController) Routes() []Route {
 			Path:         "/forms/{id}/results/excel",
 			Handler:      c.FormResultsExel,
 			AuthRequired: true,
+			Scope:        model.ScopeResultsRead,
 		},
 	}
 }
@@ -138,6 +226,32 @@ func (c *FormAPIController) FormSave(w http.ResponseWriter, r *http.Request) {
 	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
 }
 
+// FormImport takes the exported definition as the body and its source in the source query parameter.
+func (c *FormAPIController) FormImport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	definition, err := io.ReadAll(r.Body)
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	if err != nil {
+		log.Error().Msgf("form_api form_import body read error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	dryRun := r.URL.Query().Get("dry_run") == "true"
+
+	result, err := c.service.FormImport(ctx, r.URL.Query().Get("source"), definition, dryRun)
+	if err != nil {
+		log.Error().Msgf("form_api form_import error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
 func (c *FormAPIController) FormPass(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -172,10 +286,17 @@ func (c *FormAPIController) FormPass(w http.ResponseWriter, r *http.Request) {
 	}
 
 	passageMsg := &passage.Passage{
-		UserID:  currentUser.ID,
-		FormID:  *formPassage.FormID,
-		Answers: answersMsg,
+		UserID:         currentUser.ID,
+		FormID:         *formPassage.FormID,
+		Answers:        answersMsg,
+		AccessToken:    formPassage.AccessToken,
+		AccessGrant:    formPassage.AccessGrant,
+		IdempotencyKey: r.Header.Get(IdempotencyKeyHeader),
 	}
+	if startedAt := c.service.FormStartedAt(passageMsg.FormID, formPassage.StartToken); startedAt != nil {
+		passageMsg.StartedAt = startedAt.Unix()
+	}
+	passageMsg.RespondentID, passageMsg.Fingerprint = c.respondents.Identify(w, r, passageMsg.FormID)
 
 	result, err := c.passageService.Pass(ctx, passageMsg)
 	if err != nil {
@@ -261,7 +382,12 @@ func (c *FormAPIController) FormGet(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	result, err := c.service.FormGet(ctx, id)
+	access := &model.FormAccess{
+		Token: r.URL.Query().Get("token"),
+		Grant: r.Header.Get(AccessGrantHeader),
+	}
+
+	result, err := c.service.FormGet(ctx, id, access)
 	if err != nil {
 		log.Error().Msgf("form_api form_get error: %v", err)
 		c.responseEncoder.HandleError(ctx, w, err, result)
@@ -271,6 +397,104 @@ func (c *FormAPIController) FormGet(w http.ResponseWriter, r *http.Request) {
 	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
 }
 
+func (c *FormAPIController) FormUnlock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := pathID(r, "id")
+	if err != nil {
+		log.Error().Msgf("form_api form_unlock %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	requestJSON, err := io.ReadAll(r.Body)
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	if err != nil {
+		log.Error().Msgf("form_api form_unlock body read error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	var unlock model.FormUnlock
+	if err = json.Unmarshal(requestJSON, &unlock); err != nil {
+		log.Error().Msgf("form_api form_unlock unmarshal error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.FormUnlock(ctx, id, &unlock, ClientAddress(r))
+	if err != nil {
+		log.Error().Msgf("form_api form_unlock error: %v", err)
+		if retryAfter, ok := result.Body.(*model.RetryAfter); ok {
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter.Seconds))
+		}
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
+// nolint:dupl
+func (c *FormAPIController) FormClose(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	idParam, err := url.PathUnescape(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Msgf("form_api form_close unescape error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		err = fmt.Errorf("form_api form_close parse_id error: %v", err)
+		log.Error().Msg(err.Error())
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.FormClose(ctx, id)
+	if err != nil {
+		log.Error().Msgf("form_api form_close error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
+// nolint:dupl
+func (c *FormAPIController) FormPublish(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	idParam, err := url.PathUnescape(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Msgf("form_api form_publish unescape error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		err = fmt.Errorf("form_api form_publish parse_id error: %v", err)
+		log.Error().Msg(err.Error())
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.FormPublish(ctx, id)
+	if err != nil {
+		log.Error().Msgf("form_api form_publish error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
 func (c *FormAPIController) FormSearch(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	currentUser := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
@@ -303,7 +527,14 @@ func (c *FormAPIController) FormResults(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	result, err := c.service.FormResults(ctx, id)
+	filter, err := parseFormResultFilter(r.URL.Query())
+	if err != nil {
+		log.Error().Msgf("form_api form_result parse_filter error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.FormResults(ctx, id, filter)
 	if err != nil {
 		log.Error().Msgf("form_api form_results error: %e", err)
 		c.responseEncoder.HandleError(ctx, w, err, result)
@@ -313,6 +544,318 @@ func (c *FormAPIController) FormResults(w http.ResponseWriter, r *http.Request)
 	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
 }
 
+// FormResultsLive streams the form results as Server-Sent Events: once on connect and again
+// after new passages are saved. A comment is sent periodically to keep idle connections open.
+func (c *FormAPIController) FormResultsLive(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	idParam, err := url.PathUnescape(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Msgf("form_api form_results_live unescape error: %e", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		err = fmt.Errorf("form_api form_results_live parse_id error: %e", err)
+		log.Error().Msg(err.Error())
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	access, err := c.service.FormResultsAccess(ctx, id)
+	if err != nil {
+		log.Error().Msgf("form_api form_results_live error: %e", err)
+		c.responseEncoder.HandleError(ctx, w, err, access)
+		return
+	}
+	if access.StatusCode != http.StatusOK {
+		c.responseEncoder.EncodeJSONResponse(ctx, access.Body, access.StatusCode, w)
+		return
+	}
+
+	updates, unsubscribe := c.liveResults.Subscribe(id)
+	defer unsubscribe()
+
+	// the stream outlives the server write timeout
+	controller := http.NewResponseController(w)
+	if err = controller.SetWriteDeadline(time.Time{}); err != nil {
+		log.Error().Msgf("form_api form_results_live set_write_deadline error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	heartbeat := time.NewTicker(liveHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	sendResults := true
+	for {
+		if sendResults {
+			result, err := c.service.FormResults(ctx, id, nil)
+			if err != nil {
+				log.Error().Msgf("form_api form_results_live results error: %e", err)
+				return
+			}
+			// the form was deleted
+			if result.StatusCode != http.StatusOK {
+				return
+			}
+
+			data, err := json.Marshal(result.Body)
+			if err != nil {
+				log.Error().Msgf("form_api form_results_live marshal error: %v", err)
+				return
+			}
+
+			if _, err = fmt.Fprintf(w, "event: results\ndata: %s\n\n", data); err != nil {
+				return
+			}
+		} else if _, err := io.WriteString(w, ": heartbeat\n\n"); err != nil {
+			return
+		}
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-updates:
+			sendResults = true
+		case <-heartbeat.C:
+			sendResults = false
+		}
+	}
+}
+
+func (c *FormAPIController) FormCrossTab(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	idParam, err := url.PathUnescape(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Msgf("form_api form_cross_tab unescape error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		err = fmt.Errorf("form_api form_cross_tab parse_id error: %v", err)
+		log.Error().Msg(err.Error())
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	query := r.URL.Query()
+
+	rowQuestionID, err := strconv.ParseInt(query.Get("row"), 10, 64)
+	if err != nil {
+		err = fmt.Errorf("form_api form_cross_tab parse_row error: %v", err)
+		log.Error().Msg(err.Error())
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	columnQuestionID, err := strconv.ParseInt(query.Get("column"), 10, 64)
+	if err != nil {
+		err = fmt.Errorf("form_api form_cross_tab parse_column error: %v", err)
+		log.Error().Msg(err.Error())
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	filter, err := parseFormResultFilter(query)
+	if err != nil {
+		log.Error().Msgf("form_api form_cross_tab parse_filter error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.FormCrossTab(ctx, id, rowQuestionID, columnQuestionID, filter)
+	if err != nil {
+		log.Error().Msgf("form_api form_cross_tab error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
+func (c *FormAPIController) FormTimeline(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	idParam, err := url.PathUnescape(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Msgf("form_api form_timeline unescape error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		err = fmt.Errorf("form_api form_timeline parse_id error: %v", err)
+		log.Error().Msg(err.Error())
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	query := r.URL.Query()
+
+	interval := query.Get("interval")
+	if interval == "" {
+		interval = model.TimelineDay
+	}
+
+	location := time.UTC
+	if tz := query.Get("tz"); tz != "" {
+		location, err = time.LoadLocation(tz)
+		if err != nil {
+			err = fmt.Errorf("form_api form_timeline load_location error: %v", err)
+			log.Error().Msg(err.Error())
+			c.responseEncoder.HandleError(ctx, w, err, nil)
+			return
+		}
+	}
+
+	filter, err := parseFormResultFilter(query)
+	if err != nil {
+		log.Error().Msgf("form_api form_timeline parse_filter error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.FormTimeline(ctx, id, interval, location, filter)
+	if err != nil {
+		log.Error().Msgf("form_api form_timeline error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
+func (c *FormAPIController) FormTextAnalytics(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	idParam, err := url.PathUnescape(chi.URLParam(r, "id"))
+	if err != nil {
+		log.Error().Msgf("form_api form_text_analytics unescape error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	id, err := strconv.ParseInt(idParam, 10, 64)
+	if err != nil {
+		err = fmt.Errorf("form_api form_text_analytics parse_id error: %v", err)
+		log.Error().Msg(err.Error())
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	questionIDParam, err := url.PathUnescape(chi.URLParam(r, "question_id"))
+	if err != nil {
+		log.Error().Msgf("form_api form_text_analytics unescape error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	questionID, err := strconv.ParseInt(questionIDParam, 10, 64)
+	if err != nil {
+		err = fmt.Errorf("form_api form_text_analytics parse_question_id error: %v", err)
+		log.Error().Msg(err.Error())
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	query := r.URL.Query()
+	params := &model.TextAnalyticsParams{
+		Page:       1,
+		PerPage:    20,
+		Top:        10,
+		Similarity: 0.5,
+	}
+
+	for name, target := range map[string]*int{"page": &params.Page, "per_page": &params.PerPage, "top": &params.Top} {
+		value := query.Get(name)
+		if value == "" {
+			continue
+		}
+
+		if *target, err = strconv.Atoi(value); err != nil {
+			err = fmt.Errorf("form_api form_text_analytics parse_%s error: %v", name, err)
+			log.Error().Msg(err.Error())
+			c.responseEncoder.HandleError(ctx, w, err, nil)
+			return
+		}
+	}
+
+	if value := query.Get("similarity"); value != "" {
+		if params.Similarity, err = strconv.ParseFloat(value, 64); err != nil {
+			err = fmt.Errorf("form_api form_text_analytics parse_similarity error: %v", err)
+			log.Error().Msg(err.Error())
+			c.responseEncoder.HandleError(ctx, w, err, nil)
+			return
+		}
+	}
+
+	result, err := c.service.FormTextAnalytics(ctx, id, questionID, params)
+	if err != nil {
+		log.Error().Msgf("form_api form_text_analytics error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
+// parseFormResultFilter reads result filters from the query string:
+// answer=<question_id>:<answer_text> (repeatable, all must match), from and to as RFC 3339 timestamps.
+func parseFormResultFilter(query url.Values) (*model.FormResultFilter, error) {
+	filter := &model.FormResultFilter{}
+
+	for _, answerParam := range query["answer"] {
+		questionParam, text, found := strings.Cut(answerParam, ":")
+		if !found {
+			return nil, fmt.Errorf("answer filter must look like <question_id>:<answer_text>, got %q", answerParam)
+		}
+
+		questionID, err := strconv.ParseInt(questionParam, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("answer filter has invalid question id: %v", err)
+		}
+
+		filter.Answers = append(filter.Answers, &model.AnswerFilter{
+			QuestionID: questionID,
+			Text:       text,
+		})
+	}
+
+	for param, target := range map[string]**time.Time{"from": &filter.From, "to": &filter.To} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("%s filter is not a RFC 3339 timestamp: %v", param, err)
+		}
+
+		parsed = parsed.UTC()
+		*target = &parsed
+	}
+
+	return filter, nil
+}
+
 func (c *FormAPIController) FormResultsCsv(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -331,6 +874,17 @@ func (c *FormAPIController) FormResultsCsv(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
+	access, err := c.service.FormResultsAccess(ctx, id)
+	if err != nil {
+		log.Error().Msgf("form_api form_results_csv error: %e", err)
+		c.responseEncoder.HandleError(ctx, w, err, access)
+		return
+	}
+	if access.StatusCode != http.StatusOK {
+		c.responseEncoder.EncodeJSONResponse(ctx, access.Body, access.StatusCode, w)
+		return
+	}
+
 	result, err := c.service.FormResultsCsv(ctx, id)
 	if err != nil {
 		log.Error().Msgf("form_api form_results_exel error: %e", err)
@@ -366,6 +920,17 @@ func (c *FormAPIController) FormResultsExel(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	access, err := c.service.FormResultsAccess(ctx, id)
+	if err != nil {
+		log.Error().Msgf("form_api form_results_exel error: %e", err)
+		c.responseEncoder.HandleError(ctx, w, err, access)
+		return
+	}
+	if access.StatusCode != http.StatusOK {
+		c.responseEncoder.EncodeJSONResponse(ctx, access.Body, access.StatusCode, w)
+		return
+	}
+
 	result, err := c.service.FormResultsExel(ctx, id)
 	if err != nil {
 		log.Error().Msgf("form_api form_results_exel error: %e", err)
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/services/passage"
+
+	validator "github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+)
+
+// maxImportFileSize limits the uploaded file of ResponsesImport.
+const maxImportFileSize = 10 << 20
+
+type ImportAPIController struct {
+	service         passage.Service
+	validator       *validator.Validate
+	responseEncoder ResponseEncoder
+}
+
+func NewImportAPIController(service passage.Service, v *validator.Validate, responseEncoder ResponseEncoder) Router {
+	return &ImportAPIController{
+		service:         service,
+		validator:       v,
+		responseEncoder: responseEncoder,
+	}
+}
+
+func (c *ImportAPIController) Routes() []Route {
+	return []Route{
+		{
+			Name:         "ResponsesImport",
+			Method:       http.MethodPost,
+			Path:         "/forms/{id}/responses/import",
+			Handler:      c.ResponsesImport,
+			AuthRequired: true,
+			Scope:        model.ScopeFormsWrite,
+		},
+	}
+}
+
+// ResponsesImport reads a multipart form with the file in the "file" field and an optional
+// model.ResponseImport as JSON in the "options" field.
+func (c *ImportAPIController) ResponsesImport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	formID, err := pathID(r, "id")
+	if err != nil {
+		log.Error().Msgf("import_api responses_import %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize)
+	if err = r.ParseMultipartForm(maxImportFileSize); err != nil {
+		log.Error().Msgf("import_api responses_import multipart parse error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	file, fileHeader, err := r.FormFile("file")
+	if err != nil {
+		log.Error().Msgf("import_api responses_import file read error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	var responseImport model.ResponseImport
+	if options := r.FormValue("options"); options != "" {
+		if err = json.Unmarshal([]byte(options), &responseImport); err != nil {
+			log.Error().Msgf("import_api responses_import unmarshal error: %v", err)
+			c.responseEncoder.HandleError(ctx, w, err, nil)
+			return
+		}
+	}
+
+	result, err := c.service.ResponsesImport(ctx, formID, fileHeader.Filename, file, &responseImport)
+	if err != nil {
+		log.Error().Msgf("import_api responses_import error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
@@ -0,0 +1,89 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/services/notification"
+
+	validator "github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+)
+
+type NotificationAPIController struct {
+	service         notification.Service
+	validator       *validator.Validate
+	responseEncoder ResponseEncoder
+}
+
+func NewNotificationAPIController(service notification.Service, v *validator.Validate, responseEncoder ResponseEncoder) Router {
+	return &NotificationAPIController{
+		service:         service,
+		validator:       v,
+		responseEncoder: responseEncoder,
+	}
+}
+
+func (c *NotificationAPIController) Routes() []Route {
+	return []Route{
+		{
+			Name:         "NotificationPreferenceGet",
+			Method:       http.MethodGet,
+			Path:         "/profile/notifications",
+			Handler:      c.PreferenceGet,
+			AuthRequired: true,
+		},
+		{
+			Name:         "NotificationPreferenceUpdate",
+			Method:       http.MethodPut,
+			Path:         "/profile/notifications/update",
+			Handler:      c.PreferenceUpdate,
+			AuthRequired: true,
+		},
+	}
+}
+
+func (c *NotificationAPIController) PreferenceGet(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	result, err := c.service.PreferenceGet(ctx)
+	if err != nil {
+		log.Error().Msgf("notification_api preference_get error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
+func (c *NotificationAPIController) PreferenceUpdate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	requestJSON, err := io.ReadAll(r.Body)
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	if err != nil {
+		log.Error().Msgf("notification_api preference_update body read error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	var preference model.NotificationPreference
+	if err = json.Unmarshal(requestJSON, &preference); err != nil {
+		log.Error().Msgf("notification_api preference_update unmarshal error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.PreferenceUpdate(ctx, &preference)
+	if err != nil {
+		log.Error().Msgf("notification_api preference_update error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
@@ -0,0 +1,209 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-form-hub/internal/services/oidc"
+	resp "go-form-hub/internal/services/service_response"
+	"go-form-hub/microservices/auth/session"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	oidcCookieName = "oidc_login"
+	oidcCookiePath = "/api/v1/oidc/"
+	// oidcLoginTimeout is how long the user may take at the provider.
+	oidcLoginTimeout = 10 * time.Minute
+)
+
+// OIDCAPIController logs users in with OpenID Connect providers. The login ends with a redirect to
+// the frontend: to APP_URL on success, to APP_URL/login/2fa with a pending token when the user has
+// two-factor authentication, and to APP_URL/login?error= otherwise.
+type OIDCAPIController struct {
+	providers        map[string]*oidc.Provider
+	names            []string
+	authService      session.AuthCheckerClient
+	tokenParser      *HashToken
+	cookieExpiration time.Duration
+	appURL           string
+	responseEncoder  ResponseEncoder
+}
+
+func NewOIDCAPIController(providers []*oidc.Provider, authService session.AuthCheckerClient, tokenParser *HashToken, cookieExpiration time.Duration,
+	appURL string, responseEncoder ResponseEncoder) Router {
+	c := &OIDCAPIController{
+		providers:        map[string]*oidc.Provider{},
+		names:            make([]string, 0, len(providers)),
+		authService:      authService,
+		tokenParser:      tokenParser,
+		cookieExpiration: cookieExpiration,
+		appURL:           strings.TrimRight(appURL, "/"),
+		responseEncoder:  responseEncoder,
+	}
+	for _, provider := range providers {
+		c.providers[provider.Name()] = provider
+		c.names = append(c.names, provider.Name())
+	}
+	return c
+}
+
+// OIDCRedirectURL is the callback address to register with the provider.
+func OIDCRedirectURL(appURL, provider string) string {
+	return fmt.Sprintf("%s%s%s/callback", strings.TrimRight(appURL, "/"), oidcCookiePath, url.PathEscape(provider))
+}
+
+func (c *OIDCAPIController) Routes() []Route {
+	return []Route{
+		{
+			Name:         "OIDCProviders",
+			Method:       http.MethodGet,
+			Path:         "/oidc/providers",
+			Handler:      c.Providers,
+			AuthRequired: false,
+		},
+		{
+			Name:         "OIDCLogin",
+			Method:       http.MethodGet,
+			Path:         "/oidc/{provider}/login",
+			Handler:      c.Login,
+			AuthRequired: false,
+		},
+		{
+			Name:         "OIDCCallback",
+			Method:       http.MethodGet,
+			Path:         "/oidc/{provider}/callback",
+			Handler:      c.Callback,
+			AuthRequired: false,
+		},
+	}
+}
+
+func (c *OIDCAPIController) Providers(w http.ResponseWriter, r *http.Request) {
+	c.responseEncoder.EncodeJSONResponse(r.Context(), map[string][]string{"providers": c.names}, http.StatusOK, w)
+}
+
+// Login sends the user to the provider. The state, the nonce and the PKCE verifier stay in a cookie
+// for the callback.
+func (c *OIDCAPIController) Login(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	provider, ok := c.providers[chi.URLParam(r, "provider")]
+	if !ok {
+		c.responseEncoder.HandleError(ctx, w, fmt.Errorf("unknown provider"), &resp.Response{StatusCode: http.StatusNotFound})
+		return
+	}
+
+	request, err := oidc.NewAuthRequest()
+	if err != nil {
+		c.responseEncoder.HandleError(ctx, w, err, &resp.Response{StatusCode: http.StatusInternalServerError})
+		return
+	}
+
+	authURL, err := provider.AuthURL(ctx, request)
+	if err != nil {
+		log.Error().Msgf("api_oidc login err: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, &resp.Response{StatusCode: http.StatusBadGateway})
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     oidcCookieName,
+		Path:     oidcCookiePath,
+		HttpOnly: true,
+		Value:    strings.Join([]string{provider.Name(), request.State, request.Nonce, request.Verifier}, "."),
+		Expires:  time.Now().Add(oidcLoginTimeout),
+		SameSite: http.SameSiteLaxMode,
+	})
+	http.Redirect(w, r, authURL, http.StatusFound)
+}
+
+// Callback finishes the login the provider redirected back to.
+func (c *OIDCAPIController) Callback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	provider, ok := c.providers[chi.URLParam(r, "provider")]
+	if !ok {
+		c.responseEncoder.HandleError(ctx, w, fmt.Errorf("unknown provider"), &resp.Response{StatusCode: http.StatusNotFound})
+		return
+	}
+
+	// the login cookie is used up whatever happens next
+	http.SetCookie(w, &http.Cookie{Name: oidcCookieName, Path: oidcCookiePath, HttpOnly: true, Expires: time.Unix(0, 0), MaxAge: -1})
+
+	query := r.URL.Query()
+	if query.Get("error") != "" {
+		c.failLogin(w, r, "denied", fmt.Errorf("provider answered %s", query.Get("error")))
+		return
+	}
+
+	request := loginRequest(r, provider.Name())
+	if request == nil || query.Get("state") != request.State {
+		c.failLogin(w, r, "expired", fmt.Errorf("state mismatch"))
+		return
+	}
+
+	claims, err := provider.Exchange(ctx, query.Get("code"), request)
+	if err != nil {
+		c.failLogin(w, r, "provider", err)
+		return
+	}
+
+	sessionInfo, err := c.authService.LoginOIDC(ctx, &session.OIDCLogin{
+		Provider:      provider.Name(),
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: claims.EmailVerified,
+		FirstName:     claims.GivenName,
+		LastName:      claims.FamilyName,
+		UserAgent:     r.UserAgent(),
+		Ip:            ClientAddress(r),
+	})
+	if err != nil {
+		reason := "failed"
+		if status.Code(err) == codes.AlreadyExists {
+			reason = "email_not_verified"
+		}
+		c.failLogin(w, r, reason, err)
+		return
+	}
+
+	if sessionInfo.TwoFactorRequired {
+		http.Redirect(w, r, c.appURL+"/login/2fa?pending_token="+url.QueryEscape(sessionInfo.Session), http.StatusFound)
+		return
+	}
+
+	if err = setSessionCookies(w, c.tokenParser, sessionInfo.Session, c.cookieExpiration); err != nil {
+		c.failLogin(w, r, "failed", err)
+		return
+	}
+
+	http.Redirect(w, r, c.appURL+"/", http.StatusFound)
+}
+
+func (c *OIDCAPIController) failLogin(w http.ResponseWriter, r *http.Request, reason string, err error) {
+	log.Error().Msgf("api_oidc callback err: %v", err)
+	http.Redirect(w, r, c.appURL+"/login?error="+url.QueryEscape(reason), http.StatusFound)
+}
+
+// loginRequest reads back the request Login started with the provider.
+func loginRequest(r *http.Request, provider string) *oidc.AuthRequest {
+	cookie, err := r.Cookie(oidcCookieName)
+	if err != nil {
+		return nil
+	}
+
+	parts := strings.Split(cookie.Value, ".")
+	if len(parts) != 4 || parts[0] != provider {
+		return nil
+	}
+
+	return &oidc.AuthRequest{State: parts[1], Nonce: parts[2], Verifier: parts[3]}
+}
@@ -0,0 +1,168 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/services/recipient"
+
+	validator "github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+)
+
+type RecipientAPIController struct {
+	service         recipient.Service
+	validator       *validator.Validate
+	responseEncoder ResponseEncoder
+}
+
+func NewRecipientAPIController(service recipient.Service, v *validator.Validate, responseEncoder ResponseEncoder) Router {
+	return &RecipientAPIController{
+		service:         service,
+		validator:       v,
+		responseEncoder: responseEncoder,
+	}
+}
+
+func (c *RecipientAPIController) Routes() []Route {
+	return []Route{
+		{
+			Name:         "RecipientList",
+			Method:       http.MethodGet,
+			Path:         "/forms/{id}/recipients",
+			Handler:      c.RecipientList,
+			AuthRequired: true,
+			Scope:        model.ScopeFormsRead,
+		},
+		{
+			Name:         "RecipientsSave",
+			Method:       http.MethodPost,
+			Path:         "/forms/{id}/recipients/save",
+			Handler:      c.RecipientsSave,
+			AuthRequired: true,
+			Scope:        model.ScopeFormsWrite,
+		},
+		{
+			Name:         "RecipientDelete",
+			Method:       http.MethodDelete,
+			Path:         "/forms/{id}/recipients/{recipient_id}/delete",
+			Handler:      c.RecipientDelete,
+			AuthRequired: true,
+			Scope:        model.ScopeFormsWrite,
+		},
+		{
+			Name:         "RecipientsRemind",
+			Method:       http.MethodPost,
+			Path:         "/forms/{id}/recipients/remind",
+			Handler:      c.RecipientsRemind,
+			AuthRequired: true,
+			Scope:        model.ScopeFormsWrite,
+		},
+	}
+}
+
+func (c *RecipientAPIController) RecipientList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	formID, err := pathID(r, "id")
+	if err != nil {
+		log.Error().Msgf("recipient_api recipient_list %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.RecipientList(ctx, formID)
+	if err != nil {
+		log.Error().Msgf("recipient_api recipient_list error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
+func (c *RecipientAPIController) RecipientsSave(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	formID, err := pathID(r, "id")
+	if err != nil {
+		log.Error().Msgf("recipient_api recipients_save %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	requestJSON, err := io.ReadAll(r.Body)
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	if err != nil {
+		log.Error().Msgf("recipient_api recipients_save body read error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	var recipientsSave model.RecipientsSave
+	if err = json.Unmarshal(requestJSON, &recipientsSave); err != nil {
+		log.Error().Msgf("recipient_api recipients_save unmarshal error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.RecipientsSave(ctx, formID, &recipientsSave)
+	if err != nil {
+		log.Error().Msgf("recipient_api recipients_save error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
+func (c *RecipientAPIController) RecipientDelete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	formID, err := pathID(r, "id")
+	if err != nil {
+		log.Error().Msgf("recipient_api recipient_delete %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	recipientID, err := pathID(r, "recipient_id")
+	if err != nil {
+		log.Error().Msgf("recipient_api recipient_delete %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.RecipientDelete(ctx, formID, recipientID)
+	if err != nil {
+		log.Error().Msgf("recipient_api recipient_delete error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
+func (c *RecipientAPIController) RecipientsRemind(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	formID, err := pathID(r, "id")
+	if err != nil {
+		log.Error().Msgf("recipient_api recipients_remind %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.RecipientsRemind(ctx, formID)
+	if err != nil {
+		log.Error().Msgf("recipient_api recipients_remind error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
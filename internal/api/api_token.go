@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/services/apitoken"
+
+	"github.com/go-chi/chi/v5"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+)
+
+// TokenAPIController manages personal access tokens. The routes have no scope, so tokens cannot
+// be used to create more tokens.
+type TokenAPIController struct {
+	service         apitoken.Service
+	validator       *validator.Validate
+	responseEncoder ResponseEncoder
+}
+
+func NewTokenAPIController(service apitoken.Service, v *validator.Validate, responseEncoder ResponseEncoder) Router {
+	return &TokenAPIController{
+		service:         service,
+		validator:       v,
+		responseEncoder: responseEncoder,
+	}
+}
+
+func (c *TokenAPIController) Routes() []Route {
+	return []Route{
+		{
+			Name:         "TokenList",
+			Method:       http.MethodGet,
+			Path:         "/tokens",
+			Handler:      c.TokenList,
+			AuthRequired: true,
+		},
+		{
+			Name:         "TokenCreate",
+			Method:       http.MethodPost,
+			Path:         "/tokens",
+			Handler:      c.TokenCreate,
+			AuthRequired: true,
+		},
+		{
+			Name:         "TokenRevoke",
+			Method:       http.MethodDelete,
+			Path:         "/tokens/{id}",
+			Handler:      c.TokenRevoke,
+			AuthRequired: true,
+		},
+	}
+}
+
+func (c *TokenAPIController) TokenList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	result, err := c.service.TokenList(ctx)
+	if err != nil {
+		log.Error().Msgf("token_api token_list error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
+func (c *TokenAPIController) TokenCreate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	requestJSON, err := io.ReadAll(r.Body)
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	if err != nil {
+		log.Error().Msgf("token_api token_create body read error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	var token model.APITokenCreate
+	if err = json.Unmarshal(requestJSON, &token); err != nil {
+		log.Error().Msgf("token_api token_create unmarshal error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.TokenCreate(ctx, &token)
+	if err != nil {
+		log.Error().Msgf("token_api token_create error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
+func (c *TokenAPIController) TokenRevoke(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		log.Error().Msgf("token_api token_revoke parse id error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.TokenRevoke(ctx, id)
+	if err != nil {
+		log.Error().Msgf("token_api token_revoke error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
@@ -5,8 +5,11 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"time"
 
 	"go-form-hub/internal/model"
+	"go-form-hub/internal/services/sessioncache"
+	"go-form-hub/microservices/auth/session"
 	"go-form-hub/microservices/user/profile"
 
 	"github.com/go-chi/chi/v5"
@@ -15,16 +18,24 @@ import (
 )
 
 type UserAPIController struct {
-	service         profile.ProfileClient
-	validator       *validator.Validate
-	responseEncoder ResponseEncoder
+	service          profile.ProfileClient
+	authService      session.AuthCheckerClient
+	sessions         *sessioncache.Cache
+	tokenParser      *HashToken
+	cookieExpiration time.Duration
+	validator        *validator.Validate
+	responseEncoder  ResponseEncoder
 }
 
-func NewUserAPIController(service profile.ProfileClient, v *validator.Validate, responseEncoder ResponseEncoder) Router {
+func NewUserAPIController(service profile.ProfileClient, authService session.AuthCheckerClient, sessions *sessioncache.Cache, tokenParser *HashToken, cookieExpiration time.Duration, v *validator.Validate, responseEncoder ResponseEncoder) Router {
 	return &UserAPIController{
-		service:         service,
-		validator:       v,
-		responseEncoder: responseEncoder,
+		service:          service,
+		authService:      authService,
+		sessions:         sessions,
+		tokenParser:      tokenParser,
+		cookieExpiration: cookieExpiration,
+		validator:        v,
+		responseEncoder:  responseEncoder,
 	}
 }
 
@@ -76,12 +87,13 @@ func (c *UserAPIController) ProfileGet(w http.ResponseWriter, r *http.Request) {
 	}
 
 	modelUser := &model.UserGet{
-		ID:        user.Id,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Email:     user.Email,
-		Avatar:    &user.Avatar,
-		Username:  user.Username,
+		ID:            user.Id,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Email:         user.Email,
+		Avatar:        &user.Avatar,
+		Username:      user.Username,
+		EmailVerified: &user.EmailVerified,
 	}
 	c.responseEncoder.EncodeJSONResponse(ctx, modelUser, int(result.Code), w)
 }
@@ -156,18 +168,46 @@ func (c *UserAPIController) ProfileUpdate(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// the cached sessions of the user hold the old profile
+	c.sessions.ForgetUser(curUser.ID)
+
+	// A new password or email changes how the account logs in, so the session gets a new ID and a
+	// stolen one stops working.
+	if updatedUser.NewPassword != "" || updatedUser.Email != curUser.Email {
+		if err = c.rotateSession(w, r); err != nil {
+			log.Error().Msgf("user_api user_update rotate_session error: %v", err)
+			c.responseEncoder.HandleError(ctx, w, err, nil)
+			return
+		}
+	}
+
 	modelUser := &model.UserGet{
-		ID:        user.Id,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Email:     user.Email,
-		Avatar:    &user.Avatar,
-		Username:  user.Username,
+		ID:            user.Id,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Email:         user.Email,
+		Avatar:        &user.Avatar,
+		Username:      user.Username,
+		EmailVerified: &user.EmailVerified,
 	}
 
 	c.responseEncoder.EncodeJSONResponse(ctx, modelUser, int(result.Code), w)
 }
 
+func (c *UserAPIController) rotateSession(w http.ResponseWriter, r *http.Request) error {
+	cookieSession, err := r.Cookie(sessionCookieName)
+	if err != nil {
+		return err
+	}
+
+	rotated, err := c.authService.Rotate(r.Context(), &session.Session{Session: cookieSession.Value})
+	if err != nil {
+		return err
+	}
+
+	return setSessionCookies(w, c.tokenParser, rotated.Session, c.cookieExpiration)
+}
+
 func (c *UserAPIController) UserAvatarGet(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
@@ -0,0 +1,235 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/services/webhook"
+
+	"github.com/go-chi/chi/v5"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/rs/zerolog/log"
+)
+
+type WebhookAPIController struct {
+	service         webhook.Service
+	validator       *validator.Validate
+	responseEncoder ResponseEncoder
+}
+
+func NewWebhookAPIController(service webhook.Service, v *validator.Validate, responseEncoder ResponseEncoder) Router {
+	return &WebhookAPIController{
+		service:         service,
+		validator:       v,
+		responseEncoder: responseEncoder,
+	}
+}
+
+func (c *WebhookAPIController) Routes() []Route {
+	return []Route{
+		{
+			Name:         "WebhookList",
+			Method:       http.MethodGet,
+			Path:         "/forms/{id}/webhooks",
+			Handler:      c.WebhookList,
+			AuthRequired: true,
+			Scope:        model.ScopeFormsRead,
+		},
+		{
+			Name:         "WebhookSave",
+			Method:       http.MethodPost,
+			Path:         "/forms/{id}/webhooks/save",
+			Handler:      c.WebhookSave,
+			AuthRequired: true,
+			Scope:        model.ScopeFormsWrite,
+		},
+		{
+			Name:         "WebhookDelete",
+			Method:       http.MethodDelete,
+			Path:         "/forms/{id}/webhooks/{webhook_id}/delete",
+			Handler:      c.WebhookDelete,
+			AuthRequired: true,
+			Scope:        model.ScopeFormsWrite,
+		},
+		{
+			Name:         "WebhookDeliveries",
+			Method:       http.MethodGet,
+			Path:         "/forms/{id}/webhooks/{webhook_id}/deliveries",
+			Handler:      c.WebhookDeliveries,
+			AuthRequired: true,
+			Scope:        model.ScopeFormsRead,
+		},
+		{
+			Name:         "WebhookTest",
+			Method:       http.MethodPost,
+			Path:         "/forms/{id}/webhooks/{webhook_id}/test",
+			Handler:      c.WebhookTest,
+			AuthRequired: true,
+			Scope:        model.ScopeFormsWrite,
+		},
+	}
+}
+
+func (c *WebhookAPIController) WebhookList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	formID, err := pathID(r, "id")
+	if err != nil {
+		log.Error().Msgf("webhook_api webhook_list %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.WebhookList(ctx, formID)
+	if err != nil {
+		log.Error().Msgf("webhook_api webhook_list error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
+func (c *WebhookAPIController) WebhookSave(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	formID, err := pathID(r, "id")
+	if err != nil {
+		log.Error().Msgf("webhook_api webhook_save %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	requestJSON, err := io.ReadAll(r.Body)
+	defer func() {
+		_ = r.Body.Close()
+	}()
+	if err != nil {
+		log.Error().Msgf("webhook_api webhook_save body read error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	var webhookSave model.Webhook
+	if err = json.Unmarshal(requestJSON, &webhookSave); err != nil {
+		log.Error().Msgf("webhook_api webhook_save unmarshal error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.WebhookSave(ctx, formID, &webhookSave)
+	if err != nil {
+		log.Error().Msgf("webhook_api webhook_save error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
+func (c *WebhookAPIController) WebhookDelete(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	formID, webhookID, err := webhookPathIDs(r)
+	if err != nil {
+		log.Error().Msgf("webhook_api webhook_delete %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.WebhookDelete(ctx, formID, webhookID)
+	if err != nil {
+		log.Error().Msgf("webhook_api webhook_delete error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
+func (c *WebhookAPIController) WebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	formID, webhookID, err := webhookPathIDs(r)
+	if err != nil {
+		log.Error().Msgf("webhook_api webhook_deliveries %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	limit := uint64(webhook.DefaultDeliveryLimit)
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.ParseUint(value, 10, 64)
+		if err == nil && (limit == 0 || limit > webhook.MaxDeliveryLimit) {
+			err = fmt.Errorf("limit must be between 1 and %d", webhook.MaxDeliveryLimit)
+		}
+		if err != nil {
+			err = fmt.Errorf("webhook_api webhook_deliveries parse_limit error: %v", err)
+			log.Error().Msg(err.Error())
+			c.responseEncoder.HandleError(ctx, w, err, nil)
+			return
+		}
+	}
+
+	result, err := c.service.WebhookDeliveries(ctx, formID, webhookID, limit)
+	if err != nil {
+		log.Error().Msgf("webhook_api webhook_deliveries error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
+func (c *WebhookAPIController) WebhookTest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	formID, webhookID, err := webhookPathIDs(r)
+	if err != nil {
+		log.Error().Msgf("webhook_api webhook_test %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, nil)
+		return
+	}
+
+	result, err := c.service.WebhookTest(ctx, formID, webhookID)
+	if err != nil {
+		log.Error().Msgf("webhook_api webhook_test error: %v", err)
+		c.responseEncoder.HandleError(ctx, w, err, result)
+		return
+	}
+
+	c.responseEncoder.EncodeJSONResponse(ctx, result.Body, result.StatusCode, w)
+}
+
+func webhookPathIDs(r *http.Request) (int64, int64, error) {
+	formID, err := pathID(r, "id")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	webhookID, err := pathID(r, "webhook_id")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return formID, webhookID, nil
+}
+
+func pathID(r *http.Request, name string) (int64, error) {
+	param, err := url.PathUnescape(chi.URLParam(r, name))
+	if err != nil {
+		return 0, fmt.Errorf("unescape_%s error: %v", name, err)
+	}
+
+	id, err := strconv.ParseInt(param, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("parse_%s error: %v", name, err)
+	}
+
+	return id, nil
+}
@@ -2,73 +2,131 @@ package api
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"go-form-hub/internal/model"
-	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/apitoken"
+	"go-form-hub/internal/services/authsession"
 	resp "go-form-hub/internal/services/service_response"
+	"go-form-hub/internal/services/sessioncache"
+	"go-form-hub/microservices/auth/session"
 )
 
 const sessionCookieName = "session_id"
 
-func AuthMiddleware(sessionRepository repository.SessionRepository, userRepository repository.UserRepository, cookieExpiration time.Duration, responseEncoder ResponseEncoder) func(next http.HandlerFunc) http.HandlerFunc {
+// AuthMiddleware authenticates the request with the session cookie or with a personal access token
+// in the Authorization header, the auth microservice checks both.
+func AuthMiddleware(sessions *sessioncache.Cache, cookieExpiration time.Duration, authService session.AuthCheckerClient, responseEncoder ResponseEncoder) func(next http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
 
-			session, err := r.Cookie(sessionCookieName)
-			if err != nil {
-				responseEncoder.HandleError(ctx, w, fmt.Errorf("you have to log in or sign up to continue"), &resp.Response{StatusCode: http.StatusUnauthorized})
+			if token, ok := bearerToken(r); ok {
+				if r, ok = authenticateToken(w, r, token, authService, responseEncoder); ok {
+					next.ServeHTTP(w, r)
+				}
 				return
 			}
 
-			sessionInDB, err := sessionRepository.FindByID(r.Context(), session.Value)
+			cookie, err := r.Cookie(sessionCookieName)
 			if err != nil {
-				responseEncoder.HandleError(ctx, w, err, &resp.Response{StatusCode: http.StatusInternalServerError})
+				responseEncoder.HandleError(ctx, w, fmt.Errorf("you have to log in or sign up to continue"), &resp.Response{StatusCode: http.StatusUnauthorized})
 				return
 			}
 
-			if sessionInDB == nil {
-				cookie := createExpiredSessionCookie()
-				http.SetCookie(w, cookie)
+			currentUser, refreshed, err := sessions.Check(ctx, cookie.Value)
+			switch {
+			case errors.Is(err, authsession.ErrNotFound):
+				http.SetCookie(w, createExpiredSessionCookie())
 				responseEncoder.HandleError(ctx, w, fmt.Errorf("you have to log in or sign up to continue"), &resp.Response{StatusCode: http.StatusUnauthorized})
 				return
-			}
-
-			if sessionInDB.CreatedAt.UnixMilli()+cookieExpiration.Milliseconds() < time.Now().UTC().UnixMilli() {
-				cookie := createExpiredSessionCookie()
-				http.SetCookie(w, cookie)
+			case errors.Is(err, authsession.ErrExpired):
+				http.SetCookie(w, createExpiredSessionCookie())
 				responseEncoder.HandleError(ctx, w, fmt.Errorf("session expired"), &resp.Response{StatusCode: http.StatusForbidden})
 				return
-			}
-
-			currentUser, err := userRepository.FindByID(r.Context(), sessionInDB.UserID)
-			if err != nil {
+			case err != nil:
 				responseEncoder.HandleError(ctx, w, err, &resp.Response{StatusCode: http.StatusInternalServerError})
 				return
 			}
 
-			if currentUser == nil {
-				cookie := createExpiredSessionCookie()
-				http.SetCookie(w, cookie)
-				responseEncoder.HandleError(ctx, w, fmt.Errorf("you have to log in or sign up to continue"), &resp.Response{StatusCode: http.StatusUnauthorized})
-				return
+			if refreshed {
+				http.SetCookie(w, createSessionCookie(cookie.Value, cookieExpiration))
 			}
 
-			r = r.WithContext(context.WithValue(r.Context(), model.ContextCurrentUser, &model.UserGet{
-				ID:        currentUser.ID,
-				Username:  currentUser.Username,
-				FirstName: currentUser.FirstName,
-				LastName:  currentUser.LastName,
-				Email:     currentUser.Email,
-			}))
+			r = r.WithContext(context.WithValue(r.Context(), model.ContextCurrentUser, currentUser))
 			next.ServeHTTP(w, r)
 		})
 	}
 }
 
+// bearerToken returns the token of an "Authorization: Bearer" header.
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// authenticateToken puts the user of the personal access token and its scopes in the context of the
+// request. A token only reaches the routes that require one of its scopes, a route without a scope
+// needs the session cookie. When ok is false the error response is already written.
+func authenticateToken(w http.ResponseWriter, r *http.Request, token string, authService session.AuthCheckerClient, responseEncoder ResponseEncoder) (*http.Request, bool) {
+	ctx := r.Context()
+
+	result, err := authService.CheckToken(ctx, &session.Token{Token: token})
+	switch {
+	case err != nil:
+		responseEncoder.HandleError(ctx, w, err, &resp.Response{StatusCode: http.StatusInternalServerError})
+		return nil, false
+	case result.Expired:
+		responseEncoder.HandleError(ctx, w, fmt.Errorf("token expired"), &resp.Response{StatusCode: http.StatusUnauthorized})
+		return nil, false
+	case !result.Valid:
+		responseEncoder.HandleError(ctx, w, fmt.Errorf("invalid token"), &resp.Response{StatusCode: http.StatusUnauthorized})
+		return nil, false
+	}
+	scopes := result.GetScopes()
+
+	scope := routeScope(ctx)
+	if scope == "" {
+		responseEncoder.HandleError(ctx, w, fmt.Errorf("this endpoint cannot be used with a token"), &resp.Response{StatusCode: http.StatusForbidden})
+		return nil, false
+	}
+
+	if !apitoken.HasScope(scopes, scope) {
+		responseEncoder.HandleError(ctx, w, fmt.Errorf("the token does not have the %s scope", scope), &resp.Response{StatusCode: http.StatusForbidden})
+		return nil, false
+	}
+
+	ctx = context.WithValue(ctx, model.ContextTokenScopes, scopes)
+	user := result.GetCurrentUser()
+	ctx = context.WithValue(ctx, model.ContextCurrentUser, &model.UserGet{
+		ID:        user.GetId(),
+		Username:  user.GetUsername(),
+		FirstName: user.GetFirstName(),
+		LastName:  user.GetLastName(),
+		Email:     user.GetEmail(),
+	})
+	return r.WithContext(ctx), true
+}
+
+// createSessionCookie keeps the cookie for as long as the session may stay idle, the expiry moves
+// with every refresh of the session.
+func createSessionCookie(sessionID string, expiration time.Duration) *http.Cookie {
+	return &http.Cookie{
+		Name:     sessionCookieName,
+		HttpOnly: true,
+		Value:    sessionID,
+		Expires:  time.Now().Add(expiration),
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
 func createExpiredSessionCookie() *http.Cookie {
 	return &http.Cookie{
 		Name:     sessionCookieName,
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 
+	"go-form-hub/internal/model"
 	resp "go-form-hub/internal/services/service_response"
 )
 
@@ -22,7 +23,8 @@ func CSRFMiddleware(tokenParser *HashToken, responseEncoder ResponseEncoder) fun
 					break
 				}
 			}
-			if !flag {
+			// a token is sent by the client itself, a browser cannot attach it to a forged request
+			if !flag || ctx.Value(model.ContextTokenScopes) != nil {
 				next.ServeHTTP(w, r)
 				return
 			}
@@ -2,50 +2,50 @@ package api
 
 import (
 	"context"
+	"errors"
 	"net/http"
 	"time"
 
 	"go-form-hub/internal/model"
-	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/authsession"
+	"go-form-hub/internal/services/sessioncache"
+	"go-form-hub/microservices/auth/session"
 )
 
-func CurrentUserMiddleware(sessionRepository repository.SessionRepository, userRepository repository.UserRepository, cookieExpiration time.Duration) func(next http.HandlerFunc) http.HandlerFunc {
+// CurrentUserMiddleware sets the current user on routes that anonymous users can reach as well. A
+// personal access token has to be valid for the route, as with AuthMiddleware.
+func CurrentUserMiddleware(sessions *sessioncache.Cache, cookieExpiration time.Duration, authService session.AuthCheckerClient, responseEncoder ResponseEncoder) func(next http.HandlerFunc) http.HandlerFunc {
 	return func(next http.HandlerFunc) http.HandlerFunc {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			session, err := r.Cookie(sessionCookieName)
-			if err != nil {
-				next.ServeHTTP(w, r)
+			if token, ok := bearerToken(r); ok {
+				if r, ok = authenticateToken(w, r, token, authService, responseEncoder); ok {
+					next.ServeHTTP(w, r)
+				}
 				return
 			}
 
-			sessionInDB, err := sessionRepository.FindByID(r.Context(), session.Value)
-			if err != nil || sessionInDB == nil {
-				cookie := createExpiredSessionCookie()
-				http.SetCookie(w, cookie)
+			cookie, err := r.Cookie(sessionCookieName)
+			if err != nil {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			if sessionInDB.CreatedAt.UnixMilli()+cookieExpiration.Milliseconds() < time.Now().UTC().UnixMilli() {
-				cookie := createExpiredSessionCookie()
-				http.SetCookie(w, cookie)
+			currentUser, refreshed, err := sessions.Check(r.Context(), cookie.Value)
+			if errors.Is(err, authsession.ErrNotFound) || errors.Is(err, authsession.ErrExpired) {
+				http.SetCookie(w, createExpiredSessionCookie())
 				next.ServeHTTP(w, r)
 				return
 			}
-
-			currentUser, err := userRepository.FindByID(r.Context(), sessionInDB.UserID)
-			if err != nil || currentUser == nil {
+			if err != nil {
 				next.ServeHTTP(w, r)
 				return
 			}
 
-			r = r.WithContext(context.WithValue(r.Context(), model.ContextCurrentUser, &model.UserGet{
-				ID:        currentUser.ID,
-				Username:  currentUser.Username,
-				FirstName: currentUser.FirstName,
-				LastName:  currentUser.LastName,
-				Email:     currentUser.Email,
-			}))
+			if refreshed {
+				http.SetCookie(w, createSessionCookie(cookie.Value, cookieExpiration))
+			}
+
+			r = r.WithContext(context.WithValue(r.Context(), model.ContextCurrentUser, currentUser))
 			next.ServeHTTP(w, r)
 		})
 	}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-form-hub/internal/config"
+)
+
+const (
+	respondentCookieName       = "respondent_id"
+	respondentCookieExpiration = 365 * 24 * time.Hour
+	respondentIDSize           = 16
+)
+
+// RespondentIdentifier recognizes returning respondents of anonymous forms without an account:
+// by a random id in a signed cookie and, if enabled, by a fingerprint of the client.
+type RespondentIdentifier struct {
+	tokenParser *HashToken
+	cookie      bool
+	fingerprint bool
+}
+
+func NewRespondentIdentifier(tokenParser *HashToken, cfg *config.Config) *RespondentIdentifier {
+	return &RespondentIdentifier{
+		tokenParser: tokenParser,
+		cookie:      cfg.AnonRespondentCookie,
+		fingerprint: cfg.AnonFingerprint,
+	}
+}
+
+// Identify returns the respondent id from the cookie, setting a new cookie if there is no valid one,
+// and the fingerprint of the client for the form. Each is empty when disabled.
+func (ri *RespondentIdentifier) Identify(w http.ResponseWriter, r *http.Request, formID int64) (respondentID, fingerprint string) {
+	if ri.cookie {
+		respondentID = ri.respondentID(w, r)
+	}
+
+	if ri.fingerprint {
+		// scoped to the form, so the same client cannot be followed across forms
+		h := hmac.New(sha256.New, ri.tokenParser.Secret)
+		h.Write([]byte(fmt.Sprintf("%d\n%s\n%s", formID, ClientAddress(r), r.UserAgent())))
+		fingerprint = hex.EncodeToString(h.Sum(nil))
+	}
+
+	return respondentID, fingerprint
+}
+
+func (ri *RespondentIdentifier) respondentID(w http.ResponseWriter, r *http.Request) string {
+	if cookie, err := r.Cookie(respondentCookieName); err == nil {
+		// the value is "<id>.<signature>", see HashToken.Create
+		id, token, found := strings.Cut(cookie.Value, ".")
+		if found {
+			if valid, err := ri.tokenParser.Check(id, token); err == nil && valid {
+				return id
+			}
+		}
+	}
+
+	idBytes := make([]byte, respondentIDSize)
+	if _, err := rand.Read(idBytes); err != nil {
+		return ""
+	}
+	id := hex.EncodeToString(idBytes)
+
+	expires := time.Now().Add(respondentCookieExpiration)
+	token, err := ri.tokenParser.Create(id, expires.Unix())
+	if err != nil {
+		return ""
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     respondentCookieName,
+		Value:    id + "." + token,
+		Path:     "/",
+		HttpOnly: true,
+		Expires:  expires,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return id
+}
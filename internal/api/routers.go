@@ -1,6 +1,8 @@
 package api
 
 import (
+	"context"
+	"net"
 	"net/http"
 
 	"go-form-hub/internal/config"
@@ -16,8 +18,13 @@ type Route struct {
 	Path         string
 	Handler      http.HandlerFunc
 	AuthRequired bool
+	// Scope is the scope a personal access token needs for the route. Routes without one can only
+	// be used with the session cookie.
+	Scope string
 }
 
+type routeScopeKey struct{}
+
 type Router interface {
 	Routes() []Route
 }
@@ -35,13 +42,16 @@ type Router interface {
 // added to it.
 func NewRouter(cfg *config.Config, authMiddleware, currentUserMiddleware, csrfMiddleware func(http.HandlerFunc) http.HandlerFunc, routers ...Router) chi.Router {
 	router := chi.NewRouter()
+	if cfg.TrustProxyHeaders {
+		router.Use(middleware.RealIP)
+	}
 	router.Use(middleware.Logger)
 
 	router.Use(cors.Handler(cors.Options{
 		AllowOriginFunc:  AllowOriginFunc,
 		AllowedOrigins:   []string{cfg.AllowedOrigin},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", AccessGrantHeader, IdempotencyKeyHeader},
 		ExposedHeaders:   []string{"Link", "X-Csrf-Token"},
 		AllowCredentials: true,
 		MaxAge:           300,
@@ -56,6 +66,7 @@ func NewRouter(cfg *config.Config, authMiddleware, currentUserMiddleware, csrfMi
 			} else {
 				handler = currentUserMiddleware(handler)
 			}
+			handler = withRouteScope(route.Scope, handler)
 
 			apiPath := "/api/v1" + route.Path
 			router.Method(route.Method, apiPath, handler)
@@ -65,6 +76,28 @@ func NewRouter(cfg *config.Config, authMiddleware, currentUserMiddleware, csrfMi
 	return router
 }
 
+// withRouteScope tells the authentication middlewares which scope a token needs for the route.
+func withRouteScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), routeScopeKey{}, scope)))
+	}
+}
+
+func routeScope(ctx context.Context) string {
+	scope, _ := ctx.Value(routeScopeKey{}).(string)
+	return scope
+}
+
 func AllowOriginFunc(_ *http.Request, _ string) bool {
 	return true
 }
+
+// ClientAddress returns the IP address of the client. Behind a proxy it comes from the proxy
+// headers when cfg.TrustProxyHeaders is set.
+func ClientAddress(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"encoding/json"
 	"fmt"
 	"io"
 	"os"
@@ -27,6 +28,23 @@ const (
 	defaultAcquireTimeout              = 1 * time.Second
 	defaultAllowedOrigin               = "*"
 	defaultSecret                      = "vasya"
+	defaultAppURL                      = "http://localhost:8080"
+	defaultMailSender                  = "log"
+	defaultMailFrom                    = "no-reply@localhost"
+	defaultMailDir                     = "./mail"
+	defaultSMTPPort                    = "587"
+	defaultNotificationInterval        = 1 * time.Minute
+	defaultAnonRespondentCookie        = true
+	defaultAnonFingerprintRetention    = 30 * 24 * time.Hour
+	defaultPasswordResetTTL            = 1 * time.Hour
+	defaultEmailVerificationTTL        = 24 * time.Hour
+	defaultLoginAttemptStore           = LoginAttemptStorePostgres
+	defaultSessionCacheTTL             = 30 * time.Second
+)
+
+const (
+	LoginAttemptStoreMemory   = "memory"
+	LoginAttemptStorePostgres = "postgres"
 )
 
 type Config struct {
@@ -46,6 +64,54 @@ type Config struct {
 	EncryptionKey    string        `env:"ENCRYPTION_KEY" conf:"ENCRYPTION_KEY" json:"ENCRYPTION_KEY"`
 	CookieExpiration time.Duration `env:"COOKIE_EXPIRATION" conf:"COOKIE_EXPIRATION" json:"COOKIE_EXPIRATION"`
 	AllowedOrigin    string        `env:"ALLOWED_ORIGIN" conf:"ALLOWED_ORIGIN" json:"ALLOWED_ORIGIN"`
+	// TrustProxyHeaders takes the client address from X-Real-IP or X-Forwarded-For, only enable it behind a proxy.
+	TrustProxyHeaders bool `env:"TRUST_PROXY_HEADERS" conf:"TRUST_PROXY_HEADERS" json:"TRUST_PROXY_HEADERS"`
+	// AppURL is the address of the frontend used for links in emails.
+	AppURL string `env:"APP_URL" conf:"APP_URL" json:"APP_URL"`
+
+	// MailSender is smtp, file (writes .eml files to MailDir) or log.
+	MailSender           string        `env:"MAIL_SENDER" conf:"MAIL_SENDER" json:"MAIL_SENDER"`
+	MailFrom             string        `env:"MAIL_FROM" conf:"MAIL_FROM" json:"MAIL_FROM"`
+	MailDir              string        `env:"MAIL_DIR" conf:"MAIL_DIR" json:"MAIL_DIR"`
+	SMTPHost             string        `env:"SMTP_HOST" conf:"SMTP_HOST" json:"SMTP_HOST"`
+	SMTPPort             string        `env:"SMTP_PORT" conf:"SMTP_PORT" json:"SMTP_PORT"`
+	SMTPUsername         string        `env:"SMTP_USERNAME" conf:"SMTP_USERNAME" json:"SMTP_USERNAME"`
+	SMTPPassword         string        `env:"SMTP_PASSWORD" conf:"SMTP_PASSWORD" json:"-"`
+	NotificationInterval time.Duration `env:"NOTIFICATION_INTERVAL" conf:"NOTIFICATION_INTERVAL" json:"NOTIFICATION_INTERVAL"`
+
+	// AnonRespondentCookie recognizes returning respondents of anonymous forms by a signed cookie.
+	AnonRespondentCookie bool `env:"ANON_RESPONDENT_COOKIE" conf:"ANON_RESPONDENT_COOKIE" json:"ANON_RESPONDENT_COOKIE"`
+	// AnonFingerprint also recognizes them by a hash of the IP address and the user agent,
+	// which is kept for AnonFingerprintRetention after the last passage.
+	AnonFingerprint          bool          `env:"ANON_FINGERPRINT" conf:"ANON_FINGERPRINT" json:"ANON_FINGERPRINT"`
+	AnonFingerprintRetention time.Duration `env:"ANON_FINGERPRINT_RETENTION" conf:"ANON_FINGERPRINT_RETENTION" json:"ANON_FINGERPRINT_RETENTION"`
+
+	// PasswordResetTTL is how long a password reset link stays valid.
+	PasswordResetTTL time.Duration `env:"PASSWORD_RESET_TTL" conf:"PASSWORD_RESET_TTL" json:"PASSWORD_RESET_TTL"`
+	// EmailVerificationTTL is how long an email verification link stays valid.
+	EmailVerificationTTL time.Duration `env:"EMAIL_VERIFICATION_TTL" conf:"EMAIL_VERIFICATION_TTL" json:"EMAIL_VERIFICATION_TTL"`
+
+	// LoginAttemptStore is where the auth microservice counts failed logins: postgres, shared by
+	// all replicas, or memory, for a single replica.
+	LoginAttemptStore string `env:"LOGIN_ATTEMPT_STORE" conf:"LOGIN_ATTEMPT_STORE" json:"LOGIN_ATTEMPT_STORE"`
+	// SessionCacheTTL is how long the gateway trusts a session the auth microservice checked, 0
+	// checks every request. A session revoked elsewhere keeps working for up to this long.
+	SessionCacheTTL time.Duration `env:"SESSION_CACHE_TTL" conf:"SESSION_CACHE_TTL" json:"SESSION_CACHE_TTL"`
+
+	// OIDCProvidersJSON is a JSON array of OIDCProvider, NewConfig parses it into OIDCProviders.
+	OIDCProvidersJSON string         `env:"OIDC_PROVIDERS" conf:"OIDC_PROVIDERS" json:"-"`
+	OIDCProviders     []OIDCProvider `json:"-"`
+}
+
+// OIDCProvider is an OpenID Connect provider users can log in with. Name is part of the login and
+// the callback URL, the callback has to be registered with the provider as
+// APP_URL/api/v1/oidc/{name}/callback.
+type OIDCProvider struct {
+	Name         string   `json:"name"`
+	Issuer       string   `json:"issuer"`
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret"`
+	Scopes       []string `json:"scopes,omitempty"`
 }
 
 func NewConfig() (*Config, error) {
@@ -63,6 +129,18 @@ func NewConfig() (*Config, error) {
 		DatabaseConnectRetryTimeout: defaultDatabaseConnectRetryTimeout,
 		DatabaseAcquireTimeout:      defaultAcquireTimeout,
 		Secret:                      defaultSecret,
+		AppURL:                      defaultAppURL,
+		MailSender:                  defaultMailSender,
+		MailFrom:                    defaultMailFrom,
+		MailDir:                     defaultMailDir,
+		SMTPPort:                    defaultSMTPPort,
+		NotificationInterval:        defaultNotificationInterval,
+		AnonRespondentCookie:        defaultAnonRespondentCookie,
+		AnonFingerprintRetention:    defaultAnonFingerprintRetention,
+		PasswordResetTTL:            defaultPasswordResetTTL,
+		EmailVerificationTTL:        defaultEmailVerificationTTL,
+		LoginAttemptStore:           defaultLoginAttemptStore,
+		SessionCacheTTL:             defaultSessionCacheTTL,
 	}
 
 	_ = LoadConfigFile(&cfg, "config.conf")
@@ -79,6 +157,27 @@ func NewConfig() (*Config, error) {
 		return nil, fmt.Errorf("config is broken, database url is empty")
 	}
 
+	if cfg.LoginAttemptStore != LoginAttemptStoreMemory && cfg.LoginAttemptStore != LoginAttemptStorePostgres {
+		return nil, fmt.Errorf("config is broken, login attempt store %s is neither memory nor postgres", cfg.LoginAttemptStore)
+	}
+
+	if cfg.OIDCProvidersJSON != "" {
+		if err := json.Unmarshal([]byte(cfg.OIDCProvidersJSON), &cfg.OIDCProviders); err != nil {
+			return nil, fmt.Errorf("config is broken, unable to parse oidc providers: %e", err)
+		}
+	}
+
+	names := map[string]bool{}
+	for _, provider := range cfg.OIDCProviders {
+		if provider.Name == "" || provider.Issuer == "" || provider.ClientID == "" {
+			return nil, fmt.Errorf("config is broken, an oidc provider needs a name, an issuer and a client id")
+		}
+		if names[provider.Name] {
+			return nil, fmt.Errorf("config is broken, oidc provider %s is listed twice", provider.Name)
+		}
+		names[provider.Name] = true
+	}
+
 	return &cfg, nil
 }
 
@@ -130,6 +229,9 @@ func LoadConfigFile(cfg *Config, filepath string) error {
 			case "int":
 				val, _ := strconv.ParseInt(configValue, 10, 0)
 				fieldValue.Set(reflect.ValueOf(int(val)))
+			case "bool":
+				val, _ := strconv.ParseBool(configValue)
+				fieldValue.Set(reflect.ValueOf(val))
 			case "string":
 				fieldValue.Set(reflect.ValueOf(configValue))
 			case "time.Duration":
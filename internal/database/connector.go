@@ -2,14 +2,17 @@ package database
 
 import (
 	"context"
+	"fmt"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 type ConnPool interface {
 	GetSchema() string
 	Close()
 	Begin(ctx context.Context) (pgx.Tx, error)
+	Listen(ctx context.Context, channel string, handle func(payload string)) error
 }
 
 type PgxIface interface {
@@ -40,3 +43,35 @@ func (p *connPool) Close() {
 func (p *connPool) Begin(ctx context.Context) (pgx.Tx, error) {
 	return p.db.Begin(ctx)
 }
+
+// Listen subscribes to a NOTIFY channel on a dedicated connection and calls handle for every
+// notification until ctx is done or the connection fails. The connection is closed afterwards.
+func (p *connPool) Listen(ctx context.Context, channel string, handle func(payload string)) error {
+	pool, ok := p.db.(*pgxpool.Pool)
+	if !ok {
+		return fmt.Errorf("listen is not supported by %T", p.db)
+	}
+
+	pooledConn, err := pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("listen failed to acquire connection: %e", err)
+	}
+
+	conn := pooledConn.Hijack()
+	defer func() {
+		_ = conn.Close(context.Background())
+	}()
+
+	if _, err = conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		return fmt.Errorf("listen failed to subscribe to %s: %e", channel, err)
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		handle(notification.Payload)
+	}
+}
@@ -0,0 +1,55 @@
+package model
+
+import (
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// Scopes of personal access tokens. A token can only reach the endpoints of its scopes.
+const (
+	ScopeFormsRead   = "forms:read"
+	ScopeResultsRead = "results:read"
+	ScopeFormsWrite  = "forms:write"
+)
+
+// ContextTokenScopes holds the scopes of the token when a request is authenticated with a
+// personal access token instead of the session cookie.
+const ContextTokenScopes = ContextCurrentUserType("token_scopes")
+
+type APITokenCreate struct {
+	Name   string   `json:"name" validate:"required,max=64"`
+	Scopes []string `json:"scopes" validate:"required,min=1,unique,dive,oneof=forms:read results:read forms:write"`
+	// ExpiresAt is optional, a token without it stays valid until it is revoked.
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type APITokenGet struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+func (token *APITokenGet) Sanitize(sanitizer *bluemonday.Policy) {
+	token.Name = sanitizer.Sanitize(token.Name)
+}
+
+// APITokenCreated is the only response that holds the token itself, it cannot be shown again.
+type APITokenCreated struct {
+	APITokenGet
+	Token string `json:"token"`
+}
+
+type APITokenList struct {
+	CollectionResponse
+	Tokens []*APITokenGet `json:"tokens"`
+}
+
+func (tokens *APITokenList) Sanitize(sanitizer *bluemonday.Policy) {
+	for _, token := range tokens.Tokens {
+		token.Sanitize(sanitizer)
+	}
+}
@@ -2,6 +2,7 @@ package model
 
 import (
 	"database/sql"
+	"fmt"
 	"time"
 
 	"github.com/microcosm-cc/bluemonday"
@@ -15,9 +16,17 @@ type Form struct {
 	Description         *string     `json:"description"`
 	Anonymous           bool        `json:"anonymous"`
 	PassageMax          int         `json:"passage_max"`
+	InviteOnly          bool        `json:"invite_only"`
+	VerifiedOnly        bool        `json:"verified_only" validate:"excluded_if=Anonymous true"`
+	AccessPassword      *string     `json:"access_password,omitempty" validate:"omitempty,min=4,max=72"`
+	PasswordProtected   bool        `json:"password_protected"`
+	PasswordHash        string      `json:"-"`
+	Locked              bool        `json:"locked,omitempty"`
+	StartToken          string      `json:"start_token,omitempty"`
 	CurrentPassageTotal int         `json:"cur_passage_total"`
 	Author              *UserGet    `json:"author"`
 	CreatedAt           time.Time   `json:"created_at"`
+	ClosedAt            *time.Time  `json:"closed_at"`
 	Questions           []*Question `json:"questions" validate:"required"`
 }
 
@@ -32,6 +41,23 @@ func (form *Form) Sanitize(sanitizer *bluemonday.Policy) {
 	}
 }
 
+// AccessGrantSubject is what an access grant of a password-protected form is signed for.
+// It includes the password hash, so changing the password revokes the grants issued before.
+func (form *Form) AccessGrantSubject() string {
+	return fmt.Sprintf("form_access:%d:%s", *form.ID, form.PasswordHash)
+}
+
+// StartSubject is what a start token of the form opened at startedAt is signed for.
+func StartSubject(formID, startedAt int64) string {
+	return fmt.Sprintf("form_start:%d:%d", formID, startedAt)
+}
+
+// Lock hides the questions of a password-protected form from respondents who have not unlocked it.
+func (form *Form) Lock() {
+	form.Locked = true
+	form.Questions = []*Question{}
+}
+
 type FormTitle struct {
 	ID                   int64     `json:"id" validate:"required" db:"id"`
 	Title                string    `json:"title" validate:"required" db:"title"`
@@ -71,6 +97,10 @@ type FormUpdate struct {
 	Description      *string     `json:"description"`
 	Anonymous        bool        `json:"anonymous"`
 	PassageMax       int         `json:"passage_max"`
+	InviteOnly       bool        `json:"invite_only"`
+	VerifiedOnly     bool        `json:"verified_only" validate:"excluded_if=Anonymous true"`
+	AccessPassword   *string     `json:"access_password,omitempty" validate:"omitempty,min=4,max=72"`
+	PasswordHash     *string     `json:"-"`
 	Author           *UserGet    `json:"author"`
 	CreatedAt        time.Time   `json:"created_at"`
 	Questions        []*Question `json:"questions" validate:"required"`
@@ -115,9 +145,132 @@ func (form *FormResult) Sanitize(sanitizer *bluemonday.Policy) {
 	}
 }
 
+// FormResultFilter narrows form results down to passages that match every answer
+// filter and were finished within [From, To).
+type FormResultFilter struct {
+	Answers []*AnswerFilter
+	From    *time.Time
+	To      *time.Time
+}
+
+type AnswerFilter struct {
+	QuestionID int64
+	Text       string
+}
+
+func (filter *FormResultFilter) IsEmpty() bool {
+	return filter == nil || (len(filter.Answers) == 0 && filter.From == nil && filter.To == nil)
+}
+
+type CrossTabQuestion struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Type  int    `json:"type"`
+}
+
+type CrossTabCell struct {
+	Count         int     `json:"count"`
+	RowPercent    float64 `json:"row_percent"`
+	ColumnPercent float64 `json:"column_percent"`
+}
+
+// FormCrossTab is a contingency table of answers to two questions of the same form.
+// Cells[i][j] holds the number of passages that gave answer Rows[i] to RowQuestion
+// and answer Columns[j] to ColumnQuestion.
+type FormCrossTab struct {
+	FormID         int64             `json:"form_id"`
+	RowQuestion    *CrossTabQuestion `json:"row_question"`
+	ColumnQuestion *CrossTabQuestion `json:"column_question"`
+	Rows           []string          `json:"rows"`
+	Columns        []string          `json:"columns"`
+	Cells          [][]*CrossTabCell `json:"cells"`
+	RowTotals      []int             `json:"row_totals"`
+	ColumnTotals   []int             `json:"column_totals"`
+	Total          int               `json:"total"`
+}
+
+func (crossTab *FormCrossTab) Sanitize(sanitizer *bluemonday.Policy) {
+	crossTab.RowQuestion.Title = sanitizer.Sanitize(crossTab.RowQuestion.Title)
+	crossTab.ColumnQuestion.Title = sanitizer.Sanitize(crossTab.ColumnQuestion.Title)
+	for i := range crossTab.Rows {
+		crossTab.Rows[i] = sanitizer.Sanitize(crossTab.Rows[i])
+	}
+	for i := range crossTab.Columns {
+		crossTab.Columns[i] = sanitizer.Sanitize(crossTab.Columns[i])
+	}
+}
+
+const (
+	TimelineHour = "hour"
+	TimelineDay  = "day"
+	TimelineWeek = "week"
+)
+
+// FormTimeline counts passages of a form per Interval in the given Timezone.
+// Buckets cover every interval between the first and the last passage, empty ones included.
+type FormTimeline struct {
+	FormID   int64             `json:"form_id"`
+	Interval string            `json:"interval"`
+	Timezone string            `json:"timezone"`
+	Buckets  []*TimelineBucket `json:"buckets"`
+	Total    int               `json:"total"`
+	// MedianCompletionSeconds is nil when no passage recorded a start time.
+	MedianCompletionSeconds *float64 `json:"median_completion_seconds"`
+}
+
+type TimelineBucket struct {
+	Start      time.Time `json:"start"`
+	Count      int       `json:"count"`
+	Cumulative int       `json:"cumulative"`
+}
+
 type FormPassage struct {
 	FormID         *int64           `json:"form_id" validate:"required"`
 	PassageAnswers []*PassageAnswer `json:"passage_answers" validate:"required"`
+	// StartToken is the start_token of the form as the respondent opened it.
+	StartToken string `json:"start_token,omitempty"`
+	// StartedAt is when the respondent opened the form, taken from a valid StartToken.
+	StartedAt *time.Time `json:"-"`
+	// AccessToken is the invitation token required by invite-only forms.
+	AccessToken string `json:"access_token,omitempty"`
+	// RecipientID is the recipient the access token belongs to, set once the token is checked.
+	RecipientID *int64 `json:"-"`
+	// AccessGrant is the grant returned by unlocking a password-protected form.
+	AccessGrant string `json:"access_grant,omitempty"`
+	// RespondentID and Fingerprint recognize an anonymous respondent, see RespondentMark.
+	RespondentID string `json:"-"`
+	Fingerprint  string `json:"-"`
+	// RespondentMarks are counted against PassageMax when the passage is saved.
+	RespondentMarks []*RespondentMark `json:"-"`
+	PassageMax      int               `json:"-"`
+	// IdempotencyKey comes from the Idempotency-Key header, a passage is saved once per key.
+	IdempotencyKey string `json:"-" validate:"max=255"`
+	// IdempotencyHash identifies the respondent and the answers the key is used with, the key sent
+	// again with another passage is a conflict rather than a replay.
+	IdempotencyHash string `json:"-"`
+	// FinishedAt is the original time of an imported passage, saved passages are stamped by the database.
+	FinishedAt *time.Time `json:"-"`
+}
+
+// FormAccess holds what a respondent presents to open a restricted form.
+type FormAccess struct {
+	// Token is the invitation token of an invite-only form.
+	Token string
+	// Grant is the access grant of a password-protected form.
+	Grant string
+}
+
+type FormUnlock struct {
+	Password string `json:"password" validate:"required,max=72"`
+}
+
+type FormAccessGrant struct {
+	Grant     string    `json:"grant"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+type RetryAfter struct {
+	Seconds int `json:"retry_after"`
 }
 
 type PassageAnswer struct {
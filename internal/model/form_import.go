@@ -0,0 +1,24 @@
+package model
+
+import "github.com/microcosm-cc/bluemonday"
+
+// FormImport is a form converted from a definition exported by another tool.
+type FormImport struct {
+	Form   *Form              `json:"form"`
+	Issues []*FormImportIssue `json:"issues"`
+}
+
+// FormImportIssue reports an item of the definition that was left out or imported with changes.
+type FormImportIssue struct {
+	// Item is the title of the item or its ID when it has no title.
+	Item    string `json:"item"`
+	Skipped bool   `json:"skipped"`
+	Reason  string `json:"reason"`
+}
+
+func (formImport *FormImport) Sanitize(sanitizer *bluemonday.Policy) {
+	formImport.Form.Sanitize(sanitizer)
+	for _, issue := range formImport.Issues {
+		issue.Item = sanitizer.Sanitize(issue.Item)
+	}
+}
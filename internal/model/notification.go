@@ -0,0 +1,18 @@
+package model
+
+const (
+	NotificationModeOff     = "off"
+	NotificationModeInstant = "instant"
+	NotificationModeDaily   = "daily"
+)
+
+type NotificationPreference struct {
+	Mode string `json:"mode" validate:"required,oneof=off instant daily"`
+}
+
+// FormDigest is the number of passages a form got within a notification period.
+type FormDigest struct {
+	FormID      int64
+	Title       string
+	NewPassages int
+}
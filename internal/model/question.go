@@ -1,6 +1,10 @@
 package model
 
-import "github.com/microcosm-cc/bluemonday"
+import (
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+)
 
 const (
 	SingleAnswerType   = 1
@@ -46,3 +50,77 @@ func (question *QuestionResult) Sanitize(sanitizer *bluemonday.Policy) {
 		answer.Sanitize(sanitizer)
 	}
 }
+
+type TextAnalyticsParams struct {
+	Page    int `validate:"min=1"`
+	PerPage int `validate:"min=1,max=100"`
+	// Top limits the number of words and bigrams returned.
+	Top int `validate:"min=1,max=50"`
+	// Similarity is the pg_trgm similarity from which two answers are treated as near duplicates.
+	Similarity float64 `validate:"gt=0,lte=1"`
+}
+
+// SimilarTextsMax is how many of the most frequent distinct answers are grouped by similarity.
+const SimilarTextsMax = 500
+
+// TextAnalytics describes free text answers given to an input question.
+// Answers holds a single page of raw answers, Groups cover the SimilarTextsMax most frequent
+// distinct answers, all other fields cover every answer.
+type TextAnalytics struct {
+	FormID       int64          `json:"form_id"`
+	QuestionID   int64          `json:"question_id"`
+	Title        string         `json:"title"`
+	Type         int            `json:"type"`
+	Page         int            `json:"page"`
+	PerPage      int            `json:"per_page"`
+	TotalAnswers int            `json:"total_answers"`
+	Answers      []*TextAnswer  `json:"answers"`
+	Length       *LengthStats   `json:"length"`
+	TopWords     []*TermCount   `json:"top_words"`
+	TopBigrams   []*TermCount   `json:"top_bigrams"`
+	Groups       []*AnswerGroup `json:"groups"`
+}
+
+type TextAnswer struct {
+	PassageID  int64     `json:"passage_id"`
+	Text       string    `json:"text"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// LengthStats measures answers in characters.
+type LengthStats struct {
+	Min    int     `json:"min"`
+	Max    int     `json:"max"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+}
+
+type TermCount struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
+}
+
+// AnswerGroup gathers answers that are near duplicates of each other.
+// Texts are ordered by how often they were given, so the first one represents the group.
+type AnswerGroup struct {
+	Texts []string `json:"texts"`
+	Count int      `json:"count"`
+}
+
+func (analytics *TextAnalytics) Sanitize(sanitizer *bluemonday.Policy) {
+	analytics.Title = sanitizer.Sanitize(analytics.Title)
+	for _, answer := range analytics.Answers {
+		answer.Text = sanitizer.Sanitize(answer.Text)
+	}
+	for _, term := range analytics.TopWords {
+		term.Term = sanitizer.Sanitize(term.Term)
+	}
+	for _, term := range analytics.TopBigrams {
+		term.Term = sanitizer.Sanitize(term.Term)
+	}
+	for _, group := range analytics.Groups {
+		for i := range group.Texts {
+			group.Texts[i] = sanitizer.Sanitize(group.Texts[i])
+		}
+	}
+}
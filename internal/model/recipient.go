@@ -0,0 +1,51 @@
+package model
+
+import (
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+type FormRecipient struct {
+	ID          *int64     `json:"id"`
+	FormID      int64      `json:"form_id"`
+	Email       string     `json:"email" validate:"required,email,max=255"`
+	Name        string     `json:"name" validate:"max=255"`
+	MaxUses     int        `json:"max_uses"`
+	Uses        int        `json:"uses"`
+	InvitedAt   time.Time  `json:"invited_at"`
+	RemindedAt  *time.Time `json:"reminded_at"`
+	RespondedAt *time.Time `json:"responded_at"`
+	// Token and Link are only returned when the token is issued, the database keeps just its hash.
+	Token string `json:"token,omitempty"`
+	Link  string `json:"link,omitempty"`
+}
+
+func (recipient *FormRecipient) Sanitize(sanitizer *bluemonday.Policy) {
+	recipient.Name = sanitizer.Sanitize(recipient.Name)
+}
+
+// Responded reports whether the recipient has used the invitation at least once.
+func (recipient *FormRecipient) Responded() bool {
+	return recipient.Uses > 0
+}
+
+type RecipientsSave struct {
+	Recipients []*FormRecipient `json:"recipients" validate:"required,min=1,max=500,dive"`
+	// MaxUses is how many passages every token allows, 1 if not set.
+	MaxUses     int  `json:"max_uses" validate:"min=0,max=1000"`
+	SendInvites bool `json:"send_invites"`
+}
+
+type RecipientList struct {
+	CollectionResponse
+	Responded  int              `json:"responded"`
+	Pending    int              `json:"pending"`
+	Recipients []*FormRecipient `json:"recipients" validate:"required"`
+}
+
+func (list *RecipientList) Sanitize(sanitizer *bluemonday.Policy) {
+	for _, recipient := range list.Recipients {
+		recipient.Sanitize(sanitizer)
+	}
+}
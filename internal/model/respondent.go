@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+const (
+	RespondentMarkCookie      = "cookie"
+	RespondentMarkFingerprint = "fingerprint"
+	RespondentMarkUser        = "user"
+)
+
+// RespondentMark recognizes a returning respondent of an anonymous form by a hash, so that
+// passage_max is enforced without storing who passed the form.
+type RespondentMark struct {
+	Kind    string
+	KeyHash string
+	// ForgetBefore drops the passages counted before it, the zero time keeps them all.
+	ForgetBefore time.Time
+}
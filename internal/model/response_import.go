@@ -0,0 +1,39 @@
+package model
+
+import "github.com/microcosm-cc/bluemonday"
+
+// ResponseImport tells how to read the responses of a CSV or XLSX file into passages of a form.
+// The first row of the file holds the column headers.
+type ResponseImport struct {
+	// Columns maps a column header to a question ID, a column titled like a question needs no entry.
+	Columns map[string]int64 `json:"columns"`
+	// TimestampColumn holds when each response was given, without it passages are stamped with the import time.
+	TimestampColumn string `json:"timestamp_column" validate:"max=255"`
+	// Separator splits a cell of a multiple answer question, "; " as in the results export by default.
+	Separator string `json:"separator" validate:"max=8"`
+	// Sheet is the XLSX sheet to read, by default the Responses sheet of the results export or the first one.
+	Sheet string `json:"sheet" validate:"max=255"`
+}
+
+type ImportRowError struct {
+	Row    int    `json:"row"`
+	Column string `json:"column,omitempty"`
+	Error  string `json:"error"`
+}
+
+type ResponseImportResult struct {
+	Imported       int               `json:"imported"`
+	Failed         int               `json:"failed"`
+	IgnoredColumns []string          `json:"ignored_columns"`
+	Errors         []*ImportRowError `json:"errors"`
+}
+
+func (result *ResponseImportResult) Sanitize(sanitizer *bluemonday.Policy) {
+	for i := range result.IgnoredColumns {
+		result.IgnoredColumns[i] = sanitizer.Sanitize(result.IgnoredColumns[i])
+	}
+	for _, rowError := range result.Errors {
+		rowError.Column = sanitizer.Sanitize(rowError.Column)
+		rowError.Error = sanitizer.Sanitize(rowError.Error)
+	}
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"time"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+type SessionGet struct {
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	Current    bool      `json:"current"`
+}
+
+func (session *SessionGet) Sanitize(sanitizer *bluemonday.Policy) {
+	session.UserAgent = sanitizer.Sanitize(session.UserAgent)
+	session.IP = sanitizer.Sanitize(session.IP)
+}
+
+type SessionList struct {
+	CollectionResponse
+	Sessions []*SessionGet `json:"sessions"`
+}
+
+func (sessions *SessionList) Sanitize(sanitizer *bluemonday.Policy) {
+	for _, session := range sessions.Sessions {
+		session.Sanitize(sanitizer)
+	}
+}
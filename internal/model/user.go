@@ -9,8 +9,10 @@ type ContextCurrentUserType string
 const ContextCurrentUser = ContextCurrentUserType("current_user")
 
 type UserLogin struct {
-	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required"`
+	Email     string `json:"email" validate:"required,email"`
+	Password  string `json:"password" validate:"required"`
+	UserAgent string `json:"-"`
+	IP        string `json:"-"`
 }
 
 type UserSignUp struct {
@@ -20,6 +22,8 @@ type UserSignUp struct {
 	Password  string  `json:"password" validate:"required"`
 	Email     string  `json:"email" validate:"required,email"`
 	Avatar    *string `json:"avatar,omitempty"`
+	UserAgent string  `json:"-"`
+	IP        string  `json:"-"`
 }
 
 type UserGet struct {
@@ -29,6 +33,8 @@ type UserGet struct {
 	Username  string  `json:"username" validate:"required,alphanum"`
 	Email     string  `json:"email,omitempty" validate:"omitempty,email"`
 	Avatar    *string `json:"avatar,omitempty"`
+	// EmailVerified is only set on the profile of the current user.
+	EmailVerified *bool `json:"email_verified,omitempty"`
 }
 
 func (user *UserGet) Sanitize(sanitizer *bluemonday.Policy) {
@@ -48,6 +54,63 @@ type UserUpdate struct {
 	Avatar      *string `json:"avatar,omitempty"`
 }
 
+type PasswordForgot struct {
+	Email string `json:"email" validate:"required,email"`
+	IP    string `json:"-"`
+}
+
+type PasswordReset struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required"`
+}
+
+type EmailVerify struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// TwoFactorChallenge answers a login with the right password of a user with two-factor
+// authentication. The pending token completes the login together with a code.
+type TwoFactorChallenge struct {
+	TwoFactorRequired bool   `json:"two_factor_required"`
+	PendingToken      string `json:"pending_token"`
+}
+
+// SecondFactor is a code from the authenticator app or a recovery code.
+type SecondFactor struct {
+	PendingToken string `json:"pending_token" validate:"required"`
+	Code         string `json:"code" validate:"required"`
+}
+
+type TwoFactorSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`
+}
+
+type TwoFactorConfirm struct {
+	Code string `json:"code" validate:"required"`
+}
+
+type TwoFactorDisable struct {
+	Password string `json:"password" validate:"required"`
+}
+
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+// OIDCIdentity is the account of a user at an OpenID Connect provider, taken from a verified ID
+// token.
+type OIDCIdentity struct {
+	Provider      string `validate:"required"`
+	Subject       string `validate:"required"`
+	Email         string `validate:"required,email"`
+	EmailVerified bool
+	FirstName     string
+	LastName      string
+	UserAgent     string
+	IP            string
+}
+
 type UserAvatarGet struct {
 	Username string  `json:"username" validate:"required,alphanum"`
 	Avatar   *string `json:"avatar" validate:"required"`
@@ -0,0 +1,73 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	WebhookEventPassageCreated = "passage.created"
+	WebhookEventFormPublished  = "form.published"
+	WebhookEventFormClosed     = "form.closed"
+	// WebhookEventPing is only sent by the test delivery and cannot be subscribed to.
+	WebhookEventPing = "ping"
+)
+
+const (
+	DeliveryStatusPending   = "pending"
+	DeliveryStatusDelivered = "delivered"
+	DeliveryStatusFailed    = "failed"
+)
+
+type Webhook struct {
+	ID        *int64    `json:"id"`
+	FormID    int64     `json:"form_id"`
+	URL       string    `json:"url" validate:"required,http_url,max=2048"`
+	Secret    string    `json:"secret,omitempty" validate:"required,min=16,max=255"`
+	Events    []string  `json:"events" validate:"required,min=1,unique,dive,oneof=passage.created form.published form.closed"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type WebhookList struct {
+	CollectionResponse
+	Webhooks []*Webhook `json:"webhooks" validate:"required"`
+}
+
+type WebhookDelivery struct {
+	ID             int64           `json:"id"`
+	WebhookID      int64           `json:"webhook_id"`
+	Event          string          `json:"event"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	ResponseStatus *int            `json:"response_status"`
+	LastError      *string         `json:"last_error"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at"`
+	DeliveredAt    *time.Time      `json:"delivered_at"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
+
+type WebhookDeliveryList struct {
+	CollectionResponse
+	Deliveries []*WebhookDelivery `json:"deliveries" validate:"required"`
+}
+
+// WebhookPayload is the JSON body posted to subscribers.
+type WebhookPayload struct {
+	Event      string      `json:"event"`
+	FormID     int64       `json:"form_id"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+type WebhookPassageData struct {
+	PassageID int64            `json:"passage_id"`
+	UserID    *int64           `json:"user_id"`
+	StartedAt *time.Time       `json:"started_at"`
+	Answers   []*PassageAnswer `json:"answers"`
+}
+
+type WebhookFormData struct {
+	Title    string     `json:"title"`
+	ClosedAt *time.Time `json:"closed_at"`
+}
@@ -0,0 +1,247 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-form-hub/internal/database"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// APIToken is a personal access token of a user. TokenHash holds the hash of the token, not the
+// token itself. A token without ExpiresAt does not expire.
+type APIToken struct {
+	ID         int64      `db:"id"`
+	UserID     int64      `db:"user_id"`
+	Name       string     `db:"name"`
+	TokenHash  string     `db:"token_hash"`
+	Scopes     []string   `db:"scopes"`
+	CreatedAt  time.Time  `db:"created_at"`
+	ExpiresAt  *time.Time `db:"expires_at"`
+	LastUsedAt *time.Time `db:"last_used_at"`
+}
+
+var apiTokenColumns = []string{"id", "user_id", "name", "token_hash", "scopes", "created_at", "expires_at", "last_used_at"}
+
+type apiTokenDatabaseRepository struct {
+	db      database.ConnPool
+	builder squirrel.StatementBuilderType
+}
+
+func NewAPITokenDatabaseRepository(db database.ConnPool, builder squirrel.StatementBuilderType) APITokenRepository {
+	return &apiTokenDatabaseRepository{
+		db:      db,
+		builder: builder,
+	}
+}
+
+func (r *apiTokenDatabaseRepository) getTableName() string {
+	return fmt.Sprintf("%s.api_token", r.db.GetSchema())
+}
+
+func (r *apiTokenDatabaseRepository) Insert(ctx context.Context, token *APIToken) (err error) {
+	query, args, err := r.builder.
+		Insert(r.getTableName()).
+		Columns("user_id", "name", "token_hash", "scopes", "created_at", "expires_at").
+		Values(token.UserID, token.Name, token.TokenHash, token.Scopes, token.CreatedAt, token.ExpiresAt).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("api_token_repository insert failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("api_token_repository insert failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if err = tx.QueryRow(ctx, query, args...).Scan(&token.ID); err != nil {
+		return fmt.Errorf("api_token_repository insert failed to execute query: %e", err)
+	}
+
+	return nil
+}
+
+func (r *apiTokenDatabaseRepository) FindByHash(ctx context.Context, tokenHash string) (token *APIToken, err error) {
+	query, args, err := r.builder.
+		Select(apiTokenColumns...).
+		From(r.getTableName()).
+		Where(squirrel.Eq{"token_hash": tokenHash}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("api_token_repository find_by_hash failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("api_token_repository find_by_hash failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	token, err = r.fromRow(tx.QueryRow(ctx, query, args...))
+	return token, err
+}
+
+// FindByUserID returns the tokens of the user, the most recent first.
+func (r *apiTokenDatabaseRepository) FindByUserID(ctx context.Context, userID int64) (tokens []*APIToken, err error) {
+	query, args, err := r.builder.
+		Select(apiTokenColumns...).
+		From(r.getTableName()).
+		Where(squirrel.Eq{"user_id": userID}).
+		OrderBy("id DESC").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("api_token_repository find_by_user_id failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("api_token_repository find_by_user_id failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("api_token_repository find_by_user_id failed to execute query: %e", err)
+	}
+
+	tokens, err = r.fromRows(rows)
+	return tokens, err
+}
+
+// Touch records that the token was used at lastUsedAt.
+func (r *apiTokenDatabaseRepository) Touch(ctx context.Context, id int64, lastUsedAt time.Time) (err error) {
+	query, args, err := r.builder.
+		Update(r.getTableName()).
+		Set("last_used_at", lastUsedAt).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("api_token_repository touch failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("api_token_repository touch failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("api_token_repository touch failed to execute query: %e", err)
+	}
+
+	return nil
+}
+
+// Delete revokes the token if it belongs to the user, and reports whether it did.
+func (r *apiTokenDatabaseRepository) Delete(ctx context.Context, id, userID int64) (deleted bool, err error) {
+	query, args, err := r.builder.
+		Delete(r.getTableName()).
+		Where(squirrel.And{
+			squirrel.Eq{"id": id},
+			squirrel.Eq{"user_id": userID},
+		}).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("api_token_repository delete failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("api_token_repository delete failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("api_token_repository delete failed to execute query: %e", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+func (r *apiTokenDatabaseRepository) fromRows(rows pgx.Rows) ([]*APIToken, error) {
+	defer func() {
+		rows.Close()
+	}()
+
+	tokens := []*APIToken{}
+
+	for rows.Next() {
+		token, err := r.fromRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		tokens = append(tokens, token)
+	}
+
+	return tokens, nil
+}
+
+func (r *apiTokenDatabaseRepository) fromRow(row pgx.Row) (*APIToken, error) {
+	token := &APIToken{}
+	err := row.Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Name,
+		&token.TokenHash,
+		&token.Scopes,
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&token.LastUsedAt,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+
+		return nil, fmt.Errorf("api_token_repository failed to scan row: %e", err)
+	}
+
+	return token, nil
+}
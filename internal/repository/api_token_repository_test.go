@@ -0,0 +1,174 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/repository"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+var apiTokenColumns = []string{"id", "user_id", "name", "token_hash", "scopes", "created_at", "expires_at", "last_used_at"}
+
+func TestAPITokenRepositoryInsert(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewAPITokenDatabaseRepository(connPool, builder)
+
+	expiresAt := time.Now().UTC().Add(24 * time.Hour)
+	token := &repository.APIToken{
+		UserID:    1,
+		Name:      "export script",
+		TokenHash: "hash",
+		Scopes:    []string{"forms:read", "results:read"},
+		CreatedAt: time.Now().UTC(),
+		ExpiresAt: &expiresAt,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(fmt.Sprintf(`^INSERT INTO %s.api_token \(user_id,name,token_hash,scopes,created_at,expires_at\) VALUES \(\$1,\$2,\$3,\$4,\$5,\$6\) RETURNING id$`, schema)).
+		WithArgs(token.UserID, token.Name, token.TokenHash, token.Scopes, token.CreatedAt, token.ExpiresAt).
+		WillReturnRows(mock.NewRows([]string{"id"}).AddRow(int64(7)))
+	mock.ExpectCommit()
+
+	err = repo.Insert(context.Background(), token)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), token.ID)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestAPITokenRepositoryFindByHash(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewAPITokenDatabaseRepository(connPool, builder)
+
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(fmt.Sprintf(`^SELECT .* FROM %s.api_token WHERE token_hash = \$1$`, schema)).
+		WithArgs("hash").
+		WillReturnRows(mock.NewRows(apiTokenColumns).
+			AddRow(int64(7), int64(1), "export script", "hash", []string{"forms:read"}, now, (*time.Time)(nil), &now))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery(fmt.Sprintf(`^SELECT .* FROM %s.api_token WHERE token_hash = \$1$`, schema)).
+		WithArgs("unknown").
+		WillReturnRows(mock.NewRows(apiTokenColumns))
+	mock.ExpectCommit()
+
+	token, err := repo.FindByHash(context.Background(), "hash")
+	if assert.Nil(t, err) && assert.NotNil(t, token) {
+		assert.Equal(t, int64(7), token.ID)
+		assert.Equal(t, []string{"forms:read"}, token.Scopes)
+		assert.Nil(t, token.ExpiresAt)
+		assert.Equal(t, &now, token.LastUsedAt)
+	}
+
+	token, err = repo.FindByHash(context.Background(), "unknown")
+	assert.Nil(t, err)
+	assert.Nil(t, token)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestAPITokenRepositoryFindByUserID(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewAPITokenDatabaseRepository(connPool, builder)
+
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(fmt.Sprintf(`^SELECT .* FROM %s.api_token WHERE user_id = \$1 ORDER BY id DESC$`, schema)).
+		WithArgs(int64(1)).
+		WillReturnRows(mock.NewRows(apiTokenColumns).
+			AddRow(int64(8), int64(1), "ci", "hash-8", []string{"forms:write"}, now, &now, (*time.Time)(nil)).
+			AddRow(int64(7), int64(1), "export script", "hash-7", []string{"results:read"}, now, (*time.Time)(nil), (*time.Time)(nil)))
+	mock.ExpectCommit()
+
+	tokens, err := repo.FindByUserID(context.Background(), 1)
+	if assert.Nil(t, err) && assert.Len(t, tokens, 2) {
+		assert.Equal(t, "ci", tokens[0].Name)
+		assert.Equal(t, []string{"results:read"}, tokens[1].Scopes)
+	}
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestAPITokenRepositoryTouch(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewAPITokenDatabaseRepository(connPool, builder)
+
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf(`^UPDATE %s.api_token SET last_used_at = \$1 WHERE id = \$2$`, schema)).
+		WithArgs(now, int64(7)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	err = repo.Touch(context.Background(), 7, now)
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestAPITokenRepositoryDelete(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewAPITokenDatabaseRepository(connPool, builder)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf(`^DELETE FROM %s.api_token WHERE \(id = \$1 AND user_id = \$2\)$`, schema)).
+		WithArgs(int64(7), int64(1)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf(`^DELETE FROM %s.api_token WHERE \(id = \$1 AND user_id = \$2\)$`, schema)).
+		WithArgs(int64(7), int64(2)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mock.ExpectCommit()
+
+	deleted, err := repo.Delete(context.Background(), 7, 1)
+	assert.Nil(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = repo.Delete(context.Background(), 7, 2)
+	assert.Nil(t, err)
+	assert.False(t, deleted, "another user cannot revoke the token")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,125 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-form-hub/internal/database"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// EmailVerificationToken is a link to verify the email of a user. The email it was sent to is kept,
+// so that a link stops verifying anything once the user changes the email.
+type EmailVerificationToken struct {
+	ID        int64      `db:"id"`
+	UserID    int64      `db:"user_id"`
+	Email     string     `db:"email"`
+	TokenHash string     `db:"token_hash"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+}
+
+type emailVerificationDatabaseRepository struct {
+	db      database.ConnPool
+	builder squirrel.StatementBuilderType
+}
+
+func NewEmailVerificationDatabaseRepository(db database.ConnPool, builder squirrel.StatementBuilderType) EmailVerificationRepository {
+	return &emailVerificationDatabaseRepository{
+		db:      db,
+		builder: builder,
+	}
+}
+
+func (r *emailVerificationDatabaseRepository) getTableName() string {
+	return fmt.Sprintf("%s.email_verification_token", r.db.GetSchema())
+}
+
+func (r *emailVerificationDatabaseRepository) Insert(ctx context.Context, token *EmailVerificationToken) error {
+	query, args, err := r.builder.
+		Insert(r.getTableName()).
+		Columns("user_id", "email", "token_hash", "created_at", "expires_at").
+		Values(token.UserID, token.Email, token.TokenHash, token.CreatedAt, token.ExpiresAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("email_verification_repository insert failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("email_verification_repository insert failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("email_verification_repository insert failed to execute query: %e", err)
+	}
+
+	return nil
+}
+
+// Consume uses up the token if it is unused and not expired at now, and returns it, or nil if it
+// cannot be used.
+func (r *emailVerificationDatabaseRepository) Consume(ctx context.Context, tokenHash string, now time.Time) (token *EmailVerificationToken, err error) {
+	query, args, err := r.builder.
+		Update(r.getTableName()).
+		Set("used_at", now).
+		Where(squirrel.And{
+			squirrel.Eq{"token_hash": tokenHash},
+			squirrel.Eq{"used_at": nil},
+			squirrel.Gt{"expires_at": now},
+		}).
+		Suffix("RETURNING id, user_id, email, token_hash, created_at, expires_at, used_at").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("email_verification_repository consume failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("email_verification_repository consume failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	token = &EmailVerificationToken{}
+	err = tx.QueryRow(ctx, query, args...).Scan(
+		&token.ID,
+		&token.UserID,
+		&token.Email,
+		&token.TokenHash,
+		&token.CreatedAt,
+		&token.ExpiresAt,
+		&token.UsedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("email_verification_repository consume failed to execute query: %e", err)
+	}
+
+	return token, nil
+}
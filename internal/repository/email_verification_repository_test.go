@@ -0,0 +1,106 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/repository"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEmailVerificationRepositoryInsert(t *testing.T) {
+	t.Run("NoErrors", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewEmailVerificationDatabaseRepository(connPool, builder)
+
+		now := time.Now().UTC()
+		token := &repository.EmailVerificationToken{
+			UserID:    1,
+			Email:     "user@example.com",
+			TokenHash: "hash",
+			CreatedAt: now,
+			ExpiresAt: now.Add(time.Hour),
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(fmt.Sprintf(`^INSERT INTO %s.email_verification_token \(user_id,email,token_hash,created_at,expires_at\) VALUES \(\$1,\$2,\$3,\$4,\$5\)$`, schema)).
+			WithArgs(token.UserID, token.Email, token.TokenHash, token.CreatedAt, token.ExpiresAt).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		assert.Nil(t, repo.Insert(context.Background(), token))
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestEmailVerificationRepositoryConsume(t *testing.T) {
+	t.Run("NoErrors", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewEmailVerificationDatabaseRepository(connPool, builder)
+
+		now := time.Now().UTC()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^UPDATE %s.email_verification_token SET used_at = \$1 WHERE \(token_hash = \$2 AND used_at IS NULL AND expires_at > \$3\) RETURNING .*$`, schema)).
+			WithArgs(now, "hash", now).
+			WillReturnRows(mock.NewRows([]string{"id", "user_id", "email", "token_hash", "created_at", "expires_at", "used_at"}).
+				AddRow(int64(3), int64(7), "user@example.com", "hash", now.Add(-time.Hour), now.Add(time.Hour), &now))
+		mock.ExpectCommit()
+
+		token, err := repo.Consume(context.Background(), "hash", now)
+		assert.Nil(t, err)
+		if assert.NotNil(t, token) {
+			assert.Equal(t, int64(7), token.UserID)
+			assert.Equal(t, "user@example.com", token.Email)
+		}
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UsedOrExpired", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewEmailVerificationDatabaseRepository(connPool, builder)
+
+		now := time.Now().UTC()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^UPDATE %s.email_verification_token SET used_at = \$1 WHERE .* RETURNING .*$`, schema)).
+			WithArgs(now, "hash", now).
+			WillReturnRows(mock.NewRows([]string{"id", "user_id", "email", "token_hash", "created_at", "expires_at", "used_at"}))
+		mock.ExpectCommit()
+
+		token, err := repo.Consume(context.Background(), "hash", now)
+		assert.Nil(t, err)
+		assert.Nil(t, token)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
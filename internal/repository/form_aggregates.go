@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"go-form-hub/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// Result aggregates keep passage counts per form, per question and per answer text, so that
+// results and form lists do not have to scan form_passage_answer. They are updated in the
+// transaction that saves a passage and can be rebuilt from scratch by RebuildResultAggregates.
+
+type answerAggregate struct {
+	questionID    int64
+	text          string
+	selectedTimes int
+}
+
+// queueAggregateUpdates adds one passage with the given answers to the aggregates.
+func (r *formDatabaseRepository) queueAggregateUpdates(batch *pgx.Batch, formID int64, answers []*model.PassageAnswer) {
+	formQuery := fmt.Sprintf(`INSERT INTO %s.form_result_aggregate as fa
+	(form_id, passage_count)
+	VALUES($1, 1)
+	ON CONFLICT (form_id) DO UPDATE SET passage_count = fa.passage_count + 1`, r.db.GetSchema())
+
+	questionQuery := fmt.Sprintf(`INSERT INTO %s.question_result_aggregate as qa
+	(question_id, form_id, passage_count)
+	VALUES($1, $2, 1)
+	ON CONFLICT (question_id) DO UPDATE SET passage_count = qa.passage_count + 1`, r.db.GetSchema())
+
+	answerQuery := fmt.Sprintf(`INSERT INTO %s.answer_result_aggregate as aa
+	(question_id, answer_text, selected_times)
+	VALUES($1, $2, 1)
+	ON CONFLICT (question_id, answer_text) DO UPDATE SET selected_times = aa.selected_times + 1`, r.db.GetSchema())
+
+	batch.Queue(formQuery, formID)
+
+	answeredQuestions := map[int64]bool{}
+	for _, answer := range answers {
+		if !answeredQuestions[*answer.QuestionID] {
+			answeredQuestions[*answer.QuestionID] = true
+			batch.Queue(questionQuery, *answer.QuestionID, formID)
+		}
+		batch.Queue(answerQuery, *answer.QuestionID, answer.Text)
+	}
+}
+
+// RebuildResultAggregates recounts all aggregates from the saved passages.
+// New passages are blocked until the rebuild commits so that none of them is lost or counted twice.
+func (r *formDatabaseRepository) RebuildResultAggregates(ctx context.Context) (err error) {
+	schema := r.db.GetSchema()
+	queries := []string{
+		fmt.Sprintf(`LOCK TABLE %s.form_passage, %s.form_passage_answer IN SHARE MODE`, schema, schema),
+		fmt.Sprintf(`DELETE FROM %s.answer_result_aggregate`, schema),
+		fmt.Sprintf(`DELETE FROM %s.question_result_aggregate`, schema),
+		fmt.Sprintf(`DELETE FROM %s.form_result_aggregate`, schema),
+		fmt.Sprintf(`INSERT INTO %s.form_result_aggregate (form_id, passage_count)
+		SELECT fp.form_id, COUNT(*)
+		FROM %s.form_passage as fp
+		GROUP BY fp.form_id`, schema, schema),
+		fmt.Sprintf(`INSERT INTO %s.question_result_aggregate (question_id, form_id, passage_count)
+		SELECT pa.question_id, fp.form_id, COUNT(DISTINCT fp.id)
+		FROM %s.form_passage_answer as pa
+		JOIN %s.form_passage as fp ON fp.id = pa.form_passage_id
+		GROUP BY pa.question_id, fp.form_id`, schema, schema, schema),
+		fmt.Sprintf(`INSERT INTO %s.answer_result_aggregate (question_id, answer_text, selected_times)
+		SELECT pa.question_id, pa.answer_text, COUNT(*)
+		FROM %s.form_passage_answer as pa
+		GROUP BY pa.question_id, pa.answer_text`, schema, schema),
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("form_repository rebuild_result_aggregates failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	for _, query := range queries {
+		if _, err = tx.Exec(ctx, query); err != nil {
+			return fmt.Errorf("form_repository rebuild_result_aggregates failed to execute query: %e", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *formDatabaseRepository) formResultsFromAggregates(ctx context.Context, id int64) (formResult *model.FormResult, err error) {
+	formInfoQuery, formInfoArgs, err := r.builder.
+		Select(selectFieldsFormInfo...).
+		From(fmt.Sprintf("%s.form as f", r.db.GetSchema())).
+		Join(fmt.Sprintf("%s.user as u ON f.author_id = u.id", r.db.GetSchema())).
+		LeftJoin(fmt.Sprintf("%s.question as q ON q.form_id = f.id", r.db.GetSchema())).
+		LeftJoin(fmt.Sprintf("%s.answer as a ON a.question_id = q.id", r.db.GetSchema())).
+		Where(squirrel.Eq{"f.id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_results failed to build form info query: %e", err)
+	}
+
+	formCountQuery, formCountArgs, err := r.builder.
+		Select("fa.form_id", "fa.passage_count").
+		From(fmt.Sprintf("%s.form_result_aggregate as fa", r.db.GetSchema())).
+		Where(squirrel.Eq{"fa.form_id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_results failed to build form count query: %e", err)
+	}
+
+	questionCountQuery, questionCountArgs, err := r.builder.
+		Select("qa.question_id", "qa.passage_count").
+		From(fmt.Sprintf("%s.question_result_aggregate as qa", r.db.GetSchema())).
+		Where(squirrel.Eq{"qa.form_id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_results failed to build question count query: %e", err)
+	}
+
+	answerCountQuery, answerCountArgs, err := r.builder.
+		Select("aa.question_id", "aa.answer_text", "aa.selected_times").
+		From(fmt.Sprintf("%s.answer_result_aggregate as aa", r.db.GetSchema())).
+		Join(fmt.Sprintf("%s.question as q ON q.id = aa.question_id", r.db.GetSchema())).
+		Where(squirrel.Eq{"q.form_id": id}).
+		OrderBy("aa.question_id", "aa.answer_text").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_results failed to build answer count query: %e", err)
+	}
+
+	participantsQuery, participantsArgs, err := r.builder.
+		Select("ua.id", "ua.username", "ua.first_name").
+		From(fmt.Sprintf("%s.form_passage as fp", r.db.GetSchema())).
+		Join(fmt.Sprintf("%s.user as ua ON fp.user_id = ua.id", r.db.GetSchema())).
+		Where(squirrel.Eq{"fp.form_id": id}).
+		GroupBy("ua.id").
+		OrderBy("MIN(fp.id)").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_results failed to build participants query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_results failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	rowsFormInfo, err := tx.Query(ctx, formInfoQuery, formInfoArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_results failed to execute form info query: %e", err)
+	}
+
+	formResults, err := r.formResultsFromRows(rowsFormInfo)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(formResults) == 0 {
+		return nil, nil
+	}
+	formResult = formResults[0]
+
+	rowsFormCount, err := tx.Query(ctx, formCountQuery, formCountArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_results failed to execute form count query: %e", err)
+	}
+
+	formCounts, err := r.countFormFromRows(rowsFormCount)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, formCount := range formCounts {
+		formResult.NumberOfPassagesForm = formCount.NumberOfPassagesForm
+	}
+
+	rowsQuestionCount, err := tx.Query(ctx, questionCountQuery, questionCountArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_results failed to execute question count query: %e", err)
+	}
+
+	questionCounts, err := r.countQuestionFromRows(rowsQuestionCount)
+	if err != nil {
+		return nil, err
+	}
+
+	rowsAnswerCount, err := tx.Query(ctx, answerCountQuery, answerCountArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_results failed to execute answer count query: %e", err)
+	}
+
+	answerCounts, err := r.answerAggregatesFromRows(rowsAnswerCount)
+	if err != nil {
+		return nil, err
+	}
+
+	mergeResultAggregates(formResult, questionCounts, answerCounts)
+
+	if formResult.Anonymous {
+		return formResult, nil
+	}
+
+	rowsParticipants, err := tx.Query(ctx, participantsQuery, participantsArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_results failed to execute participants query: %e", err)
+	}
+
+	formResult.Participants, err = r.participantsFromRows(rowsParticipants)
+	if err != nil {
+		return nil, err
+	}
+
+	return formResult, nil
+}
+
+// mergeResultAggregates puts counts into the questions of the form. Answer texts that are not among
+// the question's answers, such as input answers or answers renamed after being given, are appended.
+func mergeResultAggregates(formResult *model.FormResult, questionCounts []*model.QuestionResult, answerCounts []*answerAggregate) {
+	questionByID := map[int64]*model.QuestionResult{}
+	for _, question := range formResult.Questions {
+		questionByID[question.ID] = question
+	}
+
+	for _, questionCount := range questionCounts {
+		if question, ok := questionByID[questionCount.ID]; ok {
+			question.NumberOfPassagesQuestion = questionCount.NumberOfPassagesQuestion
+		}
+	}
+
+	for _, answerCount := range answerCounts {
+		question, ok := questionByID[answerCount.questionID]
+		if !ok {
+			continue
+		}
+
+		answerExist := false
+		for _, answerResult := range question.Answers {
+			if answerResult.Text == answerCount.text {
+				answerResult.SelectedTimesAnswer = answerCount.selectedTimes
+				answerExist = true
+				break
+			}
+		}
+		if !answerExist {
+			question.Answers = append(question.Answers, &model.AnswerResult{
+				Text:                answerCount.text,
+				SelectedTimesAnswer: answerCount.selectedTimes,
+			})
+		}
+	}
+}
+
+func (r *formDatabaseRepository) answerAggregatesFromRows(rows pgx.Rows) ([]*answerAggregate, error) {
+	defer func() {
+		rows.Close()
+	}()
+
+	answers := make([]*answerAggregate, 0)
+
+	for rows.Next() {
+		answer := &answerAggregate{}
+		err := rows.Scan(
+			&answer.questionID,
+			&answer.text,
+			&answer.selectedTimes,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("form_repository answerAggregatesFromRows failed to scan row: %v", err)
+		}
+		answers = append(answers, answer)
+	}
+
+	return answers, nil
+}
+
+func (r *formDatabaseRepository) participantsFromRows(rows pgx.Rows) ([]*model.UserGet, error) {
+	defer func() {
+		rows.Close()
+	}()
+
+	participants := make([]*model.UserGet, 0)
+
+	for rows.Next() {
+		participant := &model.UserGet{}
+		err := rows.Scan(
+			&participant.ID,
+			&participant.Username,
+			&participant.FirstName,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("form_repository participantsFromRows failed to scan row: %v", err)
+		}
+		participants = append(participants, participant)
+	}
+
+	return participants, nil
+}
@@ -0,0 +1,125 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormRepositoryFormResultsFromAggregates(t *testing.T) {
+	t.Run("Anonymous", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewFormDatabaseRepository(connPool, builder)
+
+		formID := int64(1)
+		createdAt := time.Now().UTC()
+
+		mock.ExpectBegin()
+
+		formInfoColumns := []string{"f.id", "f.title", "f.created_at", "f.description", "f.anonymous", "f.passage_max",
+			"u.id", "u.username", "u.first_name", "u.last_name", "u.email",
+			"q.id", "q.title", "q.text", "q.type", "q.position", "a.answer_text"}
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT .* FROM %s.form as f .* WHERE f.id = \$1$`, schema)).
+			WithArgs(formID).
+			WillReturnRows(mock.NewRows(formInfoColumns).
+				AddRow(formID, "title", createdAt, "", true, 1, int64(1), "author", "", "", "", int64(10), "Color", "", model.SingleAnswerType, 1, "Red").
+				AddRow(formID, "title", createdAt, "", true, 1, int64(1), "author", "", "", "", int64(10), "Color", "", model.SingleAnswerType, 1, "Blue").
+				AddRow(formID, "title", createdAt, "", true, 1, int64(1), "author", "", "", "", int64(11), "Why", "", model.InputAnswerType, 2, ""))
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT fa.form_id, fa.passage_count FROM %s.form_result_aggregate as fa`, schema)).
+			WithArgs(formID).
+			WillReturnRows(mock.NewRows([]string{"fa.form_id", "fa.passage_count"}).
+				AddRow(formID, 3))
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT qa.question_id, qa.passage_count FROM %s.question_result_aggregate as qa`, schema)).
+			WithArgs(formID).
+			WillReturnRows(mock.NewRows([]string{"qa.question_id", "qa.passage_count"}).
+				AddRow(int64(10), 3).
+				AddRow(int64(11), 1))
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT aa.question_id, aa.answer_text, aa.selected_times FROM %s.answer_result_aggregate as aa`, schema)).
+			WithArgs(formID).
+			WillReturnRows(mock.NewRows([]string{"aa.question_id", "aa.answer_text", "aa.selected_times"}).
+				AddRow(int64(10), "Blue", 2).
+				AddRow(int64(10), "Red", 1).
+				AddRow(int64(11), "Because", 1))
+
+		mock.ExpectCommit()
+
+		result, err := repo.FormResults(context.Background(), formID, nil)
+		if err != nil {
+			t.Logf("failed to get form results: %e", err)
+			t.FailNow()
+		}
+
+		assert.Equal(t, 3, result.NumberOfPassagesForm)
+		assert.Nil(t, result.Participants)
+
+		questions := map[int64]*model.QuestionResult{}
+		for _, question := range result.Questions {
+			questions[question.ID] = question
+		}
+
+		assert.Equal(t, 3, questions[10].NumberOfPassagesQuestion)
+		assert.Equal(t, []*model.AnswerResult{
+			{Text: "Red", SelectedTimesAnswer: 1},
+			{Text: "Blue", SelectedTimesAnswer: 2},
+		}, questions[10].Answers)
+
+		assert.Equal(t, 1, questions[11].NumberOfPassagesQuestion)
+		assert.Equal(t, []*model.AnswerResult{
+			{Text: "Because", SelectedTimesAnswer: 1},
+		}, questions[11].Answers)
+
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestFormRepositoryRebuildResultAggregates(t *testing.T) {
+	t.Run("NoErrors", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewFormDatabaseRepository(connPool, builder)
+
+		mock.ExpectBegin()
+		mock.ExpectExec(fmt.Sprintf(`^LOCK TABLE %s.form_passage, %s.form_passage_answer IN SHARE MODE$`, schema, schema)).
+			WillReturnResult(pgxmock.NewResult("LOCK", 0))
+		for _, table := range []string{"answer_result_aggregate", "question_result_aggregate", "form_result_aggregate"} {
+			mock.ExpectExec(fmt.Sprintf(`^DELETE FROM %s.%s$`, schema, table)).
+				WillReturnResult(pgxmock.NewResult("DELETE", 1))
+		}
+		for _, table := range []string{"form_result_aggregate", "question_result_aggregate", "answer_result_aggregate"} {
+			mock.ExpectExec(fmt.Sprintf(`^INSERT INTO %s.%s .* SELECT`, schema, table)).
+				WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		}
+		mock.ExpectCommit()
+
+		err = repo.RebuildResultAggregates(context.Background())
+		assert.Nil(t, err)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,233 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"go-form-hub/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// the bits of GROUPING(ra.answer_text, ca.answer_text), set for the answer a count is not split by
+const (
+	crossTabAnyColumn = 1
+	crossTabAnyRow    = 2
+)
+
+type crossTabCount struct {
+	rowText    string
+	columnText string
+	grouping   int
+	count      int
+}
+
+// FormCrossTab counts passages by the pair of answers given to two questions of the form.
+// Passages which left either of the questions unanswered are not counted. A passage may give several
+// answers to a question with multiple answers, so the totals are counted by the database as well
+// rather than summed from the cells.
+func (r *formDatabaseRepository) FormCrossTab(ctx context.Context, id, rowQuestionID, columnQuestionID int64, filter *model.FormResultFilter) (crossTab *model.FormCrossTab, err error) {
+	questionsQuery, questionsArgs, err := r.builder.
+		Select("q.id", "q.title", "q.type", "COALESCE(a.answer_text, '')").
+		From(fmt.Sprintf("%s.question as q", r.db.GetSchema())).
+		LeftJoin(fmt.Sprintf("%s.answer as a ON a.question_id = q.id", r.db.GetSchema())).
+		Where(squirrel.Eq{"q.form_id": id}).
+		Where(squirrel.Eq{"q.id": []int64{rowQuestionID, columnQuestionID}}).
+		OrderBy("q.id", "a.id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_cross_tab failed to build questions query: %e", err)
+	}
+
+	countQuery, countArgs, err := r.withPassageFilter(r.builder.
+		Select("COALESCE(ra.answer_text, '')", "COALESCE(ca.answer_text, '')", "GROUPING(ra.answer_text, ca.answer_text)", "COUNT(DISTINCT fp.id)").
+		From(fmt.Sprintf("%s.form_passage as fp", r.db.GetSchema())).
+		Join(fmt.Sprintf("%s.form_passage_answer as ra ON ra.form_passage_id = fp.id", r.db.GetSchema())).
+		Join(fmt.Sprintf("%s.form_passage_answer as ca ON ca.form_passage_id = fp.id", r.db.GetSchema())).
+		Where(squirrel.Eq{"fp.form_id": id}).
+		Where(squirrel.Eq{"ra.question_id": rowQuestionID}).
+		Where(squirrel.Eq{"ca.question_id": columnQuestionID}), filter).
+		GroupBy("GROUPING SETS ((ra.answer_text, ca.answer_text), (ra.answer_text), (ca.answer_text), ())").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_cross_tab failed to build count query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_cross_tab failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	questionRows, err := tx.Query(ctx, questionsQuery, questionsArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_cross_tab failed to execute questions query: %e", err)
+	}
+
+	questions, answers, err := r.crossTabQuestionsFromRows(questionRows)
+	if err != nil {
+		return nil, err
+	}
+
+	rowQuestion, columnQuestion := questions[rowQuestionID], questions[columnQuestionID]
+	if rowQuestion == nil || columnQuestion == nil {
+		return nil, nil
+	}
+
+	countRows, err := tx.Query(ctx, countQuery, countArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_cross_tab failed to execute count query: %e", err)
+	}
+
+	counts, err := r.crossTabCountsFromRows(countRows)
+	if err != nil {
+		return nil, err
+	}
+
+	return buildCrossTab(id, rowQuestion, columnQuestion, answers[rowQuestionID], answers[columnQuestionID], counts), nil
+}
+
+func (r *formDatabaseRepository) crossTabQuestionsFromRows(rows pgx.Rows) (map[int64]*model.CrossTabQuestion, map[int64][]string, error) {
+	defer func() {
+		rows.Close()
+	}()
+
+	questions := map[int64]*model.CrossTabQuestion{}
+	answers := map[int64][]string{}
+
+	for rows.Next() {
+		question := &model.CrossTabQuestion{}
+		var answerText string
+
+		err := rows.Scan(
+			&question.ID,
+			&question.Title,
+			&question.Type,
+			&answerText,
+		)
+		if err != nil {
+			return nil, nil, fmt.Errorf("form_repository crossTabQuestionsFromRows failed to scan row: %v", err)
+		}
+
+		if _, ok := questions[question.ID]; !ok {
+			questions[question.ID] = question
+		}
+
+		if answerText != "" {
+			answers[question.ID] = append(answers[question.ID], answerText)
+		}
+	}
+
+	return questions, answers, nil
+}
+
+func (r *formDatabaseRepository) crossTabCountsFromRows(rows pgx.Rows) ([]*crossTabCount, error) {
+	defer func() {
+		rows.Close()
+	}()
+
+	counts := make([]*crossTabCount, 0)
+
+	for rows.Next() {
+		count := &crossTabCount{}
+		err := rows.Scan(
+			&count.rowText,
+			&count.columnText,
+			&count.grouping,
+			&count.count,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("form_repository crossTabCountsFromRows failed to scan row: %v", err)
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, nil
+}
+
+// buildCrossTab lays counts out as a table. Rows and columns list the question's own answers first,
+// in their original order, followed by any other answer texts found in passages.
+func buildCrossTab(formID int64, rowQuestion, columnQuestion *model.CrossTabQuestion, rowAnswers, columnAnswers []string, counts []*crossTabCount) *model.FormCrossTab {
+	rowIndex := map[string]int{}
+	columnIndex := map[string]int{}
+
+	rows := make([]string, 0, len(rowAnswers))
+	columns := make([]string, 0, len(columnAnswers))
+
+	addLabel := func(labels []string, index map[string]int, text string) []string {
+		if _, ok := index[text]; ok {
+			return labels
+		}
+		index[text] = len(labels)
+		return append(labels, text)
+	}
+
+	for _, text := range rowAnswers {
+		rows = addLabel(rows, rowIndex, text)
+	}
+	for _, text := range columnAnswers {
+		columns = addLabel(columns, columnIndex, text)
+	}
+	for _, count := range counts {
+		if count.grouping&crossTabAnyRow == 0 {
+			rows = addLabel(rows, rowIndex, count.rowText)
+		}
+		if count.grouping&crossTabAnyColumn == 0 {
+			columns = addLabel(columns, columnIndex, count.columnText)
+		}
+	}
+
+	crossTab := &model.FormCrossTab{
+		FormID:         formID,
+		RowQuestion:    rowQuestion,
+		ColumnQuestion: columnQuestion,
+		Rows:           rows,
+		Columns:        columns,
+		Cells:          make([][]*model.CrossTabCell, len(rows)),
+		RowTotals:      make([]int, len(rows)),
+		ColumnTotals:   make([]int, len(columns)),
+	}
+
+	for i := range crossTab.Cells {
+		crossTab.Cells[i] = make([]*model.CrossTabCell, len(columns))
+		for j := range crossTab.Cells[i] {
+			crossTab.Cells[i][j] = &model.CrossTabCell{}
+		}
+	}
+
+	for _, count := range counts {
+		i, j := rowIndex[count.rowText], columnIndex[count.columnText]
+		switch count.grouping {
+		case 0:
+			crossTab.Cells[i][j].Count = count.count
+		case crossTabAnyColumn:
+			crossTab.RowTotals[i] = count.count
+		case crossTabAnyRow:
+			crossTab.ColumnTotals[j] = count.count
+		default:
+			crossTab.Total = count.count
+		}
+	}
+
+	for i, cells := range crossTab.Cells {
+		for j, cell := range cells {
+			if crossTab.RowTotals[i] != 0 {
+				cell.RowPercent = 100 * float64(cell.Count) / float64(crossTab.RowTotals[i])
+			}
+			if crossTab.ColumnTotals[j] != 0 {
+				cell.ColumnPercent = 100 * float64(cell.Count) / float64(crossTab.ColumnTotals[j])
+			}
+		}
+	}
+
+	return crossTab
+}
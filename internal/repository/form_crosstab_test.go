@@ -0,0 +1,202 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormRepositoryFormCrossTab(t *testing.T) {
+	t.Run("NoErrors", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewFormDatabaseRepository(connPool, builder)
+
+		formID, rowQuestionID, columnQuestionID := int64(1), int64(10), int64(11)
+
+		mock.ExpectBegin()
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT q.id, q.title, q.type, .* FROM %s.question as q .* WHERE q.form_id = \$1 AND q.id IN \(\$2,\$3\) ORDER BY q.id, a.id$`, schema)).
+			WithArgs(formID, rowQuestionID, columnQuestionID).
+			WillReturnRows(mock.NewRows([]string{"q.id", "q.title", "q.type", "a.answer_text"}).
+				AddRow(rowQuestionID, "Color", model.SingleAnswerType, "Red").
+				AddRow(rowQuestionID, "Color", model.SingleAnswerType, "Blue").
+				AddRow(columnQuestionID, "Size", model.SingleAnswerType, "S").
+				AddRow(columnQuestionID, "Size", model.SingleAnswerType, "L"))
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT COALESCE\(ra.answer_text, ''\), COALESCE\(ca.answer_text, ''\), GROUPING\(ra.answer_text, ca.answer_text\), COUNT\(DISTINCT fp.id\) FROM %s.form_passage as fp .* GROUP BY GROUPING SETS \(\(ra.answer_text, ca.answer_text\), \(ra.answer_text\), \(ca.answer_text\), \(\)\)$`, schema)).
+			WithArgs(formID, rowQuestionID, columnQuestionID).
+			WillReturnRows(mock.NewRows([]string{"ra.answer_text", "ca.answer_text", "grouping", "count"}).
+				AddRow("Red", "S", 0, 3).
+				AddRow("Red", "L", 0, 1).
+				AddRow("Blue", "L", 0, 4).
+				AddRow("Red", "", 1, 4).
+				AddRow("Blue", "", 1, 4).
+				AddRow("", "S", 2, 3).
+				AddRow("", "L", 2, 5).
+				AddRow("", "", 3, 8))
+
+		mock.ExpectCommit()
+
+		crossTab, err := repo.FormCrossTab(context.Background(), formID, rowQuestionID, columnQuestionID, nil)
+		if err != nil {
+			t.Logf("failed to build cross tabulation: %e", err)
+			t.FailNow()
+		}
+
+		assert.Equal(t, "Color", crossTab.RowQuestion.Title)
+		assert.Equal(t, "Size", crossTab.ColumnQuestion.Title)
+		assert.Equal(t, []string{"Red", "Blue"}, crossTab.Rows)
+		assert.Equal(t, []string{"S", "L"}, crossTab.Columns)
+		assert.Equal(t, []int{4, 4}, crossTab.RowTotals)
+		assert.Equal(t, []int{3, 5}, crossTab.ColumnTotals)
+		assert.Equal(t, 8, crossTab.Total)
+
+		assert.Equal(t, 3, crossTab.Cells[0][0].Count)
+		assert.Equal(t, 75.0, crossTab.Cells[0][0].RowPercent)
+		assert.Equal(t, 100.0, crossTab.Cells[0][0].ColumnPercent)
+		assert.Equal(t, 0, crossTab.Cells[1][0].Count)
+		assert.Equal(t, 80.0, crossTab.Cells[1][1].ColumnPercent)
+
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Filtered", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewFormDatabaseRepository(connPool, builder)
+
+		formID, rowQuestionID, columnQuestionID := int64(1), int64(10), int64(11)
+		from := time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC)
+		filter := &model.FormResultFilter{
+			Answers: []*model.AnswerFilter{{QuestionID: 12, Text: "Yes"}},
+			From:    &from,
+		}
+
+		mock.ExpectBegin()
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT q.id, q.title, q.type, .* FROM %s.question as q`, schema)).
+			WithArgs(formID, rowQuestionID, columnQuestionID).
+			WillReturnRows(mock.NewRows([]string{"q.id", "q.title", "q.type", "a.answer_text"}).
+				AddRow(rowQuestionID, "Color", model.SingleAnswerType, "Red").
+				AddRow(columnQuestionID, "Size", model.SingleAnswerType, "S"))
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT COALESCE\(ra.answer_text, ''\), COALESCE\(ca.answer_text, ''\), GROUPING\(ra.answer_text, ca.answer_text\), COUNT\(DISTINCT fp.id\) FROM %s.form_passage as fp .* AND EXISTS \(SELECT 1 FROM %s.form_passage_answer as fpa .*\) AND fp.finished_at >= \$6 GROUP BY GROUPING SETS`, schema, schema)).
+			WithArgs(formID, rowQuestionID, columnQuestionID, int64(12), "Yes", from).
+			WillReturnRows(mock.NewRows([]string{"ra.answer_text", "ca.answer_text", "grouping", "count"}).
+				AddRow("Red", "S", 0, 2).
+				AddRow("Red", "", 1, 2).
+				AddRow("", "S", 2, 2).
+				AddRow("", "", 3, 2))
+
+		mock.ExpectCommit()
+
+		crossTab, err := repo.FormCrossTab(context.Background(), formID, rowQuestionID, columnQuestionID, filter)
+		if err != nil {
+			t.Logf("failed to build cross tabulation: %e", err)
+			t.FailNow()
+		}
+
+		assert.Equal(t, 2, crossTab.Total)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("MultipleAnswers", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewFormDatabaseRepository(connPool, builder)
+
+		formID, rowQuestionID, columnQuestionID := int64(1), int64(10), int64(11)
+
+		mock.ExpectBegin()
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT q.id, q.title, q.type, .* FROM %s.question as q`, schema)).
+			WithArgs(formID, rowQuestionID, columnQuestionID).
+			WillReturnRows(mock.NewRows([]string{"q.id", "q.title", "q.type", "a.answer_text"}).
+				AddRow(rowQuestionID, "Colors", model.MultipleAnswerType, "Red").
+				AddRow(rowQuestionID, "Colors", model.MultipleAnswerType, "Blue").
+				AddRow(columnQuestionID, "Size", model.SingleAnswerType, "S"))
+
+		// one passage picked both colors
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT COALESCE\(ra.answer_text, ''\), COALESCE\(ca.answer_text, ''\), GROUPING\(ra.answer_text, ca.answer_text\), COUNT\(DISTINCT fp.id\) FROM %s.form_passage as fp`, schema)).
+			WithArgs(formID, rowQuestionID, columnQuestionID).
+			WillReturnRows(mock.NewRows([]string{"ra.answer_text", "ca.answer_text", "grouping", "count"}).
+				AddRow("Red", "S", 0, 1).
+				AddRow("Blue", "S", 0, 1).
+				AddRow("Red", "", 1, 1).
+				AddRow("Blue", "", 1, 1).
+				AddRow("", "S", 2, 1).
+				AddRow("", "", 3, 1))
+
+		mock.ExpectCommit()
+
+		crossTab, err := repo.FormCrossTab(context.Background(), formID, rowQuestionID, columnQuestionID, nil)
+		if err != nil {
+			t.Logf("failed to build cross tabulation: %e", err)
+			t.FailNow()
+		}
+
+		assert.Equal(t, []int{1}, crossTab.ColumnTotals, "a passage is counted once")
+		assert.Equal(t, 1, crossTab.Total)
+		assert.Equal(t, 100.0, crossTab.Cells[0][0].ColumnPercent)
+		assert.Equal(t, 100.0, crossTab.Cells[1][0].ColumnPercent)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("QuestionNotFound", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewFormDatabaseRepository(connPool, builder)
+
+		mock.ExpectBegin()
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT q.id, q.title, q.type, .* FROM %s.question as q`, schema)).
+			WithArgs(int64(1), int64(10), int64(11)).
+			WillReturnRows(mock.NewRows([]string{"q.id", "q.title", "q.type", "a.answer_text"}).
+				AddRow(int64(10), "Color", model.SingleAnswerType, "Red"))
+
+		mock.ExpectCommit()
+
+		crossTab, err := repo.FormCrossTab(context.Background(), 1, 10, 11, nil)
+		assert.Nil(t, err)
+		assert.Nil(t, crossTab)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
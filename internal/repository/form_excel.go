@@ -0,0 +1,413 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go-form-hub/internal/model"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	excelDefaultSheet   = "Sheet1"
+	excelSummarySheet   = "Summary"
+	excelResponsesSheet = "Responses"
+
+	excelPercentFormat     = 10
+	excelQuestionTableRow  = 4
+	excelSummaryTableRow   = 8
+	excelAnswersSeparator  = "; "
+	excelChartColumn       = "E1"
+	excelChartWidth        = 480
+	excelChartHeight       = 290
+	excelSingleAnswerChart = "pie"
+	excelMultiAnswerChart  = "col"
+)
+
+var excelQuestionTypes = map[int]string{
+	model.SingleAnswerType:   "single",
+	model.MultipleAnswerType: "multiple",
+	model.InputAnswerType:    "input",
+}
+
+// passageAnswerRow is a single answer of a single passage, used to build the raw responses sheet.
+type passageAnswerRow struct {
+	PassageID  int64
+	FinishedAt time.Time
+	Username   string
+	QuestionID int64
+	AnswerText string
+}
+
+type excelChartSeries struct {
+	Name       string `json:"name"`
+	Categories string `json:"categories"`
+	Values     string `json:"values"`
+}
+
+type excelChartDimension struct {
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type excelChartTitle struct {
+	Name string `json:"name"`
+}
+
+type excelChartLegend struct {
+	Position string `json:"position"`
+}
+
+type excelChartPlotArea struct {
+	ShowVal     bool `json:"show_val"`
+	ShowPercent bool `json:"show_percent"`
+}
+
+type excelChart struct {
+	Type      string              `json:"type"`
+	Dimension excelChartDimension `json:"dimension"`
+	Series    []excelChartSeries  `json:"series"`
+	Title     excelChartTitle     `json:"title"`
+	Legend    excelChartLegend    `json:"legend"`
+	PlotArea  excelChartPlotArea  `json:"plotarea"`
+}
+
+type excelStyles struct {
+	header  int
+	percent int
+}
+
+func (r *formDatabaseRepository) formPassageAnswers(ctx context.Context, formID int64) (passages []*passageAnswerRow, err error) {
+	query, args, err := r.builder.
+		Select("fp.id", "fp.finished_at", "COALESCE(u.username, '')", "pa.question_id", "pa.answer_text").
+		From(fmt.Sprintf("%s.form_passage as fp", r.db.GetSchema())).
+		LeftJoin(fmt.Sprintf("%s.user as u ON fp.user_id = u.id", r.db.GetSchema())).
+		Join(fmt.Sprintf("%s.form_passage_answer as pa ON fp.id = pa.form_passage_id", r.db.GetSchema())).
+		Where(squirrel.Eq{"fp.form_id": formID}).
+		OrderBy("fp.finished_at", "fp.id", "pa.id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_passage_answers failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_passage_answers failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_passage_answers failed to execute query: %e", err)
+	}
+
+	return r.passageAnswersFromRows(rows)
+}
+
+func (r *formDatabaseRepository) passageAnswersFromRows(rows pgx.Rows) ([]*passageAnswerRow, error) {
+	defer func() {
+		rows.Close()
+	}()
+
+	passages := make([]*passageAnswerRow, 0)
+
+	for rows.Next() {
+		passage := &passageAnswerRow{}
+		err := rows.Scan(
+			&passage.PassageID,
+			&passage.FinishedAt,
+			&passage.Username,
+			&passage.QuestionID,
+			&passage.AnswerText,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("form_repository passageAnswersFromRows failed to scan row: %v", err)
+		}
+		passages = append(passages, passage)
+	}
+
+	return passages, nil
+}
+
+func generateExcelFile(form *model.FormResult, passages []*passageAnswerRow) ([]byte, error) {
+	file := excelize.NewFile()
+
+	err := fillExcelFile(file, form, passages)
+	if err != nil {
+		return nil, err
+	}
+
+	buf, err := file.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// fillExcelFile lays form results out as a summary sheet, one sheet per question
+// (with a chart for choice questions) and a sheet with one row per passage.
+func fillExcelFile(file *excelize.File, form *model.FormResult, passages []*passageAnswerRow) error {
+	styles, err := newExcelStyles(file)
+	if err != nil {
+		return err
+	}
+
+	questions := sortedQuestionResults(form.Questions)
+
+	file.SetSheetName(excelDefaultSheet, excelSummarySheet)
+	fillExcelSummarySheet(file, form, questions, styles)
+
+	for i, question := range questions {
+		sheet := excelQuestionSheetName(i)
+		file.NewSheet(sheet)
+
+		err = fillExcelQuestionSheet(file, sheet, question, styles)
+		if err != nil {
+			return err
+		}
+	}
+
+	file.NewSheet(excelResponsesSheet)
+	fillExcelResponsesSheet(file, form, questions, passages, styles)
+
+	file.SetActiveSheet(file.GetSheetIndex(excelSummarySheet))
+
+	return nil
+}
+
+func newExcelStyles(file *excelize.File) (*excelStyles, error) {
+	header, err := file.NewStyle(`{"font":{"bold":true}}`)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository excel failed to create header style: %v", err)
+	}
+
+	percent, err := file.NewStyle(fmt.Sprintf(`{"number_format":%d}`, excelPercentFormat))
+	if err != nil {
+		return nil, fmt.Errorf("form_repository excel failed to create percent style: %v", err)
+	}
+
+	return &excelStyles{
+		header:  header,
+		percent: percent,
+	}, nil
+}
+
+func fillExcelSummarySheet(file *excelize.File, form *model.FormResult, questions []*model.QuestionResult, styles *excelStyles) {
+	sheet := excelSummarySheet
+
+	author := ""
+	if form.Author != nil {
+		author = form.Author.Username
+	}
+
+	info := [][]interface{}{
+		{"Form Name", form.Title},
+		{"Description", form.Description},
+		{"Author", author},
+		{"Created at", form.CreatedAt},
+		{"Anonymous", form.Anonymous},
+		{"Number of passages", form.NumberOfPassagesForm},
+	}
+	for i, row := range info {
+		file.SetCellValue(sheet, excelCell(0, i+1), row[0])
+		file.SetCellValue(sheet, excelCell(1, i+1), row[1])
+	}
+	file.SetCellStyle(sheet, "A1", excelCell(0, len(info)), styles.header)
+
+	setExcelHeader(file, sheet, excelSummaryTableRow, []string{"#", "Question", "Type", "Number of passages", "Sheet"}, styles)
+
+	for i, question := range questions {
+		row := excelSummaryTableRow + i + 1
+		questionSheet := excelQuestionSheetName(i)
+
+		file.SetCellValue(sheet, excelCell(0, row), i+1)
+		file.SetCellValue(sheet, excelCell(1, row), question.Title)
+		file.SetCellValue(sheet, excelCell(2, row), excelQuestionTypes[question.Type])
+		file.SetCellValue(sheet, excelCell(3, row), question.NumberOfPassagesQuestion)
+		file.SetCellValue(sheet, excelCell(4, row), questionSheet)
+		file.SetCellHyperLink(sheet, excelCell(4, row), fmt.Sprintf("%s!A1", questionSheet), "Location")
+	}
+
+	file.SetColWidth(sheet, "A", "A", 20)
+	file.SetColWidth(sheet, "B", "B", 50)
+	file.SetColWidth(sheet, "C", "E", 20)
+	freezeExcelRows(file, sheet, excelSummaryTableRow)
+}
+
+func fillExcelQuestionSheet(file *excelize.File, sheet string, question *model.QuestionResult, styles *excelStyles) error {
+	file.SetCellValue(sheet, "A1", "Question")
+	file.SetCellValue(sheet, "B1", question.Title)
+	file.SetCellValue(sheet, "A2", "Number of passages")
+	file.SetCellValue(sheet, "B2", question.NumberOfPassagesQuestion)
+	file.SetCellStyle(sheet, "A1", "A2", styles.header)
+
+	setExcelHeader(file, sheet, excelQuestionTableRow, []string{"Answer", "Selected times", "Percent"}, styles)
+
+	for i, answer := range question.Answers {
+		row := excelQuestionTableRow + i + 1
+
+		percent := 0.0
+		if question.NumberOfPassagesQuestion != 0 {
+			percent = float64(answer.SelectedTimesAnswer) / float64(question.NumberOfPassagesQuestion)
+		}
+
+		file.SetCellValue(sheet, excelCell(0, row), answer.Text)
+		file.SetCellValue(sheet, excelCell(1, row), answer.SelectedTimesAnswer)
+		file.SetCellValue(sheet, excelCell(2, row), percent)
+		file.SetCellStyle(sheet, excelCell(2, row), excelCell(2, row), styles.percent)
+	}
+
+	file.SetColWidth(sheet, "A", "A", 40)
+	file.SetColWidth(sheet, "B", "C", 15)
+	freezeExcelRows(file, sheet, excelQuestionTableRow)
+
+	if question.Type == model.InputAnswerType || len(question.Answers) == 0 {
+		return nil
+	}
+
+	return addExcelQuestionChart(file, sheet, question)
+}
+
+func addExcelQuestionChart(file *excelize.File, sheet string, question *model.QuestionResult) error {
+	chartType := excelSingleAnswerChart
+	if question.Type == model.MultipleAnswerType {
+		chartType = excelMultiAnswerChart
+	}
+
+	firstRow := excelQuestionTableRow + 1
+	lastRow := excelQuestionTableRow + len(question.Answers)
+
+	chart := excelChart{
+		Type:      chartType,
+		Dimension: excelChartDimension{Width: excelChartWidth, Height: excelChartHeight},
+		Series: []excelChartSeries{
+			{
+				Name:       fmt.Sprintf("%s!$B$%d", sheet, excelQuestionTableRow),
+				Categories: fmt.Sprintf("%s!$A$%d:$A$%d", sheet, firstRow, lastRow),
+				Values:     fmt.Sprintf("%s!$B$%d:$B$%d", sheet, firstRow, lastRow),
+			},
+		},
+		Title:    excelChartTitle{Name: question.Title},
+		Legend:   excelChartLegend{Position: "bottom"},
+		PlotArea: excelChartPlotArea{ShowVal: true, ShowPercent: chartType == excelSingleAnswerChart},
+	}
+
+	format, err := json.Marshal(chart)
+	if err != nil {
+		return fmt.Errorf("form_repository excel failed to marshal chart format: %v", err)
+	}
+
+	err = file.AddChart(sheet, excelChartColumn, string(format))
+	if err != nil {
+		return fmt.Errorf("form_repository excel failed to add chart: %v", err)
+	}
+
+	return nil
+}
+
+func fillExcelResponsesSheet(file *excelize.File, form *model.FormResult, questions []*model.QuestionResult, passages []*passageAnswerRow, styles *excelStyles) {
+	sheet := excelResponsesSheet
+
+	header := []string{"Passage", "Finished at"}
+	if !form.Anonymous {
+		header = append(header, "User")
+	}
+	firstQuestionColumn := len(header)
+
+	questionColumns := make(map[int64]int, len(questions))
+	for i, question := range questions {
+		questionColumns[question.ID] = firstQuestionColumn + i
+		header = append(header, question.Title)
+	}
+	setExcelHeader(file, sheet, 1, header, styles)
+
+	row := 1
+	var currentPassage *passageAnswerRow
+	answers := map[int][]string{}
+
+	flush := func() {
+		if currentPassage == nil {
+			return
+		}
+
+		file.SetCellValue(sheet, excelCell(0, row), currentPassage.PassageID)
+		file.SetCellValue(sheet, excelCell(1, row), currentPassage.FinishedAt)
+		if !form.Anonymous {
+			file.SetCellValue(sheet, excelCell(2, row), currentPassage.Username)
+		}
+		for column, texts := range answers {
+			file.SetCellValue(sheet, excelCell(column, row), strings.Join(texts, excelAnswersSeparator))
+		}
+	}
+
+	for _, passage := range passages {
+		if currentPassage == nil || currentPassage.PassageID != passage.PassageID {
+			flush()
+			row++
+			currentPassage = passage
+			answers = map[int][]string{}
+		}
+
+		column, ok := questionColumns[passage.QuestionID]
+		if !ok {
+			continue
+		}
+		answers[column] = append(answers[column], passage.AnswerText)
+	}
+	flush()
+
+	file.SetColWidth(sheet, "A", excelize.ToAlphaString(len(header)-1), 20)
+	freezeExcelRows(file, sheet, 1)
+}
+
+func setExcelHeader(file *excelize.File, sheet string, row int, titles []string, styles *excelStyles) {
+	for i, title := range titles {
+		file.SetCellValue(sheet, excelCell(i, row), title)
+	}
+	file.SetCellStyle(sheet, excelCell(0, row), excelCell(len(titles)-1, row), styles.header)
+}
+
+func freezeExcelRows(file *excelize.File, sheet string, rows int) {
+	file.SetPanes(sheet, fmt.Sprintf(
+		`{"freeze":true,"split":false,"x_split":0,"y_split":%d,"top_left_cell":"A%d","active_pane":"bottomLeft","panes":[{"sqref":"A%d:XFD%d","active_cell":"A%d","pane":"bottomLeft"}]}`,
+		rows, rows+1, rows+1, rows+1, rows+1,
+	))
+}
+
+// excelCell returns the cell name for zero-based column and one-based row, e.g. excelCell(1, 3) == "B3".
+func excelCell(column, row int) string {
+	return fmt.Sprintf("%s%d", excelize.ToAlphaString(column), row)
+}
+
+func excelQuestionSheetName(index int) string {
+	return fmt.Sprintf("Q%d", index+1)
+}
+
+func sortedQuestionResults(questions []*model.QuestionResult) []*model.QuestionResult {
+	sorted := make([]*model.QuestionResult, len(questions))
+	copy(sorted, questions)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Position == sorted[j].Position {
+			return sorted[i].ID < sorted[j].ID
+		}
+		return sorted[i].Position < sorted[j].Position
+	})
+
+	return sorted
+}
@@ -0,0 +1,125 @@
+package repository_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormRepositoryFormResultsExel(t *testing.T) {
+	t.Run("NoErrors", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewFormDatabaseRepository(connPool, builder)
+
+		formID := int64(1)
+		createdAt := time.Now().UTC()
+
+		mock.ExpectBegin()
+
+		formInfoColumns := []string{"f.id", "f.title", "f.created_at", "f.description", "f.anonymous", "f.passage_max",
+			"u.id", "u.username", "u.first_name", "u.last_name", "u.email",
+			"q.id", "q.title", "q.text", "q.type", "q.position", "a.answer_text"}
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT .* FROM %s.form as f .* WHERE f.id = \$1$`, schema)).
+			WithArgs(formID).
+			WillReturnRows(mock.NewRows(formInfoColumns).
+				AddRow(formID, "title", createdAt, "", false, 1, int64(1), "author", "", "", "", int64(10), "Color", "", model.SingleAnswerType, 1, "Red").
+				AddRow(formID, "title", createdAt, "", false, 1, int64(1), "author", "", "", "", int64(10), "Color", "", model.SingleAnswerType, 1, "Blue").
+				AddRow(formID, "title", createdAt, "", false, 1, int64(1), "author", "", "", "", int64(11), "Why", "", model.InputAnswerType, 2, ""))
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT fa.form_id, fa.passage_count FROM %s.form_result_aggregate as fa WHERE fa.form_id = \$1$`, schema)).
+			WithArgs(formID).
+			WillReturnRows(mock.NewRows([]string{"fa.form_id", "fa.passage_count"}).
+				AddRow(formID, 2))
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT qa.question_id, qa.passage_count FROM %s.question_result_aggregate as qa WHERE qa.form_id = \$1$`, schema)).
+			WithArgs(formID).
+			WillReturnRows(mock.NewRows([]string{"qa.question_id", "qa.passage_count"}).
+				AddRow(int64(10), 2).
+				AddRow(int64(11), 2))
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT aa.question_id, aa.answer_text, aa.selected_times FROM %s.answer_result_aggregate as aa .* WHERE q.form_id = \$1 ORDER BY aa.question_id, aa.answer_text$`, schema)).
+			WithArgs(formID).
+			WillReturnRows(mock.NewRows([]string{"aa.question_id", "aa.answer_text", "aa.selected_times"}).
+				AddRow(int64(10), "Red", 2).
+				AddRow(int64(11), "Because", 1).
+				AddRow(int64(11), "Why not", 1))
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT ua.id, ua.username, ua.first_name FROM %s.form_passage as fp .* GROUP BY ua.id ORDER BY MIN\(fp.id\)$`, schema)).
+			WithArgs(formID).
+			WillReturnRows(mock.NewRows([]string{"ua.id", "ua.username", "ua.first_name"}).
+				AddRow(int64(2), "user2", "").
+				AddRow(int64(3), "user3", ""))
+
+		mock.ExpectCommit()
+
+		mock.ExpectBegin()
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT fp.id, fp.finished_at, .* FROM %s.form_passage as fp .* WHERE fp.form_id = \$1 ORDER BY fp.finished_at, fp.id, pa.id$`, schema)).
+			WithArgs(formID).
+			WillReturnRows(mock.NewRows([]string{"fp.id", "fp.finished_at", "u.username", "pa.question_id", "pa.answer_text"}).
+				AddRow(int64(100), createdAt, "user2", int64(10), "Red").
+				AddRow(int64(100), createdAt, "user2", int64(11), "Because").
+				AddRow(int64(101), createdAt, "user3", int64(10), "Red").
+				AddRow(int64(101), createdAt, "user3", int64(11), "Why not"))
+
+		mock.ExpectCommit()
+
+		result, err := repo.FormResultsExel(context.Background(), formID)
+		if err != nil {
+			t.Logf("failed to export form results: %e", err)
+			t.FailNow()
+		}
+
+		file, err := excelize.OpenReader(bytes.NewReader(result))
+		if err != nil {
+			t.Logf("failed to open exported file: %e", err)
+			t.FailNow()
+		}
+
+		sheets := file.GetSheetMap()
+		assert.Equal(t, 4, len(sheets))
+		assert.NotZero(t, file.GetSheetIndex("Summary"))
+		assert.NotZero(t, file.GetSheetIndex("Q1"))
+		assert.NotZero(t, file.GetSheetIndex("Q2"))
+		assert.NotZero(t, file.GetSheetIndex("Responses"))
+
+		assert.Equal(t, "title", file.GetCellValue("Summary", "B1"))
+		assert.Equal(t, "Color", file.GetCellValue("Summary", "B9"))
+		assert.Equal(t, "Why", file.GetCellValue("Summary", "B10"))
+
+		assert.Equal(t, "Red", file.GetCellValue("Q1", "A5"))
+		assert.Equal(t, "2", file.GetCellValue("Q1", "B5"))
+		assert.Equal(t, "Blue", file.GetCellValue("Q1", "A6"))
+		assert.Equal(t, "0", file.GetCellValue("Q1", "B6"))
+
+		assert.Contains(t, file.XLSX, "xl/charts/chart1.xml")
+		assert.NotContains(t, file.XLSX, "xl/charts/chart2.xml")
+
+		responses := file.GetRows("Responses")
+		assert.Equal(t, 3, len(responses))
+		assert.Equal(t, []string{"Passage", "Finished at", "User", "Color", "Why"}, responses[0])
+		assert.Equal(t, "user3", responses[2][2])
+		assert.Equal(t, "Why not", responses[2][4])
+
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"go-form-hub/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// FormPassagesImport saves passages imported from another tool in one transaction. They belong to
+// no user and, unlike FormPassageSave, fire no webhooks since nobody has just passed the form.
+func (r *formDatabaseRepository) FormPassagesImport(ctx context.Context, formID int64, passages []*model.FormPassage) (err error) {
+	passageQuery := fmt.Sprintf(`INSERT INTO %s.form_passage
+	(user_id, form_id, started_at, finished_at)
+	VALUES(NULL, $1, $2, COALESCE($3::timestamp, NOW() AT TIME ZONE 'UTC'))
+	RETURNING id`, r.db.GetSchema())
+
+	passageAnswerQuery := fmt.Sprintf(`INSERT INTO %s.form_passage_answer
+	(answer_text, question_id, form_passage_id)
+	VALUES($1::text, $2::integer, $3::integer)`, r.db.GetSchema())
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("form_repository form_passages_import failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	batch := &pgx.Batch{}
+	for _, passage := range passages {
+		var passageID int64
+		err = tx.QueryRow(ctx, passageQuery, formID, passage.StartedAt, passage.FinishedAt).Scan(&passageID)
+		if err != nil {
+			return fmt.Errorf("form_repository form_passages_import failed to insert passage: %e", err)
+		}
+
+		for _, passageAnswer := range passage.PassageAnswers {
+			batch.Queue(passageAnswerQuery, passageAnswer.Text, passageAnswer.QuestionID, passageID)
+		}
+		r.queueAggregateUpdates(batch, formID, passage.PassageAnswers)
+	}
+	batch.Queue("SELECT pg_notify($1, $2)", FormPassageChannel, strconv.FormatInt(formID, 10))
+
+	err = tx.SendBatch(ctx, batch).Close()
+	if err != nil {
+		return fmt.Errorf("form_repository form_passages_import failed to insert answers: %e", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package repository
+
+import "fmt"
+
+const noPassageLimit = -1
+
+// lockUserPassagesQuery takes a transaction lock on the passages of the user to the form. Parallel
+// submissions of the same user wait for each other, so the count that follows sees every passage
+// committed before. The key hashes both IDs, the two-key form of the lock takes int4 and would
+// overflow on larger IDs.
+func lockUserPassagesQuery(formID int64, userID uint64) (string, []interface{}) {
+	return `SELECT pg_advisory_xact_lock(hashtextextended(format('%s:%s', $1::bigint, $2::bigint), 0))`,
+		[]interface{}{formID, userID}
+}
+
+// countUserPassagesQuery returns passage_max of the form and the passages of the user to it,
+// the passage being saved included.
+func countUserPassagesQuery(schema string, formID int64, userID uint64) (string, []interface{}) {
+	query := fmt.Sprintf(`SELECT f.passage_max,
+	(SELECT count(*) FROM %s.form_passage as fp WHERE fp.form_id = f.id AND fp.user_id = $2::bigint)
+	FROM %s.form as f
+	WHERE f.id = $1`, schema, schema)
+
+	return query, []interface{}{formID, userID}
+}
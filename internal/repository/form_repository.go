@@ -4,25 +4,42 @@ import (
 	"bytes"
 	"context"
 	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"time"
 
 	"go-form-hub/internal/database"
 	"go-form-hub/internal/model"
 
-	"github.com/360EntSecGroup-Skylar/excelize"
 	"github.com/Masterminds/squirrel"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 )
 
+// FormPassageChannel is the NOTIFY channel that receives the form ID every time a passage is saved.
+const FormPassageChannel = "form_passage_saved"
+
+// ErrPassageReplayed is returned when the form already has a passage with the same idempotency key.
+var ErrPassageReplayed = errors.New("the passage has already been saved")
+
+// ErrIdempotencyKeyReused is returned when the idempotency key of the form was used by another
+// respondent or with other answers.
+var ErrIdempotencyKeyReused = errors.New("the idempotency key was used for another passage")
+
 type Form struct {
-	Title       string    `db:"title"`
-	ID          int64     `db:"id"`
-	Description *string   `db:"description"`
-	Anonymous   bool      `db:"anonymous"`
-	PassageMax  int64     `db:"passage_max"`
-	AuthorID    int64     `db:"author_id"`
-	CreatedAt   time.Time `db:"created_at"`
+	Title              string     `db:"title"`
+	ID                 int64      `db:"id"`
+	Description        *string    `db:"description"`
+	Anonymous          bool       `db:"anonymous"`
+	PassageMax         int64      `db:"passage_max"`
+	InviteOnly         bool       `db:"invite_only"`
+	VerifiedOnly       bool       `db:"verified_only"`
+	AccessPasswordHash *string    `db:"access_password_hash"`
+	AuthorID           int64      `db:"author_id"`
+	CreatedAt          time.Time  `db:"created_at"`
+	ClosedAt           *time.Time `db:"closed_at"`
 }
 
 var (
@@ -34,6 +51,10 @@ var (
 		"f.author_id",
 		"f.anonymous",
 		"f.passage_max",
+		"f.invite_only",
+		"f.verified_only",
+		"f.access_password_hash",
+		"f.closed_at",
 		"u.id",
 		"u.username",
 		"u.first_name",
@@ -96,10 +117,9 @@ func NewFormDatabaseRepository(db database.ConnPool, builder squirrel.StatementB
 
 func (r *formDatabaseRepository) FindAll(ctx context.Context) (forms []*model.FormTitle, err error) {
 	query, args, err := r.builder.
-		Select("id, title, created_at, count(fp.id) as number_of_passages").
+		Select("f.id, f.title, f.created_at, COALESCE(fa.passage_count, 0) as number_of_passages").
 		From(fmt.Sprintf("%s.form as f", r.db.GetSchema())).
-		LeftJoin(fmt.Sprintf("%s.form_passage as fp ON fp.form_id = f.id", r.db.GetSchema())).
-		GroupBy("f.id").
+		LeftJoin(fmt.Sprintf("%s.form_result_aggregate as fa ON fa.form_id = f.id", r.db.GetSchema())).
 		ToSql()
 
 	if err != nil {
@@ -132,11 +152,10 @@ func (r *formDatabaseRepository) FormsSearch(ctx context.Context, title string,
 	const limit = 5
 	query := fmt.Sprintf(`SELECT id, title, created_at, number_of_passages
 		FROM (
-		  SELECT f.title as title, f.id as id, f.created_at as created_at, COUNT(fp.id) as number_of_passages, similarity(f.title, $1::text) as sim
+		  SELECT f.title as title, f.id as id, f.created_at as created_at, COALESCE(fa.passage_count, 0) as number_of_passages, similarity(f.title, $1::text) as sim
 		  FROM %s.form as f
-		  LEFT JOIN %s.form_passage  as fp ON fp.form_id = f.id
+		  LEFT JOIN %s.form_result_aggregate as fa ON fa.form_id = f.id
 		  WHERE f.author_id = $2::integer
-		  GROUP BY f.id
 		  ORDER BY sim DESC, f.created_at
 		) AS res
 		WHERE sim > 0
@@ -165,7 +184,7 @@ func (r *formDatabaseRepository) FormsSearch(ctx context.Context, title string,
 }
 
 func (r *formDatabaseRepository) FormResultsCsv(ctx context.Context, id int64) ([]byte, error) {
-	form, err := r.FormResults(ctx, id)
+	form, err := r.FormResults(ctx, id, nil)
 	if err != nil {
 		return nil, fmt.Errorf("form_repository form_results_exel failed to run FormResults: %e", err)
 	}
@@ -205,62 +224,35 @@ func (r *formDatabaseRepository) FormResultsCsv(ctx context.Context, id int64) (
 }
 
 func (r *formDatabaseRepository) FormResultsExel(ctx context.Context, id int64) ([]byte, error) {
-	form, err := r.FormResults(ctx, id)
+	form, err := r.FormResults(ctx, id, nil)
 	if err != nil {
 		return nil, fmt.Errorf("form_repository form_results_exel failed to run FormResults: %e", err)
 	}
 
-	excelFile, err := generateExcelFile(form)
-	if err != nil {
-		return nil, err
+	if form == nil {
+		return nil, nil
 	}
 
-	return excelFile, nil
-}
-
-func generateExcelFile(form *model.FormResult) ([]byte, error) {
-	file := excelize.NewFile()
-
-	fillExcelFile(file, form)
+	passages, err := r.formPassageAnswers(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_results_exel failed to run formPassageAnswers: %e", err)
+	}
 
-	buf, err := file.WriteToBuffer()
+	excelFile, err := generateExcelFile(form, passages)
 	if err != nil {
 		return nil, err
 	}
 
-	return buf.Bytes(), nil
+	return excelFile, nil
 }
 
-func fillExcelFile(file *excelize.File, form *model.FormResult) {
-	file.SetCellValue("Sheet1", "A1", "Form Name")
-	file.SetCellValue("Sheet1", "B1", form.Title)
-
-	file.SetCellValue("Sheet1", "A2", "Description")
-	file.SetCellValue("Sheet1", "B2", form.Description)
-
-	row := 4
-	qcounter := 1
-
-	for _, question := range form.Questions {
-		file.SetCellValue("Sheet1", fmt.Sprintf("A%d", row), fmt.Sprintf("Question%d", qcounter))
-		file.SetCellValue("Sheet1", fmt.Sprintf("B%d", row), question.Title)
-		file.SetCellValue("Sheet1", fmt.Sprintf("C%d", row), fmt.Sprintf("NumberOfPassagesQuestion %d", question.NumberOfPassagesQuestion))
-		row++
-
-		acounter := 1
-		for _, answer := range question.Answers {
-			file.SetCellValue("Sheet1", fmt.Sprintf("B%d", row), fmt.Sprintf("Answer%d", acounter))
-			file.SetCellValue("Sheet1", fmt.Sprintf("C%d", row), answer.Text)
-			file.SetCellValue("Sheet1", fmt.Sprintf("D%d", row), fmt.Sprintf("SelectedTimesAnswer %d", answer.SelectedTimesAnswer))
-
-			row++
-			acounter++
-		}
-		qcounter++
+// FormResults reads precomputed aggregates unless the filter asks for a subset of passages,
+// in which case everything is counted from the passages themselves.
+func (r *formDatabaseRepository) FormResults(ctx context.Context, id int64, filter *model.FormResultFilter) (formResult *model.FormResult, err error) {
+	if filter.IsEmpty() {
+		return r.formResultsFromAggregates(ctx, id)
 	}
-}
 
-func (r *formDatabaseRepository) FormResults(ctx context.Context, id int64) (formResult *model.FormResult, err error) {
 	formInfoQuery, formInfoArgs, err := r.builder.
 		Select(selectFieldsFormInfo...).
 		From(fmt.Sprintf("%s.form as f", r.db.GetSchema())).
@@ -274,25 +266,25 @@ func (r *formDatabaseRepository) FormResults(ctx context.Context, id int64) (for
 		return nil, fmt.Errorf("form_repository form_results failed to build form info query: %e", err)
 	}
 
-	formPassageInfoQuery, formPassageInfoArgs, err := r.builder.
+	formPassageInfoQuery, formPassageInfoArgs, err := r.withPassageFilter(r.builder.
 		Select(selectFieldsFormPassageInfo...).
 		From(fmt.Sprintf("%s.form_passage as fp", r.db.GetSchema())).
 		LeftJoin(fmt.Sprintf("%s.user as ua ON fp.user_id = ua.id", r.db.GetSchema())).
 		Join(fmt.Sprintf("%s.form_passage_answer as pa ON fp.id = pa.form_passage_id", r.db.GetSchema())).
 		Join(fmt.Sprintf("%s.question as q ON pa.question_id = q.id", r.db.GetSchema())).
-		Where(squirrel.Eq{"fp.form_id": id}).
+		Where(squirrel.Eq{"fp.form_id": id}), filter).
 		ToSql()
 
 	if err != nil {
 		return nil, fmt.Errorf("form_repository form_passage_results failed to build form passage info query: %e", err)
 	}
 
-	formPassageCount, formPassageArgs, err := r.builder.
+	formPassageCount, formPassageArgs, err := r.withPassageFilter(r.builder.
 		Select("fp.form_id", "COUNT(DISTINCT fp.id) AS unique_response_count").
 		From(fmt.Sprintf("%s.form_passage as fp", r.db.GetSchema())).
 		Join(fmt.Sprintf("%s.form_passage_answer as pa ON fp.id = pa.form_passage_id", r.db.GetSchema())).
 		Join(fmt.Sprintf("%s.question as q ON pa.question_id = q.id", r.db.GetSchema())).
-		Where(squirrel.Eq{"fp.form_id": id}).
+		Where(squirrel.Eq{"fp.form_id": id}), filter).
 		GroupBy("fp.form_id").
 		ToSql()
 
@@ -300,12 +292,12 @@ func (r *formDatabaseRepository) FormResults(ctx context.Context, id int64) (for
 		return nil, fmt.Errorf("form_repository question_count failed to build form passage info query: %e", err)
 	}
 
-	questionPassageCount, questionPassageArgs, err := r.builder.
+	questionPassageCount, questionPassageArgs, err := r.withPassageFilter(r.builder.
 		Select("q.id", "COUNT(DISTINCT fp.id) AS unique_response_count").
 		From(fmt.Sprintf("%s.form_passage as fp", r.db.GetSchema())).
 		Join(fmt.Sprintf("%s.form_passage_answer as pa ON fp.id = pa.form_passage_id", r.db.GetSchema())).
 		Join(fmt.Sprintf("%s.question as q ON pa.question_id = q.id", r.db.GetSchema())).
-		Where(squirrel.Eq{"fp.form_id": id}).
+		Where(squirrel.Eq{"fp.form_id": id}), filter).
 		GroupBy("q.id").
 		ToSql()
 
@@ -421,6 +413,29 @@ func (r *formDatabaseRepository) FormResults(ctx context.Context, id int64) (for
 	return formResults[0], nil
 }
 
+// withPassageFilter restricts a query over form_passage aliased as fp to passages matching the filter.
+func (r *formDatabaseRepository) withPassageFilter(query squirrel.SelectBuilder, filter *model.FormResultFilter) squirrel.SelectBuilder {
+	if filter.IsEmpty() {
+		return query
+	}
+
+	for _, answer := range filter.Answers {
+		query = query.Where(fmt.Sprintf(`EXISTS (SELECT 1 FROM %s.form_passage_answer as fpa
+			WHERE fpa.form_passage_id = fp.id AND fpa.question_id = ? AND fpa.answer_text = ?)`, r.db.GetSchema()),
+			answer.QuestionID, answer.Text)
+	}
+
+	if filter.From != nil {
+		query = query.Where(squirrel.GtOrEq{"fp.finished_at": *filter.From})
+	}
+
+	if filter.To != nil {
+		query = query.Where(squirrel.Lt{"fp.finished_at": *filter.To})
+	}
+
+	return query
+}
+
 func (r *formDatabaseRepository) formResultsFromRows(rows pgx.Rows) ([]*model.FormResult, error) {
 	defer func() {
 		rows.Close()
@@ -626,12 +641,11 @@ func (r *formDatabaseRepository) formResultsFromRow(row pgx.Row) (*formResultsFr
 
 func (r *formDatabaseRepository) FindAllByUser(ctx context.Context, username string) (forms []*model.FormTitle, err error) {
 	query, args, err := r.builder.
-		Select("f.id, f.title, f.created_at, count(fp.id) as number_of_passages").
+		Select("f.id, f.title, f.created_at, COALESCE(fa.passage_count, 0) as number_of_passages").
 		From(fmt.Sprintf("%s.form as f", r.db.GetSchema())).
 		Join(fmt.Sprintf("%s.user as u ON f.author_id = u.id", r.db.GetSchema())).
-		LeftJoin(fmt.Sprintf("%s.form_passage as fp ON fp.form_id = f.id", r.db.GetSchema())).
+		LeftJoin(fmt.Sprintf("%s.form_result_aggregate as fa ON fa.form_id = f.id", r.db.GetSchema())).
 		Where(squirrel.Eq{"u.username": username}).
-		GroupBy("f.id").
 		ToSql()
 
 	if err != nil {
@@ -722,8 +736,10 @@ func (r *formDatabaseRepository) Insert(ctx context.Context, form *model.Form, t
 
 	formQuery, args, err := r.builder.
 		Insert(fmt.Sprintf("%s.form", r.db.GetSchema())).
-		Columns("title", "author_id", "created_at", "description", "anonymous", "passage_max").
-		Values(form.Title, form.Author.ID, form.CreatedAt, form.Description, form.Anonymous, form.PassageMax).
+		Columns("title", "author_id", "created_at", "description", "anonymous", "passage_max", "invite_only", "verified_only",
+			"access_password_hash").
+		Values(form.Title, form.Author.ID, form.CreatedAt, form.Description, form.Anonymous, form.PassageMax, form.InviteOnly,
+			form.VerifiedOnly, nullableString(form.PasswordHash)).
 		Suffix("RETURNING id").
 		ToSql()
 	err = tx.QueryRow(ctx, formQuery, args...).Scan(&form.ID)
@@ -811,17 +827,82 @@ func (r *formDatabaseRepository) FormPassageSave(ctx context.Context, formPassag
 		}
 	}()
 
+	// the passage goes first: a replay with the same idempotency key waits here for the original
+	// passage and is recognized before any limit is counted again
 	formPassageQuery := fmt.Sprintf(`INSERT INTO %s.form_passage
-	(user_id, form_id)
-	VALUES($1::integer, $2::integer)
+	(user_id, form_id, started_at, idempotency_key, recipient_id, idempotency_hash)
+	VALUES($1, $2::integer, $3, $4, $5, $6)
+	ON CONFLICT (form_id, idempotency_key) WHERE idempotency_key IS NOT NULL DO NOTHING
 	RETURNING id`, r.db.GetSchema())
 
+	// an invitee who is not logged in is known by the recipient only, the anon trigger clears both
+	// on anonymous forms
 	var formPassageID int64
-	err = tx.QueryRow(ctx, formPassageQuery, userID, formPassage.FormID).Scan(&formPassageID)
+	err = tx.QueryRow(ctx, formPassageQuery, nullableUserID(userID), formPassage.FormID, formPassage.StartedAt,
+		nullableString(formPassage.IdempotencyKey), formPassage.RecipientID, nullableString(formPassage.IdempotencyHash)).
+		Scan(&formPassageID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		// the passage that holds the key is committed by now
+		var hash string
+		hashQuery, hashArgs := idempotencyHashQuery(r.db.GetSchema(), *formPassage.FormID, formPassage.IdempotencyKey)
+		err = tx.QueryRow(ctx, hashQuery, hashArgs...).Scan(&hash)
+		if err != nil {
+			return err
+		}
+
+		err = ErrPassageReplayed
+		if !sameIdempotencyHash(hash, formPassage.IdempotencyHash) {
+			err = ErrIdempotencyKeyReused
+		}
+	}
 	if err != nil {
 		return err
 	}
 
+	if formPassage.RecipientID != nil {
+		consumeQuery, consumeArgs := consumeRecipientQuery(r.db.GetSchema(), *formPassage.RecipientID, time.Now().UTC())
+		var tag pgconn.CommandTag
+		tag, err = tx.Exec(ctx, consumeQuery, consumeArgs...)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			err = ErrRecipientUsedUp
+			return err
+		}
+	}
+
+	for _, mark := range formPassage.RespondentMarks {
+		markQuery, markArgs := countRespondentMarkQuery(r.db.GetSchema(), *formPassage.FormID, mark, formPassage.PassageMax, time.Now().UTC())
+		var passages int
+		err = tx.QueryRow(ctx, markQuery, markArgs...).Scan(&passages)
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = ErrPassageLimitReached
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if userID != model.AnonUserID && formPassage.RecipientID == nil {
+		lockQuery, lockArgs := lockUserPassagesQuery(*formPassage.FormID, userID)
+		_, err = tx.Exec(ctx, lockQuery, lockArgs...)
+		if err != nil {
+			return err
+		}
+
+		var passageMax, passages int64
+		countQuery, countArgs := countUserPassagesQuery(r.db.GetSchema(), *formPassage.FormID, userID)
+		err = tx.QueryRow(ctx, countQuery, countArgs...).Scan(&passageMax, &passages)
+		if err != nil {
+			return err
+		}
+		if passageMax != noPassageLimit && passages > passageMax {
+			err = ErrPassageLimitReached
+			return err
+		}
+	}
+
 	passageAnswerBatch := &pgx.Batch{}
 	passageAnswerQuery := fmt.Sprintf(`INSERT INTO %s.form_passage_answer
 	(answer_text, question_id, form_passage_id)
@@ -831,8 +912,39 @@ func (r *formDatabaseRepository) FormPassageSave(ctx context.Context, formPassag
 		passageAnswerBatch.Queue(passageAnswerQuery, passageAnswer.Text,
 			passageAnswer.QuestionID, formPassageID)
 	}
+	r.queueAggregateUpdates(passageAnswerBatch, *formPassage.FormID, formPassage.PassageAnswers)
+
+	passageData := &model.WebhookPassageData{
+		PassageID: formPassageID,
+		StartedAt: formPassage.StartedAt,
+		Answers:   formPassage.PassageAnswers,
+	}
+	if userID != model.AnonUserID {
+		passageUserID := int64(userID)
+		passageData.UserID = &passageUserID
+	}
+
+	now := time.Now().UTC()
+	payload, err := json.Marshal(&model.WebhookPayload{
+		Event:      model.WebhookEventPassageCreated,
+		FormID:     *formPassage.FormID,
+		OccurredAt: now,
+		Data:       passageData,
+	})
+	if err != nil {
+		return err
+	}
+	webhookQuery, webhookArgs := webhookEventQuery(r.db.GetSchema(), *formPassage.FormID, model.WebhookEventPassageCreated, payload, now)
+	passageAnswerBatch.Queue(webhookQuery, webhookArgs...)
+
+	// delivered to listeners only once the transaction commits
+	passageAnswerBatch.Queue("SELECT pg_notify($1, $2)", FormPassageChannel, strconv.FormatInt(*formPassage.FormID, 10))
+
 	answerBatch := tx.SendBatch(ctx, passageAnswerBatch)
-	answerBatch.Close()
+	err = answerBatch.Close()
+	if err != nil {
+		return err
+	}
 
 	err = tx.Commit(ctx)
 	if err != nil {
@@ -842,6 +954,53 @@ func (r *formDatabaseRepository) FormPassageSave(ctx context.Context, formPassag
 	return nil
 }
 
+// FormPassageReplayed reports whether the form already has a passage saved with the idempotency
+// key. It fails with ErrIdempotencyKeyReused when the passage was saved with another hash.
+func (r *formDatabaseRepository) FormPassageReplayed(ctx context.Context, formID int64, idempotencyKey, idempotencyHash string) (replayed bool, err error) {
+	query, args := idempotencyHashQuery(r.db.GetSchema(), formID, idempotencyKey)
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("form_repository form_passage_replayed failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	var hash string
+	err = tx.QueryRow(ctx, query, args...).Scan(&hash)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = nil
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("form_repository form_passage_replayed failed to execute query: %e", err)
+	}
+
+	if !sameIdempotencyHash(hash, idempotencyHash) {
+		return false, ErrIdempotencyKeyReused
+	}
+
+	return true, nil
+}
+
+func idempotencyHashQuery(schema string, formID int64, idempotencyKey string) (string, []interface{}) {
+	return fmt.Sprintf(`SELECT COALESCE(idempotency_hash, '') FROM %s.form_passage WHERE form_id = $1 AND idempotency_key = $2`, schema),
+		[]interface{}{formID, idempotencyKey}
+}
+
+// sameIdempotencyHash reports whether a request repeats the one that saved the passage. Passages
+// saved before the hash was kept match any request.
+func sameIdempotencyHash(saved, hash string) bool {
+	return saved == "" || saved == hash
+}
+
 func (r *formDatabaseRepository) FormPassageCount(ctx context.Context, formID int64) (int64, error) {
 	var err error
 
@@ -903,11 +1062,17 @@ func (r *formDatabaseRepository) UserFormPassageCount(ctx context.Context, formI
 }
 
 func (r *formDatabaseRepository) Update(ctx context.Context, id int64, form *model.FormUpdate) (result *model.FormUpdate, err error) {
-	query, args, err := r.builder.Update(fmt.Sprintf("%s.form", r.db.GetSchema())).
+	updateQuery := r.builder.Update(fmt.Sprintf("%s.form", r.db.GetSchema())).
 		Set("title", form.Title).
 		Set("description", form.Description).
 		Set("anonymous", form.Anonymous).
 		Set("passage_max", form.PassageMax).
+		Set("invite_only", form.InviteOnly).
+		Set("verified_only", form.VerifiedOnly)
+	if form.PasswordHash != nil {
+		updateQuery = updateQuery.Set("access_password_hash", nullableString(*form.PasswordHash))
+	}
+	query, args, err := updateQuery.
 		Where(squirrel.Eq{"id": id}).
 		Suffix("RETURNING id, title, created_at").ToSql()
 	if err != nil {
@@ -988,12 +1153,15 @@ func (r *formDatabaseRepository) fromRows(rows pgx.Rows) ([]*model.Form, error)
 
 		if _, ok := formMap[info.form.ID]; !ok {
 			formMap[info.form.ID] = &model.Form{
-				ID:          &info.form.ID,
-				Title:       info.form.Title,
-				Description: info.form.Description,
-				Anonymous:   info.form.Anonymous,
-				PassageMax:  int(info.form.PassageMax),
-				CreatedAt:   info.form.CreatedAt,
+				ID:           &info.form.ID,
+				Title:        info.form.Title,
+				Description:  info.form.Description,
+				Anonymous:    info.form.Anonymous,
+				PassageMax:   int(info.form.PassageMax),
+				InviteOnly:   info.form.InviteOnly,
+				VerifiedOnly: info.form.VerifiedOnly,
+				CreatedAt:    info.form.CreatedAt,
+				ClosedAt:     info.form.ClosedAt,
 				Author: &model.UserGet{
 					ID:        info.author.ID,
 					Username:  info.author.Username,
@@ -1005,6 +1173,11 @@ func (r *formDatabaseRepository) fromRows(rows pgx.Rows) ([]*model.Form, error)
 			}
 		}
 
+		if info.form.AccessPasswordHash != nil {
+			formMap[info.form.ID].PasswordProtected = true
+			formMap[info.form.ID].PasswordHash = *info.form.AccessPasswordHash
+		}
+
 		if _, ok := questionWasAppended[info.question.ID]; !ok {
 			questionsByFormID[info.form.ID] = append(questionsByFormID[info.form.ID], &model.Question{
 				ID:          &info.question.ID,
@@ -1096,6 +1269,22 @@ func (r *formDatabaseRepository) formTitleFromRow(row pgx.Row) (*model.FormTitle
 	return form, nil
 }
 
+// nullableString stores an empty string as NULL.
+func nullableString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func nullableUserID(userID uint64) *int64 {
+	if userID == model.AnonUserID {
+		return nil
+	}
+	id := int64(userID)
+	return &id
+}
+
 func (r *formDatabaseRepository) fromRow(row pgx.Row) (*fromRowReturn, error) {
 	form := &Form{}
 	author := &User{}
@@ -1110,6 +1299,10 @@ func (r *formDatabaseRepository) fromRow(row pgx.Row) (*fromRowReturn, error) {
 		&form.AuthorID,
 		&form.Anonymous,
 		&form.PassageMax,
+		&form.InviteOnly,
+		&form.VerifiedOnly,
+		&form.AccessPasswordHash,
+		&form.ClosedAt,
 		&author.ID,
 		&author.Username,
 		&author.FirstName,
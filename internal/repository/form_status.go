@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"go-form-hub/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// FormClose stops the form from accepting passages. It returns false if the form does not exist or is already closed.
+func (r *formDatabaseRepository) FormClose(ctx context.Context, id int64, closedAt time.Time) (bool, error) {
+	return r.setFormClosedAt(ctx, id, &closedAt, model.WebhookEventFormClosed)
+}
+
+// FormPublish reopens a closed form. It returns false if the form does not exist or is not closed.
+func (r *formDatabaseRepository) FormPublish(ctx context.Context, id int64) (bool, error) {
+	return r.setFormClosedAt(ctx, id, nil, model.WebhookEventFormPublished)
+}
+
+func (r *formDatabaseRepository) setFormClosedAt(ctx context.Context, id int64, closedAt *time.Time, event string) (changed bool, err error) {
+	update := r.builder.
+		Update(fmt.Sprintf("%s.form", r.db.GetSchema())).
+		Set("closed_at", closedAt).
+		Where(squirrel.Eq{"id": id}).
+		Suffix("RETURNING title")
+	if closedAt != nil {
+		update = update.Where(squirrel.Eq{"closed_at": nil})
+	} else {
+		update = update.Where(squirrel.NotEq{"closed_at": nil})
+	}
+
+	query, args, err := update.ToSql()
+	if err != nil {
+		return false, fmt.Errorf("form_repository set_closed_at failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("form_repository set_closed_at failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	var title string
+	err = tx.QueryRow(ctx, query, args...).Scan(&title)
+	if err == pgx.ErrNoRows {
+		err = nil
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("form_repository set_closed_at failed to execute query: %e", err)
+	}
+
+	now := time.Now().UTC()
+	payload, err := json.Marshal(&model.WebhookPayload{
+		Event:      event,
+		FormID:     id,
+		OccurredAt: now,
+		Data: &model.WebhookFormData{
+			Title:    title,
+			ClosedAt: closedAt,
+		},
+	})
+	if err != nil {
+		return false, fmt.Errorf("form_repository set_closed_at failed to marshal webhook payload: %e", err)
+	}
+
+	webhookQuery, webhookArgs := webhookEventQuery(r.db.GetSchema(), id, event, payload, now)
+	if _, err = tx.Exec(ctx, webhookQuery, webhookArgs...); err != nil {
+		return false, fmt.Errorf("form_repository set_closed_at failed to queue webhook deliveries: %e", err)
+	}
+
+	return true, nil
+}
@@ -0,0 +1,328 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"go-form-hub/internal/model"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type textCount struct {
+	text  string
+	count int
+}
+
+// FormTextAnalytics collects statistics over answers to an input question. For any other question type
+// only the question itself is filled in, nil is returned if the question does not belong to the form.
+func (r *formDatabaseRepository) FormTextAnalytics(ctx context.Context, formID, questionID int64, params *model.TextAnalyticsParams) (analytics *model.TextAnalytics, err error) {
+	questionQuery := fmt.Sprintf(`SELECT q.id, q.title, q.type
+		FROM %s.question as q
+		WHERE q.id = $1 AND q.form_id = $2`, r.db.GetSchema())
+
+	answersFrom := fmt.Sprintf(`FROM %s.form_passage_answer as pa
+		JOIN %s.form_passage as fp ON fp.id = pa.form_passage_id
+		WHERE pa.question_id = $1 AND fp.form_id = $2 AND pa.answer_text <> ''`, r.db.GetSchema(), r.db.GetSchema())
+
+	lengthQuery := `SELECT COUNT(*),
+		COALESCE(MIN(char_length(pa.answer_text)), 0),
+		COALESCE(MAX(char_length(pa.answer_text)), 0),
+		COALESCE(AVG(char_length(pa.answer_text)), 0)::float8,
+		COALESCE(percentile_cont(0.5) WITHIN GROUP (ORDER BY char_length(pa.answer_text)), 0)::float8
+		` + answersFrom
+
+	pageQuery := `SELECT fp.id, pa.answer_text, fp.finished_at
+		` + answersFrom + `
+		ORDER BY fp.finished_at DESC, pa.id
+		LIMIT $3 OFFSET $4`
+
+	textQuery := `SELECT pa.answer_text, COUNT(*)
+		` + answersFrom + `
+		GROUP BY pa.answer_text
+		ORDER BY COUNT(*) DESC, pa.answer_text`
+
+	// similarity() is symmetric, so every pair of distinct texts is compared once. The pairs grow
+	// with the square of the texts, so only the most frequent ones are compared.
+	similarQuery := `WITH texts AS (SELECT pa.answer_text as text
+		` + answersFrom + `
+		GROUP BY pa.answer_text
+		ORDER BY COUNT(*) DESC, pa.answer_text
+		LIMIT $4)
+		SELECT a.text, b.text
+		FROM texts as a
+		JOIN texts as b ON a.text < b.text
+		WHERE similarity(a.text, b.text) >= $3`
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_text_analytics failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	analytics = &model.TextAnalytics{
+		FormID:  formID,
+		Page:    params.Page,
+		PerPage: params.PerPage,
+		Length:  &model.LengthStats{},
+	}
+
+	err = tx.QueryRow(ctx, questionQuery, questionID, formID).Scan(
+		&analytics.QuestionID,
+		&analytics.Title,
+		&analytics.Type,
+	)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("form_repository form_text_analytics failed to execute question query: %e", err)
+	}
+
+	if analytics.Type != model.InputAnswerType {
+		return analytics, nil
+	}
+
+	err = tx.QueryRow(ctx, lengthQuery, questionID, formID).Scan(
+		&analytics.TotalAnswers,
+		&analytics.Length.Min,
+		&analytics.Length.Max,
+		&analytics.Length.Mean,
+		&analytics.Length.Median,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_text_analytics failed to execute length query: %e", err)
+	}
+
+	pageRows, err := tx.Query(ctx, pageQuery, questionID, formID, params.PerPage, (params.Page-1)*params.PerPage)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_text_analytics failed to execute page query: %e", err)
+	}
+
+	analytics.Answers, err = r.textAnswersFromRows(pageRows)
+	if err != nil {
+		return nil, err
+	}
+
+	textRows, err := tx.Query(ctx, textQuery, questionID, formID)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_text_analytics failed to execute text query: %e", err)
+	}
+
+	texts, err := r.textCountsFromRows(textRows)
+	if err != nil {
+		return nil, err
+	}
+
+	similarRows, err := tx.Query(ctx, similarQuery, questionID, formID, params.Similarity, model.SimilarTextsMax)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_text_analytics failed to execute similar query: %e", err)
+	}
+
+	pairs, err := r.similarTextsFromRows(similarRows)
+	if err != nil {
+		return nil, err
+	}
+
+	analytics.TopWords, analytics.TopBigrams = topTerms(texts, params.Top)
+	analytics.Groups = groupSimilarTexts(texts, pairs)
+
+	return analytics, nil
+}
+
+func (r *formDatabaseRepository) textAnswersFromRows(rows pgx.Rows) ([]*model.TextAnswer, error) {
+	defer func() {
+		rows.Close()
+	}()
+
+	answers := make([]*model.TextAnswer, 0)
+
+	for rows.Next() {
+		answer := &model.TextAnswer{}
+		err := rows.Scan(
+			&answer.PassageID,
+			&answer.Text,
+			&answer.FinishedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("form_repository textAnswersFromRows failed to scan row: %v", err)
+		}
+		answers = append(answers, answer)
+	}
+
+	return answers, nil
+}
+
+func (r *formDatabaseRepository) textCountsFromRows(rows pgx.Rows) ([]*textCount, error) {
+	defer func() {
+		rows.Close()
+	}()
+
+	texts := make([]*textCount, 0)
+
+	for rows.Next() {
+		text := &textCount{}
+		err := rows.Scan(
+			&text.text,
+			&text.count,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("form_repository textCountsFromRows failed to scan row: %v", err)
+		}
+		texts = append(texts, text)
+	}
+
+	return texts, nil
+}
+
+func (r *formDatabaseRepository) similarTextsFromRows(rows pgx.Rows) ([][2]string, error) {
+	defer func() {
+		rows.Close()
+	}()
+
+	pairs := make([][2]string, 0)
+
+	for rows.Next() {
+		var pair [2]string
+		err := rows.Scan(
+			&pair[0],
+			&pair[1],
+		)
+		if err != nil {
+			return nil, fmt.Errorf("form_repository similarTextsFromRows failed to scan row: %v", err)
+		}
+		pairs = append(pairs, pair)
+	}
+
+	return pairs, nil
+}
+
+// textWords splits text into lower case words. Words shorter than two letters are dropped,
+// stop words are kept so that callers can tell which words were adjacent.
+func textWords(text string) []string {
+	text = strings.ReplaceAll(strings.ToLower(text), "ё", "е")
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	result := words[:0]
+	for _, word := range words {
+		if utf8.RuneCountInString(word) > 1 {
+			result = append(result, word)
+		}
+	}
+
+	return result
+}
+
+// topTerms counts words and bigrams of adjacent words, skipping stop words, and returns the top most frequent of each.
+func topTerms(texts []*textCount, top int) ([]*model.TermCount, []*model.TermCount) {
+	wordCounts := map[string]int{}
+	bigramCounts := map[string]int{}
+
+	for _, text := range texts {
+		words := textWords(text.text)
+		for i, word := range words {
+			if isStopWord(word) {
+				continue
+			}
+			wordCounts[word] += text.count
+
+			if i+1 < len(words) && !isStopWord(words[i+1]) {
+				bigramCounts[word+" "+words[i+1]] += text.count
+			}
+		}
+	}
+
+	return topTermCounts(wordCounts, top), topTermCounts(bigramCounts, top)
+}
+
+func topTermCounts(counts map[string]int, top int) []*model.TermCount {
+	terms := make([]*model.TermCount, 0, len(counts))
+	for term, count := range counts {
+		terms = append(terms, &model.TermCount{Term: term, Count: count})
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Count != terms[j].Count {
+			return terms[i].Count > terms[j].Count
+		}
+		return terms[i].Term < terms[j].Term
+	})
+
+	if len(terms) > top {
+		terms = terms[:top]
+	}
+
+	return terms
+}
+
+// groupSimilarTexts joins texts connected by a chain of similar pairs into groups.
+// Texts without a similar counterpart do not form a group.
+func groupSimilarTexts(texts []*textCount, pairs [][2]string) []*model.AnswerGroup {
+	parent := map[string]string{}
+
+	var find func(text string) string
+	find = func(text string) string {
+		if parent[text] == text {
+			return text
+		}
+		parent[text] = find(parent[text])
+		return parent[text]
+	}
+
+	for _, text := range texts {
+		parent[text.text] = text.text
+	}
+
+	for _, pair := range pairs {
+		if _, ok := parent[pair[0]]; !ok {
+			continue
+		}
+		if _, ok := parent[pair[1]]; !ok {
+			continue
+		}
+		parent[find(pair[0])] = find(pair[1])
+	}
+
+	groupByRoot := map[string]*model.AnswerGroup{}
+	groups := make([]*model.AnswerGroup, 0)
+
+	// texts are already ordered by count, so every group lists its most frequent text first
+	for _, text := range texts {
+		root := find(text.text)
+		group, ok := groupByRoot[root]
+		if !ok {
+			group = &model.AnswerGroup{}
+			groupByRoot[root] = group
+			groups = append(groups, group)
+		}
+		group.Texts = append(group.Texts, text.text)
+		group.Count += text.count
+	}
+
+	result := make([]*model.AnswerGroup, 0)
+	for _, group := range groups {
+		if len(group.Texts) > 1 {
+			result = append(result, group)
+		}
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Count > result[j].Count
+	})
+
+	return result
+}
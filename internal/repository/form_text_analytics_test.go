@@ -0,0 +1,116 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormRepositoryFormTextAnalytics(t *testing.T) {
+	t.Run("NoErrors", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewFormDatabaseRepository(connPool, builder)
+
+		formID, questionID := int64(1), int64(10)
+		finishedAt := time.Now().UTC()
+		params := &model.TextAnalyticsParams{Page: 2, PerPage: 2, Top: 2, Similarity: 0.5}
+
+		mock.ExpectBegin()
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT q.id, q.title, q.type FROM %s.question as q WHERE q.id = \$1 AND q.form_id = \$2$`, schema)).
+			WithArgs(questionID, formID).
+			WillReturnRows(mock.NewRows([]string{"q.id", "q.title", "q.type"}).
+				AddRow(questionID, "What do you like?", model.InputAnswerType))
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT COUNT\(\*\), .* FROM %s.form_passage_answer as pa .* WHERE pa.question_id = \$1 AND fp.form_id = \$2 AND pa.answer_text <> ''$`, schema)).
+			WithArgs(questionID, formID).
+			WillReturnRows(mock.NewRows([]string{"count", "min", "max", "avg", "median"}).
+				AddRow(5, 9, 24, 15.2, 14.0))
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT fp.id, pa.answer_text, fp.finished_at FROM %s.form_passage_answer as pa .* LIMIT \$3 OFFSET \$4$`, schema)).
+			WithArgs(questionID, formID, 2, 2).
+			WillReturnRows(mock.NewRows([]string{"fp.id", "pa.answer_text", "fp.finished_at"}).
+				AddRow(int64(102), "Fast delivery", finishedAt).
+				AddRow(int64(101), "Очень быстрая доставка", finishedAt))
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT pa.answer_text, COUNT\(\*\) FROM %s.form_passage_answer as pa .* GROUP BY pa.answer_text`, schema)).
+			WithArgs(questionID, formID).
+			WillReturnRows(mock.NewRows([]string{"pa.answer_text", "count"}).
+				AddRow("Fast delivery", 2).
+				AddRow("The fast delivery!", 1).
+				AddRow("Очень быстрая доставка", 1).
+				AddRow("Prices", 1))
+
+		mock.ExpectQuery(fmt.Sprintf(`^WITH texts AS \(SELECT pa.answer_text as text FROM %s.form_passage_answer as pa .* GROUP BY pa.answer_text ORDER BY COUNT\(\*\) DESC, pa.answer_text LIMIT \$4\) SELECT a.text, b.text .* WHERE similarity\(a.text, b.text\) >= \$3$`, schema)).
+			WithArgs(questionID, formID, 0.5, model.SimilarTextsMax).
+			WillReturnRows(mock.NewRows([]string{"a.text", "b.text"}).
+				AddRow("Fast delivery", "The fast delivery!"))
+
+		mock.ExpectCommit()
+
+		analytics, err := repo.FormTextAnalytics(context.Background(), formID, questionID, params)
+		if err != nil {
+			t.Logf("failed to analyse answers: %e", err)
+			t.FailNow()
+		}
+
+		assert.Equal(t, 5, analytics.TotalAnswers)
+		assert.Equal(t, &model.LengthStats{Min: 9, Max: 24, Mean: 15.2, Median: 14}, analytics.Length)
+		assert.Equal(t, 2, len(analytics.Answers))
+		assert.Equal(t, int64(102), analytics.Answers[0].PassageID)
+
+		assert.Equal(t, []*model.TermCount{{Term: "delivery", Count: 3}, {Term: "fast", Count: 3}}, analytics.TopWords)
+		assert.Equal(t, []*model.TermCount{{Term: "fast delivery", Count: 3}, {Term: "быстрая доставка", Count: 1}}, analytics.TopBigrams)
+
+		assert.Equal(t, 1, len(analytics.Groups))
+		assert.Equal(t, []string{"Fast delivery", "The fast delivery!"}, analytics.Groups[0].Texts)
+		assert.Equal(t, 3, analytics.Groups[0].Count)
+
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotInputQuestion", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewFormDatabaseRepository(connPool, builder)
+
+		mock.ExpectBegin()
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT q.id, q.title, q.type FROM %s.question as q`, schema)).
+			WithArgs(int64(10), int64(1)).
+			WillReturnRows(mock.NewRows([]string{"q.id", "q.title", "q.type"}).
+				AddRow(int64(10), "Color", model.SingleAnswerType))
+
+		mock.ExpectCommit()
+
+		analytics, err := repo.FormTextAnalytics(context.Background(), 1, 10, &model.TextAnalyticsParams{Page: 1, PerPage: 20, Top: 10, Similarity: 0.5})
+		assert.Nil(t, err)
+		assert.Equal(t, model.SingleAnswerType, analytics.Type)
+		assert.Nil(t, analytics.Answers)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-form-hub/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+type timelineCount struct {
+	start time.Time
+	count int
+}
+
+// FormTimeline groups passages of the form by the time they were finished. Buckets are truncated
+// to interval (hour, day or week) in the given IANA timezone, weeks start on Monday.
+func (r *formDatabaseRepository) FormTimeline(ctx context.Context, id int64, interval string, location *time.Location, filter *model.FormResultFilter) (timeline *model.FormTimeline, err error) {
+	formQuery, formArgs, err := r.builder.
+		Select("f.id").
+		From(fmt.Sprintf("%s.form as f", r.db.GetSchema())).
+		Where(squirrel.Eq{"f.id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_timeline failed to build form query: %e", err)
+	}
+
+	bucketQuery, bucketArgs, err := r.withPassageFilter(r.builder.
+		Select().
+		Column(squirrel.Expr("date_trunc(?, (fp.finished_at AT TIME ZONE 'UTC') AT TIME ZONE ?)", interval, location.String())).
+		Column("COUNT(*)").
+		From(fmt.Sprintf("%s.form_passage as fp", r.db.GetSchema())).
+		Where(squirrel.Eq{"fp.form_id": id}), filter).
+		GroupBy("1").
+		OrderBy("1").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_timeline failed to build bucket query: %e", err)
+	}
+
+	medianQuery, medianArgs, err := r.withPassageFilter(r.builder.
+		Select("percentile_cont(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM fp.finished_at - fp.started_at))").
+		From(fmt.Sprintf("%s.form_passage as fp", r.db.GetSchema())).
+		Where(squirrel.Eq{"fp.form_id": id}).
+		Where(squirrel.NotEq{"fp.started_at": nil}), filter).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_timeline failed to build median query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_timeline failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	var formID int64
+	err = tx.QueryRow(ctx, formQuery, formArgs...).Scan(&formID)
+	if err != nil {
+		if err == pgx.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("form_repository form_timeline failed to execute form query: %e", err)
+	}
+
+	bucketRows, err := tx.Query(ctx, bucketQuery, bucketArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_timeline failed to execute bucket query: %e", err)
+	}
+
+	counts, err := r.timelineCountsFromRows(bucketRows)
+	if err != nil {
+		return nil, err
+	}
+
+	var median *float64
+	err = tx.QueryRow(ctx, medianQuery, medianArgs...).Scan(&median)
+	if err != nil {
+		return nil, fmt.Errorf("form_repository form_timeline failed to execute median query: %e", err)
+	}
+
+	return buildTimeline(id, interval, location, counts, median), nil
+}
+
+func (r *formDatabaseRepository) timelineCountsFromRows(rows pgx.Rows) ([]*timelineCount, error) {
+	defer func() {
+		rows.Close()
+	}()
+
+	counts := make([]*timelineCount, 0)
+
+	for rows.Next() {
+		count := &timelineCount{}
+		err := rows.Scan(
+			&count.start,
+			&count.count,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("form_repository timelineCountsFromRows failed to scan row: %v", err)
+		}
+		counts = append(counts, count)
+	}
+
+	return counts, nil
+}
+
+// buildTimeline fills the gaps between counted buckets and adds cumulative totals. Bucket starts
+// come from the database as wall clock time of the location and are stepped in wall clock time too,
+// so a day stays a calendar day across DST changes.
+func buildTimeline(formID int64, interval string, location *time.Location, counts []*timelineCount, median *float64) *model.FormTimeline {
+	timeline := &model.FormTimeline{
+		FormID:                  formID,
+		Interval:                interval,
+		Timezone:                location.String(),
+		Buckets:                 make([]*model.TimelineBucket, 0, len(counts)),
+		MedianCompletionSeconds: median,
+	}
+
+	if len(counts) == 0 {
+		return timeline
+	}
+
+	next := func(start time.Time) time.Time {
+		switch interval {
+		case model.TimelineHour:
+			return start.Add(time.Hour)
+		case model.TimelineWeek:
+			return start.AddDate(0, 0, 7)
+		default:
+			return start.AddDate(0, 0, 1)
+		}
+	}
+
+	i := 0
+	for start := counts[0].start; !start.After(counts[len(counts)-1].start); start = next(start) {
+		bucket := &model.TimelineBucket{
+			Start: time.Date(start.Year(), start.Month(), start.Day(), start.Hour(), 0, 0, 0, location),
+		}
+
+		if i < len(counts) && counts[i].start.Equal(start) {
+			bucket.Count = counts[i].count
+			i++
+		}
+
+		timeline.Total += bucket.Count
+		bucket.Cumulative = timeline.Total
+		timeline.Buckets = append(timeline.Buckets, bucket)
+	}
+
+	return timeline
+}
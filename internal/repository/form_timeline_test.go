@@ -0,0 +1,147 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormRepositoryFormTimeline(t *testing.T) {
+	t.Run("NoErrors", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewFormDatabaseRepository(connPool, builder)
+
+		formID := int64(1)
+		location, err := time.LoadLocation("Europe/Moscow")
+		if err != nil {
+			t.Skipf("timezone database is not available: %v", err)
+		}
+		median := 95.5
+
+		mock.ExpectBegin()
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT f.id FROM %s.form as f WHERE f.id = \$1$`, schema)).
+			WithArgs(formID).
+			WillReturnRows(mock.NewRows([]string{"f.id"}).AddRow(formID))
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT date_trunc\(\$1, \(fp.finished_at AT TIME ZONE 'UTC'\) AT TIME ZONE \$2\), COUNT\(\*\) FROM %s.form_passage as fp WHERE fp.form_id = \$3 GROUP BY 1 ORDER BY 1$`, schema)).
+			WithArgs(model.TimelineDay, "Europe/Moscow", formID).
+			WillReturnRows(mock.NewRows([]string{"date_trunc", "count"}).
+				AddRow(time.Date(2023, 12, 1, 0, 0, 0, 0, time.UTC), 2).
+				AddRow(time.Date(2023, 12, 4, 0, 0, 0, 0, time.UTC), 3))
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT percentile_cont\(0.5\) .* FROM %s.form_passage as fp WHERE fp.form_id = \$1 AND fp.started_at IS NOT NULL$`, schema)).
+			WithArgs(formID).
+			WillReturnRows(mock.NewRows([]string{"percentile_cont"}).AddRow(&median))
+
+		mock.ExpectCommit()
+
+		timeline, err := repo.FormTimeline(context.Background(), formID, model.TimelineDay, location, nil)
+		if err != nil {
+			t.Logf("failed to build timeline: %e", err)
+			t.FailNow()
+		}
+
+		assert.Equal(t, "Europe/Moscow", timeline.Timezone)
+		assert.Equal(t, 5, timeline.Total)
+		assert.Equal(t, 95.5, *timeline.MedianCompletionSeconds)
+		assert.Equal(t, 4, len(timeline.Buckets))
+
+		assert.True(t, time.Date(2023, 12, 1, 0, 0, 0, 0, location).Equal(timeline.Buckets[0].Start))
+		assert.True(t, time.Date(2023, 12, 3, 0, 0, 0, 0, location).Equal(timeline.Buckets[2].Start))
+		assert.Equal(t, []int{2, 0, 0, 3}, []int{timeline.Buckets[0].Count, timeline.Buckets[1].Count, timeline.Buckets[2].Count, timeline.Buckets[3].Count})
+		assert.Equal(t, []int{2, 2, 2, 5}, []int{timeline.Buckets[0].Cumulative, timeline.Buckets[1].Cumulative, timeline.Buckets[2].Cumulative, timeline.Buckets[3].Cumulative})
+
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NoStartTimes", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewFormDatabaseRepository(connPool, builder)
+
+		formID := int64(1)
+
+		mock.ExpectBegin()
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT f.id FROM %s.form as f`, schema)).
+			WithArgs(formID).
+			WillReturnRows(mock.NewRows([]string{"f.id"}).AddRow(formID))
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT date_trunc.* FROM %s.form_passage as fp`, schema)).
+			WithArgs(model.TimelineHour, "UTC", formID).
+			WillReturnRows(mock.NewRows([]string{"date_trunc", "count"}).
+				AddRow(time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC), 1).
+				AddRow(time.Date(2023, 12, 1, 12, 0, 0, 0, time.UTC), 1))
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT percentile_cont.* FROM %s.form_passage as fp`, schema)).
+			WithArgs(formID).
+			WillReturnRows(mock.NewRows([]string{"percentile_cont"}).AddRow(nil))
+
+		mock.ExpectCommit()
+
+		timeline, err := repo.FormTimeline(context.Background(), formID, model.TimelineHour, time.UTC, nil)
+		if err != nil {
+			t.Logf("failed to build timeline: %e", err)
+			t.FailNow()
+		}
+
+		assert.Nil(t, timeline.MedianCompletionSeconds)
+		assert.Equal(t, 3, len(timeline.Buckets))
+		assert.Equal(t, 11, timeline.Buckets[1].Start.Hour())
+		assert.Equal(t, 2, timeline.Buckets[2].Cumulative)
+
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("FormNotFound", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewFormDatabaseRepository(connPool, builder)
+
+		mock.ExpectBegin()
+
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT f.id FROM %s.form as f`, schema)).
+			WithArgs(int64(1)).
+			WillReturnError(pgx.ErrNoRows)
+
+		mock.ExpectCommit()
+
+		timeline, err := repo.FormTimeline(context.Background(), 1, model.TimelineDay, time.UTC, nil)
+		assert.Nil(t, err)
+		assert.Nil(t, timeline)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
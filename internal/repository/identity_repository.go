@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-form-hub/internal/database"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// Identity links a user to an account at an OpenID Connect provider. Subject is the ID of the
+// account at the provider, it stays the same when the email there changes.
+type Identity struct {
+	ID        int64     `db:"id"`
+	UserID    int64     `db:"user_id"`
+	Provider  string    `db:"provider"`
+	Subject   string    `db:"subject"`
+	Email     string    `db:"email"`
+	CreatedAt time.Time `db:"created_at"`
+}
+
+type identityDatabaseRepository struct {
+	db      database.ConnPool
+	builder squirrel.StatementBuilderType
+}
+
+func NewIdentityDatabaseRepository(db database.ConnPool, builder squirrel.StatementBuilderType) IdentityRepository {
+	return &identityDatabaseRepository{
+		db:      db,
+		builder: builder,
+	}
+}
+
+func (r *identityDatabaseRepository) getTableName() string {
+	return fmt.Sprintf("%s.user_identity", r.db.GetSchema())
+}
+
+// FindUserID returns the ID of the user linked to the account at the provider, or 0.
+func (r *identityDatabaseRepository) FindUserID(ctx context.Context, provider, subject string) (userID int64, err error) {
+	query, args, err := r.builder.
+		Select("user_id").
+		From(r.getTableName()).
+		Where(squirrel.And{
+			squirrel.Eq{"provider": provider},
+			squirrel.Eq{"subject": subject},
+		}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("identity_repository find_user_id failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("identity_repository find_user_id failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	err = tx.QueryRow(ctx, query, args...).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = nil
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("identity_repository find_user_id failed to execute query: %e", err)
+	}
+
+	return userID, nil
+}
+
+func (r *identityDatabaseRepository) Insert(ctx context.Context, identity *Identity) (err error) {
+	query, args, err := r.builder.
+		Insert(r.getTableName()).
+		Columns("user_id", "provider", "subject", "email", "created_at").
+		Values(identity.UserID, identity.Provider, identity.Subject, identity.Email, identity.CreatedAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("identity_repository insert failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("identity_repository insert failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("identity_repository insert failed to execute query: %e", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/repository"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIdentityRepositoryFindUserID(t *testing.T) {
+	t.Run("NoErrors", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewIdentityDatabaseRepository(connPool, builder)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT user_id FROM %s.user_identity WHERE \(provider = \$1 AND subject = \$2\)$`, schema)).
+			WithArgs("google", "subject-1").
+			WillReturnRows(mock.NewRows([]string{"user_id"}).AddRow(int64(7)))
+		mock.ExpectCommit()
+
+		userID, err := repo.FindUserID(context.Background(), "google", "subject-1")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(7), userID)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotLinked", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewIdentityDatabaseRepository(connPool, builder)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT user_id FROM %s.user_identity WHERE .*$`, schema)).
+			WithArgs("google", "subject-1").
+			WillReturnRows(mock.NewRows([]string{"user_id"}))
+		mock.ExpectCommit()
+
+		userID, err := repo.FindUserID(context.Background(), "google", "subject-1")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), userID)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestIdentityRepositoryInsert(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewIdentityDatabaseRepository(connPool, builder)
+
+	identity := &repository.Identity{UserID: 7, Provider: "google", Subject: "subject-1", Email: "user@example.com", CreatedAt: time.Now().UTC()}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf(`^INSERT INTO %s.user_identity \(user_id,provider,subject,email,created_at\) VALUES \(\$1,\$2,\$3,\$4,\$5\)$`, schema)).
+		WithArgs(identity.UserID, identity.Provider, identity.Subject, identity.Email, identity.CreatedAt).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	assert.Nil(t, repo.Insert(context.Background(), identity))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
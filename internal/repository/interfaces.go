@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"go-form-hub/internal/model"
 
@@ -16,12 +17,21 @@ type FormRepository interface {
 	Update(ctx context.Context, id int64, form *model.FormUpdate) (*model.FormUpdate, error)
 	Delete(ctx context.Context, id int64) error
 	FormsSearch(ctx context.Context, title string, userID uint) (forms []*model.FormTitle, err error)
-	FormResults(ctx context.Context, id int64) (*model.FormResult, error)
+	FormResults(ctx context.Context, id int64, filter *model.FormResultFilter) (*model.FormResult, error)
+	FormCrossTab(ctx context.Context, id, rowQuestionID, columnQuestionID int64, filter *model.FormResultFilter) (*model.FormCrossTab, error)
+	FormTimeline(ctx context.Context, id int64, interval string, location *time.Location, filter *model.FormResultFilter) (*model.FormTimeline, error)
+	FormTextAnalytics(ctx context.Context, formID, questionID int64, params *model.TextAnalyticsParams) (*model.TextAnalytics, error)
 	FormResultsCsv(ctx context.Context, id int64) ([]byte, error)
 	FormResultsExel(ctx context.Context, id int64) ([]byte, error)
 	FormPassageSave(ctx context.Context, formPassage *model.FormPassage, userID uint64) error
+	FormClose(ctx context.Context, id int64, closedAt time.Time) (bool, error)
+	FormPublish(ctx context.Context, id int64) (bool, error)
+	RebuildResultAggregates(ctx context.Context) error
 	FormPassageCount(ctx context.Context, formID int64) (int64, error)
+	FormPassageReplayed(ctx context.Context, formID int64, idempotencyKey, idempotencyHash string) (bool, error)
+	FormPassagesImport(ctx context.Context, formID int64, passages []*model.FormPassage) error
 	UserFormPassageCount(ctx context.Context, formID int64, userID int64) (int64, error)
+	PurgeRespondentMarks(ctx context.Context, kind string, before time.Time) (int64, error)
 }
 
 type UserRepository interface {
@@ -31,14 +41,60 @@ type UserRepository interface {
 	FindByID(ctx context.Context, id int64) (*User, error)
 	Insert(ctx context.Context, user *User) (int64, error)
 	Update(ctx context.Context, id int64, user *User) error
+	UpdatePassword(ctx context.Context, id int64, password string) error
+	MarkEmailVerified(ctx context.Context, id int64, verifiedAt time.Time) error
 	Delete(ctx context.Context, id int64) error
 }
 
 type SessionRepository interface {
 	FindByID(ctx context.Context, sessionID string) (*Session, error)
-	FindByUserID(ctx context.Context, userID int64) (*Session, error)
+	FindByUserID(ctx context.Context, userID int64, seenAfter time.Time) ([]*Session, error)
 	Insert(ctx context.Context, session *Session) error
+	Touch(ctx context.Context, sessionID string, lastSeenAt time.Time) error
+	Rotate(ctx context.Context, sessionID, newSessionID string, now time.Time) error
+	Activate(ctx context.Context, sessionID, newSessionID string, now time.Time) (bool, error)
 	Delete(ctx context.Context, sessionID string) error
+	DeleteByUserID(ctx context.Context, userID int64, exceptSessionID string) (int64, error)
+}
+
+type EmailVerificationRepository interface {
+	Insert(ctx context.Context, token *EmailVerificationToken) error
+	Consume(ctx context.Context, tokenHash string, now time.Time) (*EmailVerificationToken, error)
+}
+
+type TwoFactorRepository interface {
+	FindByUserID(ctx context.Context, userID int64) (*TwoFactor, error)
+	Upsert(ctx context.Context, twoFactor *TwoFactor) error
+	Confirm(ctx context.Context, userID int64, confirmedAt time.Time, step int64, codeHashes []string) (bool, error)
+	UseStep(ctx context.Context, userID, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID int64, codeHash string, now time.Time) (bool, error)
+	Delete(ctx context.Context, userID int64) error
+}
+
+type IdentityRepository interface {
+	FindUserID(ctx context.Context, provider, subject string) (int64, error)
+	Insert(ctx context.Context, identity *Identity) error
+}
+
+// LoginAttemptRepository has the methods of ratelimit.Store.
+type LoginAttemptRepository interface {
+	Failures(ctx context.Context, key string) (int, time.Time, error)
+	Fail(ctx context.Context, key string, now, since time.Time) (int, time.Time, error)
+	Reset(ctx context.Context, key string) error
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+type APITokenRepository interface {
+	Insert(ctx context.Context, token *APIToken) error
+	FindByHash(ctx context.Context, tokenHash string) (*APIToken, error)
+	FindByUserID(ctx context.Context, userID int64) ([]*APIToken, error)
+	Touch(ctx context.Context, id int64, lastUsedAt time.Time) error
+	Delete(ctx context.Context, id, userID int64) (bool, error)
+}
+
+type PasswordResetRepository interface {
+	Insert(ctx context.Context, token *PasswordResetToken) error
+	Reset(ctx context.Context, tokenHash, passwordHash string, now time.Time) (int64, error)
 }
 
 type QuestionRepository interface {
@@ -54,3 +110,30 @@ type AnswerRepository interface {
 	Insert(ctx context.Context, questionID int64, answer *model.Answer) error
 	DeleteByQuestionID(ctx context.Context, questionID int64) error
 }
+
+type WebhookRepository interface {
+	Insert(ctx context.Context, webhook *model.Webhook) error
+	FindByID(ctx context.Context, id int64) (*model.Webhook, error)
+	FindAllByForm(ctx context.Context, formID int64) ([]*model.Webhook, error)
+	Delete(ctx context.Context, id int64) error
+	Deliveries(ctx context.Context, webhookID int64, limit uint64) ([]*model.WebhookDelivery, error)
+	InsertDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+	ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]*WebhookDispatch, error)
+	UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) error
+}
+
+type NotificationRepository interface {
+	FindPreference(ctx context.Context, userID int64) (*model.NotificationPreference, error)
+	UpsertPreference(ctx context.Context, userID int64, preference *model.NotificationPreference, now time.Time) error
+	ClaimDueRecipients(ctx context.Context, until time.Time, limit int) ([]*NotificationRecipient, error)
+	ReleaseRecipient(ctx context.Context, userID int64, since, until time.Time) error
+	FormDigests(ctx context.Context, authorID int64, since, until time.Time) ([]*model.FormDigest, error)
+}
+
+type RecipientRepository interface {
+	Save(ctx context.Context, formID int64, recipients []*model.FormRecipient) error
+	FindAllByForm(ctx context.Context, formID int64) ([]*model.FormRecipient, error)
+	FindByToken(ctx context.Context, formID int64, token string) (*model.FormRecipient, error)
+	Delete(ctx context.Context, formID, id int64) (bool, error)
+	Remind(ctx context.Context, formID int64, recipients []*model.FormRecipient, now time.Time) ([]*model.FormRecipient, error)
+}
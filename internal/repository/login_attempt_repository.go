@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-form-hub/internal/database"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// loginAttemptDatabaseRepository keeps the failed logins per key in the database, so that every
+// replica of the auth microservice sees them. It is a store of ratelimit.Backoff.
+type loginAttemptDatabaseRepository struct {
+	db      database.ConnPool
+	builder squirrel.StatementBuilderType
+}
+
+func NewLoginAttemptDatabaseRepository(db database.ConnPool, builder squirrel.StatementBuilderType) LoginAttemptRepository {
+	return &loginAttemptDatabaseRepository{
+		db:      db,
+		builder: builder,
+	}
+}
+
+func (r *loginAttemptDatabaseRepository) getTableName() string {
+	return fmt.Sprintf("%s.login_attempt", r.db.GetSchema())
+}
+
+// Failures returns how many failures the key has and when the last one happened.
+func (r *loginAttemptDatabaseRepository) Failures(ctx context.Context, key string) (failures int, last time.Time, err error) {
+	query, args, err := r.builder.
+		Select("failures", "last_failure_at").
+		From(r.getTableName()).
+		Where(squirrel.Eq{"key": key}).
+		ToSql()
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("login_attempt_repository failures failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("login_attempt_repository failures failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	err = tx.QueryRow(ctx, query, args...).Scan(&failures, &last)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = nil
+		return 0, time.Time{}, nil
+	}
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("login_attempt_repository failures failed to execute query: %e", err)
+	}
+
+	return failures, last, nil
+}
+
+// Fail records a failure of the key at now in one statement, so that concurrent failures on
+// different replicas all count, and returns how many failures the key has and when the one before
+// happened. The count starts over when the last failure happened before since.
+func (r *loginAttemptDatabaseRepository) Fail(ctx context.Context, key string, now, since time.Time) (failures int, previous time.Time, err error) {
+	query := fmt.Sprintf(`INSERT INTO %s.login_attempt as la
+	(key, failures, last_failure_at)
+	VALUES($1, 1, $2)
+	ON CONFLICT (key) DO UPDATE SET
+	failures = CASE WHEN la.last_failure_at < $3 THEN 1 ELSE la.failures + 1 END,
+	previous_failure_at = CASE WHEN la.last_failure_at < $3 THEN NULL ELSE la.last_failure_at END,
+	last_failure_at = $2
+	RETURNING failures, previous_failure_at`, r.db.GetSchema())
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, time.Time{}, fmt.Errorf("login_attempt_repository fail failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	var previousFailureAt *time.Time
+	if err = tx.QueryRow(ctx, query, key, now, since).Scan(&failures, &previousFailureAt); err != nil {
+		return 0, time.Time{}, fmt.Errorf("login_attempt_repository fail failed to execute query: %e", err)
+	}
+
+	if previousFailureAt != nil {
+		previous = *previousFailureAt
+	}
+
+	return failures, previous, nil
+}
+
+func (r *loginAttemptDatabaseRepository) Reset(ctx context.Context, key string) (err error) {
+	query, args, err := r.builder.
+		Delete(r.getTableName()).
+		Where(squirrel.Eq{"key": key}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("login_attempt_repository reset failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("login_attempt_repository reset failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("login_attempt_repository reset failed to execute query: %e", err)
+	}
+
+	return nil
+}
+
+// Purge deletes the keys whose last failure happened before before.
+func (r *loginAttemptDatabaseRepository) Purge(ctx context.Context, before time.Time) (deleted int64, err error) {
+	query, args, err := r.builder.
+		Delete(r.getTableName()).
+		Where(squirrel.Lt{"last_failure_at": before}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("login_attempt_repository purge failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("login_attempt_repository purge failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("login_attempt_repository purge failed to execute query: %e", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,122 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/repository"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoginAttemptRepositoryFailures(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewLoginAttemptDatabaseRepository(connPool, builder)
+
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(fmt.Sprintf(`^SELECT failures, last_failure_at FROM %s.login_attempt WHERE key = \$1$`, schema)).
+		WithArgs("account").
+		WillReturnRows(mock.NewRows([]string{"failures", "last_failure_at"}).AddRow(3, now))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectQuery(fmt.Sprintf(`^SELECT failures, last_failure_at FROM %s.login_attempt WHERE key = \$1$`, schema)).
+		WithArgs("unknown").
+		WillReturnRows(mock.NewRows([]string{"failures", "last_failure_at"}))
+	mock.ExpectCommit()
+
+	failures, last, err := repo.Failures(context.Background(), "account")
+	assert.Nil(t, err)
+	assert.Equal(t, 3, failures)
+	assert.Equal(t, now, last)
+
+	failures, _, err = repo.Failures(context.Background(), "unknown")
+	assert.Nil(t, err)
+	assert.Zero(t, failures)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginAttemptRepositoryFail(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewLoginAttemptDatabaseRepository(connPool, builder)
+
+	now := time.Now().UTC()
+	since := now.Add(-time.Hour)
+
+	previousFailureAt := now.Add(-time.Minute)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(fmt.Sprintf(`^INSERT INTO %s.login_attempt as la .* ON CONFLICT \(key\) DO UPDATE SET .* RETURNING failures, previous_failure_at$`, schema)).
+		WithArgs("account", now, since).
+		WillReturnRows(mock.NewRows([]string{"failures", "previous_failure_at"}).AddRow(4, &previousFailureAt))
+	mock.ExpectCommit()
+
+	failures, previous, err := repo.Fail(context.Background(), "account", now, since)
+	assert.Nil(t, err)
+	assert.Equal(t, 4, failures)
+	assert.Equal(t, previousFailureAt, previous)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(fmt.Sprintf(`^INSERT INTO %s.login_attempt as la .* RETURNING failures, previous_failure_at$`, schema)).
+		WithArgs("first", now, since).
+		WillReturnRows(mock.NewRows([]string{"failures", "previous_failure_at"}).AddRow(1, (*time.Time)(nil)))
+	mock.ExpectCommit()
+
+	failures, previous, err = repo.Fail(context.Background(), "first", now, since)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, failures)
+	assert.True(t, previous.IsZero())
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginAttemptRepositoryResetAndPurge(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewLoginAttemptDatabaseRepository(connPool, builder)
+
+	before := time.Now().UTC().Add(-time.Hour)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf(`^DELETE FROM %s.login_attempt WHERE key = \$1$`, schema)).
+		WithArgs("account").
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf(`^DELETE FROM %s.login_attempt WHERE last_failure_at < \$1$`, schema)).
+		WithArgs(before).
+		WillReturnResult(pgxmock.NewResult("DELETE", 5))
+	mock.ExpectCommit()
+
+	assert.Nil(t, repo.Reset(context.Background(), "account"))
+
+	deleted, err := repo.Purge(context.Background(), before)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(5), deleted)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
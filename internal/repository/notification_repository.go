@@ -0,0 +1,267 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// NotificationRecipient is an author claimed for a notification about the passages finished after Since.
+type NotificationRecipient struct {
+	UserID    int64
+	Mode      string
+	Email     string
+	FirstName string
+	Since     time.Time
+}
+
+type notificationDatabaseRepository struct {
+	db      database.ConnPool
+	builder squirrel.StatementBuilderType
+}
+
+func NewNotificationDatabaseRepository(db database.ConnPool, builder squirrel.StatementBuilderType) NotificationRepository {
+	return &notificationDatabaseRepository{
+		db:      db,
+		builder: builder,
+	}
+}
+
+// FindPreference returns nil if the user has never set notification preferences.
+func (r *notificationDatabaseRepository) FindPreference(ctx context.Context, userID int64) (preference *model.NotificationPreference, err error) {
+	query, args, err := r.builder.
+		Select("np.mode").
+		From(fmt.Sprintf("%s.notification_preference as np", r.db.GetSchema())).
+		Where(squirrel.Eq{"np.user_id": userID}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("notification_repository find_preference failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("notification_repository find_preference failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	preference = &model.NotificationPreference{}
+	err = tx.QueryRow(ctx, query, args...).Scan(&preference.Mode)
+	if err == pgx.ErrNoRows {
+		err = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("notification_repository find_preference failed to execute query: %e", err)
+	}
+
+	return preference, nil
+}
+
+// UpsertPreference saves the preference. Switching the mode restarts the notification period at now,
+// so that enabling notifications does not report earlier passages.
+func (r *notificationDatabaseRepository) UpsertPreference(ctx context.Context, userID int64, preference *model.NotificationPreference, now time.Time) (err error) {
+	query := fmt.Sprintf(`INSERT INTO %s.notification_preference as np
+	(user_id, mode, notified_until)
+	VALUES($1, $2, $3)
+	ON CONFLICT (user_id) DO UPDATE SET mode = $2, notified_until = $3
+	WHERE np.mode <> $2`, r.db.GetSchema())
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("notification_repository upsert_preference failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, query, userID, preference.Mode, now); err != nil {
+		return fmt.Errorf("notification_repository upsert_preference failed to execute query: %e", err)
+	}
+
+	return nil
+}
+
+// ClaimDueRecipients picks authors to notify about passages finished up to until and moves their
+// period forward to it. Authors with instant notifications are due as soon as one of their forms
+// has a new passage, authors with daily digests once a day has passed since the previous digest.
+// Authors locked by another instance are skipped.
+func (r *notificationDatabaseRepository) ClaimDueRecipients(ctx context.Context, until time.Time, limit int) (recipients []*NotificationRecipient, err error) {
+	schema := r.db.GetSchema()
+	query := fmt.Sprintf(`WITH due AS (
+		SELECT np.user_id, np.notified_until
+		FROM %s.notification_preference as np
+		WHERE np.notified_until < $1 AND (
+			(np.mode = $2 AND EXISTS (
+				SELECT 1 FROM %s.form_passage as fp
+				JOIN %s.form as f ON f.id = fp.form_id
+				WHERE f.author_id = np.user_id AND fp.finished_at > np.notified_until AND fp.finished_at <= $1
+			))
+			OR (np.mode = $3 AND np.notified_until <= $4)
+		)
+		ORDER BY np.notified_until
+		LIMIT $5
+		FOR UPDATE SKIP LOCKED
+	)
+	UPDATE %s.notification_preference as np
+	SET notified_until = $1
+	FROM due, %s.user as u
+	WHERE np.user_id = due.user_id AND u.id = np.user_id
+	RETURNING np.user_id, np.mode, u.email, u.first_name, due.notified_until`, schema, schema, schema, schema, schema)
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("notification_repository claim_due_recipients failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	rows, err := tx.Query(ctx, query, until, model.NotificationModeInstant, model.NotificationModeDaily, until.Add(-24*time.Hour), limit)
+	if err != nil {
+		return nil, fmt.Errorf("notification_repository claim_due_recipients failed to execute query: %e", err)
+	}
+
+	defer func() {
+		rows.Close()
+	}()
+
+	recipients = make([]*NotificationRecipient, 0)
+	for rows.Next() {
+		recipient := &NotificationRecipient{}
+		err = rows.Scan(
+			&recipient.UserID,
+			&recipient.Mode,
+			&recipient.Email,
+			&recipient.FirstName,
+			&recipient.Since,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("notification_repository claim_due_recipients failed to scan row: %e", err)
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, nil
+}
+
+// ReleaseRecipient moves the period of a recipient back to since after a failed notification,
+// so that the passages are reported next time. A newer period set meanwhile is kept.
+func (r *notificationDatabaseRepository) ReleaseRecipient(ctx context.Context, userID int64, since, until time.Time) (err error) {
+	query, args, err := r.builder.
+		Update(fmt.Sprintf("%s.notification_preference", r.db.GetSchema())).
+		Set("notified_until", since).
+		Where(squirrel.Eq{"user_id": userID, "notified_until": until}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("notification_repository release_recipient failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("notification_repository release_recipient failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("notification_repository release_recipient failed to execute query: %e", err)
+	}
+
+	return nil
+}
+
+// FormDigests counts passages finished within (since, until] for every form of the author that got any.
+func (r *notificationDatabaseRepository) FormDigests(ctx context.Context, authorID int64, since, until time.Time) (digests []*model.FormDigest, err error) {
+	query, args, err := r.builder.
+		Select("f.id", "f.title", "COUNT(fp.id)").
+		From(fmt.Sprintf("%s.form as f", r.db.GetSchema())).
+		Join(fmt.Sprintf("%s.form_passage as fp ON fp.form_id = f.id", r.db.GetSchema())).
+		Where(squirrel.And{
+			squirrel.Eq{"f.author_id": authorID},
+			squirrel.Gt{"fp.finished_at": since},
+			squirrel.LtOrEq{"fp.finished_at": until},
+		}).
+		GroupBy("f.id", "f.title").
+		OrderBy("COUNT(fp.id) DESC", "f.id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("notification_repository form_digests failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("notification_repository form_digests failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("notification_repository form_digests failed to execute query: %e", err)
+	}
+
+	return r.formDigestsFromRows(rows)
+}
+
+func (r *notificationDatabaseRepository) formDigestsFromRows(rows pgx.Rows) ([]*model.FormDigest, error) {
+	defer func() {
+		rows.Close()
+	}()
+
+	digests := make([]*model.FormDigest, 0)
+
+	for rows.Next() {
+		digest := &model.FormDigest{}
+		err := rows.Scan(
+			&digest.FormID,
+			&digest.Title,
+			&digest.NewPassages,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("notification_repository formDigestsFromRows failed to scan row: %v", err)
+		}
+		digests = append(digests, digest)
+	}
+
+	return digests, nil
+}
@@ -0,0 +1,86 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNotificationRepositoryClaimDueRecipients(t *testing.T) {
+	t.Run("NoErrors", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewNotificationDatabaseRepository(connPool, builder)
+
+		until := time.Now().UTC()
+		since := until.Add(-time.Hour)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^WITH due AS \( SELECT np.user_id, np.notified_until FROM %s.notification_preference as np .* FOR UPDATE SKIP LOCKED \) UPDATE %s.notification_preference as np SET notified_until = \$1`, schema, schema)).
+			WithArgs(until, model.NotificationModeInstant, model.NotificationModeDaily, until.Add(-24*time.Hour), 50).
+			WillReturnRows(mock.NewRows([]string{"np.user_id", "np.mode", "u.email", "u.first_name", "due.notified_until"}).
+				AddRow(int64(1), model.NotificationModeInstant, "author@example.com", "Anna", since))
+		mock.ExpectCommit()
+
+		recipients, err := repo.ClaimDueRecipients(context.Background(), until, 50)
+		if err != nil {
+			t.Logf("failed to claim recipients: %e", err)
+			t.FailNow()
+		}
+
+		assert.Equal(t, []*repository.NotificationRecipient{
+			{UserID: 1, Mode: model.NotificationModeInstant, Email: "author@example.com", FirstName: "Anna", Since: since},
+		}, recipients)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestNotificationRepositoryFormDigests(t *testing.T) {
+	t.Run("NoErrors", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewNotificationDatabaseRepository(connPool, builder)
+
+		until := time.Now().UTC()
+		since := until.Add(-24 * time.Hour)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT f.id, f.title, COUNT\(fp.id\) FROM %s.form as f JOIN %s.form_passage as fp ON fp.form_id = f.id WHERE \(f.author_id = \$1 AND fp.finished_at > \$2 AND fp.finished_at <= \$3\) GROUP BY f.id, f.title`, schema, schema)).
+			WithArgs(int64(1), since, until).
+			WillReturnRows(mock.NewRows([]string{"f.id", "f.title", "count"}).
+				AddRow(int64(10), "Survey", 3).
+				AddRow(int64(11), "Poll", 1))
+		mock.ExpectCommit()
+
+		digests, err := repo.FormDigests(context.Background(), 1, since, until)
+		assert.Nil(t, err)
+		assert.Equal(t, []*model.FormDigest{
+			{FormID: 10, Title: "Survey", NewPassages: 3},
+			{FormID: 11, Title: "Poll", NewPassages: 1},
+		}, digests)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-form-hub/internal/database"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// PasswordResetToken is a link to reset the password of a user. Only the hash of the token in the
+// link is stored.
+type PasswordResetToken struct {
+	ID        int64      `db:"id"`
+	UserID    int64      `db:"user_id"`
+	TokenHash string     `db:"token_hash"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+}
+
+type passwordResetDatabaseRepository struct {
+	db      database.ConnPool
+	builder squirrel.StatementBuilderType
+}
+
+func NewPasswordResetDatabaseRepository(db database.ConnPool, builder squirrel.StatementBuilderType) PasswordResetRepository {
+	return &passwordResetDatabaseRepository{
+		db:      db,
+		builder: builder,
+	}
+}
+
+func (r *passwordResetDatabaseRepository) getTableName() string {
+	return fmt.Sprintf("%s.password_reset_token", r.db.GetSchema())
+}
+
+func (r *passwordResetDatabaseRepository) Insert(ctx context.Context, token *PasswordResetToken) error {
+	query, args, err := r.builder.
+		Insert(r.getTableName()).
+		Columns("user_id", "token_hash", "created_at", "expires_at").
+		Values(token.UserID, token.TokenHash, token.CreatedAt, token.ExpiresAt).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("password_reset_repository insert failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("password_reset_repository insert failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("password_reset_repository insert failed to execute query: %e", err)
+	}
+
+	return nil
+}
+
+// Reset uses up the token if it is unused and not expired at now, sets the password of its user
+// to passwordHash and ends every session of the user, all in one transaction, so a failure leaves
+// the link working. It returns the ID of the user, or 0 if the token cannot be used. The other
+// unused tokens of the user are used up with it, so a reset makes every earlier link stop working.
+func (r *passwordResetDatabaseRepository) Reset(ctx context.Context, tokenHash, passwordHash string, now time.Time) (userID int64, err error) {
+	query, args, err := r.builder.
+		Update(r.getTableName()).
+		Set("used_at", now).
+		Where(squirrel.And{
+			squirrel.Eq{"token_hash": tokenHash},
+			squirrel.Eq{"used_at": nil},
+			squirrel.Gt{"expires_at": now},
+		}).
+		Suffix("RETURNING user_id").
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("password_reset_repository reset failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("password_reset_repository reset failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	err = tx.QueryRow(ctx, query, args...).Scan(&userID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = nil
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("password_reset_repository reset failed to execute query: %e", err)
+	}
+
+	query, args, err = r.builder.
+		Update(r.getTableName()).
+		Set("used_at", now).
+		Where(squirrel.And{
+			squirrel.Eq{"user_id": userID},
+			squirrel.Eq{"used_at": nil},
+		}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("password_reset_repository reset failed to build query: %e", err)
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("password_reset_repository reset failed to execute query: %e", err)
+	}
+
+	query, args, err = r.builder.
+		Update(fmt.Sprintf("%s.user", r.db.GetSchema())).
+		Set("password", passwordHash).
+		Where(squirrel.Eq{"id": userID}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("password_reset_repository reset failed to build query: %e", err)
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("password_reset_repository reset failed to execute query: %e", err)
+	}
+
+	query, args, err = r.builder.
+		Delete(fmt.Sprintf("%s.session", r.db.GetSchema())).
+		Where(squirrel.Eq{"user_id": userID}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("password_reset_repository reset failed to build query: %e", err)
+	}
+
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("password_reset_repository reset failed to execute query: %e", err)
+	}
+
+	return userID, nil
+}
@@ -0,0 +1,169 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/repository"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPasswordResetRepositoryInsert(t *testing.T) {
+	t.Run("NoErrors", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewPasswordResetDatabaseRepository(connPool, builder)
+
+		now := time.Now().UTC()
+		token := &repository.PasswordResetToken{UserID: 1, TokenHash: "hash", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(fmt.Sprintf(`^INSERT INTO %s.password_reset_token \(user_id,token_hash,created_at,expires_at\) VALUES \(\$1,\$2,\$3,\$4\)$`, schema)).
+			WithArgs(token.UserID, token.TokenHash, token.CreatedAt, token.ExpiresAt).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		assert.Nil(t, repo.Insert(context.Background(), token))
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("PasswordNotUpdated", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewPasswordResetDatabaseRepository(connPool, builder)
+
+		now := time.Now().UTC()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^UPDATE %s.password_reset_token SET used_at = \$1 WHERE .* RETURNING user_id$`, schema)).
+			WithArgs(now, "hash", now).
+			WillReturnRows(mock.NewRows([]string{"user_id"}).AddRow(int64(7)))
+		mock.ExpectExec(fmt.Sprintf(`^UPDATE %s.password_reset_token SET used_at = \$1 WHERE \(user_id = \$2 AND used_at IS NULL\)$`, schema)).
+			WithArgs(now, int64(7)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec(fmt.Sprintf(`^UPDATE %s.user SET password = \$1 WHERE id = \$2$`, schema)).
+			WithArgs("password hash", int64(7)).
+			WillReturnError(fmt.Errorf("connection lost"))
+		mock.ExpectRollback()
+
+		userID, err := repo.Reset(context.Background(), "hash", "password hash", now)
+		assert.NotNil(t, err)
+		assert.Equal(t, int64(0), userID)
+		assert.Nil(t, mock.ExpectationsWereMet(), "the token must stay unused when the password is not set")
+	})
+}
+
+func TestPasswordResetRepositoryReset(t *testing.T) {
+	t.Run("NoErrors", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewPasswordResetDatabaseRepository(connPool, builder)
+
+		now := time.Now().UTC()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^UPDATE %s.password_reset_token SET used_at = \$1 WHERE \(token_hash = \$2 AND used_at IS NULL AND expires_at > \$3\) RETURNING user_id$`, schema)).
+			WithArgs(now, "hash", now).
+			WillReturnRows(mock.NewRows([]string{"user_id"}).AddRow(int64(7)))
+		mock.ExpectExec(fmt.Sprintf(`^UPDATE %s.password_reset_token SET used_at = \$1 WHERE \(user_id = \$2 AND used_at IS NULL\)$`, schema)).
+			WithArgs(now, int64(7)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec(fmt.Sprintf(`^UPDATE %s.user SET password = \$1 WHERE id = \$2$`, schema)).
+			WithArgs("password hash", int64(7)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec(fmt.Sprintf(`^DELETE FROM %s.session WHERE user_id = \$1$`, schema)).
+			WithArgs(int64(7)).
+			WillReturnResult(pgxmock.NewResult("DELETE", 2))
+		mock.ExpectCommit()
+
+		userID, err := repo.Reset(context.Background(), "hash", "password hash", now)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(7), userID)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("UsedOrExpired", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewPasswordResetDatabaseRepository(connPool, builder)
+
+		now := time.Now().UTC()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^UPDATE %s.password_reset_token SET used_at = \$1 WHERE .* RETURNING user_id$`, schema)).
+			WithArgs(now, "hash", now).
+			WillReturnRows(mock.NewRows([]string{"user_id"}))
+		mock.ExpectCommit()
+
+		userID, err := repo.Reset(context.Background(), "hash", "password hash", now)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(0), userID)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("PasswordNotUpdated", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewPasswordResetDatabaseRepository(connPool, builder)
+
+		now := time.Now().UTC()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^UPDATE %s.password_reset_token SET used_at = \$1 WHERE .* RETURNING user_id$`, schema)).
+			WithArgs(now, "hash", now).
+			WillReturnRows(mock.NewRows([]string{"user_id"}).AddRow(int64(7)))
+		mock.ExpectExec(fmt.Sprintf(`^UPDATE %s.password_reset_token SET used_at = \$1 WHERE \(user_id = \$2 AND used_at IS NULL\)$`, schema)).
+			WithArgs(now, int64(7)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec(fmt.Sprintf(`^UPDATE %s.user SET password = \$1 WHERE id = \$2$`, schema)).
+			WithArgs("password hash", int64(7)).
+			WillReturnError(fmt.Errorf("connection lost"))
+		mock.ExpectRollback()
+
+		userID, err := repo.Reset(context.Background(), "hash", "password hash", now)
+		assert.NotNil(t, err)
+		assert.Equal(t, int64(0), userID)
+		assert.Nil(t, mock.ExpectationsWereMet(), "the token must stay unused when the password is not set")
+	})
+}
@@ -0,0 +1,265 @@
+package repository
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+var ErrRecipientUsedUp = errors.New("the invitation has already been used")
+
+var (
+	selectFieldsRecipient = []string{
+		"r.id",
+		"r.form_id",
+		"r.email",
+		"r.name",
+		"r.max_uses",
+		"r.uses",
+		"r.invited_at",
+		"r.reminded_at",
+		"r.responded_at",
+	}
+)
+
+type recipientDatabaseRepository struct {
+	db      database.ConnPool
+	builder squirrel.StatementBuilderType
+}
+
+func NewRecipientDatabaseRepository(db database.ConnPool, builder squirrel.StatementBuilderType) RecipientRepository {
+	return &recipientDatabaseRepository{
+		db:      db,
+		builder: builder,
+	}
+}
+
+// hashRecipientToken is what the database keeps instead of an access token.
+func hashRecipientToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// consumeRecipientQuery counts a passage against the invitation. It affects no rows once the
+// invitation is used up, and it locks the row, so concurrent passages cannot exceed max_uses.
+func consumeRecipientQuery(schema string, recipientID int64, now time.Time) (string, []interface{}) {
+	query := fmt.Sprintf(`UPDATE %s.form_recipient
+	SET uses = uses + 1, responded_at = $2
+	WHERE id = $1 AND uses < max_uses`, schema)
+
+	return query, []interface{}{recipientID, now}
+}
+
+// Save adds recipients to the form with the hashes of their tokens. A recipient whose email is
+// already on the list is invited again: the token, name and limit are replaced, the uses are kept.
+func (r *recipientDatabaseRepository) Save(ctx context.Context, formID int64, recipients []*model.FormRecipient) (err error) {
+	query := fmt.Sprintf(`INSERT INTO %s.form_recipient as r
+	(form_id, email, name, token_hash, max_uses, invited_at)
+	VALUES($1, $2, $3, $4, $5, $6)
+	ON CONFLICT (form_id, email) DO UPDATE
+	SET name = EXCLUDED.name, token_hash = EXCLUDED.token_hash, max_uses = EXCLUDED.max_uses, invited_at = EXCLUDED.invited_at
+	RETURNING r.id, r.uses, r.reminded_at, r.responded_at`, r.db.GetSchema())
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("recipient_repository save failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	for _, recipient := range recipients {
+		recipient.FormID = formID
+		err = tx.QueryRow(ctx, query, formID, recipient.Email, recipient.Name, hashRecipientToken(recipient.Token),
+			recipient.MaxUses, recipient.InvitedAt).
+			Scan(&recipient.ID, &recipient.Uses, &recipient.RemindedAt, &recipient.RespondedAt)
+		if err != nil {
+			return fmt.Errorf("recipient_repository save failed to execute query: %e", err)
+		}
+	}
+
+	return nil
+}
+
+func (r *recipientDatabaseRepository) FindAllByForm(ctx context.Context, formID int64) (recipients []*model.FormRecipient, err error) {
+	query, args, err := r.builder.
+		Select(selectFieldsRecipient...).
+		From(fmt.Sprintf("%s.form_recipient as r", r.db.GetSchema())).
+		Where(squirrel.Eq{"r.form_id": formID}).
+		OrderBy("r.id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("recipient_repository find_all_by_form failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("recipient_repository find_all_by_form failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("recipient_repository find_all_by_form failed to execute query: %e", err)
+	}
+
+	return r.recipientsFromRows(rows)
+}
+
+// FindByToken returns the recipient of the form the token was issued to, or nil for an unknown token.
+func (r *recipientDatabaseRepository) FindByToken(ctx context.Context, formID int64, token string) (recipient *model.FormRecipient, err error) {
+	query, args, err := r.builder.
+		Select(selectFieldsRecipient...).
+		From(fmt.Sprintf("%s.form_recipient as r", r.db.GetSchema())).
+		Where(squirrel.Eq{"r.form_id": formID, "r.token_hash": hashRecipientToken(token)}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("recipient_repository find_by_token failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("recipient_repository find_by_token failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("recipient_repository find_by_token failed to execute query: %e", err)
+	}
+
+	recipients, err := r.recipientsFromRows(rows)
+	if err != nil || len(recipients) == 0 {
+		return nil, err
+	}
+
+	return recipients[0], nil
+}
+
+func (r *recipientDatabaseRepository) Delete(ctx context.Context, formID, id int64) (deleted bool, err error) {
+	query, args, err := r.builder.
+		Delete(fmt.Sprintf("%s.form_recipient", r.db.GetSchema())).
+		Where(squirrel.Eq{"id": id, "form_id": formID}).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("recipient_repository delete failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("recipient_repository delete failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("recipient_repository delete failed to execute query: %e", err)
+	}
+
+	return tag.RowsAffected() > 0, nil
+}
+
+// Remind replaces the tokens of the given recipients who have not responded yet and marks them
+// reminded. It returns the recipients that were updated.
+func (r *recipientDatabaseRepository) Remind(ctx context.Context, formID int64, recipients []*model.FormRecipient, now time.Time) (reminded []*model.FormRecipient, err error) {
+	query := fmt.Sprintf(`UPDATE %s.form_recipient
+	SET token_hash = $1, reminded_at = $2
+	WHERE id = $3 AND form_id = $4 AND uses = 0`, r.db.GetSchema())
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("recipient_repository remind failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	reminded = make([]*model.FormRecipient, 0, len(recipients))
+	for _, recipient := range recipients {
+		tag, err := tx.Exec(ctx, query, hashRecipientToken(recipient.Token), now, *recipient.ID, formID)
+		if err != nil {
+			return nil, fmt.Errorf("recipient_repository remind failed to execute query: %e", err)
+		}
+
+		if tag.RowsAffected() > 0 {
+			recipient.RemindedAt = &now
+			reminded = append(reminded, recipient)
+		}
+	}
+
+	return reminded, nil
+}
+
+func (r *recipientDatabaseRepository) recipientsFromRows(rows pgx.Rows) ([]*model.FormRecipient, error) {
+	defer func() {
+		rows.Close()
+	}()
+
+	recipients := make([]*model.FormRecipient, 0)
+
+	for rows.Next() {
+		recipient := &model.FormRecipient{}
+		err := rows.Scan(
+			&recipient.ID,
+			&recipient.FormID,
+			&recipient.Email,
+			&recipient.Name,
+			&recipient.MaxUses,
+			&recipient.Uses,
+			&recipient.InvitedAt,
+			&recipient.RemindedAt,
+			&recipient.RespondedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("recipient_repository recipientsFromRows failed to scan row: %v", err)
+		}
+		recipients = append(recipients, recipient)
+	}
+
+	return recipients, nil
+}
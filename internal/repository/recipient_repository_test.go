@@ -0,0 +1,121 @@
+package repository_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+var recipientColumns = []string{"r.id", "r.form_id", "r.email", "r.name", "r.max_uses", "r.uses", "r.invited_at", "r.reminded_at", "r.responded_at"}
+
+func tokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestRecipientRepositoryFindByToken(t *testing.T) {
+	t.Run("Found", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewRecipientDatabaseRepository(connPool, builder)
+
+		invitedAt := time.Now().UTC()
+		id := int64(5)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT .* FROM %s.form_recipient as r WHERE r.form_id = \$1 AND r.token_hash = \$2$`, schema)).
+			WithArgs(int64(1), tokenHash("secret-token")).
+			WillReturnRows(mock.NewRows(recipientColumns).
+				AddRow(&id, int64(1), "ann@example.com", "Ann", 2, 1, invitedAt, nil, &invitedAt))
+		mock.ExpectCommit()
+
+		recipient, err := repo.FindByToken(context.Background(), 1, "secret-token")
+		assert.Nil(t, err)
+		assert.Equal(t, int64(5), *recipient.ID)
+		assert.Equal(t, "ann@example.com", recipient.Email)
+		assert.Equal(t, 2, recipient.MaxUses)
+		assert.True(t, recipient.Responded())
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewRecipientDatabaseRepository(connPool, builder)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT .* FROM %s.form_recipient as r WHERE r.form_id = \$1 AND r.token_hash = \$2$`, schema)).
+			WithArgs(int64(1), tokenHash("forged")).
+			WillReturnRows(mock.NewRows(recipientColumns))
+		mock.ExpectCommit()
+
+		recipient, err := repo.FindByToken(context.Background(), 1, "forged")
+		assert.Nil(t, err)
+		assert.Nil(t, recipient)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestRecipientRepositoryRemind(t *testing.T) {
+	t.Run("SkipsResponded", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewRecipientDatabaseRepository(connPool, builder)
+
+		now := time.Now().UTC()
+		first, second := int64(1), int64(2)
+		recipients := []*model.FormRecipient{
+			{ID: &first, Token: "first-token"},
+			{ID: &second, Token: "second-token"},
+		}
+
+		mock.ExpectBegin()
+		mock.ExpectExec(fmt.Sprintf(`^UPDATE %s.form_recipient SET token_hash = \$1, reminded_at = \$2 WHERE id = \$3 AND form_id = \$4 AND uses = 0$`, schema)).
+			WithArgs(tokenHash("first-token"), now, first, int64(7)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		// the second recipient has responded in the meantime
+		mock.ExpectExec(fmt.Sprintf(`^UPDATE %s.form_recipient SET token_hash = \$1, reminded_at = \$2 WHERE id = \$3 AND form_id = \$4 AND uses = 0$`, schema)).
+			WithArgs(tokenHash("second-token"), now, second, int64(7)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectCommit()
+
+		reminded, err := repo.Remind(context.Background(), 7, recipients, now)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, len(reminded))
+		assert.Equal(t, first, *reminded[0].ID)
+		assert.Equal(t, now, *reminded[0].RemindedAt)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-form-hub/internal/model"
+
+	"github.com/Masterminds/squirrel"
+)
+
+var ErrPassageLimitReached = errors.New("the respondent has reached passage_max of the form")
+
+// countRespondentMarkQuery counts a passage against the mark. It returns no row once the mark
+// has passageMax passages since mark.ForgetBefore, and the row lock it takes keeps concurrent
+// passages of the same respondent from exceeding the limit.
+func countRespondentMarkQuery(schema string, formID int64, mark *model.RespondentMark, passageMax int, now time.Time) (string, []interface{}) {
+	query := fmt.Sprintf(`INSERT INTO %s.respondent_mark as m
+	(form_id, kind, key_hash, passages, last_passage_at)
+	VALUES($1, $2, $3, 1, $4)
+	ON CONFLICT (form_id, kind, key_hash) DO UPDATE
+	SET passages = CASE WHEN m.last_passage_at < $5 THEN 1 ELSE m.passages + 1 END, last_passage_at = $4
+	WHERE m.last_passage_at < $5 OR m.passages < $6
+	RETURNING m.passages`, schema)
+
+	return query, []interface{}{formID, mark.Kind, mark.KeyHash, now, mark.ForgetBefore, passageMax}
+}
+
+// PurgeRespondentMarks deletes the marks of the kind without passages since before.
+func (r *formDatabaseRepository) PurgeRespondentMarks(ctx context.Context, kind string, before time.Time) (deleted int64, err error) {
+	query, args, err := r.builder.
+		Delete(fmt.Sprintf("%s.respondent_mark", r.db.GetSchema())).
+		Where(squirrel.Eq{"kind": kind}).
+		Where(squirrel.Lt{"last_passage_at": before}).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("form_repository purge_respondent_marks failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("form_repository purge_respondent_marks failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("form_repository purge_respondent_marks failed to execute query: %e", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,89 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormRepositoryFormPassageSaveRespondentMarks(t *testing.T) {
+	t.Run("LimitReached", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewFormDatabaseRepository(connPool, builder)
+
+		formID := int64(2)
+		forgetBefore := time.Now().UTC().Add(-time.Hour)
+		passage := &model.FormPassage{
+			FormID: &formID,
+			RespondentMarks: []*model.RespondentMark{
+				{Kind: model.RespondentMarkCookie, KeyHash: "cookie-hash"},
+				{Kind: model.RespondentMarkFingerprint, KeyHash: "fingerprint-hash", ForgetBefore: forgetBefore},
+			},
+			PassageMax: 1,
+		}
+
+		markQuery := fmt.Sprintf(`^INSERT INTO %s.respondent_mark as m .* ON CONFLICT \(form_id, kind, key_hash\) DO UPDATE `+
+			`SET passages = CASE WHEN m.last_passage_at < \$5 THEN 1 ELSE m.passages \+ 1 END, last_passage_at = \$4 `+
+			`WHERE m.last_passage_at < \$5 OR m.passages < \$6 RETURNING m.passages$`, schema)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^INSERT INTO %s.form_passage .* RETURNING id$`, schema)).
+			WithArgs((*int64)(nil), &formID, passage.StartedAt, (*string)(nil), (*int64)(nil), (*string)(nil)).
+			WillReturnRows(mock.NewRows([]string{"id"}).AddRow(int64(7)))
+		mock.ExpectQuery(markQuery).
+			WithArgs(formID, model.RespondentMarkCookie, "cookie-hash", pgxmock.AnyArg(), time.Time{}, 1).
+			WillReturnRows(mock.NewRows([]string{"passages"}).AddRow(1))
+		// the same client already passed the form with another cookie
+		mock.ExpectQuery(markQuery).
+			WithArgs(formID, model.RespondentMarkFingerprint, "fingerprint-hash", pgxmock.AnyArg(), forgetBefore, 1).
+			WillReturnRows(mock.NewRows([]string{"passages"}))
+		mock.ExpectRollback()
+
+		err = repo.FormPassageSave(context.Background(), passage, model.AnonUserID)
+		assert.ErrorIs(t, err, repository.ErrPassageLimitReached)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestFormRepositoryPurgeRespondentMarks(t *testing.T) {
+	t.Parallel()
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewFormDatabaseRepository(connPool, builder)
+
+	before := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf(`^DELETE FROM %s.respondent_mark WHERE kind = \$1 AND last_passage_at < \$2$`, schema)).
+		WithArgs(model.RespondentMarkFingerprint, before).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+	mock.ExpectCommit()
+
+	deleted, err := repo.PurgeRespondentMarks(context.Background(), model.RespondentMarkFingerprint, before)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(3), deleted)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
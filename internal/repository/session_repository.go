@@ -11,12 +11,20 @@ import (
 	"github.com/jackc/pgx/v5"
 )
 
+// Session is a login of a user. SessionID holds the hash of the ID in the cookie, not the ID itself.
+// A pending session waits for the second factor of the login and does not authenticate anything.
 type Session struct {
-	SessionID string    `db:"id"`
-	UserID    int64     `db:"user_id"`
-	CreatedAt time.Time `db:"created_at"`
+	SessionID  string    `db:"id"`
+	UserID     int64     `db:"user_id"`
+	CreatedAt  time.Time `db:"created_at"`
+	LastSeenAt time.Time `db:"last_seen_at"`
+	UserAgent  string    `db:"user_agent"`
+	IP         string    `db:"ip"`
+	Pending    bool      `db:"pending"`
 }
 
+var sessionColumns = []string{"id", "user_id", "created_at", "last_seen_at", "user_agent", "ip", "pending"}
+
 type sessionRepository struct {
 	db      database.ConnPool
 	builder squirrel.StatementBuilderType
@@ -35,7 +43,7 @@ func (r *sessionRepository) getTableName() string {
 
 func (r *sessionRepository) FindByID(ctx context.Context, sessionID string) (session *Session, err error) {
 	query, args, err := r.builder.
-		Select("id", "user_id", "created_at").
+		Select(sessionColumns...).
 		From(r.getTableName()).
 		Where(squirrel.Eq{"id": sessionID}).
 		ToSql()
@@ -63,12 +71,18 @@ func (r *sessionRepository) FindByID(ctx context.Context, sessionID string) (ses
 	return session, err
 }
 
-func (r *sessionRepository) FindByUserID(ctx context.Context, userID int64) (session *Session, err error) {
+// FindByUserID returns the sessions of the user seen after seenAfter, the most recently used first.
+// Pending sessions are left out.
+func (r *sessionRepository) FindByUserID(ctx context.Context, userID int64, seenAfter time.Time) (sessions []*Session, err error) {
 	query, args, err := r.builder.
-		Select("id", "user_id", "created_at").
+		Select(sessionColumns...).
 		From(r.getTableName()).
-		Where(squirrel.Eq{"user_id": userID}).
-		Limit(1).
+		Where(squirrel.And{
+			squirrel.Eq{"user_id": userID},
+			squirrel.Gt{"last_seen_at": seenAfter},
+			squirrel.Eq{"pending": false},
+		}).
+		OrderBy("last_seen_at DESC").
 		ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("session_repository find_by_user_id failed to build query: %e", err)
@@ -88,17 +102,20 @@ func (r *sessionRepository) FindByUserID(ctx context.Context, userID int64) (ses
 		}
 	}()
 
-	row := tx.QueryRow(ctx, query, args...)
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("session_repository find_by_user_id failed to execute query: %e", err)
+	}
 
-	session, err = r.fromRow(row)
-	return session, err
+	sessions, err = r.fromRows(rows)
+	return sessions, err
 }
 
 func (r *sessionRepository) Insert(ctx context.Context, session *Session) error {
 	query, args, err := r.builder.
 		Insert(r.getTableName()).
-		Columns("id", "user_id", "created_at").
-		Values(session.SessionID, session.UserID, session.CreatedAt).
+		Columns(sessionColumns...).
+		Values(session.SessionID, session.UserID, session.CreatedAt, session.LastSeenAt, session.UserAgent, session.IP, session.Pending).
 		ToSql()
 	if err != nil {
 		return fmt.Errorf("session_repository insert failed to build query: %e", err)
@@ -122,6 +139,106 @@ func (r *sessionRepository) Insert(ctx context.Context, session *Session) error
 	return err
 }
 
+// Touch extends the session by moving its last activity to lastSeenAt.
+func (r *sessionRepository) Touch(ctx context.Context, sessionID string, lastSeenAt time.Time) error {
+	query, args, err := r.builder.
+		Update(r.getTableName()).
+		Set("last_seen_at", lastSeenAt).
+		Where(squirrel.Eq{"id": sessionID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("session_repository touch failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("session_repository touch failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	_, err = tx.Exec(ctx, query, args...)
+	return err
+}
+
+// Rotate replaces the ID of the session, so the old one can no longer be used, and restarts it at now.
+func (r *sessionRepository) Rotate(ctx context.Context, sessionID, newSessionID string, now time.Time) error {
+	query, args, err := r.builder.
+		Update(r.getTableName()).
+		Set("id", newSessionID).
+		Set("created_at", now).
+		Set("last_seen_at", now).
+		Where(squirrel.Eq{"id": sessionID}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("session_repository rotate failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("session_repository rotate failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	_, err = tx.Exec(ctx, query, args...)
+	return err
+}
+
+// Activate turns the pending session into a regular one under a new ID, and reports whether the
+// session was pending, so that only one request can complete a login.
+func (r *sessionRepository) Activate(ctx context.Context, sessionID, newSessionID string, now time.Time) (activated bool, err error) {
+	query, args, err := r.builder.
+		Update(r.getTableName()).
+		Set("id", newSessionID).
+		Set("pending", false).
+		Set("created_at", now).
+		Set("last_seen_at", now).
+		Where(squirrel.And{
+			squirrel.Eq{"id": sessionID},
+			squirrel.Eq{"pending": true},
+		}).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("session_repository activate failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("session_repository activate failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("session_repository activate failed to execute query: %e", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
 func (r *sessionRepository) Delete(ctx context.Context, sessionID string) error {
 	query, args, err := r.builder.
 		Delete(r.getTableName()).
@@ -149,12 +266,73 @@ func (r *sessionRepository) Delete(ctx context.Context, sessionID string) error
 	return err
 }
 
+// DeleteByUserID ends the sessions of the user, except the session with the ID exceptSessionID
+// when it is not empty, and returns how many were ended.
+func (r *sessionRepository) DeleteByUserID(ctx context.Context, userID int64, exceptSessionID string) (deleted int64, err error) {
+	where := squirrel.And{squirrel.Eq{"user_id": userID}}
+	if exceptSessionID != "" {
+		where = append(where, squirrel.NotEq{"id": exceptSessionID})
+	}
+
+	query, args, err := r.builder.
+		Delete(r.getTableName()).
+		Where(where).
+		ToSql()
+	if err != nil {
+		return 0, fmt.Errorf("session_repository delete_by_user_id failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("session_repository delete_by_user_id failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("session_repository delete_by_user_id failed to execute query: %e", err)
+	}
+
+	return tag.RowsAffected(), nil
+}
+
+func (r *sessionRepository) fromRows(rows pgx.Rows) ([]*Session, error) {
+	defer func() {
+		rows.Close()
+	}()
+
+	sessions := []*Session{}
+
+	for rows.Next() {
+		session, err := r.fromRow(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
+
 func (r *sessionRepository) fromRow(row pgx.Row) (*Session, error) {
 	session := &Session{}
 	err := row.Scan(
 		&session.SessionID,
 		&session.UserID,
 		&session.CreatedAt,
+		&session.LastSeenAt,
+		&session.UserAgent,
+		&session.IP,
+		&session.Pending,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
@@ -30,8 +30,8 @@ func TestSessionRepositoryFindByID(t *testing.T) {
 		mock.ExpectBegin()
 
 		id1 := "this-is-uuid"
-		rows := mock.NewRows([]string{"id", "user_id", "created_at"}).
-			AddRow(id1, int64(1), time.Now().UTC())
+		rows := mock.NewRows([]string{"id", "user_id", "created_at", "last_seen_at", "user_agent", "ip", "pending"}).
+			AddRow(id1, int64(1), time.Now().UTC(), time.Now().UTC(), "curl/8.0", "127.0.0.1", false)
 		mock.ExpectQuery(fmt.Sprintf(`^SELECT .* FROM %s.session WHERE id = \$1$`, schema)).
 			WithArgs(id1).
 			WillReturnRows(rows)
@@ -64,21 +64,27 @@ func TestSessionRepositoryFindByUserID(t *testing.T) {
 		mock.ExpectBegin()
 
 		id := int64(1)
-		rows := mock.NewRows([]string{"id", "user_id", "created_at"}).
-			AddRow("this-is-uuid", id, time.Now().UTC())
-		mock.ExpectQuery(fmt.Sprintf(`^SELECT .* FROM %s.session WHERE user_id = \$1 LIMIT 1$`, schema)).
-			WithArgs(id).
+		seenAfter := time.Now().UTC().Add(-time.Hour)
+		rows := mock.NewRows([]string{"id", "user_id", "created_at", "last_seen_at", "user_agent", "ip", "pending"}).
+			AddRow("hash-1", id, time.Now().UTC(), time.Now().UTC(), "curl/8.0", "127.0.0.1", false).
+			AddRow("hash-2", id, time.Now().UTC(), seenAfter.Add(time.Minute), "Firefox", "10.0.0.2", false)
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT .* FROM %s.session WHERE \(user_id = \$1 AND last_seen_at > \$2 AND pending = \$3\) ORDER BY last_seen_at DESC$`, schema)).
+			WithArgs(id, seenAfter, false).
 			WillReturnRows(rows)
 
 		mock.ExpectCommit()
 
-		session, err := repo.FindByUserID(context.Background(), int64(1))
+		sessions, err := repo.FindByUserID(context.Background(), id, seenAfter)
 		if err != nil {
 			t.Logf("failed to find_by_user_id form: %e", err)
 			t.FailNow()
 		}
 
-		assert.Equal(t, id, session.UserID)
+		if assert.Len(t, sessions, 2) {
+			assert.Equal(t, "hash-1", sessions[0].SessionID)
+			assert.Equal(t, "Firefox", sessions[1].UserAgent)
+			assert.Equal(t, "10.0.0.2", sessions[1].IP)
+		}
 	})
 }
 
@@ -98,13 +104,16 @@ func TestSessionRepositoryInsert(t *testing.T) {
 		mock.ExpectBegin()
 
 		session := &repository.Session{
-			SessionID: "uuid",
-			UserID:    int64(1),
-			CreatedAt: time.Now().UTC(),
+			SessionID:  "uuid",
+			UserID:     int64(1),
+			CreatedAt:  time.Now().UTC(),
+			LastSeenAt: time.Now().UTC(),
+			UserAgent:  "curl/8.0",
+			IP:         "127.0.0.1",
 		}
 
 		mock.ExpectExec(fmt.Sprintf(`^INSERT INTO %s.session (.*) VALUES (.*)$`, schema)).
-			WithArgs(session.SessionID, session.UserID, session.CreatedAt).
+			WithArgs(session.SessionID, session.UserID, session.CreatedAt, session.LastSeenAt, session.UserAgent, session.IP, session.Pending).
 			WillReturnResult(pgxmock.NewResult("INSERT", 1))
 
 		mock.ExpectCommit()
@@ -117,6 +126,88 @@ func TestSessionRepositoryInsert(t *testing.T) {
 	})
 }
 
+func TestSessionRepositoryTouch(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewSessionDatabaseRepository(connPool, builder)
+
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf(`^UPDATE %s.session SET last_seen_at = \$1 WHERE id = \$2$`, schema)).
+		WithArgs(now, "hash").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	err = repo.Touch(context.Background(), "hash", now)
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionRepositoryRotate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewSessionDatabaseRepository(connPool, builder)
+
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf(`^UPDATE %s.session SET id = \$1, created_at = \$2, last_seen_at = \$3 WHERE id = \$4$`, schema)).
+		WithArgs("new-hash", now, now, "old-hash").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	err = repo.Rotate(context.Background(), "old-hash", "new-hash", now)
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionRepositoryActivate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewSessionDatabaseRepository(connPool, builder)
+
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf(`^UPDATE %s.session SET id = \$1, pending = \$2, created_at = \$3, last_seen_at = \$4 WHERE \(id = \$5 AND pending = \$6\)$`, schema)).
+		WithArgs("new-hash", false, now, now, "old-hash", true).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf(`^UPDATE %s.session SET id = \$1, pending = \$2, created_at = \$3, last_seen_at = \$4 WHERE \(id = \$5 AND pending = \$6\)$`, schema)).
+		WithArgs("new-hash", false, now, now, "old-hash", true).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectCommit()
+
+	activated, err := repo.Activate(context.Background(), "old-hash", "new-hash", now)
+	assert.Nil(t, err)
+	assert.True(t, activated)
+
+	activated, err = repo.Activate(context.Background(), "old-hash", "new-hash", now)
+	assert.Nil(t, err)
+	assert.False(t, activated, "a session that is not pending is not activated")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
 func TestSessionRepositoryDelete(t *testing.T) {
 	t.Run("NoErrors", func(t *testing.T) {
 		t.Parallel()
@@ -146,3 +237,47 @@ func TestSessionRepositoryDelete(t *testing.T) {
 		}
 	})
 }
+
+func TestSessionRepositoryDeleteByUserID(t *testing.T) {
+	tests := []struct {
+		name   string
+		except string
+		query  string
+		args   []any
+	}{
+		{name: "All", query: `^DELETE FROM %s.session WHERE \(user_id = \$1\)$`, args: []any{int64(1)}},
+		{
+			name:   "ExceptCurrent",
+			except: "current",
+			query:  `^DELETE FROM %s.session WHERE \(user_id = \$1 AND id <> \$2\)$`,
+			args:   []any{int64(1), "current"},
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+			mock, err := pgxmock.NewPool()
+			if err != nil {
+				t.Logf("failed to create mock: %e", err)
+				t.FailNow()
+			}
+
+			schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+			connPool := database.NewConnPool(mock, schema)
+			repo := repository.NewSessionDatabaseRepository(connPool, builder)
+
+			mock.ExpectBegin()
+			mock.ExpectExec(fmt.Sprintf(test.query, schema)).
+				WithArgs(test.args...).
+				WillReturnResult(pgxmock.NewResult("DELETE", 3))
+			mock.ExpectCommit()
+
+			deleted, err := repo.DeleteByUserID(context.Background(), 1, test.except)
+			assert.Nil(t, err)
+			assert.Equal(t, int64(3), deleted)
+			assert.Nil(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package repository
+
+import "strings"
+
+// stopWords holds English and Russian words too common to say anything about an answer.
+// Russian words are written with "е" instead of "ё", the same way textWords normalizes them.
+var stopWords = func() map[string]struct{} {
+	words := map[string]struct{}{}
+	for _, list := range []string{englishStopWords, russianStopWords} {
+		for _, word := range strings.Fields(list) {
+			words[word] = struct{}{}
+		}
+	}
+	return words
+}()
+
+func isStopWord(word string) bool {
+	_, ok := stopWords[word]
+	return ok
+}
+
+const englishStopWords = `
+about above after again against all am an and any are aren as at be because been before being below
+between both but by can cannot could couldn did didn do does doesn doing don down during each few for
+from further had hadn has hasn have haven having he her here hers herself him himself his how if in
+into is isn it its itself just let ll me more most mustn my myself no nor not now of off on once only
+or other ought our ours ourselves out over own re same shan she should shouldn so some such than that
+the their theirs them themselves then there these they this those through to too under until up ve
+very was wasn we were weren what when where which while who whom why will with won would wouldn you
+your yours yourself yourselves
+`
+
+const russianStopWords = `
+без более больше будем будет будете будешь буду будут будто бы был была были было быть вам вас вдруг
+ведь во вот впрочем все всегда всего всех всю вы где да даже два для до другой его ее ей ему если есть
+еще же за зачем здесь из или им иногда их к как какая какой когда конечно кто куда ли лучше между меня
+мне много может можно мой моя мы на над надо наконец нас не него нее ней нельзя нет ни нибудь никогда
+ним них ничего но ну об один он она они оно опять от перед по под после потом потому почти при про
+раз разве с сам свою себе себя сейчас со совсем так такой там тебя тем теперь то тогда того тоже
+только том тот три тут ты уж уже хорошо хоть чего чем через что чтоб чтобы чуть эти этого этой этом
+этот эту я
+`
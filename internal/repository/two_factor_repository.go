@@ -0,0 +1,294 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-form-hub/internal/database"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// TwoFactor is the TOTP secret of a user. It protects the login only once it is confirmed with a
+// code, so that a user who never finished setting up the authenticator app is not locked out.
+// LastUsedStep is the period of the last accepted code, a code is never accepted twice.
+type TwoFactor struct {
+	UserID       int64      `db:"user_id"`
+	Secret       string     `db:"secret"`
+	CreatedAt    time.Time  `db:"created_at"`
+	ConfirmedAt  *time.Time `db:"confirmed_at"`
+	LastUsedStep int64      `db:"last_used_step"`
+}
+
+type twoFactorDatabaseRepository struct {
+	db      database.ConnPool
+	builder squirrel.StatementBuilderType
+}
+
+func NewTwoFactorDatabaseRepository(db database.ConnPool, builder squirrel.StatementBuilderType) TwoFactorRepository {
+	return &twoFactorDatabaseRepository{
+		db:      db,
+		builder: builder,
+	}
+}
+
+func (r *twoFactorDatabaseRepository) getTableName() string {
+	return fmt.Sprintf("%s.two_factor", r.db.GetSchema())
+}
+
+func (r *twoFactorDatabaseRepository) getRecoveryCodeTableName() string {
+	return fmt.Sprintf("%s.recovery_code", r.db.GetSchema())
+}
+
+func (r *twoFactorDatabaseRepository) FindByUserID(ctx context.Context, userID int64) (twoFactor *TwoFactor, err error) {
+	query, args, err := r.builder.
+		Select("user_id", "secret", "created_at", "confirmed_at", "last_used_step").
+		From(r.getTableName()).
+		Where(squirrel.Eq{"user_id": userID}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("two_factor_repository find_by_user_id failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("two_factor_repository find_by_user_id failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	twoFactor = &TwoFactor{}
+	err = tx.QueryRow(ctx, query, args...).
+		Scan(&twoFactor.UserID, &twoFactor.Secret, &twoFactor.CreatedAt, &twoFactor.ConfirmedAt, &twoFactor.LastUsedStep)
+	if errors.Is(err, pgx.ErrNoRows) {
+		err = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("two_factor_repository find_by_user_id failed to execute query: %e", err)
+	}
+
+	return twoFactor, nil
+}
+
+// Upsert saves a new unconfirmed secret of the user. A confirmed secret is kept, it has to be
+// deleted before the user can enroll again.
+func (r *twoFactorDatabaseRepository) Upsert(ctx context.Context, twoFactor *TwoFactor) (err error) {
+	query := fmt.Sprintf(`INSERT INTO %s.two_factor as tf
+	(user_id, secret, created_at)
+	VALUES($1, $2, $3)
+	ON CONFLICT (user_id) DO UPDATE SET secret = $2, created_at = $3, confirmed_at = NULL, last_used_step = 0
+	WHERE tf.confirmed_at IS NULL`, r.db.GetSchema())
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("two_factor_repository upsert failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, query, twoFactor.UserID, twoFactor.Secret, twoFactor.CreatedAt); err != nil {
+		return fmt.Errorf("two_factor_repository upsert failed to execute query: %e", err)
+	}
+
+	return nil
+}
+
+// Confirm turns on the second factor of the user with the code of the step that proved the secret,
+// and replaces the recovery codes of the user with the hashes. It reports whether the secret was
+// still unconfirmed.
+func (r *twoFactorDatabaseRepository) Confirm(ctx context.Context, userID int64, confirmedAt time.Time, step int64, codeHashes []string) (confirmed bool, err error) {
+	query, args, err := r.builder.
+		Update(r.getTableName()).
+		Set("confirmed_at", confirmedAt).
+		Set("last_used_step", step).
+		Where(squirrel.And{
+			squirrel.Eq{"user_id": userID},
+			squirrel.Eq{"confirmed_at": nil},
+		}).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("two_factor_repository confirm failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("two_factor_repository confirm failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("two_factor_repository confirm failed to execute query: %e", err)
+	}
+
+	if tag.RowsAffected() == 0 {
+		return false, nil
+	}
+
+	query, args, err = r.builder.
+		Delete(r.getRecoveryCodeTableName()).
+		Where(squirrel.Eq{"user_id": userID}).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("two_factor_repository confirm failed to build query: %e", err)
+	}
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return false, fmt.Errorf("two_factor_repository confirm failed to execute query: %e", err)
+	}
+
+	insert := r.builder.
+		Insert(r.getRecoveryCodeTableName()).
+		Columns("user_id", "code_hash")
+	for _, codeHash := range codeHashes {
+		insert = insert.Values(userID, codeHash)
+	}
+
+	query, args, err = insert.ToSql()
+	if err != nil {
+		return false, fmt.Errorf("two_factor_repository confirm failed to build query: %e", err)
+	}
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return false, fmt.Errorf("two_factor_repository confirm failed to execute query: %e", err)
+	}
+
+	return true, nil
+}
+
+// UseStep records that the code of the step was accepted, and reports false if a code of the same
+// or a later step was accepted before, so that a code cannot be replayed.
+func (r *twoFactorDatabaseRepository) UseStep(ctx context.Context, userID, step int64) (used bool, err error) {
+	query, args, err := r.builder.
+		Update(r.getTableName()).
+		Set("last_used_step", step).
+		Where(squirrel.And{
+			squirrel.Eq{"user_id": userID},
+			squirrel.NotEq{"confirmed_at": nil},
+			squirrel.Lt{"last_used_step": step},
+		}).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("two_factor_repository use_step failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("two_factor_repository use_step failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("two_factor_repository use_step failed to execute query: %e", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// UseRecoveryCode uses up the recovery code of the user, and reports false if the user has no such
+// unused code.
+func (r *twoFactorDatabaseRepository) UseRecoveryCode(ctx context.Context, userID int64, codeHash string, now time.Time) (used bool, err error) {
+	query, args, err := r.builder.
+		Update(r.getRecoveryCodeTableName()).
+		Set("used_at", now).
+		Where(squirrel.And{
+			squirrel.Eq{"user_id": userID},
+			squirrel.Eq{"code_hash": codeHash},
+			squirrel.Eq{"used_at": nil},
+		}).
+		ToSql()
+	if err != nil {
+		return false, fmt.Errorf("two_factor_repository use_recovery_code failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return false, fmt.Errorf("two_factor_repository use_recovery_code failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	tag, err := tx.Exec(ctx, query, args...)
+	if err != nil {
+		return false, fmt.Errorf("two_factor_repository use_recovery_code failed to execute query: %e", err)
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// Delete turns off the second factor of the user, with the recovery codes.
+func (r *twoFactorDatabaseRepository) Delete(ctx context.Context, userID int64) (err error) {
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("two_factor_repository delete failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	for _, table := range []string{r.getRecoveryCodeTableName(), r.getTableName()} {
+		var query string
+		var args []interface{}
+		query, args, err = r.builder.
+			Delete(table).
+			Where(squirrel.Eq{"user_id": userID}).
+			ToSql()
+		if err != nil {
+			return fmt.Errorf("two_factor_repository delete failed to build query: %e", err)
+		}
+
+		if _, err = tx.Exec(ctx, query, args...); err != nil {
+			return fmt.Errorf("two_factor_repository delete failed to execute query: %e", err)
+		}
+	}
+
+	return nil
+}
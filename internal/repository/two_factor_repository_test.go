@@ -0,0 +1,239 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/repository"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTwoFactorRepositoryFindByUserID(t *testing.T) {
+	t.Run("NoErrors", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewTwoFactorDatabaseRepository(connPool, builder)
+
+		now := time.Now().UTC()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT user_id, secret, created_at, confirmed_at, last_used_step FROM %s.two_factor WHERE user_id = \$1$`, schema)).
+			WithArgs(int64(7)).
+			WillReturnRows(mock.NewRows([]string{"user_id", "secret", "created_at", "confirmed_at", "last_used_step"}).
+				AddRow(int64(7), "SECRET", now, &now, int64(42)))
+		mock.ExpectCommit()
+
+		twoFactor, err := repo.FindByUserID(context.Background(), 7)
+		assert.Nil(t, err)
+		if assert.NotNil(t, twoFactor) {
+			assert.Equal(t, "SECRET", twoFactor.Secret)
+			assert.Equal(t, int64(42), twoFactor.LastUsedStep)
+			assert.NotNil(t, twoFactor.ConfirmedAt)
+		}
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("NotEnrolled", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewTwoFactorDatabaseRepository(connPool, builder)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^SELECT .* FROM %s.two_factor WHERE user_id = \$1$`, schema)).
+			WithArgs(int64(7)).
+			WillReturnRows(mock.NewRows([]string{"user_id", "secret", "created_at", "confirmed_at", "last_used_step"}))
+		mock.ExpectCommit()
+
+		twoFactor, err := repo.FindByUserID(context.Background(), 7)
+		assert.Nil(t, err)
+		assert.Nil(t, twoFactor)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTwoFactorRepositoryUpsert(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewTwoFactorDatabaseRepository(connPool, builder)
+
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf(`^INSERT INTO %s.two_factor as tf .* ON CONFLICT \(user_id\) DO UPDATE .* WHERE tf.confirmed_at IS NULL$`, schema)).
+		WithArgs(int64(7), "SECRET", now).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+	mock.ExpectCommit()
+
+	err = repo.Upsert(context.Background(), &repository.TwoFactor{UserID: 7, Secret: "SECRET", CreatedAt: now})
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorRepositoryConfirm(t *testing.T) {
+	t.Run("NoErrors", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewTwoFactorDatabaseRepository(connPool, builder)
+
+		now := time.Now().UTC()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(fmt.Sprintf(`^UPDATE %s.two_factor SET confirmed_at = \$1, last_used_step = \$2 WHERE \(user_id = \$3 AND confirmed_at IS NULL\)$`, schema)).
+			WithArgs(now, int64(42), int64(7)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+		mock.ExpectExec(fmt.Sprintf(`^DELETE FROM %s.recovery_code WHERE user_id = \$1$`, schema)).
+			WithArgs(int64(7)).
+			WillReturnResult(pgxmock.NewResult("DELETE", 0))
+		mock.ExpectExec(fmt.Sprintf(`^INSERT INTO %s.recovery_code \(user_id,code_hash\) VALUES \(\$1,\$2\),\(\$3,\$4\)$`, schema)).
+			WithArgs(int64(7), "hash-1", int64(7), "hash-2").
+			WillReturnResult(pgxmock.NewResult("INSERT", 2))
+		mock.ExpectCommit()
+
+		confirmed, err := repo.Confirm(context.Background(), 7, now, 42, []string{"hash-1", "hash-2"})
+		assert.Nil(t, err)
+		assert.True(t, confirmed)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AlreadyConfirmed", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewTwoFactorDatabaseRepository(connPool, builder)
+
+		now := time.Now().UTC()
+
+		mock.ExpectBegin()
+		mock.ExpectExec(fmt.Sprintf(`^UPDATE %s.two_factor SET .*$`, schema)).
+			WithArgs(now, int64(42), int64(7)).
+			WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+		mock.ExpectCommit()
+
+		confirmed, err := repo.Confirm(context.Background(), 7, now, 42, []string{"hash-1"})
+		assert.Nil(t, err)
+		assert.False(t, confirmed)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTwoFactorRepositoryUseStep(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewTwoFactorDatabaseRepository(connPool, builder)
+
+	query := fmt.Sprintf(`^UPDATE %s.two_factor SET last_used_step = \$1 WHERE \(user_id = \$2 AND confirmed_at IS NOT NULL AND last_used_step < \$3\)$`, schema)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(query).
+		WithArgs(int64(42), int64(7), int64(42)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+	mock.ExpectBegin()
+	mock.ExpectExec(query).
+		WithArgs(int64(42), int64(7), int64(42)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+	mock.ExpectCommit()
+
+	used, err := repo.UseStep(context.Background(), 7, 42)
+	assert.Nil(t, err)
+	assert.True(t, used)
+
+	used, err = repo.UseStep(context.Background(), 7, 42)
+	assert.Nil(t, err)
+	assert.False(t, used, "a step is used once")
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorRepositoryUseRecoveryCode(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewTwoFactorDatabaseRepository(connPool, builder)
+
+	now := time.Now().UTC()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf(`^UPDATE %s.recovery_code SET used_at = \$1 WHERE \(user_id = \$2 AND code_hash = \$3 AND used_at IS NULL\)$`, schema)).
+		WithArgs(now, int64(7), "hash").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	used, err := repo.UseRecoveryCode(context.Background(), 7, "hash", now)
+	assert.Nil(t, err)
+	assert.True(t, used)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorRepositoryDelete(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewTwoFactorDatabaseRepository(connPool, builder)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf(`^DELETE FROM %s.recovery_code WHERE user_id = \$1$`, schema)).
+		WithArgs(int64(7)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 10))
+	mock.ExpectExec(fmt.Sprintf(`^DELETE FROM %s.two_factor WHERE user_id = \$1$`, schema)).
+		WithArgs(int64(7)).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectCommit()
+
+	assert.Nil(t, repo.Delete(context.Background(), 7))
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"go-form-hub/internal/database"
 
@@ -11,15 +12,18 @@ import (
 )
 
 type User struct {
-	ID        int64   `db:"id"`
-	Username  string  `db:"username"`
-	FirstName string  `db:"first_name"`
-	LastName  string  `db:"last_name"`
-	Password  string  `db:"password"`
-	Email     string  `db:"email"`
-	Avatar    *string `db:"avatar"`
+	ID              int64      `db:"id"`
+	Username        string     `db:"username"`
+	FirstName       string     `db:"first_name"`
+	LastName        string     `db:"last_name"`
+	Password        string     `db:"password"`
+	Email           string     `db:"email"`
+	Avatar          *string    `db:"avatar"`
+	EmailVerifiedAt *time.Time `db:"email_verified_at"`
 }
 
+var userColumns = []string{"id", "username", "first_name", "last_name", "password", "email", "avatar", "email_verified_at"}
+
 type userDatabaseRepository struct {
 	db      database.ConnPool
 	builder squirrel.StatementBuilderType
@@ -37,7 +41,7 @@ func (r *userDatabaseRepository) getTableName() string {
 }
 
 func (r *userDatabaseRepository) FindAll(ctx context.Context) (users []*User, err error) {
-	query, _, err := r.builder.Select("id", "username", "first_name", "last_name", "password", "email", "avatar").
+	query, _, err := r.builder.Select(userColumns...).
 		From(r.getTableName()).ToSql()
 	if err != nil {
 		return nil, fmt.Errorf("user_repository find_by_username failed to build query: %e", err)
@@ -68,7 +72,7 @@ func (r *userDatabaseRepository) FindAll(ctx context.Context) (users []*User, er
 }
 
 func (r *userDatabaseRepository) FindByUsername(ctx context.Context, username string) (user *User, err error) {
-	query, args, err := r.builder.Select("id", "username", "first_name", "last_name", "password", "email", "avatar").
+	query, args, err := r.builder.Select(userColumns...).
 		From(r.getTableName()).
 		Where(squirrel.Eq{"username": username}).Limit(1).ToSql()
 	if err != nil {
@@ -95,7 +99,7 @@ func (r *userDatabaseRepository) FindByUsername(ctx context.Context, username st
 }
 
 func (r *userDatabaseRepository) FindByEmail(ctx context.Context, email string) (user *User, err error) {
-	query, args, err := r.builder.Select("id", "username", "first_name", "last_name", "password", "email", "avatar").
+	query, args, err := r.builder.Select(userColumns...).
 		From(r.getTableName()).
 		Where(squirrel.Eq{"email": email}).Limit(1).ToSql()
 	if err != nil {
@@ -122,7 +126,7 @@ func (r *userDatabaseRepository) FindByEmail(ctx context.Context, email string)
 }
 
 func (r *userDatabaseRepository) FindByID(ctx context.Context, id int64) (user *User, err error) {
-	query, args, err := r.builder.Select("id", "username", "first_name", "last_name", "password", "email", "avatar").
+	query, args, err := r.builder.Select(userColumns...).
 		From(r.getTableName()).
 		Where(squirrel.Eq{"id": id}).Limit(1).ToSql()
 	if err != nil {
@@ -194,6 +198,7 @@ func (r *userDatabaseRepository) Update(ctx context.Context, id int64, user *Use
 		Set("password", user.Password).
 		Set("email", user.Email).
 		Set("avatar", user.Avatar).
+		Set("email_verified_at", user.EmailVerifiedAt).
 		Where(squirrel.Eq{"id": id}).ToSql()
 	if err != nil {
 		return fmt.Errorf("user_repository update failed to build query: %e", err)
@@ -221,6 +226,66 @@ func (r *userDatabaseRepository) Update(ctx context.Context, id int64, user *Use
 	return nil
 }
 
+func (r *userDatabaseRepository) UpdatePassword(ctx context.Context, id int64, password string) error {
+	query, args, err := r.builder.Update(r.getTableName()).
+		Set("password", password).
+		Where(squirrel.Eq{"id": id}).ToSql()
+	if err != nil {
+		return fmt.Errorf("user_repository update_password failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("user_repository update_password failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("user_repository update_password failed to execute query: %e", err)
+	}
+
+	return nil
+}
+
+func (r *userDatabaseRepository) MarkEmailVerified(ctx context.Context, id int64, verifiedAt time.Time) error {
+	query, args, err := r.builder.Update(r.getTableName()).
+		Set("email_verified_at", verifiedAt).
+		Where(squirrel.Eq{"id": id}).ToSql()
+	if err != nil {
+		return fmt.Errorf("user_repository mark_email_verified failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("user_repository mark_email_verified failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	_, err = tx.Exec(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("user_repository mark_email_verified failed to execute query: %e", err)
+	}
+
+	return nil
+}
+
 func (r *userDatabaseRepository) Delete(ctx context.Context, id int64) error {
 	query, args, err := r.builder.Delete(r.getTableName()).
 		Where(squirrel.Eq{"id": id}).ToSql()
@@ -279,6 +344,7 @@ func (r *userDatabaseRepository) fromRow(row pgx.Row) (*User, error) {
 		&user.Password,
 		&user.Email,
 		&user.Avatar,
+		&user.EmailVerifiedAt,
 	)
 	if err != nil {
 		if err == pgx.ErrNoRows {
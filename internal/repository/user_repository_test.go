@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"testing"
+	"time"
 
 	"go-form-hub/internal/database"
 	"go-form-hub/internal/repository"
@@ -34,9 +35,9 @@ func TestUserRepositoryFindAll(t *testing.T) {
 
 		mock.ExpectBegin()
 
-		rows := mock.NewRows([]string{"id", "username", "first_name", "last_name", "password", "email", "avatar"}).
-			AddRow(int64(1), "username1", "first_name1", "last_name1", "password1", "email1", emptyString).
-			AddRow(int64(2), "username2", "first_name2", "last_name2", "password2", "email2", emptyString)
+		rows := mock.NewRows([]string{"id", "username", "first_name", "last_name", "password", "email", "avatar", "email_verified_at"}).
+			AddRow(int64(1), "username1", "first_name1", "last_name1", "password1", "email1", emptyString, nil).
+			AddRow(int64(2), "username2", "first_name2", "last_name2", "password2", "email2", emptyString, nil)
 
 		mock.ExpectQuery(fmt.Sprintf(`^SELECT (.*) FROM %s.user`, schema)).
 			WillReturnRows(rows)
@@ -69,8 +70,8 @@ func TestUserRepositoryFindByUsername(t *testing.T) {
 		mock.ExpectBegin()
 
 		username := "unique-username"
-		rows := mock.NewRows([]string{"id", "username", "first_name", "last_name", "password", "email", "avatar"}).
-			AddRow(int64(1), username, "first_name1", "last_name1", "password1", "email1", emptyString)
+		rows := mock.NewRows([]string{"id", "username", "first_name", "last_name", "password", "email", "avatar", "email_verified_at"}).
+			AddRow(int64(1), username, "first_name1", "last_name1", "password1", "email1", emptyString, nil)
 
 		mock.ExpectQuery(fmt.Sprintf(`^SELECT (.*) FROM %s.user WHERE username = .* LIMIT 1`, schema)).
 			WithArgs(username).
@@ -104,8 +105,8 @@ func TestUserRepositoryFindByEmail(t *testing.T) {
 		mock.ExpectBegin()
 
 		email := "unique-email"
-		rows := mock.NewRows([]string{"id", "username", "first_name", "last_name", "password", "email", "avatar"}).
-			AddRow(int64(1), "username", "first_name1", "last_name1", "password1", email, emptyString)
+		rows := mock.NewRows([]string{"id", "username", "first_name", "last_name", "password", "email", "avatar", "email_verified_at"}).
+			AddRow(int64(1), "username", "first_name1", "last_name1", "password1", email, emptyString, nil)
 
 		mock.ExpectQuery(fmt.Sprintf(`^SELECT (.*) FROM %s.user WHERE email = \$1 LIMIT 1`, schema)).
 			WithArgs(email).
@@ -139,8 +140,8 @@ func TestUserRepositoryFindByUD(t *testing.T) {
 		mock.ExpectBegin()
 
 		id := int64(123)
-		rows := mock.NewRows([]string{"id", "username", "first_name", "last_name", "password", "email", "avatar"}).
-			AddRow(id, "username", "first_name1", "last_name1", "password1", "email", emptyString)
+		rows := mock.NewRows([]string{"id", "username", "first_name", "last_name", "password", "email", "avatar", "email_verified_at"}).
+			AddRow(id, "username", "first_name1", "last_name1", "password1", "email", emptyString, nil)
 
 		mock.ExpectQuery(fmt.Sprintf(`^SELECT (.*) FROM %s.user WHERE id = \$1 LIMIT 1`, schema)).
 			WithArgs(id).
@@ -287,9 +288,9 @@ func TestUserRepositoryUpdate(t *testing.T) {
 
 		mock.ExpectBegin()
 
-		query := fmt.Sprintf(`^UPDATE %s.user SET .* WHERE id = \$7$`, schema)
+		query := fmt.Sprintf(`^UPDATE %s.user SET .* WHERE id = \$8$`, schema)
 		mock.ExpectExec(query).
-			WithArgs("username", "first_name", "last_name", "password", "email", emptyString, int64(1)).
+			WithArgs("username", "first_name", "last_name", "password", "email", emptyString, (*time.Time)(nil), int64(1)).
 			WillReturnResult(pgxmock.NewResult("UPDATE", 1))
 
 		mock.ExpectCommit()
@@ -312,6 +313,58 @@ func TestUserRepositoryUpdate(t *testing.T) {
 	})
 }
 
+func TestUserRepositoryUpdatePassword(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewUserDatabaseRepository(connPool, builder)
+
+	mock.ExpectBegin()
+
+	query := fmt.Sprintf(`^UPDATE %s.user SET password = \$1 WHERE id = \$2$`, schema)
+	mock.ExpectExec(query).
+		WithArgs("$argon2id$hash", int64(1)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	mock.ExpectCommit()
+
+	err = repo.UpdatePassword(context.Background(), 1, "$argon2id$hash")
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
+func TestUserRepositoryMarkEmailVerified(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	if err != nil {
+		t.Logf("failed to create mock: %e", err)
+		t.FailNow()
+	}
+
+	schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+	connPool := database.NewConnPool(mock, schema)
+	repo := repository.NewUserDatabaseRepository(connPool, builder)
+
+	verifiedAt := time.Now().UTC()
+
+	mock.ExpectBegin()
+
+	query := fmt.Sprintf(`^UPDATE %s.user SET email_verified_at = \$1 WHERE id = \$2$`, schema)
+	mock.ExpectExec(query).
+		WithArgs(verifiedAt, int64(1)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	mock.ExpectCommit()
+
+	err = repo.MarkEmailVerified(context.Background(), 1, verifiedAt)
+	assert.Nil(t, err)
+	assert.Nil(t, mock.ExpectationsWereMet())
+}
+
 func TestUserRepositoryDelete(t *testing.T) {
 	t.Run("NoErrors", func(t *testing.T) {
 		t.Parallel()
@@ -0,0 +1,425 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/model"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v5"
+)
+
+// WebhookDispatch is a claimed delivery together with the subscription it is sent to.
+type WebhookDispatch struct {
+	Delivery *model.WebhookDelivery
+	URL      string
+	Secret   string
+}
+
+var (
+	selectFieldsWebhook = []string{
+		"w.id",
+		"w.form_id",
+		"w.url",
+		"w.secret",
+		"w.events",
+		"w.created_at",
+	}
+	selectFieldsWebhookDelivery = []string{
+		"d.id",
+		"d.webhook_id",
+		"d.event",
+		"d.payload",
+		"d.status",
+		"d.attempts",
+		"d.response_status",
+		"d.last_error",
+		"d.next_attempt_at",
+		"d.delivered_at",
+		"d.created_at",
+	}
+)
+
+type webhookDatabaseRepository struct {
+	db      database.ConnPool
+	builder squirrel.StatementBuilderType
+}
+
+func NewWebhookDatabaseRepository(db database.ConnPool, builder squirrel.StatementBuilderType) WebhookRepository {
+	return &webhookDatabaseRepository{
+		db:      db,
+		builder: builder,
+	}
+}
+
+// webhookEventQuery builds the statement that adds a pending delivery of the event for every webhook
+// of the form subscribed to it. It runs in the transaction that causes the event, so deliveries exist
+// only for committed changes.
+func webhookEventQuery(schema string, formID int64, event string, payload []byte, now time.Time) (string, []interface{}) {
+	query := fmt.Sprintf(`INSERT INTO %s.webhook_delivery
+	(webhook_id, event, payload, status, next_attempt_at, created_at)
+	SELECT w.id, $2, $3::jsonb, $4, $5, $5
+	FROM %s.webhook as w
+	WHERE w.form_id = $1 AND $2 = ANY(w.events)`, schema, schema)
+
+	return query, []interface{}{formID, event, string(payload), model.DeliveryStatusPending, now}
+}
+
+func (r *webhookDatabaseRepository) Insert(ctx context.Context, webhook *model.Webhook) (err error) {
+	query, args, err := r.builder.
+		Insert(fmt.Sprintf("%s.webhook", r.db.GetSchema())).
+		Columns("form_id", "url", "secret", "events", "created_at").
+		Values(webhook.FormID, webhook.URL, webhook.Secret, webhook.Events, webhook.CreatedAt).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("webhook_repository insert failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("webhook_repository insert failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if err = tx.QueryRow(ctx, query, args...).Scan(&webhook.ID); err != nil {
+		return fmt.Errorf("webhook_repository insert failed to execute query: %e", err)
+	}
+
+	return nil
+}
+
+func (r *webhookDatabaseRepository) FindByID(ctx context.Context, id int64) (webhook *model.Webhook, err error) {
+	query, args, err := r.builder.
+		Select(selectFieldsWebhook...).
+		From(fmt.Sprintf("%s.webhook as w", r.db.GetSchema())).
+		Where(squirrel.Eq{"w.id": id}).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("webhook_repository find_by_id failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("webhook_repository find_by_id failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("webhook_repository find_by_id failed to execute query: %e", err)
+	}
+
+	webhooks, err := r.webhooksFromRows(rows)
+	if err != nil || len(webhooks) == 0 {
+		return nil, err
+	}
+
+	return webhooks[0], nil
+}
+
+func (r *webhookDatabaseRepository) FindAllByForm(ctx context.Context, formID int64) (webhooks []*model.Webhook, err error) {
+	query, args, err := r.builder.
+		Select(selectFieldsWebhook...).
+		From(fmt.Sprintf("%s.webhook as w", r.db.GetSchema())).
+		Where(squirrel.Eq{"w.form_id": formID}).
+		OrderBy("w.id").
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("webhook_repository find_all_by_form failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("webhook_repository find_all_by_form failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("webhook_repository find_all_by_form failed to execute query: %e", err)
+	}
+
+	return r.webhooksFromRows(rows)
+}
+
+func (r *webhookDatabaseRepository) Delete(ctx context.Context, id int64) (err error) {
+	query, args, err := r.builder.
+		Delete(fmt.Sprintf("%s.webhook", r.db.GetSchema())).
+		Where(squirrel.Eq{"id": id}).
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("webhook_repository delete failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("webhook_repository delete failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("webhook_repository delete failed to execute query: %e", err)
+	}
+
+	return nil
+}
+
+// Deliveries returns the latest deliveries of the webhook, newest first.
+func (r *webhookDatabaseRepository) Deliveries(ctx context.Context, webhookID int64, limit uint64) (deliveries []*model.WebhookDelivery, err error) {
+	query, args, err := r.builder.
+		Select(selectFieldsWebhookDelivery...).
+		From(fmt.Sprintf("%s.webhook_delivery as d", r.db.GetSchema())).
+		Where(squirrel.Eq{"d.webhook_id": webhookID}).
+		OrderBy("d.id DESC").
+		Limit(limit).
+		ToSql()
+	if err != nil {
+		return nil, fmt.Errorf("webhook_repository deliveries failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("webhook_repository deliveries failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("webhook_repository deliveries failed to execute query: %e", err)
+	}
+
+	return r.deliveriesFromRows(rows)
+}
+
+// InsertDelivery queues a delivery to a single webhook regardless of the events it is subscribed to.
+func (r *webhookDatabaseRepository) InsertDelivery(ctx context.Context, delivery *model.WebhookDelivery) (err error) {
+	query, args, err := r.builder.
+		Insert(fmt.Sprintf("%s.webhook_delivery", r.db.GetSchema())).
+		Columns("webhook_id", "event", "payload", "status", "next_attempt_at", "created_at").
+		Values(delivery.WebhookID, delivery.Event, string(delivery.Payload), delivery.Status, delivery.NextAttemptAt, delivery.CreatedAt).
+		Suffix("RETURNING id").
+		ToSql()
+	if err != nil {
+		return fmt.Errorf("webhook_repository insert_delivery failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("webhook_repository insert_delivery failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if err = tx.QueryRow(ctx, query, args...).Scan(&delivery.ID); err != nil {
+		return fmt.Errorf("webhook_repository insert_delivery failed to execute query: %e", err)
+	}
+
+	return nil
+}
+
+// ClaimDueDeliveries picks pending deliveries due at now and postpones them by lease, so that
+// concurrent dispatchers skip them and a dispatcher that dies mid-send retries them after the lease.
+func (r *webhookDatabaseRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) (dispatches []*WebhookDispatch, err error) {
+	schema := r.db.GetSchema()
+	query := fmt.Sprintf(`UPDATE %s.webhook_delivery as d
+	SET next_attempt_at = $1
+	FROM %s.webhook as w
+	WHERE w.id = d.webhook_id AND d.id IN (
+		SELECT id FROM %s.webhook_delivery
+		WHERE status = $2 AND next_attempt_at <= $3
+		ORDER BY next_attempt_at
+		LIMIT $4
+		FOR UPDATE SKIP LOCKED
+	)
+	RETURNING d.id, d.webhook_id, d.event, d.payload, d.status, d.attempts, d.response_status,
+		d.last_error, d.next_attempt_at, d.delivered_at, d.created_at, w.url, w.secret`, schema, schema, schema)
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("webhook_repository claim_due_deliveries failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	rows, err := tx.Query(ctx, query, now.Add(lease), model.DeliveryStatusPending, now, limit)
+	if err != nil {
+		return nil, fmt.Errorf("webhook_repository claim_due_deliveries failed to execute query: %e", err)
+	}
+
+	defer func() {
+		rows.Close()
+	}()
+
+	dispatches = make([]*WebhookDispatch, 0)
+	for rows.Next() {
+		dispatch := &WebhookDispatch{Delivery: &model.WebhookDelivery{}}
+		if err = rows.Scan(append(deliveryScanTargets(dispatch.Delivery), &dispatch.URL, &dispatch.Secret)...); err != nil {
+			return nil, fmt.Errorf("webhook_repository claim_due_deliveries failed to scan row: %e", err)
+		}
+		dispatches = append(dispatches, dispatch)
+	}
+
+	return dispatches, nil
+}
+
+// UpdateDelivery stores the outcome of a delivery attempt.
+func (r *webhookDatabaseRepository) UpdateDelivery(ctx context.Context, delivery *model.WebhookDelivery) (err error) {
+	update := r.builder.
+		Update(fmt.Sprintf("%s.webhook_delivery", r.db.GetSchema())).
+		Set("status", delivery.Status).
+		Set("attempts", delivery.Attempts).
+		Set("response_status", delivery.ResponseStatus).
+		Set("last_error", delivery.LastError).
+		Set("delivered_at", delivery.DeliveredAt).
+		Where(squirrel.Eq{"id": delivery.ID})
+	if delivery.NextAttemptAt != nil {
+		update = update.Set("next_attempt_at", delivery.NextAttemptAt)
+	}
+
+	query, args, err := update.ToSql()
+	if err != nil {
+		return fmt.Errorf("webhook_repository update_delivery failed to build query: %e", err)
+	}
+
+	tx, err := r.db.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("webhook_repository update_delivery failed to begin transaction: %e", err)
+	}
+
+	defer func() {
+		switch err {
+		case nil:
+			err = tx.Commit(ctx)
+		default:
+			_ = tx.Rollback(ctx)
+		}
+	}()
+
+	if _, err = tx.Exec(ctx, query, args...); err != nil {
+		return fmt.Errorf("webhook_repository update_delivery failed to execute query: %e", err)
+	}
+
+	return nil
+}
+
+func (r *webhookDatabaseRepository) webhooksFromRows(rows pgx.Rows) ([]*model.Webhook, error) {
+	defer func() {
+		rows.Close()
+	}()
+
+	webhooks := make([]*model.Webhook, 0)
+
+	for rows.Next() {
+		webhook := &model.Webhook{}
+		err := rows.Scan(
+			&webhook.ID,
+			&webhook.FormID,
+			&webhook.URL,
+			&webhook.Secret,
+			&webhook.Events,
+			&webhook.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("webhook_repository webhooksFromRows failed to scan row: %v", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+
+	return webhooks, nil
+}
+
+func (r *webhookDatabaseRepository) deliveriesFromRows(rows pgx.Rows) ([]*model.WebhookDelivery, error) {
+	defer func() {
+		rows.Close()
+	}()
+
+	deliveries := make([]*model.WebhookDelivery, 0)
+
+	for rows.Next() {
+		delivery := &model.WebhookDelivery{}
+		if err := rows.Scan(deliveryScanTargets(delivery)...); err != nil {
+			return nil, fmt.Errorf("webhook_repository deliveriesFromRows failed to scan row: %v", err)
+		}
+		if delivery.Status != model.DeliveryStatusPending {
+			delivery.NextAttemptAt = nil
+		}
+		deliveries = append(deliveries, delivery)
+	}
+
+	return deliveries, nil
+}
+
+// deliveryScanTargets matches selectFieldsWebhookDelivery.
+func deliveryScanTargets(delivery *model.WebhookDelivery) []interface{} {
+	return []interface{}{
+		&delivery.ID,
+		&delivery.WebhookID,
+		&delivery.Event,
+		&delivery.Payload,
+		&delivery.Status,
+		&delivery.Attempts,
+		&delivery.ResponseStatus,
+		&delivery.LastError,
+		&delivery.NextAttemptAt,
+		&delivery.DeliveredAt,
+		&delivery.CreatedAt,
+	}
+}
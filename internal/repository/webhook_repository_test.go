@@ -0,0 +1,113 @@
+package repository_test
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+
+	"github.com/pashagolub/pgxmock/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookRepositoryClaimDueDeliveries(t *testing.T) {
+	t.Run("NoErrors", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewWebhookDatabaseRepository(connPool, builder)
+
+		now := time.Now().UTC()
+		lease := time.Minute
+		payload := []byte(`{"event":"form.closed"}`)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^UPDATE %s.webhook_delivery as d SET next_attempt_at = \$1 FROM %s.webhook as w .* FOR UPDATE SKIP LOCKED \) RETURNING .* w.url, w.secret$`, schema, schema)).
+			WithArgs(now.Add(lease), model.DeliveryStatusPending, now, 10).
+			WillReturnRows(mock.NewRows([]string{"d.id", "d.webhook_id", "d.event", "d.payload", "d.status", "d.attempts", "d.response_status",
+				"d.last_error", "d.next_attempt_at", "d.delivered_at", "d.created_at", "w.url", "w.secret"}).
+				AddRow(int64(3), int64(2), model.WebhookEventFormClosed, payload, model.DeliveryStatusPending, 1, nil,
+					nil, &now, nil, now, "https://example.com/hook", "0123456789abcdef"))
+		mock.ExpectCommit()
+
+		dispatches, err := repo.ClaimDueDeliveries(context.Background(), now, 10, lease)
+		if err != nil {
+			t.Logf("failed to claim deliveries: %e", err)
+			t.FailNow()
+		}
+
+		assert.Equal(t, 1, len(dispatches))
+		assert.Equal(t, int64(3), dispatches[0].Delivery.ID)
+		assert.Equal(t, 1, dispatches[0].Delivery.Attempts)
+		assert.Equal(t, string(payload), string(dispatches[0].Delivery.Payload))
+		assert.Equal(t, "https://example.com/hook", dispatches[0].URL)
+		assert.Equal(t, "0123456789abcdef", dispatches[0].Secret)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestFormRepositoryFormClose(t *testing.T) {
+	t.Run("Closed", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewFormDatabaseRepository(connPool, builder)
+
+		closedAt := time.Now().UTC()
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^UPDATE %s.form SET closed_at = \$1 WHERE id = \$2 AND closed_at IS NULL RETURNING title$`, schema)).
+			WithArgs(&closedAt, int64(1)).
+			WillReturnRows(mock.NewRows([]string{"title"}).AddRow("Survey"))
+		mock.ExpectExec(fmt.Sprintf(`^INSERT INTO %s.webhook_delivery .* FROM %s.webhook as w WHERE w.form_id = \$1 AND \$2 = ANY\(w.events\)$`, schema, schema)).
+			WithArgs(int64(1), model.WebhookEventFormClosed, pgxmock.AnyArg(), model.DeliveryStatusPending, pgxmock.AnyArg()).
+			WillReturnResult(pgxmock.NewResult("INSERT", 1))
+		mock.ExpectCommit()
+
+		changed, err := repo.FormClose(context.Background(), 1, closedAt)
+		assert.Nil(t, err)
+		assert.True(t, changed)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("AlreadyClosed", func(t *testing.T) {
+		t.Parallel()
+		mock, err := pgxmock.NewPool()
+		if err != nil {
+			t.Logf("failed to create mock: %e", err)
+			t.FailNow()
+		}
+
+		schema := strings.ToLower(strings.ReplaceAll(t.Name(), "/", "_"))
+		connPool := database.NewConnPool(mock, schema)
+		repo := repository.NewFormDatabaseRepository(connPool, builder)
+
+		mock.ExpectBegin()
+		mock.ExpectQuery(fmt.Sprintf(`^UPDATE %s.form SET closed_at = \$1 WHERE id = \$2 AND closed_at IS NULL RETURNING title$`, schema)).
+			WithArgs(pgxmock.AnyArg(), int64(1)).
+			WillReturnRows(mock.NewRows([]string{"title"}))
+		mock.ExpectCommit()
+
+		changed, err := repo.FormClose(context.Background(), 1, time.Now().UTC())
+		assert.Nil(t, err)
+		assert.False(t, changed)
+		assert.Nil(t, mock.ExpectationsWereMet())
+	})
+}
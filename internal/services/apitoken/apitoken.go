@@ -0,0 +1,204 @@
+// Package apitoken manages the personal access tokens that API clients send as
+// "Authorization: Bearer <token>" in place of the session cookie and the CSRF token.
+package apitoken
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/authsession"
+	resp "go-form-hub/internal/services/service_response"
+
+	validator "github.com/go-playground/validator/v10"
+)
+
+const (
+	// Prefix marks the tokens, so that a leaked one is easy to recognize in logs and code.
+	Prefix      = "fhp_"
+	tokenLength = 32
+	// MaxTokens is how many tokens a user may have.
+	MaxTokens = 50
+)
+
+var (
+	ErrNotFound      = errors.New("token not found")
+	ErrExpired       = errors.New("token expired")
+	ErrExpiryInPast  = errors.New("the expiry of the token has to be in the future")
+	ErrTooManyTokens = fmt.Errorf("a user can have at most %d tokens", MaxTokens)
+)
+
+type Service interface {
+	TokenCreate(ctx context.Context, token *model.APITokenCreate) (*resp.Response, error)
+	TokenList(ctx context.Context) (*resp.Response, error)
+	TokenRevoke(ctx context.Context, id int64) (*resp.Response, error)
+	// Authenticate returns the user of the token and the scopes it grants.
+	Authenticate(ctx context.Context, token string) (*repository.User, []string, error)
+}
+
+type apiTokenService struct {
+	tokenRepository repository.APITokenRepository
+	userRepository  repository.UserRepository
+	now             func() time.Time
+	validate        *validator.Validate
+}
+
+func NewAPITokenService(tokenRepository repository.APITokenRepository, userRepository repository.UserRepository, now func() time.Time, validate *validator.Validate) Service {
+	return &apiTokenService{
+		tokenRepository: tokenRepository,
+		userRepository:  userRepository,
+		now:             now,
+		validate:        validate,
+	}
+}
+
+// TokenCreate creates a token of the current user. The token is only returned here, the database
+// keeps its hash.
+func (s *apiTokenService) TokenCreate(ctx context.Context, create *model.APITokenCreate) (*resp.Response, error) {
+	if err := s.validate.Struct(create); err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), err
+	}
+
+	now := s.now().UTC()
+	if create.ExpiresAt != nil && !create.ExpiresAt.After(now) {
+		return resp.NewResponse(http.StatusBadRequest, nil), ErrExpiryInPast
+	}
+
+	currentUser := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
+
+	existing, err := s.tokenRepository.FindByUserID(ctx, currentUser.ID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if len(existing) >= MaxTokens {
+		return resp.NewResponse(http.StatusConflict, nil), ErrTooManyTokens
+	}
+
+	secret := make([]byte, tokenLength)
+	if _, err = rand.Read(secret); err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), fmt.Errorf("apitoken token_create failed to read random bytes: %v", err)
+	}
+	value := Prefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	var expiresAt *time.Time
+	if create.ExpiresAt != nil {
+		utc := create.ExpiresAt.UTC()
+		expiresAt = &utc
+	}
+
+	token := &repository.APIToken{
+		UserID:    currentUser.ID,
+		Name:      create.Name,
+		TokenHash: authsession.Hash(value),
+		Scopes:    create.Scopes,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	if err = s.tokenRepository.Insert(ctx, token); err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	return resp.NewResponse(http.StatusCreated, &model.APITokenCreated{
+		APITokenGet: *tokenGet(token),
+		Token:       value,
+	}), nil
+}
+
+func (s *apiTokenService) TokenList(ctx context.Context) (*resp.Response, error) {
+	currentUser := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
+
+	tokens, err := s.tokenRepository.FindByUserID(ctx, currentUser.ID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	response := &model.APITokenList{Tokens: make([]*model.APITokenGet, 0, len(tokens))}
+	for _, token := range tokens {
+		response.Tokens = append(response.Tokens, tokenGet(token))
+	}
+	response.Count = len(response.Tokens)
+
+	return resp.NewResponse(http.StatusOK, response), nil
+}
+
+func (s *apiTokenService) TokenRevoke(ctx context.Context, id int64) (*resp.Response, error) {
+	currentUser := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
+
+	deleted, err := s.tokenRepository.Delete(ctx, id, currentUser.ID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if !deleted {
+		return resp.NewResponse(http.StatusNotFound, nil), ErrNotFound
+	}
+
+	return resp.NewResponse(http.StatusNoContent, nil), nil
+}
+
+func (s *apiTokenService) Authenticate(ctx context.Context, value string) (*repository.User, []string, error) {
+	if !strings.HasPrefix(value, Prefix) {
+		return nil, nil, ErrNotFound
+	}
+
+	token, err := s.tokenRepository.FindByHash(ctx, authsession.Hash(value))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if token == nil {
+		return nil, nil, ErrNotFound
+	}
+
+	now := s.now().UTC()
+	if token.ExpiresAt != nil && !token.ExpiresAt.After(now) {
+		return nil, nil, ErrExpired
+	}
+
+	user, err := s.userRepository.FindByID(ctx, token.UserID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if user == nil {
+		return nil, nil, ErrNotFound
+	}
+
+	// like sessions, the last use is only written once in a while
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= authsession.TouchInterval {
+		if err = s.tokenRepository.Touch(ctx, token.ID, now); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return user, token.Scopes, nil
+}
+
+// HasScope reports whether the scopes include scope.
+func HasScope(scopes []string, scope string) bool {
+	for _, s := range scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+func tokenGet(token *repository.APIToken) *model.APITokenGet {
+	return &model.APITokenGet{
+		ID:         token.ID,
+		Name:       token.Name,
+		Scopes:     token.Scopes,
+		CreatedAt:  token.CreatedAt,
+		ExpiresAt:  token.ExpiresAt,
+		LastUsedAt: token.LastUsedAt,
+	}
+}
@@ -0,0 +1,207 @@
+package apitoken_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/apitoken"
+
+	validator "github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+type memoryTokenRepository struct {
+	tokens  []*repository.APIToken
+	touches int
+}
+
+func (r *memoryTokenRepository) Insert(_ context.Context, token *repository.APIToken) error {
+	token.ID = int64(len(r.tokens) + 1)
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *memoryTokenRepository) FindByHash(_ context.Context, tokenHash string) (*repository.APIToken, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash {
+			found := *token
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *memoryTokenRepository) FindByUserID(_ context.Context, userID int64) ([]*repository.APIToken, error) {
+	tokens := []*repository.APIToken{}
+	for _, token := range r.tokens {
+		if token.UserID == userID {
+			tokens = append(tokens, token)
+		}
+	}
+	return tokens, nil
+}
+
+func (r *memoryTokenRepository) Touch(_ context.Context, id int64, lastUsedAt time.Time) error {
+	r.touches++
+	for _, token := range r.tokens {
+		if token.ID == id {
+			token.LastUsedAt = &lastUsedAt
+		}
+	}
+	return nil
+}
+
+func (r *memoryTokenRepository) Delete(_ context.Context, id, userID int64) (bool, error) {
+	for i, token := range r.tokens {
+		if token.ID == id && token.UserID == userID {
+			r.tokens = append(r.tokens[:i], r.tokens[i+1:]...)
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+type memoryUserRepository struct {
+	repository.UserRepository
+}
+
+func (r *memoryUserRepository) FindByID(_ context.Context, id int64) (*repository.User, error) {
+	if id != 1 && id != 2 {
+		return nil, nil
+	}
+	return &repository.User{ID: id, Username: "user"}, nil
+}
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func userContext(id int64) context.Context {
+	return context.WithValue(context.Background(), model.ContextCurrentUser, &model.UserGet{ID: id, Username: "user"})
+}
+
+func TestTokenLifecycle(t *testing.T) {
+	tokens := &memoryTokenRepository{}
+	c := &clock{now: time.Date(2023, 11, 1, 12, 0, 0, 0, time.UTC)}
+	service := apitoken.NewAPITokenService(tokens, &memoryUserRepository{}, c.Now, validator.New())
+	ctx := userContext(1)
+
+	expiresAt := c.now.Add(24 * time.Hour)
+	response, err := service.TokenCreate(ctx, &model.APITokenCreate{
+		Name:      "export script",
+		Scopes:    []string{model.ScopeFormsRead, model.ScopeResultsRead},
+		ExpiresAt: &expiresAt,
+	})
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, http.StatusCreated, response.StatusCode)
+
+	created := response.Body.(*model.APITokenCreated)
+	assert.True(t, strings.HasPrefix(created.Token, apitoken.Prefix))
+	assert.NotContains(t, tokens.tokens[0].TokenHash, created.Token, "only the hash is stored")
+
+	user, scopes, err := service.Authenticate(context.Background(), created.Token)
+	if assert.Nil(t, err) {
+		assert.Equal(t, int64(1), user.ID)
+		assert.Equal(t, []string{model.ScopeFormsRead, model.ScopeResultsRead}, scopes)
+	}
+
+	c.now = c.now.Add(10 * time.Second)
+	_, _, err = service.Authenticate(context.Background(), created.Token)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, tokens.touches, "the last use is not written on every request")
+
+	response, err = service.TokenList(ctx)
+	if assert.Nil(t, err) {
+		list := response.Body.(*model.APITokenList)
+		if assert.Equal(t, 1, list.Count) {
+			assert.Equal(t, "export script", list.Tokens[0].Name)
+			assert.NotNil(t, list.Tokens[0].LastUsedAt)
+		}
+	}
+
+	response, err = service.TokenRevoke(userContext(2), created.ID)
+	assert.ErrorIs(t, err, apitoken.ErrNotFound, "another user cannot revoke the token")
+	assert.Equal(t, http.StatusNotFound, response.StatusCode)
+
+	response, err = service.TokenRevoke(ctx, created.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, response.StatusCode)
+
+	_, _, err = service.Authenticate(context.Background(), created.Token)
+	assert.ErrorIs(t, err, apitoken.ErrNotFound)
+}
+
+func TestTokenExpiry(t *testing.T) {
+	tokens := &memoryTokenRepository{}
+	c := &clock{now: time.Date(2023, 11, 1, 12, 0, 0, 0, time.UTC)}
+	service := apitoken.NewAPITokenService(tokens, &memoryUserRepository{}, c.Now, validator.New())
+	ctx := userContext(1)
+
+	past := c.now.Add(-time.Minute)
+	response, err := service.TokenCreate(ctx, &model.APITokenCreate{Name: "old", Scopes: []string{model.ScopeFormsRead}, ExpiresAt: &past})
+	assert.ErrorIs(t, err, apitoken.ErrExpiryInPast)
+	assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+
+	expiresAt := c.now.Add(time.Hour)
+	response, err = service.TokenCreate(ctx, &model.APITokenCreate{Name: "short", Scopes: []string{model.ScopeFormsWrite}, ExpiresAt: &expiresAt})
+	if !assert.Nil(t, err) {
+		return
+	}
+	short := response.Body.(*model.APITokenCreated).Token
+
+	response, err = service.TokenCreate(ctx, &model.APITokenCreate{Name: "forever", Scopes: []string{model.ScopeFormsRead}})
+	if !assert.Nil(t, err) {
+		return
+	}
+	forever := response.Body.(*model.APITokenCreated).Token
+
+	c.now = c.now.Add(2 * time.Hour)
+	_, _, err = service.Authenticate(context.Background(), short)
+	assert.ErrorIs(t, err, apitoken.ErrExpired)
+
+	_, _, err = service.Authenticate(context.Background(), forever)
+	assert.Nil(t, err)
+}
+
+func TestTokenCreateValidation(t *testing.T) {
+	service := apitoken.NewAPITokenService(&memoryTokenRepository{}, &memoryUserRepository{}, time.Now, validator.New())
+
+	tests := []struct {
+		name   string
+		create *model.APITokenCreate
+	}{
+		{name: "NoName", create: &model.APITokenCreate{Scopes: []string{model.ScopeFormsRead}}},
+		{name: "NoScopes", create: &model.APITokenCreate{Name: "script"}},
+		{name: "UnknownScope", create: &model.APITokenCreate{Name: "script", Scopes: []string{"admin"}}},
+		{name: "RepeatedScope", create: &model.APITokenCreate{Name: "script", Scopes: []string{model.ScopeFormsRead, model.ScopeFormsRead}}},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			response, err := service.TokenCreate(userContext(1), test.create)
+			assert.NotNil(t, err)
+			assert.Equal(t, http.StatusBadRequest, response.StatusCode)
+		})
+	}
+}
+
+func TestAuthenticateUnknownToken(t *testing.T) {
+	service := apitoken.NewAPITokenService(&memoryTokenRepository{}, &memoryUserRepository{}, time.Now, validator.New())
+
+	for _, token := range []string{"", "not-a-token", apitoken.Prefix + "unknown"} {
+		_, _, err := service.Authenticate(context.Background(), token)
+		assert.ErrorIs(t, err, apitoken.ErrNotFound)
+	}
+}
@@ -0,0 +1,274 @@
+// Package authsession creates and validates the login sessions of users. The gateway middlewares and
+// the auth microservice share it, so a session expires the same way everywhere.
+package authsession
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"go-form-hub/internal/repository"
+)
+
+const (
+	idLength        = 32
+	userAgentLength = 512
+
+	// TouchInterval is how stale the last activity of a session may get before a request refreshes
+	// it, so that not every request writes to the database.
+	TouchInterval = time.Minute
+
+	// PendingTTL is how long a login may wait for its second factor.
+	PendingTTL = 5 * time.Minute
+)
+
+var (
+	ErrNotFound       = errors.New("session not found")
+	ErrExpired        = errors.New("session expired")
+	ErrUnknownSession = errors.New("the user has no such session")
+)
+
+// NewID returns a random session ID for the cookie.
+func NewID() (string, error) {
+	id := make([]byte, idLength)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("authsession new_id failed to read random bytes: %v", err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(id), nil
+}
+
+// Hash returns what is stored in place of the session ID, so that a leaked session table does not
+// give away valid cookies.
+func Hash(id string) string {
+	sum := sha256.Sum256([]byte(id))
+	return hex.EncodeToString(sum[:])
+}
+
+// Client describes the device a session was started from, to tell the sessions of a user apart.
+type Client struct {
+	UserAgent string
+	IP        string
+}
+
+// Manager keeps sessions alive while they are used: a session expires once it has been idle for
+// the TTL.
+type Manager struct {
+	sessionRepository repository.SessionRepository
+	userRepository    repository.UserRepository
+	ttl               time.Duration
+	now               func() time.Time
+}
+
+func NewManager(sessionRepository repository.SessionRepository, userRepository repository.UserRepository, ttl time.Duration, now func() time.Time) *Manager {
+	return &Manager{
+		sessionRepository: sessionRepository,
+		userRepository:    userRepository,
+		ttl:               ttl,
+		now:               now,
+	}
+}
+
+// TTL is how long a session may be idle, and so how long its cookie should live after a refresh.
+func (m *Manager) TTL() time.Duration {
+	return m.ttl
+}
+
+// Create starts a session of the user on the client and returns its ID.
+func (m *Manager) Create(ctx context.Context, userID int64, client Client) (string, error) {
+	return m.insert(ctx, userID, client, false)
+}
+
+// CreatePending starts a session of the user that only becomes valid once Activate is called,
+// after the user proved the second factor, and returns its ID.
+func (m *Manager) CreatePending(ctx context.Context, userID int64, client Client) (string, error) {
+	return m.insert(ctx, userID, client, true)
+}
+
+func (m *Manager) insert(ctx context.Context, userID int64, client Client, pending bool) (string, error) {
+	id, err := NewID()
+	if err != nil {
+		return "", err
+	}
+
+	now := m.now().UTC()
+	err = m.sessionRepository.Insert(ctx, &repository.Session{
+		SessionID:  Hash(id),
+		UserID:     userID,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		UserAgent:  truncate(client.UserAgent, userAgentLength),
+		IP:         client.IP,
+		Pending:    pending,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// Validate returns the user of the session and extends the session. refreshed reports whether the
+// expiry moved, and so whether the cookie should be sent again.
+func (m *Manager) Validate(ctx context.Context, id string) (user *repository.User, refreshed bool, err error) {
+	session, err := m.sessionRepository.FindByID(ctx, Hash(id))
+	if err != nil {
+		return nil, false, err
+	}
+
+	// a pending session cannot be used before the login is complete
+	if session == nil || session.Pending {
+		return nil, false, ErrNotFound
+	}
+
+	now := m.now().UTC()
+	if session.LastSeenAt.Add(m.ttl).Before(now) {
+		return nil, false, ErrExpired
+	}
+
+	user, err = m.userRepository.FindByID(ctx, session.UserID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if user == nil {
+		return nil, false, ErrNotFound
+	}
+
+	if now.Sub(session.LastSeenAt) >= TouchInterval {
+		if err = m.sessionRepository.Touch(ctx, session.SessionID, now); err != nil {
+			return nil, false, err
+		}
+		refreshed = true
+	}
+
+	return user, refreshed, nil
+}
+
+// Rotate replaces the ID of a valid session with a new one, after a change of the credentials of
+// its user for example, and returns the new ID.
+func (m *Manager) Rotate(ctx context.Context, id string) (string, error) {
+	if _, _, err := m.Validate(ctx, id); err != nil {
+		return "", err
+	}
+
+	newID, err := NewID()
+	if err != nil {
+		return "", err
+	}
+
+	if err = m.sessionRepository.Rotate(ctx, Hash(id), Hash(newID), m.now().UTC()); err != nil {
+		return "", err
+	}
+
+	return newID, nil
+}
+
+// PendingUser returns the ID of the user of a pending session that has not waited longer than
+// PendingTTL.
+func (m *Manager) PendingUser(ctx context.Context, id string) (int64, error) {
+	session, err := m.sessionRepository.FindByID(ctx, Hash(id))
+	if err != nil {
+		return 0, err
+	}
+
+	if session == nil || !session.Pending {
+		return 0, ErrNotFound
+	}
+
+	if session.CreatedAt.Add(PendingTTL).Before(m.now().UTC()) {
+		return 0, ErrExpired
+	}
+
+	return session.UserID, nil
+}
+
+// Activate turns a pending session into a valid one under a new ID and returns the ID. The pending
+// ID stops working, so it cannot be activated twice.
+func (m *Manager) Activate(ctx context.Context, id string) (string, error) {
+	if _, err := m.PendingUser(ctx, id); err != nil {
+		return "", err
+	}
+
+	newID, err := NewID()
+	if err != nil {
+		return "", err
+	}
+
+	activated, err := m.sessionRepository.Activate(ctx, Hash(id), Hash(newID), m.now().UTC())
+	if err != nil {
+		return "", err
+	}
+
+	if !activated {
+		return "", ErrNotFound
+	}
+
+	return newID, nil
+}
+
+// List returns the sessions of the user of the session that have not expired, the most recently
+// used first.
+func (m *Manager) List(ctx context.Context, id string) ([]*repository.Session, error) {
+	user, _, err := m.Validate(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return m.sessionRepository.FindByUserID(ctx, user.ID, m.now().UTC().Add(-m.ttl))
+}
+
+// Revoke ends the session stored as the hash target, which must belong to the user of the
+// session id.
+func (m *Manager) Revoke(ctx context.Context, id, target string) error {
+	user, _, err := m.Validate(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	session, err := m.sessionRepository.FindByID(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	if session == nil || session.UserID != user.ID {
+		return ErrUnknownSession
+	}
+
+	return m.sessionRepository.Delete(ctx, target)
+}
+
+// RevokeOthers ends every session of the user of the session id but that one, and returns how
+// many were ended.
+func (m *Manager) RevokeOthers(ctx context.Context, id string) (int64, error) {
+	user, _, err := m.Validate(ctx, id)
+	if err != nil {
+		return 0, err
+	}
+
+	return m.sessionRepository.DeleteByUserID(ctx, user.ID, Hash(id))
+}
+
+// RevokeAll ends every session of the user, after the password was reset for example.
+func (m *Manager) RevokeAll(ctx context.Context, userID int64) error {
+	_, err := m.sessionRepository.DeleteByUserID(ctx, userID, "")
+	return err
+}
+
+// Delete ends the session.
+func (m *Manager) Delete(ctx context.Context, id string) error {
+	return m.sessionRepository.Delete(ctx, Hash(id))
+}
+
+func truncate(value string, length int) string {
+	runes := []rune(value)
+	if len(runes) <= length {
+		return value
+	}
+	return string(runes[:length])
+}
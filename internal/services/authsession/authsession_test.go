@@ -0,0 +1,277 @@
+package authsession_test
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/authsession"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type memorySessionRepository struct {
+	repository.SessionRepository
+
+	sessions map[string]*repository.Session
+}
+
+func (r *memorySessionRepository) FindByID(_ context.Context, sessionID string) (*repository.Session, error) {
+	session, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, nil
+	}
+	found := *session
+	return &found, nil
+}
+
+func (r *memorySessionRepository) Insert(_ context.Context, session *repository.Session) error {
+	r.sessions[session.SessionID] = session
+	return nil
+}
+
+func (r *memorySessionRepository) Touch(_ context.Context, sessionID string, lastSeenAt time.Time) error {
+	r.sessions[sessionID].LastSeenAt = lastSeenAt
+	return nil
+}
+
+func (r *memorySessionRepository) Rotate(_ context.Context, sessionID, newSessionID string, now time.Time) error {
+	session := r.sessions[sessionID]
+	delete(r.sessions, sessionID)
+	session.SessionID, session.CreatedAt, session.LastSeenAt = newSessionID, now, now
+	r.sessions[newSessionID] = session
+	return nil
+}
+
+func (r *memorySessionRepository) Activate(_ context.Context, sessionID, newSessionID string, now time.Time) (bool, error) {
+	session, ok := r.sessions[sessionID]
+	if !ok || !session.Pending {
+		return false, nil
+	}
+	delete(r.sessions, sessionID)
+	session.SessionID, session.Pending, session.CreatedAt, session.LastSeenAt = newSessionID, false, now, now
+	r.sessions[newSessionID] = session
+	return true, nil
+}
+
+func (r *memorySessionRepository) FindByUserID(_ context.Context, userID int64, seenAfter time.Time) ([]*repository.Session, error) {
+	sessions := []*repository.Session{}
+	for _, session := range r.sessions {
+		if session.UserID == userID && session.LastSeenAt.After(seenAfter) && !session.Pending {
+			sessions = append(sessions, session)
+		}
+	}
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastSeenAt.After(sessions[j].LastSeenAt)
+	})
+	return sessions, nil
+}
+
+func (r *memorySessionRepository) DeleteByUserID(_ context.Context, userID int64, exceptSessionID string) (int64, error) {
+	deleted := int64(0)
+	for id, session := range r.sessions {
+		if session.UserID == userID && id != exceptSessionID {
+			delete(r.sessions, id)
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+func (r *memorySessionRepository) Delete(_ context.Context, sessionID string) error {
+	delete(r.sessions, sessionID)
+	return nil
+}
+
+type userRepository struct {
+	repository.UserRepository
+}
+
+func (r *userRepository) FindByID(_ context.Context, id int64) (*repository.User, error) {
+	return &repository.User{ID: id, Username: "user"}, nil
+}
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func newManager() (*authsession.Manager, *memorySessionRepository, *clock) {
+	sessions := &memorySessionRepository{sessions: map[string]*repository.Session{}}
+	c := &clock{now: time.Date(2023, 11, 1, 12, 0, 0, 0, time.UTC)}
+	return authsession.NewManager(sessions, &userRepository{}, time.Hour, c.Now), sessions, c
+}
+
+func TestManagerCreate(t *testing.T) {
+	manager, sessions, _ := newManager()
+
+	id, err := manager.Create(context.Background(), 7, authsession.Client{UserAgent: "Firefox", IP: "10.0.0.2"})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	other, err := manager.Create(context.Background(), 7, authsession.Client{})
+	assert.Nil(t, err)
+	assert.NotEqual(t, id, other)
+	assert.Len(t, id, 43)
+
+	_, stored := sessions.sessions[id]
+	assert.False(t, stored, "the session ID itself must not be stored")
+	if assert.Contains(t, sessions.sessions, authsession.Hash(id)) {
+		assert.Equal(t, int64(7), sessions.sessions[authsession.Hash(id)].UserID)
+		assert.Equal(t, "Firefox", sessions.sessions[authsession.Hash(id)].UserAgent)
+		assert.Equal(t, "10.0.0.2", sessions.sessions[authsession.Hash(id)].IP)
+	}
+
+	user, _, err := manager.Validate(context.Background(), id)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), user.ID)
+}
+
+func TestManagerValidateSliding(t *testing.T) {
+	manager, _, c := newManager()
+	id, err := manager.Create(context.Background(), 7, authsession.Client{})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	c.now = c.now.Add(30 * time.Second)
+	_, refreshed, err := manager.Validate(context.Background(), id)
+	assert.Nil(t, err)
+	assert.False(t, refreshed, "a session seen within the touch interval is not refreshed")
+
+	c.now = c.now.Add(50 * time.Minute)
+	_, refreshed, err = manager.Validate(context.Background(), id)
+	assert.Nil(t, err)
+	assert.True(t, refreshed)
+
+	c.now = c.now.Add(50 * time.Minute)
+	_, _, err = manager.Validate(context.Background(), id)
+	assert.Nil(t, err, "a session in use stays valid past the TTL from its creation")
+
+	c.now = c.now.Add(time.Hour + time.Second)
+	_, _, err = manager.Validate(context.Background(), id)
+	assert.ErrorIs(t, err, authsession.ErrExpired)
+
+	_, _, err = manager.Validate(context.Background(), "unknown")
+	assert.ErrorIs(t, err, authsession.ErrNotFound)
+}
+
+func TestManagerRotate(t *testing.T) {
+	manager, sessions, _ := newManager()
+	id, err := manager.Create(context.Background(), 7, authsession.Client{})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	newID, err := manager.Rotate(context.Background(), id)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.NotEqual(t, id, newID)
+	assert.Len(t, sessions.sessions, 1)
+
+	_, _, err = manager.Validate(context.Background(), id)
+	assert.ErrorIs(t, err, authsession.ErrNotFound)
+
+	user, _, err := manager.Validate(context.Background(), newID)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), user.ID)
+
+	_, err = manager.Rotate(context.Background(), id)
+	assert.ErrorIs(t, err, authsession.ErrNotFound)
+
+	assert.Nil(t, manager.Delete(context.Background(), newID))
+	assert.Empty(t, sessions.sessions)
+}
+
+func TestManagerListAndRevoke(t *testing.T) {
+	manager, sessions, c := newManager()
+	ctx := context.Background()
+
+	laptop, _ := manager.Create(ctx, 7, authsession.Client{UserAgent: "laptop"})
+	c.now = c.now.Add(10 * time.Minute)
+	phone, _ := manager.Create(ctx, 7, authsession.Client{UserAgent: "phone"})
+	c.now = c.now.Add(10 * time.Minute)
+	tablet, _ := manager.Create(ctx, 7, authsession.Client{UserAgent: "tablet"})
+	stranger, _ := manager.Create(ctx, 8, authsession.Client{UserAgent: "stranger"})
+
+	c.now = c.now.Add(time.Minute)
+	listed, err := manager.List(ctx, phone)
+	if assert.Nil(t, err) && assert.Len(t, listed, 3) {
+		assert.Equal(t, "phone", listed[0].UserAgent, "listing refreshes the current session")
+		assert.Equal(t, "tablet", listed[1].UserAgent)
+		assert.Equal(t, "laptop", listed[2].UserAgent)
+	}
+
+	c.now = c.now.Add(55 * time.Minute)
+	listed, err = manager.List(ctx, tablet)
+	if assert.Nil(t, err) && assert.Len(t, listed, 2, "expired sessions are not listed") {
+		assert.Equal(t, "tablet", listed[0].UserAgent)
+		assert.Equal(t, "phone", listed[1].UserAgent)
+	}
+
+	assert.ErrorIs(t, manager.Revoke(ctx, tablet, authsession.Hash(stranger)), authsession.ErrUnknownSession)
+	assert.ErrorIs(t, manager.Revoke(ctx, tablet, "unknown"), authsession.ErrUnknownSession)
+	assert.Nil(t, manager.Revoke(ctx, tablet, authsession.Hash(phone)))
+	_, _, err = manager.Validate(ctx, phone)
+	assert.ErrorIs(t, err, authsession.ErrNotFound)
+
+	revoked, err := manager.RevokeOthers(ctx, tablet)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), revoked)
+	assert.Len(t, sessions.sessions, 2)
+	assert.Contains(t, sessions.sessions, authsession.Hash(tablet))
+	assert.Contains(t, sessions.sessions, authsession.Hash(stranger))
+	assert.NotContains(t, sessions.sessions, authsession.Hash(laptop))
+
+	_, err = manager.RevokeOthers(ctx, laptop)
+	assert.ErrorIs(t, err, authsession.ErrNotFound)
+}
+
+func TestManagerPending(t *testing.T) {
+	manager, sessions, c := newManager()
+	ctx := context.Background()
+
+	pending, err := manager.CreatePending(ctx, 7, authsession.Client{UserAgent: "Firefox"})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	_, _, err = manager.Validate(ctx, pending)
+	assert.ErrorIs(t, err, authsession.ErrNotFound, "a pending session does not authenticate")
+
+	listed, _ := manager.List(ctx, pending)
+	assert.Empty(t, listed)
+
+	userID, err := manager.PendingUser(ctx, pending)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), userID)
+
+	c.now = c.now.Add(time.Minute)
+	id, err := manager.Activate(ctx, pending)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.NotEqual(t, pending, id)
+	assert.Len(t, sessions.sessions, 1)
+
+	user, _, err := manager.Validate(ctx, id)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(7), user.ID)
+
+	_, err = manager.Activate(ctx, pending)
+	assert.ErrorIs(t, err, authsession.ErrNotFound, "a pending session is activated once")
+	_, err = manager.PendingUser(ctx, id)
+	assert.ErrorIs(t, err, authsession.ErrNotFound)
+
+	late, _ := manager.CreatePending(ctx, 7, authsession.Client{})
+	c.now = c.now.Add(authsession.PendingTTL + time.Second)
+	_, err = manager.Activate(ctx, late)
+	assert.ErrorIs(t, err, authsession.ErrExpired)
+}
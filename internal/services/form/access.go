@@ -0,0 +1,104 @@
+package form
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"time"
+
+	"go-form-hub/internal/model"
+	resp "go-form-hub/internal/services/service_response"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// AccessGrantTTL is how long a respondent may fill in a form after unlocking it.
+	AccessGrantTTL = time.Hour
+	// UnlockMaxFailures wrong passwords within UnlockWindow block the client from unlocking the form.
+	UnlockMaxFailures = 5
+	UnlockWindow      = 15 * time.Minute
+)
+
+var (
+	ErrWrongAccessPassword   = errors.New("wrong access password")
+	ErrTooManyUnlockAttempts = errors.New("too many wrong passwords, try again later")
+	ErrNoAccessPassword      = errors.New("the form has no access password")
+)
+
+// GrantSigner signs access grants, api.HashToken implements it.
+type GrantSigner interface {
+	Create(subject string, expiration int64) (string, error)
+	Check(subject, token string) (bool, error)
+}
+
+// UnlockLimiter limits wrong password attempts, ratelimit.Limiter implements it.
+type UnlockLimiter interface {
+	Allow(key string) (bool, time.Duration)
+	Fail(key string)
+	Reset(key string)
+}
+
+// FormUnlock checks the access password of the form and returns a grant to open and pass it.
+// Wrong passwords are counted per client and form.
+func (s *formService) FormUnlock(ctx context.Context, id int64, unlock *model.FormUnlock, client string) (*resp.Response, error) {
+	if err := s.validate.Struct(unlock); err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), err
+	}
+
+	form, err := s.formRepository.FindByID(ctx, id)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if form == nil {
+		return resp.NewResponse(http.StatusNotFound, nil), nil
+	}
+
+	if !form.PasswordProtected {
+		return resp.NewResponse(http.StatusBadRequest, nil), ErrNoAccessPassword
+	}
+
+	key := fmt.Sprintf("%s:%d", client, id)
+	if allowed, retryAfter := s.unlockLimiter.Allow(key); !allowed {
+		seconds := int(math.Ceil(retryAfter.Seconds()))
+		return resp.NewResponse(http.StatusTooManyRequests, &model.RetryAfter{Seconds: seconds}), ErrTooManyUnlockAttempts
+	}
+
+	if bcrypt.CompareHashAndPassword([]byte(form.PasswordHash), []byte(unlock.Password)) != nil {
+		s.unlockLimiter.Fail(key)
+		return resp.NewResponse(http.StatusForbidden, nil), ErrWrongAccessPassword
+	}
+	s.unlockLimiter.Reset(key)
+
+	expiresAt := time.Now().UTC().Add(AccessGrantTTL).Truncate(time.Second)
+	grant, err := s.grants.Create(form.AccessGrantSubject(), expiresAt.Unix())
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	return resp.NewResponse(http.StatusOK, &model.FormAccessGrant{
+		Grant:     grant,
+		ExpiresAt: expiresAt,
+	}), nil
+}
+
+// hasAccessGrant reports whether the grant was issued for the form and has not expired.
+func (s *formService) hasAccessGrant(form *model.Form, grant string) bool {
+	if grant == "" {
+		return false
+	}
+
+	valid, err := s.grants.Check(form.AccessGrantSubject(), grant)
+	return err == nil && valid
+}
+
+func hashAccessPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
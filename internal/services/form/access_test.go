@@ -0,0 +1,209 @@
+package form_test
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/api"
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/form"
+	"go-form-hub/internal/services/ratelimit"
+
+	validator "github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type fakeFormRepository struct {
+	repository.FormRepository
+
+	form *model.Form
+}
+
+func (r *fakeFormRepository) FindByID(_ context.Context, _ int64) (*model.Form, error) {
+	form := *r.form
+	return &form, nil
+}
+
+func (r *fakeFormRepository) UserFormPassageCount(_ context.Context, _ int64, _ int64) (int64, error) {
+	return 0, nil
+}
+
+func (r *fakeFormRepository) FormResults(_ context.Context, id int64, _ *model.FormResultFilter) (*model.FormResult, error) {
+	return &model.FormResult{ID: id, Author: &model.UserGet{ID: 1}}, nil
+}
+
+func (r *fakeFormRepository) FormCrossTab(_ context.Context, id, _, _ int64, _ *model.FormResultFilter) (*model.FormCrossTab, error) {
+	return &model.FormCrossTab{FormID: id, RowQuestion: &model.CrossTabQuestion{}, ColumnQuestion: &model.CrossTabQuestion{}}, nil
+}
+
+func (r *fakeFormRepository) FormTimeline(_ context.Context, id int64, interval string, _ *time.Location, _ *model.FormResultFilter) (*model.FormTimeline, error) {
+	return &model.FormTimeline{FormID: id, Interval: interval}, nil
+}
+
+func (r *fakeFormRepository) FormTextAnalytics(_ context.Context, formID, questionID int64, _ *model.TextAnalyticsParams) (*model.TextAnalytics, error) {
+	return &model.TextAnalytics{FormID: formID, QuestionID: questionID, Type: model.InputAnswerType}, nil
+}
+
+func newProtectedForm(t *testing.T, password string) *model.Form {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := int64(4)
+	return &model.Form{
+		ID:                &id,
+		Title:             "Staff survey",
+		PasswordProtected: true,
+		PasswordHash:      string(hash),
+		Author:            &model.UserGet{ID: 1, Username: "author"},
+		Questions:         []*model.Question{{Title: "Salary"}},
+	}
+}
+
+func newService(protected *model.Form) form.Service {
+	return form.NewFormService(&fakeFormRepository{form: protected}, nil, nil, nil, api.NewHMACHashToken("secret"),
+		ratelimit.NewLimiter(form.UnlockMaxFailures, form.UnlockWindow, time.Now), validator.New())
+}
+
+func TestFormUnlock(t *testing.T) {
+	respondent := context.WithValue(context.Background(), model.ContextCurrentUser, &model.UserGet{ID: 2})
+
+	t.Run("Unlocked", func(t *testing.T) {
+		t.Parallel()
+		service := newService(newProtectedForm(t, "letmein"))
+
+		result, err := service.FormGet(respondent, 4, &model.FormAccess{})
+		assert.Nil(t, err)
+		locked := result.Body.(*model.Form)
+		assert.True(t, locked.Locked)
+		assert.Empty(t, locked.Questions)
+		assert.Equal(t, "Staff survey", locked.Title)
+
+		result, err = service.FormUnlock(respondent, 4, &model.FormUnlock{Password: "letmein"}, "10.0.0.1")
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode)
+		grant := result.Body.(*model.FormAccessGrant)
+		assert.True(t, grant.ExpiresAt.After(time.Now().Add(form.AccessGrantTTL-time.Minute)))
+
+		result, err = service.FormGet(respondent, 4, &model.FormAccess{Grant: grant.Grant})
+		assert.Nil(t, err)
+		unlocked := result.Body.(*model.Form)
+		assert.False(t, unlocked.Locked)
+		assert.Equal(t, 1, len(unlocked.Questions))
+	})
+
+	t.Run("GrantOfOldPassword", func(t *testing.T) {
+		t.Parallel()
+		service := newService(newProtectedForm(t, "letmein"))
+
+		result, _ := service.FormUnlock(respondent, 4, &model.FormUnlock{Password: "letmein"}, "10.0.0.1")
+		grant := result.Body.(*model.FormAccessGrant)
+
+		changed := newService(newProtectedForm(t, "letmein"))
+		result, err := changed.FormGet(respondent, 4, &model.FormAccess{Grant: grant.Grant})
+		assert.Nil(t, err)
+		assert.True(t, result.Body.(*model.Form).Locked, "a new password hash revokes the grant")
+	})
+
+	t.Run("AuthorSeesQuestions", func(t *testing.T) {
+		t.Parallel()
+		service := newService(newProtectedForm(t, "letmein"))
+		author := context.WithValue(context.Background(), model.ContextCurrentUser, &model.UserGet{ID: 1})
+
+		result, err := service.FormGet(author, 4, &model.FormAccess{})
+		assert.Nil(t, err)
+		assert.False(t, result.Body.(*model.Form).Locked)
+	})
+
+	t.Run("RateLimited", func(t *testing.T) {
+		t.Parallel()
+		service := newService(newProtectedForm(t, "letmein"))
+
+		for i := 0; i < form.UnlockMaxFailures; i++ {
+			result, err := service.FormUnlock(respondent, 4, &model.FormUnlock{Password: "guess"}, "10.0.0.2")
+			assert.ErrorIs(t, err, form.ErrWrongAccessPassword)
+			assert.Equal(t, http.StatusForbidden, result.StatusCode)
+		}
+
+		result, err := service.FormUnlock(respondent, 4, &model.FormUnlock{Password: "letmein"}, "10.0.0.2")
+		assert.ErrorIs(t, err, form.ErrTooManyUnlockAttempts)
+		assert.Equal(t, http.StatusTooManyRequests, result.StatusCode)
+		assert.Greater(t, result.Body.(*model.RetryAfter).Seconds, 0)
+
+		result, err = service.FormUnlock(respondent, 4, &model.FormUnlock{Password: "letmein"}, "10.0.0.3")
+		assert.Nil(t, err, "other clients are not blocked")
+		assert.Equal(t, http.StatusOK, result.StatusCode)
+	})
+}
+
+func TestFormResultsAnalyticsAccess(t *testing.T) {
+	service := newService(newProtectedForm(t, "letmein"))
+	author := context.WithValue(context.Background(), model.ContextCurrentUser, &model.UserGet{ID: 1})
+	other := context.WithValue(context.Background(), model.ContextCurrentUser, &model.UserGet{ID: 2})
+
+	result, err := service.FormResults(other, 4, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, result.StatusCode)
+
+	result, err = service.FormResults(author, 4, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+
+	result, err = service.FormCrossTab(other, 4, 10, 11, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, result.StatusCode)
+
+	result, err = service.FormCrossTab(author, 4, 10, 11, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+
+	result, err = service.FormTimeline(other, 4, model.TimelineDay, time.UTC, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, result.StatusCode)
+
+	result, err = service.FormTimeline(author, 4, model.TimelineDay, time.UTC, nil)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+
+	params := &model.TextAnalyticsParams{Page: 1, PerPage: 20, Top: 10, Similarity: 0.5}
+	result, err = service.FormTextAnalytics(other, 4, 10, params)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusForbidden, result.StatusCode)
+
+	result, err = service.FormTextAnalytics(author, 4, 10, params)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+}
+
+func TestFormStartedAt(t *testing.T) {
+	service := newService(newProtectedForm(t, "letmein"))
+	respondent := context.WithValue(context.Background(), model.ContextCurrentUser, &model.UserGet{ID: 2})
+
+	result, err := service.FormGet(respondent, 4, &model.FormAccess{})
+	assert.Nil(t, err)
+	assert.Empty(t, result.Body.(*model.Form).StartToken, "a locked form is not started")
+
+	result, _ = service.FormUnlock(respondent, 4, &model.FormUnlock{Password: "letmein"}, "10.0.0.1")
+	grant := result.Body.(*model.FormAccessGrant).Grant
+
+	before := time.Now().UTC().Truncate(time.Second)
+	result, err = service.FormGet(respondent, 4, &model.FormAccess{Grant: grant})
+	assert.Nil(t, err)
+	token := result.Body.(*model.Form).StartToken
+
+	startedAt := service.FormStartedAt(4, token)
+	if assert.NotNil(t, startedAt) {
+		assert.False(t, startedAt.Before(before))
+		assert.False(t, startedAt.After(time.Now().UTC()))
+	}
+
+	assert.Nil(t, service.FormStartedAt(5, token), "the token belongs to one form")
+	assert.Nil(t, service.FormStartedAt(4, "1."+token[strings.Index(token, ".")+1:]), "the start time is signed")
+	assert.Nil(t, service.FormStartedAt(4, ""))
+}
@@ -2,52 +2,81 @@ package form
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
 
 	"go-form-hub/internal/model"
 	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/formimport"
 	resp "go-form-hub/internal/services/service_response"
 
 	validator "github.com/go-playground/validator/v10"
 	"github.com/microcosm-cc/bluemonday"
 )
 
+var (
+	ErrSameCrossTabQuestion = errors.New("cross tabulation requires two different questions")
+	ErrUnknownInterval      = errors.New("timeline interval must be one of hour, day or week")
+	ErrNotInputQuestion     = errors.New("text analytics is only available for input questions")
+)
+
 type Service interface {
 	FormSave(ctx context.Context, form *model.Form) (*resp.Response, error)
+	FormImport(ctx context.Context, source string, definition []byte, dryRun bool) (*resp.Response, error)
 	FormUpdate(ctx context.Context, id int64, form *model.FormUpdate) (*resp.Response, error)
 	FormList(ctx context.Context) (*resp.Response, error)
 	FormListByUser(ctx context.Context, username string) (*resp.Response, error)
 	FormDelete(ctx context.Context, id int64) (*resp.Response, error)
-	FormGet(ctx context.Context, id int64) (*resp.Response, error)
+	FormGet(ctx context.Context, id int64, access *model.FormAccess) (*resp.Response, error)
+	FormUnlock(ctx context.Context, id int64, unlock *model.FormUnlock, client string) (*resp.Response, error)
+	FormStartedAt(formID int64, token string) *time.Time
+	FormClose(ctx context.Context, id int64) (*resp.Response, error)
+	FormPublish(ctx context.Context, id int64) (*resp.Response, error)
 	FormSearch(ctx context.Context, title string, userID uint) (*resp.Response, error)
-	FormResults(ctx context.Context, id int64) (*resp.Response, error)
+	FormResults(ctx context.Context, id int64, filter *model.FormResultFilter) (*resp.Response, error)
+	FormResultsAccess(ctx context.Context, id int64) (*resp.Response, error)
+	FormCrossTab(ctx context.Context, id, rowQuestionID, columnQuestionID int64, filter *model.FormResultFilter) (*resp.Response, error)
+	FormTimeline(ctx context.Context, id int64, interval string, location *time.Location, filter *model.FormResultFilter) (*resp.Response, error)
+	FormTextAnalytics(ctx context.Context, formID, questionID int64, params *model.TextAnalyticsParams) (*resp.Response, error)
 	FormResultsCsv(ctx context.Context, formID int64) ([]byte, error)
 	FormResultsExel(ctx context.Context, formID int64) ([]byte, error)
 }
 
 type formService struct {
-	formRepository     repository.FormRepository
-	questionRepository repository.QuestionRepository
-	answerRepository   repository.AnswerRepository
-	sanitizer          *bluemonday.Policy
-	validate           *validator.Validate
+	formRepository      repository.FormRepository
+	questionRepository  repository.QuestionRepository
+	answerRepository    repository.AnswerRepository
+	recipientRepository repository.RecipientRepository
+	grants              GrantSigner
+	unlockLimiter       UnlockLimiter
+	sanitizer           *bluemonday.Policy
+	validate            *validator.Validate
 }
 
-func NewFormService(formRepository repository.FormRepository, questionRepository repository.QuestionRepository, answerRepository repository.AnswerRepository, validate *validator.Validate) Service {
+func NewFormService(formRepository repository.FormRepository, questionRepository repository.QuestionRepository, answerRepository repository.AnswerRepository,
+	recipientRepository repository.RecipientRepository, grants GrantSigner, unlockLimiter UnlockLimiter, validate *validator.Validate) Service {
 	sanitizer := bluemonday.UGCPolicy()
 	return &formService{
-		formRepository:     formRepository,
-		validate:           validate,
-		questionRepository: questionRepository,
-		sanitizer:          sanitizer,
-		answerRepository:   answerRepository,
+		formRepository:      formRepository,
+		validate:            validate,
+		questionRepository:  questionRepository,
+		sanitizer:           sanitizer,
+		answerRepository:    answerRepository,
+		recipientRepository: recipientRepository,
+		grants:              grants,
+		unlockLimiter:       unlockLimiter,
 	}
 }
 
-func (s *formService) FormResults(ctx context.Context, formID int64) (*resp.Response, error) {
-	formResults, err := s.formRepository.FormResults(ctx, formID)
+func (s *formService) FormResults(ctx context.Context, formID int64, filter *model.FormResultFilter) (*resp.Response, error) {
+	access, err := s.FormResultsAccess(ctx, formID)
+	if err != nil || access.StatusCode != http.StatusOK {
+		return access, err
+	}
+
+	formResults, err := s.formRepository.FormResults(ctx, formID, filter)
 	if err != nil {
 		return resp.NewResponse(http.StatusInternalServerError, nil), err
 	}
@@ -60,6 +89,100 @@ func (s *formService) FormResults(ctx context.Context, formID int64) (*resp.Resp
 	return resp.NewResponse(http.StatusOK, formResults), nil
 }
 
+// FormResultsAccess checks that the form exists and belongs to the current user.
+func (s *formService) FormResultsAccess(ctx context.Context, formID int64) (*resp.Response, error) {
+	currentUser := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
+
+	existing, err := s.formRepository.FindByID(ctx, formID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if existing == nil {
+		return resp.NewResponse(http.StatusNotFound, nil), nil
+	}
+
+	if existing.Author.ID != currentUser.ID {
+		return resp.NewResponse(http.StatusForbidden, nil), nil
+	}
+
+	return resp.NewResponse(http.StatusOK, nil), nil
+}
+
+func (s *formService) FormCrossTab(ctx context.Context, formID, rowQuestionID, columnQuestionID int64, filter *model.FormResultFilter) (*resp.Response, error) {
+	if rowQuestionID == columnQuestionID {
+		return resp.NewResponse(http.StatusBadRequest, nil), ErrSameCrossTabQuestion
+	}
+
+	access, err := s.FormResultsAccess(ctx, formID)
+	if err != nil || access.StatusCode != http.StatusOK {
+		return access, err
+	}
+
+	crossTab, err := s.formRepository.FormCrossTab(ctx, formID, rowQuestionID, columnQuestionID, filter)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if crossTab == nil {
+		return resp.NewResponse(http.StatusNotFound, nil), nil
+	}
+	crossTab.Sanitize(s.sanitizer)
+
+	return resp.NewResponse(http.StatusOK, crossTab), nil
+}
+
+func (s *formService) FormTimeline(ctx context.Context, formID int64, interval string, location *time.Location, filter *model.FormResultFilter) (*resp.Response, error) {
+	switch interval {
+	case model.TimelineHour, model.TimelineDay, model.TimelineWeek:
+	default:
+		return resp.NewResponse(http.StatusBadRequest, nil), ErrUnknownInterval
+	}
+
+	access, err := s.FormResultsAccess(ctx, formID)
+	if err != nil || access.StatusCode != http.StatusOK {
+		return access, err
+	}
+
+	timeline, err := s.formRepository.FormTimeline(ctx, formID, interval, location, filter)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if timeline == nil {
+		return resp.NewResponse(http.StatusNotFound, nil), nil
+	}
+
+	return resp.NewResponse(http.StatusOK, timeline), nil
+}
+
+func (s *formService) FormTextAnalytics(ctx context.Context, formID, questionID int64, params *model.TextAnalyticsParams) (*resp.Response, error) {
+	if err := s.validate.Struct(params); err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), err
+	}
+
+	access, err := s.FormResultsAccess(ctx, formID)
+	if err != nil || access.StatusCode != http.StatusOK {
+		return access, err
+	}
+
+	analytics, err := s.formRepository.FormTextAnalytics(ctx, formID, questionID, params)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if analytics == nil {
+		return resp.NewResponse(http.StatusNotFound, nil), nil
+	}
+
+	if analytics.Type != model.InputAnswerType {
+		return resp.NewResponse(http.StatusBadRequest, nil), ErrNotInputQuestion
+	}
+	analytics.Sanitize(s.sanitizer)
+
+	return resp.NewResponse(http.StatusOK, analytics), nil
+}
+
 func (s *formService) FormResultsCsv(ctx context.Context, formID int64) ([]byte, error) {
 	FormResultsExelCsv, err := s.formRepository.FormResultsCsv(ctx, formID)
 	if err != nil {
@@ -95,6 +218,16 @@ func (s *formService) FormSave(ctx context.Context, form *model.Form) (*resp.Res
 	form.Author = currentUser
 	form.CreatedAt = time.Now().UTC()
 
+	if form.AccessPassword != nil && *form.AccessPassword != "" {
+		passwordHash, err := hashAccessPassword(*form.AccessPassword)
+		if err != nil {
+			return resp.NewResponse(http.StatusInternalServerError, nil), err
+		}
+		form.PasswordHash = passwordHash
+		form.PasswordProtected = true
+	}
+	form.AccessPassword = nil
+
 	result, err := s.formRepository.Insert(ctx, form, nil)
 	if err != nil {
 		return resp.NewResponse(http.StatusInternalServerError, nil), err
@@ -105,6 +238,35 @@ func (s *formService) FormSave(ctx context.Context, form *model.Form) (*resp.Res
 	return resp.NewResponse(http.StatusOK, result), nil
 }
 
+// FormImport converts a form definition exported by another tool and saves it unless dryRun is set.
+// The response lists the items that were left out or imported with changes.
+func (s *formService) FormImport(ctx context.Context, source string, definition []byte, dryRun bool) (*resp.Response, error) {
+	currentUser := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
+
+	formImport, err := formimport.Convert(source, definition)
+	if err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), err
+	}
+
+	if dryRun {
+		formImport.Form.Author = currentUser
+		formImport.Sanitize(s.sanitizer)
+		return resp.NewResponse(http.StatusOK, formImport), nil
+	}
+
+	response, err := s.FormSave(ctx, formImport.Form)
+	if err != nil || response.StatusCode != http.StatusOK {
+		return response, err
+	}
+
+	formImport.Form = response.Body.(*model.Form)
+	for _, issue := range formImport.Issues {
+		issue.Item = s.sanitizer.Sanitize(issue.Item)
+	}
+
+	return resp.NewResponse(http.StatusOK, formImport), nil
+}
+
 func (s *formService) FormUpdate(ctx context.Context, id int64, form *model.FormUpdate) (*resp.Response, error) {
 	if err := s.validate.Struct(form); err != nil {
 		return resp.NewResponse(http.StatusBadRequest, nil), err
@@ -125,6 +287,18 @@ func (s *formService) FormUpdate(ctx context.Context, id int64, form *model.Form
 		return resp.NewResponse(http.StatusForbidden, nil), nil
 	}
 
+	// an empty password removes the protection, a missing one keeps the current password
+	if form.AccessPassword != nil {
+		passwordHash := ""
+		if *form.AccessPassword != "" {
+			if passwordHash, err = hashAccessPassword(*form.AccessPassword); err != nil {
+				return resp.NewResponse(http.StatusInternalServerError, nil), err
+			}
+		}
+		form.PasswordHash = &passwordHash
+		form.AccessPassword = nil
+	}
+
 	form.Author = currentUser
 	formUpdate, err := s.formRepository.Update(ctx, id, form)
 	if err != nil {
@@ -243,7 +417,48 @@ func (s *formService) FormDelete(ctx context.Context, id int64) (*resp.Response,
 	return resp.NewResponse(http.StatusOK, nil), nil
 }
 
-func (s *formService) FormGet(ctx context.Context, id int64) (*resp.Response, error) {
+// FormClose stops the form from accepting passages. Closing a closed form changes nothing.
+func (s *formService) FormClose(ctx context.Context, id int64) (*resp.Response, error) {
+	return s.setFormClosed(ctx, id, true)
+}
+
+// FormPublish makes a closed form accept passages again. Publishing an open form changes nothing.
+func (s *formService) FormPublish(ctx context.Context, id int64) (*resp.Response, error) {
+	return s.setFormClosed(ctx, id, false)
+}
+
+func (s *formService) setFormClosed(ctx context.Context, id int64, closed bool) (*resp.Response, error) {
+	currentUser := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
+
+	existing, err := s.formRepository.FindByID(ctx, id)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if existing == nil {
+		return resp.NewResponse(http.StatusNotFound, nil), nil
+	}
+
+	if existing.Author.ID != currentUser.ID {
+		return resp.NewResponse(http.StatusForbidden, nil), nil
+	}
+
+	if closed {
+		_, err = s.formRepository.FormClose(ctx, id, time.Now().UTC())
+	} else {
+		_, err = s.formRepository.FormPublish(ctx, id)
+	}
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	return s.FormGet(ctx, id, &model.FormAccess{})
+}
+
+// FormGet returns the form with its questions. An invite-only form is shown to its author and
+// to whoever has an invitation token that is not used up yet. The questions of a password-protected
+// form are only returned to its author and with an access grant.
+func (s *formService) FormGet(ctx context.Context, id int64, access *model.FormAccess) (*resp.Response, error) {
 	form, err := s.formRepository.FindByID(ctx, id)
 	if err != nil {
 		return resp.NewResponse(http.StatusInternalServerError, nil), err
@@ -252,11 +467,24 @@ func (s *formService) FormGet(ctx context.Context, id int64) (*resp.Response, er
 	if form == nil {
 		return resp.NewResponse(http.StatusNotFound, nil), nil
 	}
-	form.Sanitize(s.sanitizer)
 
-	if ctx.Value(model.ContextCurrentUser) != nil {
-		currentUser := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
+	currentUser, _ := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
+	isAuthor := currentUser != nil && form.Author.ID == currentUser.ID
+
+	if form.InviteOnly && !isAuthor {
+		if response, err := s.checkInvitation(ctx, form, access.Token); response != nil {
+			return response, err
+		}
+	}
 
+	if form.PasswordProtected && !isAuthor && !s.hasAccessGrant(form, access.Grant) {
+		form.Lock()
+	} else if form.StartToken, err = s.startToken(*form.ID, time.Now().UTC()); err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+	form.Sanitize(s.sanitizer)
+
+	if currentUser != nil {
 		total, err := s.formRepository.UserFormPassageCount(ctx, *form.ID, currentUser.ID)
 		if err != nil {
 			return resp.NewResponse(http.StatusInternalServerError, nil), nil
@@ -269,6 +497,23 @@ func (s *formService) FormGet(ctx context.Context, id int64) (*resp.Response, er
 	return resp.NewResponse(http.StatusOK, form), nil
 }
 
+func (s *formService) checkInvitation(ctx context.Context, form *model.Form, accessToken string) (*resp.Response, error) {
+	if accessToken == "" {
+		return resp.NewResponse(http.StatusForbidden, nil), nil
+	}
+
+	recipient, err := s.recipientRepository.FindByToken(ctx, *form.ID, accessToken)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if recipient == nil || recipient.Uses >= recipient.MaxUses {
+		return resp.NewResponse(http.StatusForbidden, nil), nil
+	}
+
+	return nil, nil
+}
+
 func (s *formService) FormSearch(ctx context.Context, title string, userID uint) (*resp.Response, error) {
 	forms, err := s.formRepository.FormsSearch(ctx, title, userID)
 	if err != nil {
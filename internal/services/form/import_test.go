@@ -0,0 +1,85 @@
+package form_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/form"
+	"go-form-hub/internal/services/formimport"
+
+	validator "github.com/go-playground/validator/v10"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+type insertingFormRepository struct {
+	repository.FormRepository
+
+	inserted []*model.Form
+}
+
+func (r *insertingFormRepository) Insert(_ context.Context, form *model.Form, _ pgx.Tx) (*model.Form, error) {
+	id := int64(len(r.inserted) + 1)
+	form.ID = &id
+	r.inserted = append(r.inserted, form)
+	return form, nil
+}
+
+const googleDefinition = `{
+	"formId": "1FAIp",
+	"info": {"title": "Lunch"},
+	"items": [
+		{"itemId": "a", "title": "Main course", "questionItem": {"question": {"required": true,
+			"choiceQuestion": {"type": "RADIO", "options": [{"value": "Fish"}, {"value": "Pasta"}, {"isOther": true}]}}}},
+		{"itemId": "b", "title": "Menu", "imageItem": {}}
+	]
+}`
+
+func TestFormImport(t *testing.T) {
+	author := context.WithValue(context.Background(), model.ContextCurrentUser, &model.UserGet{ID: 3, Username: "author"})
+
+	t.Run("Saved", func(t *testing.T) {
+		t.Parallel()
+		repo := &insertingFormRepository{}
+		service := form.NewFormService(repo, nil, nil, nil, nil, nil, validator.New())
+
+		result, err := service.FormImport(author, formimport.SourceGoogleForms, []byte(googleDefinition), false)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode)
+
+		formImport := result.Body.(*model.FormImport)
+		assert.Equal(t, int64(1), *formImport.Form.ID)
+		assert.Equal(t, int64(3), formImport.Form.Author.ID)
+		assert.Len(t, formImport.Issues, 2)
+		if assert.Len(t, repo.inserted, 1) {
+			assert.Equal(t, "Lunch", repo.inserted[0].Title)
+			assert.Len(t, repo.inserted[0].Questions[0].Answers, 2)
+		}
+	})
+
+	t.Run("DryRun", func(t *testing.T) {
+		t.Parallel()
+		repo := &insertingFormRepository{}
+		service := form.NewFormService(repo, nil, nil, nil, nil, nil, validator.New())
+
+		result, err := service.FormImport(author, formimport.SourceGoogleForms, []byte(googleDefinition), true)
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusOK, result.StatusCode)
+		assert.Nil(t, result.Body.(*model.FormImport).Form.ID)
+		assert.Empty(t, repo.inserted)
+	})
+
+	t.Run("UnknownSource", func(t *testing.T) {
+		t.Parallel()
+		repo := &insertingFormRepository{}
+		service := form.NewFormService(repo, nil, nil, nil, nil, nil, validator.New())
+
+		result, err := service.FormImport(author, "jotform", []byte(googleDefinition), false)
+		assert.ErrorIs(t, err, formimport.ErrUnknownSource)
+		assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+		assert.Empty(t, repo.inserted)
+	})
+}
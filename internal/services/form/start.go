@@ -0,0 +1,46 @@
+package form
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"go-form-hub/internal/model"
+)
+
+// StartTokenTTL is how long after opening a form a passage still records when it started.
+const StartTokenTTL = 24 * time.Hour
+
+// startToken signs the time the form is opened, so that completion times do not rely on the clock
+// of the client. The time goes in front of the signature, which covers it through the subject.
+func (s *formService) startToken(formID int64, now time.Time) (string, error) {
+	startedAt := now.Unix()
+	signature, err := s.grants.Create(model.StartSubject(formID, startedAt), now.Add(StartTokenTTL).Unix())
+	if err != nil {
+		return "", err
+	}
+
+	return strconv.FormatInt(startedAt, 10) + "." + signature, nil
+}
+
+// FormStartedAt returns when the form was opened according to the start token, nil if the token
+// is missing, forged, issued for another form or expired.
+func (s *formService) FormStartedAt(formID int64, token string) *time.Time {
+	startedAtText, signature, found := strings.Cut(token, ".")
+	if !found {
+		return nil
+	}
+
+	startedAt, err := strconv.ParseInt(startedAtText, 10, 64)
+	if err != nil {
+		return nil
+	}
+
+	valid, err := s.grants.Check(model.StartSubject(formID, startedAt), signature)
+	if err != nil || !valid {
+		return nil
+	}
+
+	started := time.Unix(startedAt, 0).UTC()
+	return &started
+}
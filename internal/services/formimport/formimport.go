@@ -0,0 +1,146 @@
+// Package formimport converts form definitions exported by other tools into model.Form.
+package formimport
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"go-form-hub/internal/model"
+)
+
+const (
+	SourceGoogleForms = "google_forms"
+	SourceTypeform    = "typeform"
+
+	untitledForm     = "Imported form"
+	untitledQuestion = "Untitled question"
+	noPassageLimit   = -1
+)
+
+var (
+	ErrUnknownSource     = errors.New("source must be one of google_forms or typeform")
+	ErrInvalidDefinition = errors.New("the definition is not a valid export")
+	ErrNoQuestions       = errors.New("the definition has no question that can be imported")
+)
+
+// Convert converts the definition exported by the source. Items that could not be mapped, or were
+// mapped with changes, are listed in the issues of the result.
+func Convert(source string, definition []byte) (*model.FormImport, error) {
+	switch source {
+	case SourceGoogleForms:
+		return FromGoogleForms(definition)
+	case SourceTypeform:
+		return FromTypeform(definition)
+	default:
+		return nil, ErrUnknownSource
+	}
+}
+
+// builder collects the questions and issues of a form being converted.
+type builder struct {
+	formImport *model.FormImport
+}
+
+func newBuilder(title, description string) *builder {
+	form := &model.Form{
+		Title:      strings.TrimSpace(title),
+		PassageMax: noPassageLimit,
+		Questions:  []*model.Question{},
+	}
+	if form.Title == "" {
+		form.Title = untitledForm
+	}
+	if description = strings.TrimSpace(description); description != "" {
+		form.Description = &description
+	}
+
+	return &builder{
+		formImport: &model.FormImport{
+			Form:   form,
+			Issues: []*model.FormImportIssue{},
+		},
+	}
+}
+
+func (b *builder) addQuestion(item, title, description string, questionType int, required bool, answers []string) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		title = untitledQuestion
+		b.warn(item, "the question has no title")
+	}
+
+	question := &model.Question{
+		Title:    title,
+		Type:     questionType,
+		Required: required,
+		Position: len(b.formImport.Form.Questions) + 1,
+	}
+	if description = strings.TrimSpace(description); description != "" {
+		question.Description = &description
+	}
+
+	seen := map[string]bool{}
+	for _, text := range answers {
+		text = strings.TrimSpace(text)
+		if text == "" || seen[text] {
+			continue
+		}
+		seen[text] = true
+		question.Answers = append(question.Answers, &model.Answer{Text: text})
+	}
+
+	if questionType != model.InputAnswerType && len(question.Answers) == 0 {
+		b.skip(item, "the question has no choices")
+		return
+	}
+
+	b.formImport.Form.Questions = append(b.formImport.Form.Questions, question)
+}
+
+func (b *builder) skip(item, reason string) {
+	b.formImport.Issues = append(b.formImport.Issues, &model.FormImportIssue{Item: item, Skipped: true, Reason: reason})
+}
+
+func (b *builder) warn(item, reason string) {
+	b.formImport.Issues = append(b.formImport.Issues, &model.FormImportIssue{Item: item, Reason: reason})
+}
+
+func (b *builder) result() (*model.FormImport, error) {
+	if len(b.formImport.Form.Questions) == 0 {
+		return nil, ErrNoQuestions
+	}
+	return b.formImport, nil
+}
+
+// maxScalePoints is the longest scale the form builders offer, from 0 to 10.
+const maxScalePoints = 11
+
+// validScale reports whether a scale starts at 0 or 1 and has at most maxScalePoints points. The
+// bounds come from the uploaded definition, a reversed or huge scale is skipped.
+func validScale(low, high int) bool {
+	return (low == 0 || low == 1) && high > low && high-low < maxScalePoints
+}
+
+// scaleSkipReason explains why a scale that is not valid is skipped.
+func scaleSkipReason(low, high int) string {
+	return fmt.Sprintf("the scale from %d to %d is not supported", low, high)
+}
+
+// scaleAnswers lists the points of a linear scale as answers of a single answer question.
+func scaleAnswers(low, high int) []string {
+	answers := make([]string, 0, high-low+1)
+	for point := low; point <= high; point++ {
+		answers = append(answers, strconv.Itoa(point))
+	}
+	return answers
+}
+
+// itemName names an item in issues by its title, or by its ID when it has none.
+func itemName(title, id string) string {
+	if title = strings.TrimSpace(title); title != "" {
+		return title
+	}
+	return fmt.Sprintf("item %s", id)
+}
@@ -0,0 +1,157 @@
+package formimport_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/services/formimport"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type expectedQuestion struct {
+	title    string
+	kind     int
+	required bool
+	answers  []string
+}
+
+func readFixture(t *testing.T, name string) []byte {
+	definition, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read fixture: %v", err)
+	}
+	return definition
+}
+
+func assertQuestions(t *testing.T, expected []expectedQuestion, questions []*model.Question) {
+	if !assert.Len(t, questions, len(expected)) {
+		return
+	}
+
+	for i, question := range questions {
+		answers := []string{}
+		for _, answer := range question.Answers {
+			answers = append(answers, answer.Text)
+		}
+
+		assert.Equal(t, expected[i].title, question.Title)
+		assert.Equal(t, expected[i].kind, question.Type, question.Title)
+		assert.Equal(t, expected[i].required, question.Required, question.Title)
+		assert.Equal(t, expected[i].answers, answers, question.Title)
+		assert.Equal(t, i+1, question.Position)
+	}
+}
+
+func issuesByItem(issues []*model.FormImportIssue) map[string][]*model.FormImportIssue {
+	byItem := map[string][]*model.FormImportIssue{}
+	for _, issue := range issues {
+		byItem[issue.Item] = append(byItem[issue.Item], issue)
+	}
+	return byItem
+}
+
+func TestFromGoogleForms(t *testing.T) {
+	result, err := formimport.Convert(formimport.SourceGoogleForms, readFixture(t, "google_forms.json"))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	form := result.Form
+	assert.Equal(t, "Team offsite survey", form.Title)
+	assert.Equal(t, "Help us plan the next offsite.", *form.Description)
+	assert.Equal(t, -1, form.PassageMax)
+
+	assertQuestions(t, []expectedQuestion{
+		{title: "Your name", kind: model.InputAnswerType, required: true, answers: []string{}},
+		{title: "Preferred month", kind: model.SingleAnswerType, required: true, answers: []string{"June", "July"}},
+		{title: "Activities", kind: model.MultipleAnswerType, answers: []string{"Hiking", "Kayaking", "Board games"}},
+		{title: "How much did you like the last one?", kind: model.SingleAnswerType, answers: []string{"1", "2", "3", "4", "5"}},
+		{title: "Dietary needs", kind: model.SingleAnswerType, answers: []string{"None", "Vegetarian", "Vegan"}},
+		{title: "Rate the sessions: Keynote", kind: model.SingleAnswerType, required: true, answers: []string{"Poor", "Good", "Great"}},
+		{title: "Rate the sessions: Workshops", kind: model.SingleAnswerType, answers: []string{"Poor", "Good", "Great"}},
+		{title: "Arrival date", kind: model.InputAnswerType, answers: []string{}},
+	}, form.Questions)
+	assert.Equal(t, "Pick one", *form.Questions[1].Description)
+
+	issues := issuesByItem(result.Issues)
+	assert.Len(t, result.Issues, 11)
+	for _, item := range []string{"Second section", "Upload a photo", "Venue map", "Reversed scale", "Negative rating", "Huge scale"} {
+		if assert.Len(t, issues[item], 1, item) {
+			assert.True(t, issues[item][0].Skipped, item)
+		}
+	}
+	for _, item := range []string{"Preferred month", "How much did you like the last one?", "Dietary needs", "Rate the sessions", "Arrival date"} {
+		if assert.Len(t, issues[item], 1, item) {
+			assert.False(t, issues[item][0].Skipped, item)
+		}
+	}
+}
+
+func TestFromTypeform(t *testing.T) {
+	result, err := formimport.Convert(formimport.SourceTypeform, readFixture(t, "typeform.json"))
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	form := result.Form
+	assert.Equal(t, "Customer feedback", form.Title)
+	assert.Nil(t, form.Description)
+
+	assertQuestions(t, []expectedQuestion{
+		{title: "What is your email?", kind: model.InputAnswerType, required: true, answers: []string{}},
+		{title: "Which plan are you on?", kind: model.SingleAnswerType, required: true, answers: []string{"Free", "Pro"}},
+		{title: "Which features do you use?", kind: model.MultipleAnswerType, answers: []string{"Reports", "Exports", "Webhooks"}},
+		{
+			title:   "How likely are you to recommend us?",
+			kind:    model.SingleAnswerType,
+			answers: []string{"0", "1", "2", "3", "4", "5", "6", "7", "8", "9", "10"},
+		},
+		{title: "Would you buy again?", kind: model.SingleAnswerType, answers: []string{"Yes", "No"}},
+		{title: "Company size", kind: model.SingleAnswerType, answers: []string{"1-10", "11-50"}},
+		{title: "Anything else?", kind: model.InputAnswerType, answers: []string{}},
+		{title: "Rate our support", kind: model.SingleAnswerType, answers: []string{"1", "2", "3", "4", "5"}},
+	}, form.Questions)
+	assert.Equal(t, "As shown on your invoice", *form.Questions[1].Description)
+
+	issues := issuesByItem(result.Issues)
+	for _, item := range []string{"Thanks for sharing", "Attach a screenshot", "utm_source", "Negative rating", "Huge scale"} {
+		if assert.Len(t, issues[item], 1, item) {
+			assert.True(t, issues[item][0].Skipped, item)
+		}
+	}
+	assert.Len(t, issues["Customer feedback"], 1)
+	assert.Len(t, issues["Which plan are you on?"], 1)
+	assert.Len(t, issues["How likely are you to recommend us?"], 1)
+	assert.Len(t, issues["About you"], 1)
+}
+
+func TestConvertRejected(t *testing.T) {
+	tests := []struct {
+		name       string
+		source     string
+		definition string
+		err        error
+	}{
+		{name: "UnknownSource", source: "surveymonkey", definition: `{}`, err: formimport.ErrUnknownSource},
+		{name: "NotJSON", source: formimport.SourceGoogleForms, definition: `<html>`, err: formimport.ErrInvalidDefinition},
+		{name: "NotAnExport", source: formimport.SourceTypeform, definition: `{"name": "x"}`, err: formimport.ErrInvalidDefinition},
+		{
+			name:       "NoQuestions",
+			source:     formimport.SourceGoogleForms,
+			definition: `{"formId": "1", "items": [{"itemId": "a", "title": "Intro", "textItem": {}}]}`,
+			err:        formimport.ErrNoQuestions,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			result, err := formimport.Convert(test.source, []byte(test.definition))
+			assert.Nil(t, result)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package formimport
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go-form-hub/internal/model"
+)
+
+// googleForm is the part of a Form resource of the Google Forms API the import reads.
+type googleForm struct {
+	FormID string `json:"formId"`
+	Info   struct {
+		Title         string `json:"title"`
+		DocumentTitle string `json:"documentTitle"`
+		Description   string `json:"description"`
+	} `json:"info"`
+	Items []*googleItem `json:"items"`
+}
+
+type googleItem struct {
+	ItemID            string           `json:"itemId"`
+	Title             string           `json:"title"`
+	Description       string           `json:"description"`
+	QuestionItem      *googleQuestion  `json:"questionItem"`
+	QuestionGroupItem *googleGroup     `json:"questionGroupItem"`
+	PageBreakItem     *json.RawMessage `json:"pageBreakItem"`
+	TextItem          *json.RawMessage `json:"textItem"`
+	ImageItem         *json.RawMessage `json:"imageItem"`
+	VideoItem         *json.RawMessage `json:"videoItem"`
+}
+
+type googleQuestion struct {
+	Question struct {
+		Required           bool                  `json:"required"`
+		Grading            *json.RawMessage      `json:"grading"`
+		ChoiceQuestion     *googleChoiceQuestion `json:"choiceQuestion"`
+		TextQuestion       *json.RawMessage      `json:"textQuestion"`
+		ScaleQuestion      *googleScaleQuestion  `json:"scaleQuestion"`
+		RatingQuestion     *googleRatingQuestion `json:"ratingQuestion"`
+		DateQuestion       *json.RawMessage      `json:"dateQuestion"`
+		TimeQuestion       *json.RawMessage      `json:"timeQuestion"`
+		FileUploadQuestion *json.RawMessage      `json:"fileUploadQuestion"`
+	} `json:"question"`
+}
+
+type googleChoiceQuestion struct {
+	Type    string `json:"type"`
+	Options []struct {
+		Value         string `json:"value"`
+		IsOther       bool   `json:"isOther"`
+		GoToAction    string `json:"goToAction"`
+		GoToSectionID string `json:"goToSectionId"`
+	} `json:"options"`
+}
+
+type googleScaleQuestion struct {
+	Low       int    `json:"low"`
+	High      int    `json:"high"`
+	LowLabel  string `json:"lowLabel"`
+	HighLabel string `json:"highLabel"`
+}
+
+type googleRatingQuestion struct {
+	RatingScaleLevel int `json:"ratingScaleLevel"`
+}
+
+type googleGroup struct {
+	Questions []struct {
+		Required    bool `json:"required"`
+		RowQuestion struct {
+			Title string `json:"title"`
+		} `json:"rowQuestion"`
+	} `json:"questions"`
+	Grid *struct {
+		Columns googleChoiceQuestion `json:"columns"`
+	} `json:"grid"`
+}
+
+// FromGoogleForms converts a form returned by forms.get of the Google Forms API.
+func FromGoogleForms(definition []byte) (*model.FormImport, error) {
+	var form googleForm
+	if err := json.Unmarshal(definition, &form); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDefinition, err)
+	}
+	if form.FormID == "" && form.Items == nil {
+		return nil, ErrInvalidDefinition
+	}
+
+	title := form.Info.Title
+	if title == "" {
+		title = form.Info.DocumentTitle
+	}
+	b := newBuilder(title, form.Info.Description)
+
+	for _, item := range form.Items {
+		name := itemName(item.Title, item.ItemID)
+
+		switch {
+		case item.QuestionItem != nil:
+			b.addGoogleQuestion(name, item)
+		case item.QuestionGroupItem != nil:
+			b.addGoogleGrid(name, item)
+		case item.PageBreakItem != nil:
+			b.skip(name, "sections are not supported, the questions are imported on one page")
+		case item.TextItem != nil:
+			b.skip(name, "text blocks are not supported")
+		case item.ImageItem != nil, item.VideoItem != nil:
+			b.skip(name, "images and videos are not supported")
+		default:
+			b.skip(name, "unknown item kind")
+		}
+	}
+
+	return b.result()
+}
+
+func (b *builder) addGoogleQuestion(name string, item *googleItem) {
+	question := item.QuestionItem.Question
+	if question.Grading != nil {
+		b.warn(name, "quiz grading is dropped")
+	}
+
+	switch {
+	case question.TextQuestion != nil:
+		b.addQuestion(name, item.Title, item.Description, model.InputAnswerType, question.Required, nil)
+	case question.ChoiceQuestion != nil:
+		questionType, ok := googleChoiceType(question.ChoiceQuestion.Type)
+		if !ok {
+			b.skip(name, fmt.Sprintf("choice type %q is not supported", question.ChoiceQuestion.Type))
+			return
+		}
+		b.addQuestion(name, item.Title, item.Description, questionType, question.Required, b.googleOptions(name, question.ChoiceQuestion))
+	case question.ScaleQuestion != nil:
+		scale := question.ScaleQuestion
+		if !validScale(scale.Low, scale.High) {
+			b.skip(name, scaleSkipReason(scale.Low, scale.High))
+			return
+		}
+		if scale.LowLabel != "" || scale.HighLabel != "" {
+			b.warn(name, "the labels of the scale are dropped")
+		}
+		b.addQuestion(name, item.Title, item.Description, model.SingleAnswerType, question.Required, scaleAnswers(scale.Low, scale.High))
+	case question.RatingQuestion != nil:
+		level := question.RatingQuestion.RatingScaleLevel
+		if !validScale(1, level) {
+			b.skip(name, scaleSkipReason(1, level))
+			return
+		}
+		b.addQuestion(name, item.Title, item.Description, model.SingleAnswerType, question.Required, scaleAnswers(1, level))
+	case question.DateQuestion != nil, question.TimeQuestion != nil:
+		b.warn(name, "date and time questions are imported as text questions")
+		b.addQuestion(name, item.Title, item.Description, model.InputAnswerType, question.Required, nil)
+	case question.FileUploadQuestion != nil:
+		b.skip(name, "file upload questions are not supported")
+	default:
+		b.skip(name, "unknown question kind")
+	}
+}
+
+// addGoogleGrid imports every row of a grid as a question of its own.
+func (b *builder) addGoogleGrid(name string, item *googleItem) {
+	group := item.QuestionGroupItem
+	if group.Grid == nil {
+		b.skip(name, "question groups other than grids are not supported")
+		return
+	}
+
+	questionType, ok := googleChoiceType(group.Grid.Columns.Type)
+	if !ok {
+		b.skip(name, fmt.Sprintf("grid type %q is not supported", group.Grid.Columns.Type))
+		return
+	}
+
+	b.warn(name, "the grid is imported as one question per row")
+	answers := b.googleOptions(name, &group.Grid.Columns)
+	for _, row := range group.Questions {
+		b.addQuestion(name, fmt.Sprintf("%s: %s", item.Title, row.RowQuestion.Title), item.Description, questionType, row.Required, answers)
+	}
+}
+
+func (b *builder) googleOptions(name string, choice *googleChoiceQuestion) []string {
+	answers := make([]string, 0, len(choice.Options))
+	branching := false
+	for _, option := range choice.Options {
+		if option.IsOther {
+			b.warn(name, `the "Other" option with a free text answer is dropped`)
+			continue
+		}
+		if option.GoToAction != "" || option.GoToSectionID != "" {
+			branching = true
+		}
+		answers = append(answers, option.Value)
+	}
+	if branching {
+		b.warn(name, "going to a section based on the answer is dropped")
+	}
+	return answers
+}
+
+func googleChoiceType(choiceType string) (int, bool) {
+	switch choiceType {
+	case "RADIO", "DROP_DOWN":
+		return model.SingleAnswerType, true
+	case "CHECKBOX":
+		return model.MultipleAnswerType, true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,155 @@
+package formimport
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"go-form-hub/internal/model"
+)
+
+const (
+	typeformRatingSteps  = 5
+	typeformOpinionSteps = 11
+)
+
+// typeform is the part of a form returned by the Typeform Create API the import reads.
+type typeform struct {
+	ID        string           `json:"id"`
+	Title     string           `json:"title"`
+	Fields    []*typeformField `json:"fields"`
+	Logic     []any            `json:"logic"`
+	Hidden    []string         `json:"hidden"`
+	Variables map[string]any   `json:"variables"`
+}
+
+type typeformField struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	Type       string `json:"type"`
+	Properties struct {
+		Description            string           `json:"description"`
+		AllowMultipleSelection bool             `json:"allow_multiple_selection"`
+		AllowOtherChoice       bool             `json:"allow_other_choice"`
+		Choices                []typeformChoice `json:"choices"`
+		Steps                  int              `json:"steps"`
+		StartAtOne             bool             `json:"start_at_one"`
+		Labels                 map[string]any   `json:"labels"`
+		Fields                 []*typeformField `json:"fields"`
+	} `json:"properties"`
+	Validations struct {
+		Required bool `json:"required"`
+	} `json:"validations"`
+}
+
+type typeformChoice struct {
+	Label string `json:"label"`
+}
+
+// FromTypeform converts a form returned by the Typeform Create API.
+func FromTypeform(definition []byte) (*model.FormImport, error) {
+	var form typeform
+	if err := json.Unmarshal(definition, &form); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidDefinition, err)
+	}
+	if form.ID == "" && form.Fields == nil {
+		return nil, ErrInvalidDefinition
+	}
+
+	b := newBuilder(form.Title, "")
+	for _, field := range form.Fields {
+		b.addTypeformField(field, "")
+	}
+
+	if len(form.Logic) > 0 {
+		b.warn(form.Title, "logic jumps are dropped")
+	}
+	for _, hidden := range form.Hidden {
+		b.skip(hidden, "hidden fields are not supported")
+	}
+	if len(form.Variables) > 0 {
+		b.warn(form.Title, "score and price variables are dropped")
+	}
+
+	return b.result()
+}
+
+// addTypeformField imports the field, the fields of groups and the rows of matrices are prefixed
+// with the title of their parent.
+func (b *builder) addTypeformField(field *typeformField, parent string) {
+	title := field.Title
+	if parent != "" {
+		title = fmt.Sprintf("%s: %s", parent, field.Title)
+	}
+	name := itemName(title, field.ID)
+	description := field.Properties.Description
+	required := field.Validations.Required
+
+	switch field.Type {
+	case "short_text", "long_text", "email", "number", "website", "phone_number":
+		b.addQuestion(name, title, description, model.InputAnswerType, required, nil)
+	case "date":
+		b.warn(name, "date questions are imported as text questions")
+		b.addQuestion(name, title, description, model.InputAnswerType, required, nil)
+	case "multiple_choice", "dropdown", "picture_choice":
+		questionType := model.SingleAnswerType
+		if field.Properties.AllowMultipleSelection {
+			questionType = model.MultipleAnswerType
+		}
+		if field.Properties.AllowOtherChoice {
+			b.warn(name, `the "Other" choice with a free text answer is dropped`)
+		}
+		if field.Type == "picture_choice" {
+			b.warn(name, "the pictures of the choices are dropped")
+		}
+		answers := make([]string, 0, len(field.Properties.Choices))
+		for _, choice := range field.Properties.Choices {
+			answers = append(answers, choice.Label)
+		}
+		b.addQuestion(name, title, description, questionType, required, answers)
+	case "yes_no":
+		b.addQuestion(name, title, description, model.SingleAnswerType, required, []string{"Yes", "No"})
+	case "legal":
+		b.addQuestion(name, title, description, model.SingleAnswerType, required, []string{"I accept", "I don't accept"})
+	case "rating":
+		steps := field.Properties.Steps
+		if steps == 0 {
+			steps = typeformRatingSteps
+		}
+		if !validScale(1, steps) {
+			b.skip(name, scaleSkipReason(1, steps))
+			return
+		}
+		b.addQuestion(name, title, description, model.SingleAnswerType, required, scaleAnswers(1, steps))
+	case "opinion_scale", "nps":
+		steps := field.Properties.Steps
+		if steps == 0 {
+			steps = typeformOpinionSteps
+		}
+		low := 0
+		if field.Properties.StartAtOne {
+			low = 1
+		}
+		if !validScale(low, low+steps-1) {
+			b.skip(name, scaleSkipReason(low, low+steps-1))
+			return
+		}
+		if len(field.Properties.Labels) > 0 {
+			b.warn(name, "the labels of the scale are dropped")
+		}
+		b.addQuestion(name, title, description, model.SingleAnswerType, required, scaleAnswers(low, low+steps-1))
+	case "group", "inline_group":
+		b.warn(name, "the questions of the group are imported one by one")
+		for _, child := range field.Properties.Fields {
+			b.addTypeformField(child, "")
+		}
+	case "matrix":
+		b.warn(name, "the matrix is imported as one question per row")
+		for _, row := range field.Properties.Fields {
+			b.addTypeformField(row, field.Title)
+		}
+	case "statement":
+		b.skip(name, "statements are not supported")
+	default:
+		b.skip(name, fmt.Sprintf("field type %q is not supported", field.Type))
+	}
+}
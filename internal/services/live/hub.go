@@ -0,0 +1,90 @@
+package live
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"go-form-hub/internal/database"
+
+	"github.com/rs/zerolog/log"
+)
+
+const listenRetryTimeout = time.Second
+
+// Subscriber lets handlers wait for new passages of a form.
+type Subscriber interface {
+	// Subscribe returns a channel that receives a value after one or more passages of the form were saved,
+	// and a function that must be called once the caller stops reading.
+	Subscribe(formID int64) (<-chan struct{}, func())
+}
+
+// Hub fans notifications about saved passages out to subscribers of the form.
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[int64]map[chan struct{}]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: map[int64]map[chan struct{}]struct{}{},
+	}
+}
+
+func (h *Hub) Subscribe(formID int64) (<-chan struct{}, func()) {
+	updates := make(chan struct{}, 1)
+
+	h.mu.Lock()
+	if h.subscribers[formID] == nil {
+		h.subscribers[formID] = map[chan struct{}]struct{}{}
+	}
+	h.subscribers[formID][updates] = struct{}{}
+	h.mu.Unlock()
+
+	return updates, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+
+		delete(h.subscribers[formID], updates)
+		if len(h.subscribers[formID]) == 0 {
+			delete(h.subscribers, formID)
+		}
+	}
+}
+
+// Publish wakes up subscribers of the form. A subscriber that has not yet read the previous update
+// is not sent another one, so a burst of passages results in a single refresh.
+func (h *Hub) Publish(formID int64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for updates := range h.subscribers[formID] {
+		select {
+		case updates <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Listen publishes form IDs received on the NOTIFY channel until ctx is done,
+// reconnecting whenever the listening connection fails.
+func (h *Hub) Listen(ctx context.Context, db database.ConnPool, channel string) {
+	for {
+		err := db.Listen(ctx, channel, func(payload string) {
+			formID, err := strconv.ParseInt(payload, 10, 64)
+			if err != nil {
+				log.Error().Msgf("live_hub listen unexpected payload %q: %v", payload, err)
+				return
+			}
+			h.Publish(formID)
+		})
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(listenRetryTimeout):
+			log.Error().Msgf("live_hub listen error: %v", err)
+		}
+	}
+}
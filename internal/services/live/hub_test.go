@@ -0,0 +1,126 @@
+package live_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/database"
+	"go-form-hub/internal/services/live"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func received(updates <-chan struct{}) bool {
+	select {
+	case <-updates:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestHubPublish(t *testing.T) {
+	t.Run("Subscribers", func(t *testing.T) {
+		t.Parallel()
+		hub := live.NewHub()
+
+		first, unsubscribeFirst := hub.Subscribe(1)
+		defer unsubscribeFirst()
+		second, unsubscribeSecond := hub.Subscribe(1)
+		defer unsubscribeSecond()
+		other, unsubscribeOther := hub.Subscribe(2)
+		defer unsubscribeOther()
+
+		hub.Publish(1)
+		assert.True(t, received(first))
+		assert.True(t, received(second))
+		assert.False(t, received(other), "a subscriber only hears about its form")
+	})
+
+	t.Run("Coalesced", func(t *testing.T) {
+		t.Parallel()
+		hub := live.NewHub()
+
+		updates, unsubscribe := hub.Subscribe(1)
+		defer unsubscribe()
+
+		hub.Publish(1)
+		hub.Publish(1)
+		hub.Publish(1)
+		assert.True(t, received(updates))
+		assert.False(t, received(updates), "a burst of passages must result in a single update")
+
+		hub.Publish(1)
+		assert.True(t, received(updates), "an update read makes room for the next one")
+	})
+
+	t.Run("Unsubscribed", func(t *testing.T) {
+		t.Parallel()
+		hub := live.NewHub()
+
+		updates, unsubscribe := hub.Subscribe(1)
+		remaining, unsubscribeRemaining := hub.Subscribe(1)
+
+		unsubscribe()
+		hub.Publish(1)
+		assert.False(t, received(updates))
+		assert.True(t, received(remaining))
+
+		unsubscribeRemaining()
+		hub.Publish(1)
+		assert.False(t, received(remaining))
+	})
+
+	t.Run("NoSubscribers", func(t *testing.T) {
+		t.Parallel()
+		hub := live.NewHub()
+
+		assert.NotPanics(t, func() { hub.Publish(1) })
+	})
+}
+
+// notifyingConnPool delivers the payloads to the listener, then waits until ctx is done.
+type notifyingConnPool struct {
+	database.ConnPool
+
+	payloads []string
+}
+
+func (p *notifyingConnPool) Listen(ctx context.Context, _ string, handle func(payload string)) error {
+	for _, payload := range p.payloads {
+		handle(payload)
+	}
+
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestHubListen(t *testing.T) {
+	hub := live.NewHub()
+	updates, unsubscribe := hub.Subscribe(7)
+	defer unsubscribe()
+	other, unsubscribeOther := hub.Subscribe(8)
+	defer unsubscribeOther()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		hub.Listen(ctx, &notifyingConnPool{payloads: []string{"not a form", "7"}}, "form_passage_saved")
+	}()
+
+	select {
+	case <-updates:
+	case <-time.After(time.Second):
+		t.Fatal("the notified form got no update")
+	}
+	assert.False(t, received(other))
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("listen must stop once ctx is done")
+	}
+}
@@ -0,0 +1,59 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+type fileSender struct {
+	dir  string
+	from string
+}
+
+// NewFileSender writes every message into dir as an .eml file instead of sending it. Meant for development.
+func NewFileSender(dir, from string) Sender {
+	return &fileSender{
+		dir:  dir,
+		from: from,
+	}
+}
+
+func (s *fileSender) Send(_ context.Context, message *Message) error {
+	now := time.Now()
+	data, err := buildMessage(s.from, message, now)
+	if err != nil {
+		return fmt.Errorf("mail file_sender failed to build message: %v", err)
+	}
+
+	if err = os.MkdirAll(s.dir, 0o755); err != nil {
+		return fmt.Errorf("mail file_sender failed to create directory: %v", err)
+	}
+
+	name := fmt.Sprintf("%d-%s.eml", now.UnixNano(), strings.Join(message.To, "_"))
+	if err = os.WriteFile(filepath.Join(s.dir, filepath.Base(name)), data, 0o600); err != nil {
+		return fmt.Errorf("mail file_sender failed to write message: %v", err)
+	}
+
+	return nil
+}
+
+type logSender struct{}
+
+// NewLogSender writes messages to the log instead of sending them. Meant for development.
+func NewLogSender() Sender {
+	return &logSender{}
+}
+
+func (s *logSender) Send(_ context.Context, message *Message) error {
+	log.Info().
+		Strs("to", message.To).
+		Str("subject", message.Subject).
+		Msgf("mail log_sender message:\n%s", message.Text)
+	return nil
+}
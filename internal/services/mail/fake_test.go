@@ -0,0 +1,41 @@
+package mail_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go-form-hub/internal/services/mail"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFileSenderSend(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "mail")
+	sender := mail.NewFileSender(dir, "no-reply@example.com")
+
+	err := sender.Send(context.Background(), &mail.Message{
+		To:      []string{"author@example.com"},
+		Subject: "Новые ответы",
+		Text:    "Hello!",
+		HTML:    "<p>Hello!</p>",
+	})
+	assert.Nil(t, err)
+
+	files, err := os.ReadDir(dir)
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(files))
+
+	content, err := os.ReadFile(filepath.Join(dir, files[0].Name()))
+	assert.Nil(t, err)
+
+	message := string(content)
+	assert.Contains(t, message, "From: no-reply@example.com\r\n")
+	assert.Contains(t, message, "To: author@example.com\r\n")
+	assert.Contains(t, message, "Subject: =?utf-8?q?")
+	assert.Contains(t, message, "Content-Type: multipart/alternative; boundary=")
+	assert.Contains(t, message, "Content-Type: text/plain; charset=utf-8")
+	assert.Contains(t, message, "Content-Type: text/html; charset=utf-8")
+	assert.Contains(t, message, "<p>Hello!</p>")
+}
@@ -0,0 +1,92 @@
+package mail
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"go-form-hub/internal/config"
+)
+
+const (
+	SenderSMTP = "smtp"
+	SenderFile = "file"
+	SenderLog  = "log"
+)
+
+// Message is an email with a plain text and an HTML version of the same content.
+type Message struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+type Sender interface {
+	Send(ctx context.Context, message *Message) error
+}
+
+// NewSender returns the sender selected by cfg.MailSender.
+func NewSender(cfg *config.Config) (Sender, error) {
+	switch cfg.MailSender {
+	case SenderSMTP:
+		return NewSMTPSender(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom), nil
+	case SenderFile:
+		return NewFileSender(cfg.MailDir, cfg.MailFrom), nil
+	case SenderLog:
+		return NewLogSender(), nil
+	default:
+		return nil, fmt.Errorf("unknown mail sender %q", cfg.MailSender)
+	}
+}
+
+// buildMessage encodes the message as a multipart/alternative MIME message.
+func buildMessage(from string, message *Message, date time.Time) ([]byte, error) {
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+
+	for _, part := range []struct {
+		contentType string
+		content     string
+	}{
+		{"text/plain; charset=utf-8", message.Text},
+		{"text/html; charset=utf-8", message.HTML},
+	} {
+		writer, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err = encoder.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+		if err = encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+
+	var result bytes.Buffer
+	fmt.Fprintf(&result, "From: %s\r\n", from)
+	fmt.Fprintf(&result, "To: %s\r\n", strings.Join(message.To, ", "))
+	fmt.Fprintf(&result, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", message.Subject))
+	fmt.Fprintf(&result, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&result, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&result, "Content-Type: multipart/alternative; boundary=%s\r\n\r\n", parts.Boundary())
+	result.Write(body.Bytes())
+
+	return result.Bytes(), nil
+}
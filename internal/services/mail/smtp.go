@@ -0,0 +1,42 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+type smtpSender struct {
+	addr string
+	auth smtp.Auth
+	from string
+}
+
+// NewSMTPSender sends through an SMTP server, authenticating with PLAIN when username is set.
+func NewSMTPSender(host, port, username, password, from string) Sender {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &smtpSender{
+		addr: net.JoinHostPort(host, port),
+		auth: auth,
+		from: from,
+	}
+}
+
+func (s *smtpSender) Send(_ context.Context, message *Message) error {
+	data, err := buildMessage(s.from, message, time.Now())
+	if err != nil {
+		return fmt.Errorf("mail smtp_sender failed to build message: %v", err)
+	}
+
+	if err = smtp.SendMail(s.addr, s.auth, s.from, message.To, data); err != nil {
+		return fmt.Errorf("mail smtp_sender failed to send message: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package notification
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+	resp "go-form-hub/internal/services/service_response"
+
+	validator "github.com/go-playground/validator/v10"
+)
+
+type Service interface {
+	PreferenceGet(ctx context.Context) (*resp.Response, error)
+	PreferenceUpdate(ctx context.Context, preference *model.NotificationPreference) (*resp.Response, error)
+}
+
+type notificationService struct {
+	repository repository.NotificationRepository
+	validate   *validator.Validate
+}
+
+func NewNotificationService(notificationRepository repository.NotificationRepository, validate *validator.Validate) Service {
+	return &notificationService{
+		repository: notificationRepository,
+		validate:   validate,
+	}
+}
+
+// PreferenceGet returns the preference of the current user. Notifications are off until the user sets them.
+func (s *notificationService) PreferenceGet(ctx context.Context) (*resp.Response, error) {
+	currentUser := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
+
+	preference, err := s.repository.FindPreference(ctx, currentUser.ID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if preference == nil {
+		preference = &model.NotificationPreference{Mode: model.NotificationModeOff}
+	}
+
+	return resp.NewResponse(http.StatusOK, preference), nil
+}
+
+func (s *notificationService) PreferenceUpdate(ctx context.Context, preference *model.NotificationPreference) (*resp.Response, error) {
+	if err := s.validate.Struct(preference); err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), err
+	}
+
+	currentUser := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
+
+	if err := s.repository.UpsertPreference(ctx, currentUser.ID, preference, time.Now().UTC()); err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	return resp.NewResponse(http.StatusOK, preference), nil
+}
@@ -0,0 +1,121 @@
+package notification
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/mail"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	claimBatchSize = 50
+	// commitLag keeps passages that may still be committing out of the current period.
+	commitLag = 10 * time.Second
+)
+
+// Scheduler emails authors about new passages of their forms, either soon after they arrive
+// or once a day, depending on the author's preference.
+type Scheduler struct {
+	repository repository.NotificationRepository
+	sender     mail.Sender
+	appURL     string
+	now        func() time.Time
+}
+
+func NewScheduler(notificationRepository repository.NotificationRepository, sender mail.Sender, appURL string, now func() time.Time) *Scheduler {
+	return &Scheduler{
+		repository: notificationRepository,
+		sender:     sender,
+		appURL:     strings.TrimRight(appURL, "/"),
+		now:        now,
+	}
+}
+
+// Run sends due notifications every interval until ctx is done.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			sent, err := s.SendDue(ctx)
+			if err != nil {
+				log.Error().Msgf("notification_scheduler send error: %v", err)
+			}
+			if err != nil || sent < claimBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendDue notifies one batch of due authors and returns how many were claimed.
+// An author whose email fails is released to be notified again next time. A failed release is
+// only logged, so that it does not keep the rest of the batch from being notified or released.
+func (s *Scheduler) SendDue(ctx context.Context) (int, error) {
+	until := s.now().UTC().Add(-commitLag).Truncate(time.Microsecond)
+
+	recipients, err := s.repository.ClaimDueRecipients(ctx, until, claimBatchSize)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, recipient := range recipients {
+		if err = s.notify(ctx, recipient, until); err == nil {
+			continue
+		}
+
+		log.Error().Msgf("notification_scheduler failed to notify user %d: %v", recipient.UserID, err)
+		if err = s.repository.ReleaseRecipient(ctx, recipient.UserID, recipient.Since, until); err != nil {
+			log.Error().Msgf("notification_scheduler failed to release user %d: %v", recipient.UserID, err)
+		}
+	}
+
+	return len(recipients), nil
+}
+
+func (s *Scheduler) notify(ctx context.Context, recipient *repository.NotificationRecipient, until time.Time) error {
+	digests, err := s.repository.FormDigests(ctx, recipient.UserID, recipient.Since, until)
+	if err != nil {
+		return err
+	}
+
+	// a daily digest without passages is skipped
+	if len(digests) == 0 {
+		return nil
+	}
+
+	data := &digestData{
+		FirstName:   recipient.FirstName,
+		Instant:     recipient.Mode == model.NotificationModeInstant,
+		Since:       recipient.Since,
+		SettingsURL: s.appURL + "/profile",
+	}
+	for _, digest := range digests {
+		data.Total += digest.NewPassages
+		data.Forms = append(data.Forms, &digestForm{
+			Title:       digest.Title,
+			NewPassages: digest.NewPassages,
+			ResultsURL:  fmt.Sprintf("%s/forms/%d/results", s.appURL, digest.FormID),
+		})
+	}
+
+	message, err := renderDigest(recipient.Email, data)
+	if err != nil {
+		return fmt.Errorf("failed to render digest: %v", err)
+	}
+
+	return s.sender.Send(ctx, message)
+}
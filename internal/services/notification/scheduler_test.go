@@ -0,0 +1,140 @@
+package notification_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/mail"
+	"go-form-hub/internal/services/notification"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeNotificationRepository struct {
+	repository.NotificationRepository
+
+	recipients []*repository.NotificationRecipient
+	digests    map[int64][]*model.FormDigest
+	claimedAt  time.Time
+	released   []int64
+	releaseErr error
+}
+
+func (r *fakeNotificationRepository) ClaimDueRecipients(_ context.Context, until time.Time, _ int) ([]*repository.NotificationRecipient, error) {
+	r.claimedAt = until
+	return r.recipients, nil
+}
+
+func (r *fakeNotificationRepository) FormDigests(_ context.Context, authorID int64, _, _ time.Time) ([]*model.FormDigest, error) {
+	return r.digests[authorID], nil
+}
+
+func (r *fakeNotificationRepository) ReleaseRecipient(_ context.Context, userID int64, _, _ time.Time) error {
+	r.released = append(r.released, userID)
+	return r.releaseErr
+}
+
+type recordingSender struct {
+	messages []*mail.Message
+	err      error
+}
+
+func (s *recordingSender) Send(_ context.Context, message *mail.Message) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.messages = append(s.messages, message)
+	return nil
+}
+
+func TestSchedulerSendDue(t *testing.T) {
+	now := time.Date(2023, 12, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	since := now.Add(-24 * time.Hour)
+
+	t.Run("Digests", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &fakeNotificationRepository{
+			recipients: []*repository.NotificationRecipient{
+				{UserID: 1, Mode: model.NotificationModeDaily, Email: "author@example.com", FirstName: "Anna", Since: since},
+				{UserID: 2, Mode: model.NotificationModeInstant, Email: "other@example.com", FirstName: "Ivan", Since: since},
+				{UserID: 3, Mode: model.NotificationModeDaily, Email: "idle@example.com", Since: since},
+			},
+			digests: map[int64][]*model.FormDigest{
+				1: {{FormID: 10, Title: "Survey <b>", NewPassages: 3}, {FormID: 11, Title: "Poll", NewPassages: 1}},
+				2: {{FormID: 20, Title: "Feedback", NewPassages: 1}},
+			},
+		}
+		sender := &recordingSender{}
+		scheduler := notification.NewScheduler(repo, sender, "https://forms.example.com/", clock)
+
+		claimed, err := scheduler.SendDue(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, 3, claimed)
+		assert.Equal(t, now.Add(-10*time.Second), repo.claimedAt)
+
+		// the idle author has nothing to report
+		assert.Equal(t, 2, len(sender.messages))
+
+		daily := sender.messages[0]
+		assert.Equal(t, []string{"author@example.com"}, daily.To)
+		assert.Equal(t, "Your daily form digest", daily.Subject)
+		assert.Contains(t, daily.Text, "Hello, Anna!")
+		assert.Contains(t, daily.Text, "4 new responses since 30.11.2023 09:00 UTC")
+		assert.Contains(t, daily.Text, "- Survey <b>: 3 — https://forms.example.com/forms/10/results")
+		assert.Contains(t, daily.HTML, `<a href="https://forms.example.com/forms/10/results">Survey &lt;b&gt;</a>`)
+
+		instant := sender.messages[1]
+		assert.Equal(t, "New responses to Feedback", instant.Subject)
+		assert.Contains(t, instant.Text, "Your forms got new responses:")
+
+		assert.Nil(t, repo.released)
+	})
+
+	t.Run("SendFailed", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &fakeNotificationRepository{
+			recipients: []*repository.NotificationRecipient{
+				{UserID: 1, Mode: model.NotificationModeInstant, Email: "author@example.com", Since: since},
+			},
+			digests: map[int64][]*model.FormDigest{
+				1: {{FormID: 10, Title: "Survey", NewPassages: 1}},
+			},
+		}
+		sender := &recordingSender{err: errors.New("connection refused")}
+		scheduler := notification.NewScheduler(repo, sender, "https://forms.example.com", clock)
+
+		_, err := scheduler.SendDue(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, []int64{1}, repo.released)
+	})
+
+	t.Run("ReleaseFailed", func(t *testing.T) {
+		t.Parallel()
+
+		repo := &fakeNotificationRepository{
+			recipients: []*repository.NotificationRecipient{
+				{UserID: 1, Mode: model.NotificationModeInstant, Email: "author@example.com", Since: since},
+				{UserID: 2, Mode: model.NotificationModeInstant, Email: "other@example.com", Since: since},
+			},
+			digests: map[int64][]*model.FormDigest{
+				1: {{FormID: 10, Title: "Survey", NewPassages: 1}},
+				2: {{FormID: 20, Title: "Feedback", NewPassages: 1}},
+			},
+			releaseErr: errors.New("connection lost"),
+		}
+		sender := &recordingSender{err: errors.New("connection refused")}
+		scheduler := notification.NewScheduler(repo, sender, "https://forms.example.com", clock)
+
+		claimed, err := scheduler.SendDue(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, 2, claimed)
+		assert.Equal(t, []int64{1, 2}, repo.released, "a failed release must not leave the rest of the batch claimed")
+	})
+}
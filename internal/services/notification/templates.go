@@ -0,0 +1,62 @@
+package notification
+
+import (
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"go-form-hub/internal/services/mail"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+var (
+	digestText = texttemplate.Must(texttemplate.ParseFS(templateFiles, "templates/digest.txt"))
+	digestHTML = htmltemplate.Must(htmltemplate.ParseFS(templateFiles, "templates/digest.html"))
+)
+
+type digestForm struct {
+	Title       string
+	NewPassages int
+	ResultsURL  string
+}
+
+type digestData struct {
+	FirstName   string
+	Instant     bool
+	Since       time.Time
+	Total       int
+	Forms       []*digestForm
+	SettingsURL string
+}
+
+func (d *digestData) subject() string {
+	if d.Instant {
+		if len(d.Forms) == 1 {
+			return "New responses to " + d.Forms[0].Title
+		}
+		return "New responses to your forms"
+	}
+	return "Your daily form digest"
+}
+
+func renderDigest(to string, data *digestData) (*mail.Message, error) {
+	var text, html strings.Builder
+	if err := digestText.Execute(&text, data); err != nil {
+		return nil, err
+	}
+
+	if err := digestHTML.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	return &mail.Message{
+		To:      []string{to},
+		Subject: data.subject(),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,400 @@
+// Package oidc logs users in with OpenID Connect providers: the authorization code flow with PKCE,
+// and the verification of the ID token, signed with RS256, against the keys of the provider.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"go-form-hub/internal/config"
+)
+
+const (
+	randomSize = 32
+	// RequestTimeout bounds a request to a provider.
+	RequestTimeout = 10 * time.Second
+	// clockSkew is how far the clock of the provider may be off when checking the expiry of a token.
+	clockSkew = time.Minute
+	// maxResponseSize limits what is read from the provider.
+	maxResponseSize = 1 << 20
+)
+
+var (
+	ErrInvalidToken = errors.New("oidc id token is invalid")
+	ErrProvider     = errors.New("oidc provider request failed")
+
+	defaultScopes = []string{"openid", "email", "profile"}
+)
+
+// AuthRequest is what the client keeps between the redirect to the provider and the callback.
+type AuthRequest struct {
+	State    string
+	Nonce    string
+	Verifier string
+}
+
+// NewAuthRequest returns a request with a random state, nonce and PKCE code verifier.
+func NewAuthRequest() (*AuthRequest, error) {
+	values := make([]string, 3)
+	for i := range values {
+		value := make([]byte, randomSize)
+		if _, err := rand.Read(value); err != nil {
+			return nil, fmt.Errorf("oidc new_auth_request failed to read random bytes: %v", err)
+		}
+		values[i] = base64.RawURLEncoding.EncodeToString(value)
+	}
+
+	return &AuthRequest{State: values[0], Nonce: values[1], Verifier: values[2]}, nil
+}
+
+// Claims are the claims of a verified ID token that identify the user.
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	GivenName     string
+	FamilyName    string
+}
+
+type discovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// Provider talks to one provider. The discovery document and the keys are fetched on first use,
+// the keys again when a token is signed with an unknown key.
+type Provider struct {
+	cfg         config.OIDCProvider
+	redirectURL string
+	client      *http.Client
+	now         func() time.Time
+
+	mu        sync.Mutex
+	discovery *discovery
+	keys      map[string]*rsa.PublicKey
+}
+
+func NewProvider(cfg config.OIDCProvider, redirectURL string, client *http.Client, now func() time.Time) *Provider {
+	return &Provider{
+		cfg:         cfg,
+		redirectURL: redirectURL,
+		client:      client,
+		now:         now,
+	}
+}
+
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// AuthURL returns the address of the provider to send the user to.
+func (p *Provider) AuthURL(ctx context.Context, request *AuthRequest) (string, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	scopes := p.cfg.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultScopes
+	}
+
+	challenge := sha256.Sum256([]byte(request.Verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.cfg.ClientID},
+		"redirect_uri":          {p.redirectURL},
+		"scope":                 {strings.Join(scopes, " ")},
+		"state":                 {request.State},
+		"nonce":                 {request.Nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(d.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+
+	return d.AuthorizationEndpoint + separator + query.Encode(), nil
+}
+
+// Exchange trades the code of the callback for an ID token and returns its claims once the token
+// is verified.
+func (p *Provider) Exchange(ctx context.Context, code string, request *AuthRequest) (*Claims, error) {
+	d, err := p.getDiscovery(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.redirectURL},
+		"client_id":     {p.cfg.ClientID},
+		"code_verifier": {request.Verifier},
+	}
+	if p.cfg.ClientSecret != "" {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, fmt.Errorf("oidc exchange failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	var token struct {
+		IDToken string `json:"id_token"`
+	}
+	if err = p.do(req, &token); err != nil {
+		return nil, err
+	}
+
+	if token.IDToken == "" {
+		return nil, fmt.Errorf("%w: no id token in the token response", ErrProvider)
+	}
+
+	return p.verify(ctx, d, token.IDToken, request.Nonce)
+}
+
+type idTokenClaims struct {
+	Issuer        string          `json:"iss"`
+	Subject       string          `json:"sub"`
+	Audience      json.RawMessage `json:"aud"`
+	Expiry        int64           `json:"exp"`
+	Nonce         string          `json:"nonce"`
+	Email         string          `json:"email"`
+	EmailVerified json.RawMessage `json:"email_verified"`
+	GivenName     string          `json:"given_name"`
+	FamilyName    string          `json:"family_name"`
+}
+
+func (p *Provider) verify(ctx context.Context, d *discovery, idToken, nonce string) (*Claims, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var header struct {
+		Algorithm string `json:"alg"`
+		KeyID     string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, err
+	}
+
+	if header.Algorithm != "RS256" {
+		return nil, fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, header.Algorithm)
+	}
+
+	key, err := p.getKey(ctx, d, header.KeyID)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err = rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var claims idTokenClaims
+	if err = decodeSegment(parts[1], &claims); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case claims.Issuer != d.Issuer:
+		return nil, fmt.Errorf("%w: issued by %q", ErrInvalidToken, claims.Issuer)
+	case !hasAudience(claims.Audience, p.cfg.ClientID):
+		return nil, fmt.Errorf("%w: issued for another client", ErrInvalidToken)
+	case time.Unix(claims.Expiry, 0).Add(clockSkew).Before(p.now()):
+		return nil, fmt.Errorf("%w: expired", ErrInvalidToken)
+	case claims.Nonce != nonce:
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrInvalidToken)
+	case claims.Subject == "":
+		return nil, fmt.Errorf("%w: no subject", ErrInvalidToken)
+	}
+
+	return &Claims{
+		Subject:       claims.Subject,
+		Email:         claims.Email,
+		EmailVerified: isTrue(claims.EmailVerified),
+		GivenName:     claims.GivenName,
+		FamilyName:    claims.FamilyName,
+	}, nil
+}
+
+func (p *Provider) getDiscovery(ctx context.Context) (*discovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimRight(p.cfg.Issuer, "/")+"/.well-known/openid-configuration", http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery failed to create request: %v", err)
+	}
+
+	d := &discovery{}
+	if err = p.do(req, d); err != nil {
+		return nil, err
+	}
+
+	// the issuer in the tokens has to be the configured one, not any issuer the document names
+	if d.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("%w: discovery names issuer %q", ErrProvider, d.Issuer)
+	}
+
+	if d.AuthorizationEndpoint == "" || d.TokenEndpoint == "" || d.JWKSURI == "" {
+		return nil, fmt.Errorf("%w: incomplete discovery document", ErrProvider)
+	}
+
+	p.discovery = d
+	return d, nil
+}
+
+func (p *Provider) getKey(ctx context.Context, d *discovery, keyID string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if key, ok := p.keys[keyID]; ok {
+		return key, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.JWKSURI, http.NoBody)
+	if err != nil {
+		return nil, fmt.Errorf("oidc get_key failed to create request: %v", err)
+	}
+
+	var set struct {
+		Keys []struct {
+			KeyType string `json:"kty"`
+			KeyID   string `json:"kid"`
+			Use     string `json:"use"`
+			N       string `json:"n"`
+			E       string `json:"e"`
+		} `json:"keys"`
+	}
+	if err = p.do(req, &set); err != nil {
+		return nil, err
+	}
+
+	p.keys = map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.KeyType != "RSA" || (k.Use != "" && k.Use != "sig") {
+			continue
+		}
+
+		n, errN := base64.RawURLEncoding.DecodeString(k.N)
+		e, errE := base64.RawURLEncoding.DecodeString(k.E)
+		if errN != nil || errE != nil || len(e) > 4 {
+			continue
+		}
+
+		p.keys[k.KeyID] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, keyID)
+	}
+
+	return key, nil
+}
+
+func (p *Provider) do(req *http.Request, target interface{}) error {
+	res, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+	defer func() {
+		_ = res.Body.Close()
+	}()
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, maxResponseSize))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s answered %d", ErrProvider, req.URL.Path, res.StatusCode)
+	}
+
+	if err = json.Unmarshal(body, target); err != nil {
+		return fmt.Errorf("%w: %v", ErrProvider, err)
+	}
+
+	return nil
+}
+
+func decodeSegment(segment string, target interface{}) error {
+	decoded, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidToken)
+	}
+
+	if err = json.Unmarshal(decoded, target); err != nil {
+		return fmt.Errorf("%w: malformed segment", ErrInvalidToken)
+	}
+
+	return nil
+}
+
+// hasAudience accepts aud as a string or as an array of strings.
+func hasAudience(audience json.RawMessage, clientID string) bool {
+	var single string
+	if json.Unmarshal(audience, &single) == nil {
+		return single == clientID
+	}
+
+	var many []string
+	if json.Unmarshal(audience, &many) != nil {
+		return false
+	}
+
+	for _, aud := range many {
+		if aud == clientID {
+			return true
+		}
+	}
+	return false
+}
+
+// isTrue accepts email_verified as a boolean or, as some providers send it, as a string.
+func isTrue(value json.RawMessage) bool {
+	var flag bool
+	if json.Unmarshal(value, &flag) == nil {
+		return flag
+	}
+
+	var text string
+	return json.Unmarshal(value, &text) == nil && text == "true"
+}
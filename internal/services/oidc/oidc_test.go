@@ -0,0 +1,238 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/config"
+	"go-form-hub/internal/services/oidc"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// mockProvider is a local OpenID Connect provider that issues one code per authorization.
+type mockProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	now    time.Time
+	claims map[string]interface{}
+
+	// what the last authorization asked for
+	challenge string
+	nonce     string
+	code      string
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	m := &mockProvider{key: key, now: time.Date(2023, 11, 1, 12, 0, 0, 0, time.UTC)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 m.server.URL,
+			"authorization_endpoint": m.server.URL + "/authorize",
+			"token_endpoint":         m.server.URL + "/token",
+			"jwks_uri":               m.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": "key-1",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		verifier := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if r.PostFormValue("code") != m.code || r.PostFormValue("client_secret") != "secret" ||
+			base64.RawURLEncoding.EncodeToString(verifier[:]) != m.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		m.code = ""
+		_ = json.NewEncoder(w).Encode(map[string]string{"id_token": m.idToken(t)})
+	})
+	m.server = httptest.NewServer(mux)
+	t.Cleanup(m.server.Close)
+
+	m.claims = map[string]interface{}{
+		"iss":            m.server.URL,
+		"sub":            "subject-1",
+		"aud":            "client",
+		"exp":            m.now.Add(time.Hour).Unix(),
+		"email":          "user@example.com",
+		"email_verified": true,
+		"given_name":     "Ada",
+		"family_name":    "Lovelace",
+	}
+
+	return m
+}
+
+// authorize plays the user agreeing at the provider, and returns the code of the callback.
+func (m *mockProvider) authorize(t *testing.T, authURL string) string {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatalf("failed to parse auth url: %v", err)
+	}
+	m.challenge = u.Query().Get("code_challenge")
+	m.nonce = u.Query().Get("nonce")
+	m.code = "code-" + u.Query().Get("state")
+	return m.code
+}
+
+func (m *mockProvider) idToken(t *testing.T) string {
+	claims := map[string]interface{}{"nonce": m.nonce}
+	for name, value := range m.claims {
+		claims[name] = value
+	}
+
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "kid": "key-1", "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, m.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func (m *mockProvider) provider() *oidc.Provider {
+	cfg := config.OIDCProvider{Name: "mock", Issuer: m.server.URL, ClientID: "client", ClientSecret: "secret"}
+	return oidc.NewProvider(cfg, "https://forms.example.com/api/v1/oidc/mock/callback", m.server.Client(), func() time.Time { return m.now })
+}
+
+func TestProviderLogin(t *testing.T) {
+	mock := newMockProvider(t)
+	provider := mock.provider()
+	ctx := context.Background()
+
+	request, err := oidc.NewAuthRequest()
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	authURL, err := provider.AuthURL(ctx, request)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	u, _ := url.Parse(authURL)
+	assert.Equal(t, mock.server.URL+"/authorize", u.Scheme+"://"+u.Host+u.Path)
+	assert.Equal(t, "S256", u.Query().Get("code_challenge_method"))
+	assert.Equal(t, "openid email profile", u.Query().Get("scope"))
+	assert.Equal(t, request.State, u.Query().Get("state"))
+	assert.NotContains(t, authURL, request.Verifier, "only the challenge is sent with the redirect")
+
+	code := mock.authorize(t, authURL)
+	claims, err := provider.Exchange(ctx, code, request)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, &oidc.Claims{
+		Subject:       "subject-1",
+		Email:         "user@example.com",
+		EmailVerified: true,
+		GivenName:     "Ada",
+		FamilyName:    "Lovelace",
+	}, claims)
+
+	_, err = provider.Exchange(ctx, code, request)
+	assert.ErrorIs(t, err, oidc.ErrProvider, "a code works once")
+}
+
+func TestProviderRejectsBadTokens(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(m *mockProvider, request *oidc.AuthRequest)
+		err    error
+	}{
+		{
+			name:   "WrongVerifier",
+			change: func(_ *mockProvider, request *oidc.AuthRequest) { request.Verifier = "stolen code" },
+			err:    oidc.ErrProvider,
+		},
+		{
+			name:   "WrongNonce",
+			change: func(_ *mockProvider, request *oidc.AuthRequest) { request.Nonce = "other" },
+			err:    oidc.ErrInvalidToken,
+		},
+		{
+			name:   "OtherAudience",
+			change: func(m *mockProvider, _ *oidc.AuthRequest) { m.claims["aud"] = []string{"another client"} },
+			err:    oidc.ErrInvalidToken,
+		},
+		{
+			name:   "OtherIssuer",
+			change: func(m *mockProvider, _ *oidc.AuthRequest) { m.claims["iss"] = "https://evil.example.com" },
+			err:    oidc.ErrInvalidToken,
+		},
+		{
+			name:   "Expired",
+			change: func(m *mockProvider, _ *oidc.AuthRequest) { m.now = m.now.Add(2 * time.Hour) },
+			err:    oidc.ErrInvalidToken,
+		},
+		{
+			name:   "OtherKey",
+			change: func(m *mockProvider, _ *oidc.AuthRequest) { m.key, _ = rsa.GenerateKey(rand.Reader, 2048) },
+			err:    oidc.ErrInvalidToken,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			mock := newMockProvider(t)
+			provider := mock.provider()
+			ctx := context.Background()
+
+			request, _ := oidc.NewAuthRequest()
+			authURL, err := provider.AuthURL(ctx, request)
+			if !assert.Nil(t, err) {
+				return
+			}
+			code := mock.authorize(t, authURL)
+
+			test.change(mock, request)
+			_, err = provider.Exchange(ctx, code, request)
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+}
+
+func TestProviderAudienceListAndStringVerified(t *testing.T) {
+	mock := newMockProvider(t)
+	mock.claims["aud"] = []string{"other", "client"}
+	mock.claims["email_verified"] = "false"
+	provider := mock.provider()
+	ctx := context.Background()
+
+	request, _ := oidc.NewAuthRequest()
+	authURL, _ := provider.AuthURL(ctx, request)
+	claims, err := provider.Exchange(ctx, mock.authorize(t, authURL), request)
+	if assert.Nil(t, err) {
+		assert.False(t, claims.EmailVerified)
+	}
+}
@@ -0,0 +1,345 @@
+package passage
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+	resp "go-form-hub/internal/services/service_response"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/microcosm-cc/bluemonday"
+)
+
+const (
+	ImportMaxRows    = 10000
+	importBatchSize  = 500
+	defaultSeparator = "; "
+	// exportResponsesSheet is the sheet with raw responses in the XLSX results export.
+	exportResponsesSheet = "Responses"
+)
+
+var (
+	ErrUnsupportedImportFile = errors.New("only .csv and .xlsx files can be imported")
+	ErrImportFileEmpty       = errors.New("the file has no header row")
+	ErrImportTooManyRows     = fmt.Errorf("the file has more than %d responses", ImportMaxRows)
+	ErrImportUnknownColumn   = errors.New("a mapped column is not in the header row")
+	ErrImportUnknownQuestion = errors.New("a column is mapped to a question of another form")
+	ErrImportTimestamp       = errors.New("timestamp is in an unknown format or in the future")
+)
+
+// importTimestampLayouts are tried in order on the timestamp column, spreadsheets that keep dates
+// as numbers are read as Excel serial dates.
+var importTimestampLayouts = []string{
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"1/2/2006 15:04:05",
+	"1/2/2006 15:04",
+	"1/2/06 15:04",
+	"1/2/2006",
+	"02.01.2006 15:04:05",
+	"02.01.2006",
+}
+
+type Service interface {
+	ResponsesImport(ctx context.Context, formID int64, fileName string, file io.Reader, responseImport *model.ResponseImport) (*resp.Response, error)
+}
+
+type importService struct {
+	formRepository repository.FormRepository
+	now            func() time.Time
+	sanitizer      *bluemonday.Policy
+	validate       *validator.Validate
+}
+
+func NewImportService(formRepository repository.FormRepository, now func() time.Time, validate *validator.Validate) Service {
+	return &importService{
+		formRepository: formRepository,
+		now:            now,
+		sanitizer:      bluemonday.UGCPolicy(),
+		validate:       validate,
+	}
+}
+
+// importColumn is what a column of the file is read into, a question or the timestamp.
+type importColumn struct {
+	question  *model.Question
+	timestamp bool
+}
+
+// ResponsesImport saves every valid row of the file as a passage and reports the rows it skipped.
+// Rows are saved in batches, so a database error leaves the batches saved before it in place.
+func (s *importService) ResponsesImport(ctx context.Context, formID int64, fileName string, file io.Reader,
+	responseImport *model.ResponseImport) (*resp.Response, error) {
+	if err := s.validate.Struct(responseImport); err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), err
+	}
+
+	currentUser := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
+
+	form, err := s.formRepository.FindByID(ctx, formID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if form == nil {
+		return resp.NewResponse(http.StatusNotFound, nil), nil
+	}
+
+	if form.Author.ID != currentUser.ID {
+		return resp.NewResponse(http.StatusForbidden, nil), nil
+	}
+
+	rows, err := readImportRows(fileName, file, responseImport.Sheet)
+	if err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), err
+	}
+
+	if len(rows) == 0 {
+		return resp.NewResponse(http.StatusBadRequest, nil), ErrImportFileEmpty
+	}
+
+	if len(rows)-1 > ImportMaxRows {
+		return resp.NewResponse(http.StatusBadRequest, nil), ErrImportTooManyRows
+	}
+
+	header := rows[0]
+	columns, ignored, err := mapImportColumns(header, form, responseImport)
+	if err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), err
+	}
+
+	separator := responseImport.Separator
+	if separator == "" {
+		separator = defaultSeparator
+	}
+
+	result := &model.ResponseImportResult{
+		IgnoredColumns: ignored,
+		Errors:         []*model.ImportRowError{},
+	}
+	passages := make([]*model.FormPassage, 0, len(rows)-1)
+	for i, row := range rows[1:] {
+		if isEmptyRow(row) {
+			continue
+		}
+
+		// rows are numbered as in the spreadsheet, the header being the first one
+		passage, rowError := s.readPassage(form, header, columns, row, separator)
+		if rowError != nil {
+			rowError.Row = i + 2
+			result.Errors = append(result.Errors, rowError)
+			continue
+		}
+		passages = append(passages, passage)
+	}
+	result.Failed = len(result.Errors)
+
+	for start := 0; start < len(passages); start += importBatchSize {
+		end := start + importBatchSize
+		if end > len(passages) {
+			end = len(passages)
+		}
+
+		err = s.formRepository.FormPassagesImport(ctx, formID, passages[start:end])
+		if err != nil {
+			return resp.NewResponse(http.StatusInternalServerError, nil), err
+		}
+		result.Imported = end
+	}
+	result.Sanitize(s.sanitizer)
+
+	return resp.NewResponse(http.StatusOK, result), nil
+}
+
+func (s *importService) readPassage(form *model.Form, header []string, columns []*importColumn, row []string,
+	separator string) (*model.FormPassage, *model.ImportRowError) {
+	passage := &model.FormPassage{
+		FormID:         form.ID,
+		PassageAnswers: []*model.PassageAnswer{},
+	}
+
+	for i, column := range columns {
+		if column == nil || i >= len(row) {
+			continue
+		}
+
+		cell := strings.TrimSpace(row[i])
+		if cell == "" {
+			continue
+		}
+
+		if column.timestamp {
+			finishedAt, err := parseImportTimestamp(cell, s.now())
+			if err != nil {
+				return nil, &model.ImportRowError{Column: header[i], Error: err.Error()}
+			}
+			passage.FinishedAt = &finishedAt
+			continue
+		}
+
+		texts := []string{cell}
+		if column.question.Type == model.MultipleAnswerType {
+			texts = strings.Split(cell, separator)
+		}
+		for _, text := range texts {
+			if text = strings.TrimSpace(text); text == "" {
+				continue
+			}
+			passage.PassageAnswers = append(passage.PassageAnswers, &model.PassageAnswer{
+				QuestionID: column.question.ID,
+				Text:       text,
+			})
+		}
+	}
+
+	if err := s.validate.Struct(passage); err != nil {
+		return nil, &model.ImportRowError{Error: err.Error()}
+	}
+
+	var formValidator Validator
+	if err := formValidator.Validate(passage, form); err != nil {
+		return nil, &model.ImportRowError{Error: err.Error()}
+	}
+
+	return passage, nil
+}
+
+// mapImportColumns maps the header row onto the questions of the form, columns mapped to nothing
+// are returned as ignored.
+func mapImportColumns(header []string, form *model.Form, responseImport *model.ResponseImport) ([]*importColumn, []string, error) {
+	questions := make(map[int64]*model.Question, len(form.Questions))
+	questionsByTitle := make(map[string]*model.Question, len(form.Questions))
+	for _, question := range form.Questions {
+		questions[*question.ID] = question
+		questionsByTitle[strings.ToLower(strings.TrimSpace(question.Title))] = question
+	}
+
+	headerColumns := make(map[string]bool, len(header))
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+		headerColumns[header[i]] = true
+	}
+
+	for title, questionID := range responseImport.Columns {
+		if !headerColumns[title] {
+			return nil, nil, fmt.Errorf("%w: %q", ErrImportUnknownColumn, title)
+		}
+		if _, ok := questions[questionID]; !ok {
+			return nil, nil, fmt.Errorf("%w: %q", ErrImportUnknownQuestion, title)
+		}
+	}
+
+	if responseImport.TimestampColumn != "" && !headerColumns[responseImport.TimestampColumn] {
+		return nil, nil, fmt.Errorf("%w: %q", ErrImportUnknownColumn, responseImport.TimestampColumn)
+	}
+
+	columns := make([]*importColumn, len(header))
+	ignored := []string{}
+	for i, title := range header {
+		if title == "" {
+			continue
+		}
+
+		if responseImport.TimestampColumn != "" && title == responseImport.TimestampColumn {
+			columns[i] = &importColumn{timestamp: true}
+			continue
+		}
+
+		if questionID, ok := responseImport.Columns[title]; ok {
+			columns[i] = &importColumn{question: questions[questionID]}
+			continue
+		}
+
+		if question, ok := questionsByTitle[strings.ToLower(title)]; ok {
+			columns[i] = &importColumn{question: question}
+			continue
+		}
+
+		ignored = append(ignored, title)
+	}
+
+	return columns, ignored, nil
+}
+
+func readImportRows(fileName string, file io.Reader, sheet string) ([][]string, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv":
+		reader := csv.NewReader(file)
+		reader.FieldsPerRecord = -1
+		rows, err := reader.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read csv: %w", err)
+		}
+		if len(rows) > 0 && len(rows[0]) > 0 {
+			// spreadsheets often save csv with a byte order mark
+			rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+		}
+		return rows, nil
+	case ".xlsx":
+		excelFile, err := excelize.OpenReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read xlsx: %w", err)
+		}
+		if sheet == "" {
+			sheet = excelFile.GetSheetName(1)
+			for _, name := range excelFile.GetSheetMap() {
+				if name == exportResponsesSheet {
+					sheet = name
+				}
+			}
+		}
+		return excelFile.GetRows(sheet), nil
+	default:
+		return nil, ErrUnsupportedImportFile
+	}
+}
+
+func parseImportTimestamp(value string, now time.Time) (time.Time, error) {
+	for _, layout := range importTimestampLayouts {
+		if timestamp, err := time.Parse(layout, value); err == nil {
+			return checkImportTimestamp(timestamp.UTC(), now)
+		}
+	}
+
+	if serial, err := strconv.ParseFloat(value, 64); err == nil && serial > 0 {
+		// Excel counts days from 1899-12-30
+		days, fraction := math.Modf(serial)
+		timestamp := time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).
+			AddDate(0, 0, int(days)).
+			Add(time.Duration(math.Round(fraction*24*60*60)) * time.Second)
+		return checkImportTimestamp(timestamp, now)
+	}
+
+	return time.Time{}, ErrImportTimestamp
+}
+
+func checkImportTimestamp(timestamp, now time.Time) (time.Time, error) {
+	if timestamp.After(now) {
+		return time.Time{}, ErrImportTimestamp
+	}
+	return timestamp, nil
+}
+
+func isEmptyRow(row []string) bool {
+	for _, cell := range row {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,273 @@
+package passage_test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/passage"
+
+	"github.com/360EntSecGroup-Skylar/excelize"
+	validator "github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeFormRepository struct {
+	repository.FormRepository
+
+	form    *model.Form
+	batches [][]*model.FormPassage
+}
+
+func (r *fakeFormRepository) FindByID(_ context.Context, _ int64) (*model.Form, error) {
+	return r.form, nil
+}
+
+func (r *fakeFormRepository) FormPassagesImport(_ context.Context, _ int64, passages []*model.FormPassage) error {
+	r.batches = append(r.batches, passages)
+	return nil
+}
+
+func (r *fakeFormRepository) saved() []*model.FormPassage {
+	passages := []*model.FormPassage{}
+	for _, batch := range r.batches {
+		passages = append(passages, batch...)
+	}
+	return passages
+}
+
+var importNow = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func int64Ptr(value int64) *int64 {
+	return &value
+}
+
+func importForm() *model.Form {
+	return &model.Form{
+		ID:     int64Ptr(1),
+		Title:  "Pets",
+		Author: &model.UserGet{ID: 7},
+		Questions: []*model.Question{
+			{
+				ID:       int64Ptr(10),
+				Title:    "Favourite color",
+				Type:     model.SingleAnswerType,
+				Required: true,
+				Answers: []*model.Answer{
+					{ID: int64Ptr(100), Text: "Red"},
+					{ID: int64Ptr(101), Text: "Blue"},
+				},
+			},
+			{
+				ID:    int64Ptr(11),
+				Title: "Pets",
+				Type:  model.MultipleAnswerType,
+				Answers: []*model.Answer{
+					{ID: int64Ptr(110), Text: "Cat"},
+					{ID: int64Ptr(111), Text: "Dog"},
+				},
+			},
+			{
+				ID:    int64Ptr(12),
+				Title: "Comment",
+				Type:  model.InputAnswerType,
+			},
+		},
+	}
+}
+
+func newImportService(repo *fakeFormRepository) passage.Service {
+	return passage.NewImportService(repo, func() time.Time { return importNow }, validator.New())
+}
+
+func authorContext() context.Context {
+	return context.WithValue(context.Background(), model.ContextCurrentUser, &model.UserGet{ID: 7})
+}
+
+func TestResponsesImportCSV(t *testing.T) {
+	repo := &fakeFormRepository{form: importForm()}
+	service := newImportService(repo)
+
+	file := strings.Join([]string{
+		"\ufeffSubmitted,favourite color,Pets,Comment,Source",
+		"2023-05-04 10:30:00,Red,Cat; Dog,on paper,paper",
+		"2023-05-05,Green,,,paper",
+		",,Cat,,web",
+		"not a date,Blue,,,web",
+		",,,,",
+		"2023-05-06T08:00:00Z,Blue,Dog,,web",
+	}, "\n")
+
+	response, err := service.ResponsesImport(authorContext(), 1, "survey.CSV", strings.NewReader(file), &model.ResponseImport{
+		TimestampColumn: "Submitted",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	result := response.Body.(*model.ResponseImportResult)
+	assert.Equal(t, 2, result.Imported)
+	assert.Equal(t, 3, result.Failed)
+	assert.Equal(t, []string{"Source"}, result.IgnoredColumns)
+
+	rows := []int{}
+	for _, rowError := range result.Errors {
+		rows = append(rows, rowError.Row)
+	}
+	assert.Equal(t, []int{3, 4, 5}, rows)
+	assert.Contains(t, result.Errors[0].Error, passage.ErrAnswerDoesntExist.Error())
+	assert.Equal(t, passage.ErrRequiredQuestionUnanswered.Error(), result.Errors[1].Error)
+	assert.Equal(t, "Submitted", result.Errors[2].Column)
+
+	saved := repo.saved()
+	if assert.Len(t, saved, 2) {
+		assert.Equal(t, time.Date(2023, 5, 4, 10, 30, 0, 0, time.UTC), *saved[0].FinishedAt)
+		assert.Len(t, saved[0].PassageAnswers, 4)
+		assert.Equal(t, "Dog", saved[0].PassageAnswers[2].Text)
+		assert.Equal(t, time.Date(2023, 5, 6, 8, 0, 0, 0, time.UTC), *saved[1].FinishedAt)
+	}
+}
+
+func TestResponsesImportXLSX(t *testing.T) {
+	repo := &fakeFormRepository{form: importForm()}
+	service := newImportService(repo)
+
+	excelFile := excelize.NewFile()
+	excelFile.NewSheet("Responses")
+	excelFile.SetCellValue("Responses", "A1", "Finished at")
+	excelFile.SetCellValue("Responses", "B1", "Q1")
+	excelFile.SetCellValue("Responses", "C1", "Q2")
+	excelFile.SetCellValue("Responses", "A2", time.Date(2022, 1, 2, 9, 15, 0, 0, time.UTC))
+	excelFile.SetCellValue("Responses", "B2", "Blue")
+	excelFile.SetCellValue("Responses", "C2", "Cat, Dog")
+	excelFile.SetCellValue("Responses", "A3", "2022-01-03 10:00:00")
+	excelFile.SetCellValue("Responses", "B3", "Red")
+
+	var file bytes.Buffer
+	assert.Nil(t, excelFile.Write(&file))
+
+	response, err := service.ResponsesImport(authorContext(), 1, "export.xlsx", &file, &model.ResponseImport{
+		Columns:         map[string]int64{"Q1": 10, "Q2": 11},
+		TimestampColumn: "Finished at",
+		Separator:       ",",
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	result := response.Body.(*model.ResponseImportResult)
+	assert.Equal(t, 2, result.Imported)
+	assert.Empty(t, result.Errors)
+
+	saved := repo.saved()
+	if assert.Len(t, saved, 2) {
+		assert.Equal(t, time.Date(2022, 1, 2, 9, 15, 0, 0, time.UTC), saved[0].FinishedAt.Truncate(time.Minute))
+		assert.Len(t, saved[0].PassageAnswers, 3)
+		assert.Len(t, saved[1].PassageAnswers, 1)
+	}
+}
+
+func TestResponsesImportBatches(t *testing.T) {
+	repo := &fakeFormRepository{form: importForm()}
+	service := newImportService(repo)
+
+	lines := []string{"Favourite color"}
+	for i := 0; i < 1201; i++ {
+		lines = append(lines, "Red")
+	}
+
+	response, err := service.ResponsesImport(authorContext(), 1, "paper.csv", strings.NewReader(strings.Join(lines, "\n")), &model.ResponseImport{})
+	assert.Nil(t, err)
+	assert.Equal(t, 1201, response.Body.(*model.ResponseImportResult).Imported)
+
+	sizes := []int{}
+	for _, batch := range repo.batches {
+		sizes = append(sizes, len(batch))
+	}
+	assert.Equal(t, []int{500, 500, 201}, sizes)
+}
+
+func TestResponsesImportRejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		fileName string
+		file     string
+		options  *model.ResponseImport
+		status   int
+		err      error
+	}{
+		{
+			name:     "NotAuthor",
+			ctx:      context.WithValue(context.Background(), model.ContextCurrentUser, &model.UserGet{ID: 8}),
+			fileName: "paper.csv",
+			file:     "Favourite color\nRed",
+			options:  &model.ResponseImport{},
+			status:   http.StatusForbidden,
+		},
+		{
+			name:     "UnsupportedFile",
+			ctx:      authorContext(),
+			fileName: "paper.txt",
+			file:     "Favourite color\nRed",
+			options:  &model.ResponseImport{},
+			status:   http.StatusBadRequest,
+			err:      passage.ErrUnsupportedImportFile,
+		},
+		{
+			name:     "UnknownColumn",
+			ctx:      authorContext(),
+			fileName: "paper.csv",
+			file:     "Favourite color\nRed",
+			options:  &model.ResponseImport{TimestampColumn: "Date"},
+			status:   http.StatusBadRequest,
+			err:      passage.ErrImportUnknownColumn,
+		},
+		{
+			name:     "UnknownQuestion",
+			ctx:      authorContext(),
+			fileName: "paper.csv",
+			file:     "Color\nRed",
+			options:  &model.ResponseImport{Columns: map[string]int64{"Color": 99}},
+			status:   http.StatusBadRequest,
+			err:      passage.ErrImportUnknownQuestion,
+		},
+		{
+			name:     "Empty",
+			ctx:      authorContext(),
+			fileName: "paper.csv",
+			file:     "",
+			options:  &model.ResponseImport{},
+			status:   http.StatusBadRequest,
+			err:      passage.ErrImportFileEmpty,
+		},
+		{
+			name:     "TooManyRows",
+			ctx:      authorContext(),
+			fileName: "paper.csv",
+			file:     "Favourite color" + strings.Repeat("\nRed", passage.ImportMaxRows+1),
+			options:  &model.ResponseImport{},
+			status:   http.StatusBadRequest,
+			err:      passage.ErrImportTooManyRows,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			repo := &fakeFormRepository{form: importForm()}
+			service := newImportService(repo)
+
+			response, err := service.ResponsesImport(test.ctx, 1, test.fileName, strings.NewReader(test.file), test.options)
+			assert.Equal(t, test.status, response.StatusCode)
+			if test.err != nil {
+				assert.ErrorIs(t, err, test.err, fmt.Sprint(err))
+			}
+			assert.Empty(t, repo.batches)
+		})
+	}
+}
@@ -1,4 +1,4 @@
-package usecase
+package passage
 
 import (
 	"errors"
@@ -7,7 +7,9 @@ import (
 	"go-form-hub/internal/model"
 )
 
-type passageValidator struct {
+// Validator checks the answers of a passage against the questions of the form. The passage microservice
+// and the response import apply the same rules through it.
+type Validator struct {
 	questionMap       map[int64]*model.Question
 	foundAnswerMap    map[int64]bool
 	foundQuestionsMap map[int64]bool
@@ -21,7 +23,7 @@ var (
 	ErrDuplicateAnswer            = errors.New("duplicate answer to multiple answer question")
 )
 
-func (v *passageValidator) validateFormPassage(formPassage *model.FormPassage, form *model.Form) error {
+func (v *Validator) Validate(formPassage *model.FormPassage, form *model.Form) error {
 	v.questionMap = questionMapFromArray(form.Questions)
 	v.foundQuestionsMap = make(map[int64]bool)
 	v.foundAnswerMap = make(map[int64]bool)
@@ -43,7 +45,7 @@ func (v *passageValidator) validateFormPassage(formPassage *model.FormPassage, f
 	return nil
 }
 
-func (v *passageValidator) validatePassageAnswer(passageAnswer *model.PassageAnswer) error {
+func (v *Validator) validatePassageAnswer(passageAnswer *model.PassageAnswer) error {
 	question, found := v.questionMap[*passageAnswer.QuestionID]
 	if !found {
 		return ErrQuestionDoesntExist
@@ -0,0 +1,159 @@
+// Package password hashes the passwords of users and verifies them against the stored hashes,
+// including the formats of earlier versions so that they can be upgraded on the next login.
+package password
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5" // nolint:gosec
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const argon2idPrefix = "$argon2id$"
+
+var ErrMalformedHash = errors.New("the stored password hash is malformed")
+
+// Params are the argon2id parameters new hashes are made with. They are encoded into every hash,
+// so changing them does not break the verification of existing ones.
+type Params struct {
+	Memory      uint32
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultParams follow the second recommended option of RFC 9106 with a smaller memory cost.
+var DefaultParams = Params{
+	Memory:      64 * 1024,
+	Iterations:  3,
+	Parallelism: 2,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// Hasher hashes passwords with argon2id. It verifies argon2id and bcrypt hashes, and the passwords
+// encrypted with AES-GCM under legacyKey that were stored before hashing was introduced.
+type Hasher struct {
+	params    Params
+	legacyKey string
+}
+
+func NewHasher(params Params, legacyKey string) *Hasher {
+	return &Hasher{
+		params:    params,
+		legacyKey: legacyKey,
+	}
+}
+
+// Hash returns the PHC encoded argon2id hash of the password with a random salt.
+func (h *Hasher) Hash(password string) (string, error) {
+	salt := make([]byte, h.params.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("password hash failed to generate salt: %v", err)
+	}
+
+	key := argon2.IDKey([]byte(password), salt, h.params.Iterations, h.params.Memory, h.params.Parallelism, h.params.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", argon2idPrefix, argon2.Version,
+		h.params.Memory, h.params.Iterations, h.params.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify reports whether the password matches the stored hash, and whether the hash should be
+// replaced by Hash because it is in a legacy format or was made with other parameters.
+func (h *Hasher) Verify(password, stored string) (ok, rehash bool, err error) {
+	switch {
+	case strings.HasPrefix(stored, argon2idPrefix):
+		return h.verifyArgon2id(password, stored)
+	case strings.HasPrefix(stored, "$2"):
+		err = bcrypt.CompareHashAndPassword([]byte(stored), []byte(password))
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, false, nil
+		}
+		if err != nil {
+			return false, false, fmt.Errorf("%w: %v", ErrMalformedHash, err)
+		}
+		return true, true, nil
+	default:
+		return h.verifyLegacy(password, stored)
+	}
+}
+
+func (h *Hasher) verifyArgon2id(password, stored string) (ok, rehash bool, err error) {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return false, false, ErrMalformedHash
+	}
+
+	var version int
+	if _, err = fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false, false, ErrMalformedHash
+	}
+
+	var params Params
+	if _, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism); err != nil {
+		return false, false, ErrMalformedHash
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, ErrMalformedHash
+	}
+
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return false, false, ErrMalformedHash
+	}
+
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(key))
+
+	computed := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+	if subtle.ConstantTimeCompare(computed, key) != 1 {
+		return false, false, nil
+	}
+
+	return true, params != h.params, nil
+}
+
+// verifyLegacy encrypts the password the way it was stored before and compares the result.
+func (h *Hasher) verifyLegacy(password, stored string) (ok, rehash bool, err error) {
+	keyBytes, err := hex.DecodeString(h.legacyKey)
+	if err != nil {
+		return false, false, fmt.Errorf("password verify invalid hex-encoded legacy key: %v", err)
+	}
+
+	if len(keyBytes) != 32 {
+		return false, false, fmt.Errorf("password verify invalid legacy key length: expected 32 bytes, got %d", len(keyBytes))
+	}
+
+	block, err := aes.NewCipher(keyBytes)
+	if err != nil {
+		return false, false, err
+	}
+
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return false, false, err
+	}
+
+	digest := md5.Sum([]byte(password)) // nolint:gosec
+	nonce := digest[:aesgcm.NonceSize()]
+	encrypted := hex.EncodeToString(nonce) + hex.EncodeToString(aesgcm.Seal(nil, nonce, []byte(password), nil))
+
+	if subtle.ConstantTimeCompare([]byte(encrypted), []byte(stored)) != 1 {
+		return false, false, nil
+	}
+
+	return true, true, nil
+}
@@ -0,0 +1,125 @@
+package password_test
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/md5" // nolint:gosec
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"go-form-hub/internal/services/password"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const legacyKey = "6368616e676520746869732070617373776f726420746f206120736563726574"
+
+var testParams = password.Params{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// legacyEncrypt stores the password the way the services did before hashing was introduced.
+func legacyEncrypt(t *testing.T, pass string) string {
+	keyBytes, _ := hex.DecodeString(legacyKey)
+	block, err := aes.NewCipher(keyBytes)
+	if err != nil {
+		t.Fatalf("failed to create cipher: %v", err)
+	}
+	aesgcm, err := cipher.NewGCM(block)
+	if err != nil {
+		t.Fatalf("failed to create gcm: %v", err)
+	}
+
+	digest := md5.Sum([]byte(pass)) // nolint:gosec
+	nonce := digest[:12]
+	return hex.EncodeToString(nonce) + hex.EncodeToString(aesgcm.Seal(nil, nonce, []byte(pass), nil))
+}
+
+func TestHasherHash(t *testing.T) {
+	hasher := password.NewHasher(testParams, legacyKey)
+
+	hash, err := hasher.Hash("correct horse")
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.True(t, strings.HasPrefix(hash, "$argon2id$v=19$m=1024,t=1,p=1$"))
+
+	again, err := hasher.Hash("correct horse")
+	assert.Nil(t, err)
+	assert.NotEqual(t, hash, again, "hashes of the same password must use different salts")
+
+	ok, rehash, err := hasher.Verify("correct horse", hash)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.False(t, rehash)
+
+	ok, rehash, err = hasher.Verify("battery staple", hash)
+	assert.Nil(t, err)
+	assert.False(t, ok)
+	assert.False(t, rehash)
+}
+
+func TestHasherVerify(t *testing.T) {
+	hasher := password.NewHasher(testParams, legacyKey)
+
+	weaker := testParams
+	weaker.Iterations = 2
+	otherParams, err := password.NewHasher(weaker, legacyKey).Hash("correct horse")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	tests := []struct {
+		name   string
+		stored string
+		ok     bool
+		rehash bool
+	}{
+		{name: "OtherParams", stored: otherParams, ok: true, rehash: true},
+		{name: "Bcrypt", stored: string(bcryptHash), ok: true, rehash: true},
+		{name: "Legacy", stored: legacyEncrypt(t, "correct horse"), ok: true, rehash: true},
+		{name: "LegacyWrongPassword", stored: legacyEncrypt(t, "battery staple")},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ok, rehash, err := hasher.Verify("correct horse", test.stored)
+			assert.Nil(t, err)
+			assert.Equal(t, test.ok, ok)
+			assert.Equal(t, test.rehash, rehash)
+		})
+	}
+}
+
+func TestHasherVerifyMalformed(t *testing.T) {
+	hasher := password.NewHasher(testParams, legacyKey)
+
+	for _, stored := range []string{
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA",
+		"$argon2id$v=16$m=1024,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdA$a2V5",
+		"$argon2id$v=19$m=1024,t=1,p=1$!!$a2V5",
+		"$2a$10$short",
+	} {
+		ok, _, err := hasher.Verify("correct horse", stored)
+		assert.ErrorIs(t, err, password.ErrMalformedHash, stored)
+		assert.False(t, ok, stored)
+	}
+
+	_, _, err := password.NewHasher(testParams, "not hex").Verify("correct horse", "00")
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,205 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store keeps the failed attempts per key for a Backoff. MemoryStore serves a single process,
+// replicas have to share a store in the database.
+type Store interface {
+	// Failures returns how many failures the key has and when the last one happened.
+	Failures(ctx context.Context, key string) (int, time.Time, error)
+	// Fail records a failure of the key at now and returns how many failures the key has and when
+	// the failure before it happened, zero if it is the first. The failures are counted from
+	// scratch when the last one happened before since.
+	Fail(ctx context.Context, key string, now, since time.Time) (int, time.Time, error)
+	// Reset forgets the failures of the key.
+	Reset(ctx context.Context, key string) error
+	// Purge forgets the keys whose last failure happened before before, and returns how many.
+	Purge(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Policy says how much a key slows down with its failures.
+type Policy struct {
+	// Free is how many failures go without a delay.
+	Free int
+	// BaseDelay is the delay after the first failure past Free, it doubles with every further
+	// failure up to MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutAfter failures lock the key out for Lockout, and so does every failure after them.
+	LockoutAfter int
+	Lockout      time.Duration
+	// Window is how long a key has to go without failures for them to be forgotten.
+	Window time.Duration
+}
+
+// Delay returns how long a key with the failures has to wait after the last of them.
+func (p Policy) Delay(failures int) time.Duration {
+	switch {
+	case failures >= p.LockoutAfter:
+		return p.Lockout
+	case failures <= p.Free:
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.Free + 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+
+	if delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// Backoff delays the attempts of a key exponentially with its failures and locks the key out
+// after too many, unlike Limiter, which allows a fixed number of failures in a window.
+type Backoff struct {
+	store  Store
+	policy Policy
+	now    func() time.Time
+}
+
+func NewBackoff(store Store, policy Policy, now func() time.Time) *Backoff {
+	return &Backoff{
+		store:  store,
+		policy: policy,
+		now:    now,
+	}
+}
+
+// Wait returns how long the key has to wait before its next attempt, 0 if it may try now.
+func (b *Backoff) Wait(ctx context.Context, key string) (time.Duration, error) {
+	failures, last, err := b.store.Failures(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+
+	now := b.now().UTC()
+	if failures == 0 || !last.After(now.Add(-b.policy.Window)) {
+		return 0, nil
+	}
+
+	wait := last.Add(b.policy.Delay(failures)).Sub(now)
+	if wait < 0 {
+		return 0, nil
+	}
+	return wait, nil
+}
+
+// Fail records a failed attempt of the key, and reports whether the failure locked the key out
+// for the first time since its failures were last forgotten.
+func (b *Backoff) Fail(ctx context.Context, key string) (lockedOut bool, err error) {
+	now := b.now().UTC()
+	failures, _, err := b.store.Fail(ctx, key, now, now.Add(-b.policy.Window))
+	if err != nil {
+		return false, err
+	}
+
+	return failures == b.policy.LockoutAfter, nil
+}
+
+// Attempt records an attempt of the key as a failure before it is made, so that parallel attempts
+// cannot all get past Wait before any of them fails, and returns how long the attempt had to wait,
+// 0 if it may go ahead. Reset forgets it once it succeeds. lockedOut reports whether the attempt
+// locks the key out for the first time, should it fail.
+func (b *Backoff) Attempt(ctx context.Context, key string) (wait time.Duration, lockedOut bool, err error) {
+	now := b.now().UTC()
+	failures, previous, err := b.store.Fail(ctx, key, now, now.Add(-b.policy.Window))
+	if err != nil {
+		return 0, false, err
+	}
+
+	// the failures before the attempt decide its delay, counted from the last of them
+	if failures > 1 {
+		wait = previous.Add(b.policy.Delay(failures - 1)).Sub(now)
+	}
+	if wait < 0 {
+		wait = 0
+	}
+
+	return wait, failures == b.policy.LockoutAfter, nil
+}
+
+// Reset forgets the failures of the key, after a successful attempt.
+func (b *Backoff) Reset(ctx context.Context, key string) error {
+	return b.store.Reset(ctx, key)
+}
+
+// Purge forgets the keys without failures in the window of the policy.
+func (b *Backoff) Purge(ctx context.Context) (int64, error) {
+	return b.store.Purge(ctx, b.now().UTC().Add(-b.policy.Window))
+}
+
+// Lockout is how long a key stays locked out.
+func (b *Backoff) Lockout() time.Duration {
+	return b.policy.Lockout
+}
+
+type memoryFailures struct {
+	count int
+	last  time.Time
+}
+
+// MemoryStore keeps the failures in the process. It is safe for concurrent use.
+type MemoryStore struct {
+	mu       sync.Mutex
+	failures map[string]*memoryFailures
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{failures: make(map[string]*memoryFailures)}
+}
+
+func (s *MemoryStore) Failures(_ context.Context, key string) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failures, ok := s.failures[key]
+	if !ok {
+		return 0, time.Time{}, nil
+	}
+	return failures.count, failures.last, nil
+}
+
+func (s *MemoryStore) Fail(_ context.Context, key string, now, since time.Time) (int, time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	failures, ok := s.failures[key]
+	if !ok || failures.last.Before(since) {
+		failures = &memoryFailures{}
+		s.failures[key] = failures
+	}
+	previous := failures.last
+	failures.count++
+	failures.last = now
+
+	return failures.count, previous, nil
+}
+
+func (s *MemoryStore) Reset(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.failures, key)
+	return nil
+}
+
+func (s *MemoryStore) Purge(_ context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var purged int64
+	for key, failures := range s.failures {
+		if failures.last.Before(before) {
+			delete(s.failures, key)
+			purged++
+		}
+	}
+	return purged, nil
+}
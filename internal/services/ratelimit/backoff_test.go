@@ -0,0 +1,167 @@
+package ratelimit_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/services/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testPolicy = ratelimit.Policy{
+	Free:         2,
+	BaseDelay:    time.Second,
+	MaxDelay:     8 * time.Second,
+	LockoutAfter: 8,
+	Lockout:      15 * time.Minute,
+	Window:       time.Hour,
+}
+
+func TestPolicyDelay(t *testing.T) {
+	delays := []time.Duration{0, 0, 0, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second, 15 * time.Minute, 15 * time.Minute}
+	for failures, delay := range delays {
+		assert.Equal(t, delay, testPolicy.Delay(failures), "failures: %d", failures)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	now := time.Date(2023, 12, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	backoff := ratelimit.NewBackoff(ratelimit.NewMemoryStore(), testPolicy, clock)
+	ctx := context.Background()
+
+	fail := func(times int) (lockedOut bool) {
+		for i := 0; i < times; i++ {
+			locked, err := backoff.Fail(ctx, "account")
+			assert.Nil(t, err)
+			lockedOut = lockedOut || locked
+		}
+		return lockedOut
+	}
+
+	assert.False(t, fail(2))
+	wait, err := backoff.Wait(ctx, "account")
+	assert.Nil(t, err)
+	assert.Zero(t, wait, "the first failures are free")
+
+	assert.False(t, fail(2))
+	wait, _ = backoff.Wait(ctx, "account")
+	assert.Equal(t, 2*time.Second, wait)
+
+	now = now.Add(time.Second)
+	wait, _ = backoff.Wait(ctx, "account")
+	assert.Equal(t, time.Second, wait, "the wait counts from the last failure")
+
+	wait, _ = backoff.Wait(ctx, "other")
+	assert.Zero(t, wait, "keys are limited separately")
+
+	assert.True(t, fail(4), "the eighth failure locks the key out")
+	wait, _ = backoff.Wait(ctx, "account")
+	assert.Equal(t, 15*time.Minute, wait)
+
+	assert.False(t, fail(1), "the lockout is only reported once")
+
+	now = now.Add(15 * time.Minute)
+	wait, _ = backoff.Wait(ctx, "account")
+	assert.Zero(t, wait)
+
+	assert.False(t, fail(1))
+	wait, _ = backoff.Wait(ctx, "account")
+	assert.Equal(t, 15*time.Minute, wait, "failures after the lockout lock the key out again")
+
+	now = now.Add(2 * time.Hour)
+	wait, _ = backoff.Wait(ctx, "account")
+	assert.Zero(t, wait, "failures are forgotten after the window")
+	assert.False(t, fail(1))
+	wait, _ = backoff.Wait(ctx, "account")
+	assert.Zero(t, wait, "the count starts over")
+
+	assert.Nil(t, backoff.Reset(ctx, "account"))
+	fail(3)
+	wait, _ = backoff.Wait(ctx, "account")
+	assert.Equal(t, time.Second, wait)
+}
+
+func TestBackoffAttempt(t *testing.T) {
+	now := time.Date(2023, 12, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	backoff := ratelimit.NewBackoff(ratelimit.NewMemoryStore(), testPolicy, clock)
+	ctx := context.Background()
+
+	for i := 0; i < testPolicy.Free+1; i++ {
+		wait, lockedOut, err := backoff.Attempt(ctx, "account")
+		assert.Nil(t, err)
+		assert.Zero(t, wait, "the attempts after the free failures go ahead")
+		assert.False(t, lockedOut)
+	}
+
+	wait, _, _ := backoff.Attempt(ctx, "account")
+	assert.Equal(t, time.Second, wait, "an attempt made right after the last one waits")
+
+	now = now.Add(4 * time.Second)
+	wait, _, _ = backoff.Attempt(ctx, "account")
+	assert.Zero(t, wait, "the attempts waited for count too")
+
+	assert.Nil(t, backoff.Reset(ctx, "account"))
+	wait, _, _ = backoff.Attempt(ctx, "account")
+	assert.Zero(t, wait, "a successful attempt forgets the failures")
+
+	for i := 0; i < testPolicy.LockoutAfter-2; i++ {
+		_, _ = backoff.Fail(ctx, "account")
+	}
+	_, lockedOut, _ := backoff.Attempt(ctx, "account")
+	assert.True(t, lockedOut, "the attempt locks the key out if it fails")
+}
+
+func TestBackoffAttemptParallel(t *testing.T) {
+	now := time.Date(2023, 12, 1, 9, 0, 0, 0, time.UTC)
+	backoff := ratelimit.NewBackoff(ratelimit.NewMemoryStore(), testPolicy, func() time.Time { return now })
+
+	const attempts = 20
+	waits := make(chan time.Duration, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait, _, err := backoff.Attempt(context.Background(), "account")
+			assert.Nil(t, err)
+			waits <- wait
+		}()
+	}
+	wg.Wait()
+	close(waits)
+
+	allowed := 0
+	for wait := range waits {
+		if wait == 0 {
+			allowed++
+		}
+	}
+	assert.Equal(t, testPolicy.Free+1, allowed, "parallel attempts cannot all go ahead")
+}
+
+func TestBackoffPurge(t *testing.T) {
+	now := time.Date(2023, 12, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	store := ratelimit.NewMemoryStore()
+	backoff := ratelimit.NewBackoff(store, testPolicy, clock)
+	ctx := context.Background()
+
+	_, _ = backoff.Fail(ctx, "old")
+	now = now.Add(50 * time.Minute)
+	_, _ = backoff.Fail(ctx, "recent")
+	now = now.Add(20 * time.Minute)
+
+	purged, err := backoff.Purge(ctx)
+	assert.Nil(t, err)
+	assert.Equal(t, int64(1), purged)
+
+	failures, _, _ := store.Failures(ctx, "recent")
+	assert.Equal(t, 1, failures)
+	failures, _, _ = store.Failures(ctx, "old")
+	assert.Zero(t, failures)
+}
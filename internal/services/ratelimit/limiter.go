@@ -0,0 +1,89 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// Limiter counts failed attempts per key, a client for example, and blocks the key once it has
+// max failures within the sliding window. It is safe for concurrent use.
+type Limiter struct {
+	mu        sync.Mutex
+	max       int
+	window    time.Duration
+	now       func() time.Time
+	failures  map[string][]time.Time
+	lastSweep time.Time
+}
+
+func NewLimiter(max int, window time.Duration, now func() time.Time) *Limiter {
+	return &Limiter{
+		max:       max,
+		window:    window,
+		now:       now,
+		failures:  make(map[string][]time.Time),
+		lastSweep: now(),
+	}
+}
+
+// Allow reports whether the key may make another attempt, and if not, how long until it may.
+func (l *Limiter) Allow(key string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	failures := l.recent(key, now)
+	if len(failures) < l.max {
+		return true, 0
+	}
+
+	return false, failures[len(failures)-l.max].Add(l.window).Sub(now)
+}
+
+// Fail records a failed attempt of the key.
+func (l *Limiter) Fail(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.failures[key] = append(l.recent(key, now), now)
+	l.sweep(now)
+}
+
+// Reset forgets the failures of the key, after a successful attempt.
+func (l *Limiter) Reset(key string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	delete(l.failures, key)
+}
+
+// recent drops the failures of the key that are out of the window and returns the rest.
+func (l *Limiter) recent(key string, now time.Time) []time.Time {
+	failures := l.failures[key]
+	start := 0
+	for start < len(failures) && !failures[start].After(now.Add(-l.window)) {
+		start++
+	}
+
+	if start == len(failures) {
+		delete(l.failures, key)
+		return nil
+	}
+
+	failures = failures[start:]
+	l.failures[key] = failures
+	return failures
+}
+
+// sweep drops the keys without recent failures once per window, so the map does not grow forever.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+
+	for key := range l.failures {
+		l.recent(key, now)
+	}
+}
@@ -0,0 +1,42 @@
+package ratelimit_test
+
+import (
+	"testing"
+	"time"
+
+	"go-form-hub/internal/services/ratelimit"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLimiter(t *testing.T) {
+	now := time.Date(2023, 12, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	limiter := ratelimit.NewLimiter(3, time.Minute, clock)
+
+	for i := 0; i < 3; i++ {
+		allowed, _ := limiter.Allow("client")
+		assert.True(t, allowed)
+		limiter.Fail("client")
+		now = now.Add(10 * time.Second)
+	}
+
+	allowed, retryAfter := limiter.Allow("client")
+	assert.False(t, allowed)
+	assert.Equal(t, 30*time.Second, retryAfter)
+
+	allowed, _ = limiter.Allow("other")
+	assert.True(t, allowed, "keys are limited separately")
+
+	now = now.Add(30 * time.Second)
+	allowed, _ = limiter.Allow("client")
+	assert.True(t, allowed, "the first failure is out of the window")
+
+	limiter.Fail("client")
+	allowed, _ = limiter.Allow("client")
+	assert.False(t, allowed)
+
+	limiter.Reset("client")
+	allowed, _ = limiter.Allow("client")
+	assert.True(t, allowed)
+}
@@ -0,0 +1,260 @@
+package recipient
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/mail"
+	resp "go-form-hub/internal/services/service_response"
+
+	validator "github.com/go-playground/validator/v10"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/rs/zerolog/log"
+)
+
+const tokenSize = 32
+
+type Service interface {
+	RecipientList(ctx context.Context, formID int64) (*resp.Response, error)
+	RecipientsSave(ctx context.Context, formID int64, save *model.RecipientsSave) (*resp.Response, error)
+	RecipientDelete(ctx context.Context, formID, recipientID int64) (*resp.Response, error)
+	RecipientsRemind(ctx context.Context, formID int64) (*resp.Response, error)
+}
+
+type recipientService struct {
+	formRepository      repository.FormRepository
+	recipientRepository repository.RecipientRepository
+	sender              mail.Sender
+	appURL              string
+	now                 func() time.Time
+	sanitizer           *bluemonday.Policy
+	validate            *validator.Validate
+}
+
+func NewRecipientService(formRepository repository.FormRepository, recipientRepository repository.RecipientRepository, sender mail.Sender,
+	appURL string, now func() time.Time, validate *validator.Validate) Service {
+	return &recipientService{
+		formRepository:      formRepository,
+		recipientRepository: recipientRepository,
+		sender:              sender,
+		appURL:              strings.TrimRight(appURL, "/"),
+		now:                 now,
+		sanitizer:           bluemonday.UGCPolicy(),
+		validate:            validate,
+	}
+}
+
+func (s *recipientService) RecipientList(ctx context.Context, formID int64) (*resp.Response, error) {
+	if _, response, err := s.findAuthorForm(ctx, formID); response != nil {
+		return response, err
+	}
+
+	recipients, err := s.recipientRepository.FindAllByForm(ctx, formID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	list := &model.RecipientList{
+		CollectionResponse: model.CollectionResponse{
+			Count: len(recipients),
+		},
+		Recipients: recipients,
+	}
+	for _, recipient := range recipients {
+		if recipient.Responded() {
+			list.Responded++
+		} else {
+			list.Pending++
+		}
+	}
+	list.Sanitize(s.sanitizer)
+
+	return resp.NewResponse(http.StatusOK, list), nil
+}
+
+// RecipientsSave invites the recipients to the form. Each of them gets a new personal link,
+// which is returned once and, if asked, emailed right away.
+func (s *recipientService) RecipientsSave(ctx context.Context, formID int64, save *model.RecipientsSave) (*resp.Response, error) {
+	for _, recipient := range save.Recipients {
+		if recipient != nil {
+			recipient.Email = strings.ToLower(strings.TrimSpace(recipient.Email))
+		}
+	}
+
+	if err := s.validate.Struct(save); err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), err
+	}
+
+	form, response, err := s.findAuthorForm(ctx, formID)
+	if response != nil {
+		return response, err
+	}
+
+	maxUses := save.MaxUses
+	if maxUses == 0 {
+		maxUses = 1
+	}
+
+	now := s.now().UTC()
+	byEmail := make(map[string]*model.FormRecipient, len(save.Recipients))
+	recipients := make([]*model.FormRecipient, 0, len(save.Recipients))
+	for _, recipient := range save.Recipients {
+		if _, ok := byEmail[recipient.Email]; ok {
+			continue
+		}
+		byEmail[recipient.Email] = recipient
+
+		if recipient.Token, err = newToken(); err != nil {
+			return resp.NewResponse(http.StatusInternalServerError, nil), err
+		}
+		recipient.MaxUses = maxUses
+		recipient.InvitedAt = now
+		recipients = append(recipients, recipient)
+	}
+
+	if err = s.recipientRepository.Save(ctx, formID, recipients); err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	for _, recipient := range recipients {
+		recipient.Link = s.link(formID, recipient.Token)
+		if save.SendInvites {
+			s.sendInvitation(ctx, form, recipient, false)
+		}
+	}
+
+	list := &model.RecipientList{
+		CollectionResponse: model.CollectionResponse{
+			Count: len(recipients),
+		},
+		Recipients: recipients,
+	}
+	list.Sanitize(s.sanitizer)
+
+	return resp.NewResponse(http.StatusOK, list), nil
+}
+
+func (s *recipientService) RecipientDelete(ctx context.Context, formID, recipientID int64) (*resp.Response, error) {
+	if _, response, err := s.findAuthorForm(ctx, formID); response != nil {
+		return response, err
+	}
+
+	deleted, err := s.recipientRepository.Delete(ctx, formID, recipientID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if !deleted {
+		return resp.NewResponse(http.StatusNotFound, nil), nil
+	}
+
+	return resp.NewResponse(http.StatusOK, nil), nil
+}
+
+// RecipientsRemind emails new links to everyone who has not responded yet; their earlier links stop working.
+// The links are returned as well, so the author can pass them on if an email does not arrive.
+func (s *recipientService) RecipientsRemind(ctx context.Context, formID int64) (*resp.Response, error) {
+	form, response, err := s.findAuthorForm(ctx, formID)
+	if response != nil {
+		return response, err
+	}
+
+	recipients, err := s.recipientRepository.FindAllByForm(ctx, formID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	pending := make([]*model.FormRecipient, 0, len(recipients))
+	for _, recipient := range recipients {
+		if recipient.Responded() {
+			continue
+		}
+
+		if recipient.Token, err = newToken(); err != nil {
+			return resp.NewResponse(http.StatusInternalServerError, nil), err
+		}
+		pending = append(pending, recipient)
+	}
+
+	reminded, err := s.recipientRepository.Remind(ctx, formID, pending, s.now().UTC())
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	for _, recipient := range reminded {
+		recipient.Link = s.link(formID, recipient.Token)
+		s.sendInvitation(ctx, form, recipient, true)
+	}
+
+	list := &model.RecipientList{
+		CollectionResponse: model.CollectionResponse{
+			Count: len(reminded),
+		},
+		Pending:    len(reminded),
+		Recipients: reminded,
+	}
+	list.Sanitize(s.sanitizer)
+
+	return resp.NewResponse(http.StatusOK, list), nil
+}
+
+// sendInvitation only logs a failure: the link is already issued and is returned to the author anyway.
+func (s *recipientService) sendInvitation(ctx context.Context, form *model.Form, recipient *model.FormRecipient, reminder bool) {
+	authorName := strings.TrimSpace(form.Author.FirstName + " " + form.Author.LastName)
+	if authorName == "" {
+		authorName = form.Author.Username
+	}
+
+	message, err := renderInvitation(recipient.Email, &invitationData{
+		Name:       recipient.Name,
+		AuthorName: authorName,
+		Title:      form.Title,
+		Link:       recipient.Link,
+		Reminder:   reminder,
+	})
+	if err == nil {
+		err = s.sender.Send(ctx, message)
+	}
+	if err != nil {
+		log.Error().Msgf("recipient_service failed to email recipient %d: %v", *recipient.ID, err)
+	}
+}
+
+func (s *recipientService) link(formID int64, token string) string {
+	return fmt.Sprintf("%s/forms/%d?token=%s", s.appURL, formID, url.QueryEscape(token))
+}
+
+func (s *recipientService) findAuthorForm(ctx context.Context, formID int64) (*model.Form, *resp.Response, error) {
+	currentUser := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
+
+	form, err := s.formRepository.FindByID(ctx, formID)
+	if err != nil {
+		return nil, resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if form == nil {
+		return nil, resp.NewResponse(http.StatusNotFound, nil), nil
+	}
+
+	if form.Author.ID != currentUser.ID {
+		return nil, resp.NewResponse(http.StatusForbidden, nil), nil
+	}
+
+	return form, nil, nil
+}
+
+func newToken() (string, error) {
+	token := make([]byte, tokenSize)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
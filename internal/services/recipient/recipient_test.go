@@ -0,0 +1,154 @@
+package recipient_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/mail"
+	"go-form-hub/internal/services/recipient"
+
+	validator "github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeFormRepository struct {
+	repository.FormRepository
+
+	form *model.Form
+}
+
+func (r *fakeFormRepository) FindByID(_ context.Context, _ int64) (*model.Form, error) {
+	return r.form, nil
+}
+
+type fakeRecipientRepository struct {
+	repository.RecipientRepository
+
+	recipients []*model.FormRecipient
+	saved      []*model.FormRecipient
+}
+
+func (r *fakeRecipientRepository) Save(_ context.Context, _ int64, recipients []*model.FormRecipient) error {
+	for i, recipient := range recipients {
+		id := int64(i + 1)
+		recipient.ID = &id
+	}
+	r.saved = recipients
+	return nil
+}
+
+func (r *fakeRecipientRepository) FindAllByForm(_ context.Context, _ int64) ([]*model.FormRecipient, error) {
+	return r.recipients, nil
+}
+
+func (r *fakeRecipientRepository) Remind(_ context.Context, _ int64, recipients []*model.FormRecipient, now time.Time) ([]*model.FormRecipient, error) {
+	for _, recipient := range recipients {
+		recipient.RemindedAt = &now
+	}
+	return recipients, nil
+}
+
+type recordingSender struct {
+	messages []*mail.Message
+}
+
+func (s *recordingSender) Send(_ context.Context, message *mail.Message) error {
+	s.messages = append(s.messages, message)
+	return nil
+}
+
+func newForm() *model.Form {
+	id := int64(3)
+	return &model.Form{
+		ID:         &id,
+		Title:      "Team survey",
+		InviteOnly: true,
+		Author:     &model.UserGet{ID: 1, Username: "author", FirstName: "Kim"},
+	}
+}
+
+func TestRecipientsSave(t *testing.T) {
+	now := time.Date(2023, 12, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	ctx := context.WithValue(context.Background(), model.ContextCurrentUser, &model.UserGet{ID: 1})
+
+	t.Run("Invites", func(t *testing.T) {
+		t.Parallel()
+
+		recipientRepository := &fakeRecipientRepository{}
+		sender := &recordingSender{}
+		service := recipient.NewRecipientService(&fakeFormRepository{form: newForm()}, recipientRepository, sender, "https://forms.example.com/", clock, validator.New())
+
+		result, err := service.RecipientsSave(ctx, 3, &model.RecipientsSave{
+			Recipients: []*model.FormRecipient{
+				{Email: "Ann@Example.com ", Name: "Ann"},
+				{Email: "ann@example.com"},
+				{Email: "bob@example.com"},
+			},
+			SendInvites: true,
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 200, result.StatusCode)
+
+		list := result.Body.(*model.RecipientList)
+		assert.Equal(t, 2, list.Count)
+		assert.Equal(t, "ann@example.com", list.Recipients[0].Email)
+		assert.Equal(t, 1, list.Recipients[0].MaxUses)
+		assert.Equal(t, now, list.Recipients[0].InvitedAt)
+		assert.NotEqual(t, list.Recipients[0].Token, list.Recipients[1].Token)
+		assert.Equal(t, "https://forms.example.com/forms/3?token="+list.Recipients[0].Token, list.Recipients[0].Link)
+
+		assert.Equal(t, 2, len(sender.messages))
+		assert.Equal(t, []string{"ann@example.com"}, sender.messages[0].To)
+		assert.Equal(t, "You are invited to answer Team survey", sender.messages[0].Subject)
+		assert.True(t, strings.Contains(sender.messages[0].Text, list.Recipients[0].Link))
+		assert.True(t, strings.Contains(sender.messages[0].Text, "Kim invites you"))
+	})
+
+	t.Run("NotAuthor", func(t *testing.T) {
+		t.Parallel()
+
+		form := newForm()
+		form.Author.ID = 2
+		service := recipient.NewRecipientService(&fakeFormRepository{form: form}, &fakeRecipientRepository{}, &recordingSender{}, "", clock, validator.New())
+
+		result, err := service.RecipientsSave(ctx, 3, &model.RecipientsSave{
+			Recipients: []*model.FormRecipient{{Email: "ann@example.com"}},
+		})
+		assert.Nil(t, err)
+		assert.Equal(t, 403, result.StatusCode)
+	})
+}
+
+func TestRecipientsRemind(t *testing.T) {
+	now := time.Date(2023, 12, 2, 9, 0, 0, 0, time.UTC)
+	ctx := context.WithValue(context.Background(), model.ContextCurrentUser, &model.UserGet{ID: 1})
+
+	respondedID, pendingID := int64(1), int64(2)
+	recipientRepository := &fakeRecipientRepository{recipients: []*model.FormRecipient{
+		{ID: &respondedID, Email: "ann@example.com", MaxUses: 1, Uses: 1},
+		{ID: &pendingID, Email: "bob@example.com", MaxUses: 1},
+	}}
+	sender := &recordingSender{}
+	service := recipient.NewRecipientService(&fakeFormRepository{form: newForm()}, recipientRepository, sender, "https://forms.example.com",
+		func() time.Time { return now }, validator.New())
+
+	result, err := service.RecipientsRemind(ctx, 3)
+	assert.Nil(t, err)
+	assert.Equal(t, 200, result.StatusCode)
+
+	list := result.Body.(*model.RecipientList)
+	assert.Equal(t, 1, list.Count)
+	assert.Equal(t, pendingID, *list.Recipients[0].ID)
+	assert.Equal(t, now, *list.Recipients[0].RemindedAt)
+	assert.NotEmpty(t, list.Recipients[0].Token)
+
+	assert.Equal(t, 1, len(sender.messages))
+	assert.Equal(t, []string{"bob@example.com"}, sender.messages[0].To)
+	assert.Equal(t, "Reminder: Team survey", sender.messages[0].Subject)
+	assert.True(t, strings.Contains(sender.messages[0].HTML, "still waiting"))
+}
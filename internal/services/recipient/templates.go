@@ -0,0 +1,51 @@
+package recipient
+
+import (
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"go-form-hub/internal/services/mail"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+var (
+	invitationText = texttemplate.Must(texttemplate.ParseFS(templateFiles, "templates/invitation.txt"))
+	invitationHTML = htmltemplate.Must(htmltemplate.ParseFS(templateFiles, "templates/invitation.html"))
+)
+
+type invitationData struct {
+	Name       string
+	AuthorName string
+	Title      string
+	Link       string
+	Reminder   bool
+}
+
+func (d *invitationData) subject() string {
+	if d.Reminder {
+		return "Reminder: " + d.Title
+	}
+	return "You are invited to answer " + d.Title
+}
+
+func renderInvitation(to string, data *invitationData) (*mail.Message, error) {
+	var text, html strings.Builder
+	if err := invitationText.Execute(&text, data); err != nil {
+		return nil, err
+	}
+
+	if err := invitationHTML.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	return &mail.Message{
+		To:      []string{to},
+		Subject: data.subject(),
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
@@ -0,0 +1,153 @@
+package sessioncache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/services/authsession"
+	"go-form-hub/microservices/auth/session"
+)
+
+type entry struct {
+	user    model.UserGet
+	expires time.Time
+}
+
+// Cache checks sessions with the auth microservice and keeps the users of the valid ones for ttl,
+// so the gateway does not ask for every request. A session that ends elsewhere, by a password
+// reset for example, stays valid in the cache for at most ttl. It is safe for concurrent use.
+type Cache struct {
+	mu          sync.Mutex
+	authService session.AuthCheckerClient
+	ttl         time.Duration
+	now         func() time.Time
+	entries     map[string]*entry
+	lastSweep   time.Time
+	// generation counts the sessions forgotten, a check that overlapped one does not cache its
+	// result, which may predate the logout
+	generation uint64
+}
+
+// NewCache returns a cache that keeps sessions for ttl, a ttl of 0 asks the auth microservice
+// every time.
+func NewCache(authService session.AuthCheckerClient, ttl time.Duration, now func() time.Time) *Cache {
+	return &Cache{
+		authService: authService,
+		ttl:         ttl,
+		now:         now,
+		entries:     make(map[string]*entry),
+		lastSweep:   now(),
+	}
+}
+
+// Check returns the user of the session. refreshed reports whether the expiry of the session moved,
+// and so whether the cookie should be sent again, it is false for a cached session. An unknown or
+// expired session fails with authsession.ErrNotFound or authsession.ErrExpired.
+func (c *Cache) Check(ctx context.Context, sessionID string) (user *model.UserGet, refreshed bool, err error) {
+	// the cache holds hashes like the database, not the IDs that log in
+	key := authsession.Hash(sessionID)
+	user, generation, ok := c.get(key)
+	if ok {
+		return user, false, nil
+	}
+
+	result, err := c.authService.Check(ctx, &session.Session{Session: sessionID})
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !result.Valid {
+		if result.Expired {
+			return nil, false, authsession.ErrExpired
+		}
+		return nil, false, authsession.ErrNotFound
+	}
+
+	currentUser := result.GetCurrentUser()
+	user = &model.UserGet{
+		ID:        currentUser.GetId(),
+		Username:  currentUser.GetUsername(),
+		FirstName: currentUser.GetFirstName(),
+		LastName:  currentUser.GetLastName(),
+		Email:     currentUser.GetEmail(),
+	}
+	c.put(key, user, generation)
+
+	return user, result.Refreshed, nil
+}
+
+// Forget drops the session, after a logout.
+func (c *Cache) Forget(sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, authsession.Hash(sessionID))
+	c.generation++
+}
+
+// ForgetUser drops every session of the user, after its sessions are revoked or its profile changes.
+func (c *Cache) ForgetUser(userID int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, e := range c.entries {
+		if e.user.ID == userID {
+			delete(c.entries, key)
+		}
+	}
+	c.generation++
+}
+
+// get returns a copy of the cached user, so that a request cannot change it for the next ones,
+// and the generation to put the checked user with on a miss.
+func (c *Cache) get(key string) (*model.UserGet, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[key]
+	if !ok {
+		return nil, c.generation, false
+	}
+
+	if !c.now().Before(e.expires) {
+		delete(c.entries, key)
+		return nil, c.generation, false
+	}
+
+	user := e.user
+	return &user, c.generation, true
+}
+
+// put caches the user unless a session was forgotten since generation was read.
+func (c *Cache) put(key string, user *model.UserGet, generation uint64) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.generation != generation {
+		return
+	}
+
+	now := c.now()
+	c.entries[key] = &entry{user: *user, expires: now.Add(c.ttl)}
+	c.sweep(now)
+}
+
+// sweep drops the expired sessions once per ttl, so the map does not grow forever.
+func (c *Cache) sweep(now time.Time) {
+	if now.Sub(c.lastSweep) < c.ttl {
+		return
+	}
+	c.lastSweep = now
+
+	for key, e := range c.entries {
+		if !now.Before(e.expires) {
+			delete(c.entries, key)
+		}
+	}
+}
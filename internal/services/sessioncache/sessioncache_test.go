@@ -0,0 +1,138 @@
+package sessioncache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/services/authsession"
+	"go-form-hub/internal/services/sessioncache"
+	"go-form-hub/microservices/auth/session"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+type fakeAuthChecker struct {
+	session.AuthCheckerClient
+
+	results map[string]*session.CheckResult
+	checks  int
+	// when set, Check signals started and waits for release before it answers
+	started chan struct{}
+	release chan struct{}
+}
+
+func (c *fakeAuthChecker) Check(_ context.Context, in *session.Session, _ ...grpc.CallOption) (*session.CheckResult, error) {
+	c.checks++
+	if c.started != nil {
+		c.started <- struct{}{}
+		<-c.release
+	}
+	if result, ok := c.results[in.Session]; ok {
+		return result, nil
+	}
+	return &session.CheckResult{Valid: false}, nil
+}
+
+func TestCache(t *testing.T) {
+	authChecker := &fakeAuthChecker{results: map[string]*session.CheckResult{
+		"first":   {Valid: true, Refreshed: true, CurrentUser: &session.User{Id: 1, Username: "user", Email: "user@example.com"}},
+		"second":  {Valid: true, CurrentUser: &session.User{Id: 1, Username: "user", Email: "user@example.com"}},
+		"other":   {Valid: true, CurrentUser: &session.User{Id: 2, Username: "other"}},
+		"expired": {Valid: false, Expired: true},
+	}}
+	now := time.Date(2023, 12, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	cache := sessioncache.NewCache(authChecker, 30*time.Second, clock)
+	ctx := context.Background()
+
+	user, refreshed, err := cache.Check(ctx, "first")
+	if assert.Nil(t, err) {
+		assert.Equal(t, int64(1), user.ID)
+		assert.Equal(t, "user@example.com", user.Email)
+		assert.True(t, refreshed)
+	}
+
+	user.Username = "changed"
+	user, refreshed, err = cache.Check(ctx, "first")
+	assert.Nil(t, err)
+	assert.Equal(t, "user", user.Username, "a request cannot change the cached user")
+	assert.False(t, refreshed, "a cached session is not refreshed")
+	assert.Equal(t, 1, authChecker.checks)
+
+	now = now.Add(30 * time.Second)
+	_, _, _ = cache.Check(ctx, "first")
+	assert.Equal(t, 2, authChecker.checks, "the session is checked again after the ttl")
+
+	_, _, err = cache.Check(ctx, "unknown")
+	assert.ErrorIs(t, err, authsession.ErrNotFound)
+	_, _, err = cache.Check(ctx, "expired")
+	assert.ErrorIs(t, err, authsession.ErrExpired)
+	_, _, _ = cache.Check(ctx, "unknown")
+	assert.Equal(t, 5, authChecker.checks, "invalid sessions are not cached")
+
+	cache.Forget("first")
+	_, _, _ = cache.Check(ctx, "first")
+	assert.Equal(t, 6, authChecker.checks, "a logout drops the session")
+
+	_, _, _ = cache.Check(ctx, "second")
+	_, _, _ = cache.Check(ctx, "other")
+	cache.ForgetUser(1)
+	checks := authChecker.checks
+	_, _, _ = cache.Check(ctx, "other")
+	assert.Equal(t, checks, authChecker.checks, "the sessions of other users stay")
+	_, _, _ = cache.Check(ctx, "first")
+	_, _, _ = cache.Check(ctx, "second")
+	assert.Equal(t, checks+2, authChecker.checks, "every session of the user is dropped")
+}
+
+func TestCacheForgetDuringCheck(t *testing.T) {
+	tests := []struct {
+		name   string
+		forget func(cache *sessioncache.Cache)
+	}{
+		{name: "Forget", forget: func(cache *sessioncache.Cache) { cache.Forget("first") }},
+		{name: "ForgetUser", forget: func(cache *sessioncache.Cache) { cache.ForgetUser(1) }},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			authChecker := &fakeAuthChecker{
+				results: map[string]*session.CheckResult{"first": {Valid: true, CurrentUser: &session.User{Id: 1}}},
+				started: make(chan struct{}),
+				release: make(chan struct{}),
+			}
+			cache := sessioncache.NewCache(authChecker, 30*time.Second, time.Now)
+
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				_, _, err := cache.Check(context.Background(), "first")
+				assert.Nil(t, err)
+			}()
+
+			// the logout lands while the auth microservice answers the check
+			<-authChecker.started
+			test.forget(cache)
+			close(authChecker.release)
+			<-done
+
+			authChecker.started = nil
+			_, _, _ = cache.Check(context.Background(), "first")
+			assert.Equal(t, 2, authChecker.checks, "a session forgotten during its check is not cached")
+		})
+	}
+}
+
+func TestCacheDisabled(t *testing.T) {
+	authChecker := &fakeAuthChecker{results: map[string]*session.CheckResult{
+		"first": {Valid: true, CurrentUser: &session.User{Id: 1}},
+	}}
+	cache := sessioncache.NewCache(authChecker, 0, time.Now)
+
+	_, _, _ = cache.Check(context.Background(), "first")
+	_, _, _ = cache.Check(context.Background(), "first")
+	assert.Equal(t, 2, authChecker.checks)
+}
@@ -0,0 +1,120 @@
+// Package totp implements time-based one-time passwords (RFC 6238) as generated by authenticator
+// apps: HMAC-SHA1, 6 digits and a 30 second period.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" // nolint:gosec
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	secretSize = 20
+	digits     = 6
+	modulo     = 1000000
+
+	// Period is how long a code stays the current one.
+	Period = 30 * time.Second
+	// Skew is how many periods a code may be behind or ahead of the server clock.
+	Skew = 1
+)
+
+var (
+	ErrMalformedSecret = errors.New("totp secret is not valid base32")
+
+	encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+)
+
+// NewSecret returns a random secret, base32 encoded the way authenticator apps expect it.
+func NewSecret() (string, error) {
+	secret := make([]byte, secretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("totp new_secret failed to read random bytes: %v", err)
+	}
+
+	return encoding.EncodeToString(secret), nil
+}
+
+// URI returns the otpauth:// URI of the secret, which authenticator apps read from a QR code.
+func URI(issuer, account, secret string) string {
+	label := url.PathEscape(issuer) + ":" + url.PathEscape(account)
+	query := url.Values{
+		"secret":    {secret},
+		"issuer":    {issuer},
+		"algorithm": {"SHA1"},
+		"digits":    {fmt.Sprint(digits)},
+		"period":    {fmt.Sprint(int(Period / time.Second))},
+	}
+
+	return "otpauth://totp/" + label + "?" + query.Encode()
+}
+
+// Step returns the number of the period t falls into.
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// Code returns the code of the secret for the period t falls into.
+func Code(secret string, t time.Time) (string, error) {
+	key, err := decode(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return code(key, Step(t)), nil
+}
+
+// Validate checks the code against the periods around t and returns the step it matched, so the
+// caller can refuse a code that was already used.
+func Validate(secret, value string, t time.Time) (step int64, ok bool, err error) {
+	key, err := decode(secret)
+	if err != nil {
+		return 0, false, err
+	}
+
+	value = strings.ReplaceAll(value, " ", "")
+	if len(value) != digits {
+		return 0, false, nil
+	}
+
+	current := Step(t)
+	for step = current - Skew; step <= current+Skew; step++ {
+		if subtle.ConstantTimeCompare([]byte(code(key, step)), []byte(value)) == 1 {
+			return step, true, nil
+		}
+	}
+
+	return 0, false, nil
+}
+
+func decode(secret string) ([]byte, error) {
+	key, err := encoding.DecodeString(strings.ToUpper(strings.TrimRight(secret, "=")))
+	if err != nil {
+		return nil, ErrMalformedSecret
+	}
+
+	return key, nil
+}
+
+func code(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+
+	mac := hmac.New(sha1.New, key)
+	_, _ = mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// dynamic truncation, RFC 4226 section 5.3
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	return fmt.Sprintf("%0*d", digits, value%modulo)
+}
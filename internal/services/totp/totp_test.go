@@ -0,0 +1,86 @@
+package totp_test
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/services/totp"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// rfcSecret is the SHA1 key of the test vectors in RFC 6238, appendix B.
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// the RFC lists 8 digit codes, these are their last 6 digits
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{unix: 59, code: "287082"},
+		{unix: 1111111109, code: "081804"},
+		{unix: 1111111111, code: "050471"},
+		{unix: 1234567890, code: "005924"},
+		{unix: 2000000000, code: "279037"},
+		{unix: 20000000000, code: "353130"},
+	}
+
+	for _, test := range tests {
+		code, err := totp.Code(rfcSecret, time.Unix(test.unix, 0))
+		assert.Nil(t, err)
+		assert.Equal(t, test.code, code, test.unix)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+
+	step, ok, err := totp.Validate(rfcSecret, "050471", now)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, totp.Step(now), step)
+
+	previous, _ := totp.Code(rfcSecret, now.Add(-totp.Period))
+	step, ok, _ = totp.Validate(rfcSecret, previous, now)
+	assert.True(t, ok, "a code of the previous period is accepted")
+	assert.Equal(t, totp.Step(now)-1, step)
+
+	_, ok, _ = totp.Validate(rfcSecret, "050 471", now)
+	assert.True(t, ok, "spaces are ignored")
+
+	old, _ := totp.Code(rfcSecret, now.Add(-2*totp.Period))
+	_, ok, _ = totp.Validate(rfcSecret, old, now)
+	assert.False(t, ok, "a code two periods old is refused")
+
+	_, ok, _ = totp.Validate(rfcSecret, "12345", now)
+	assert.False(t, ok)
+
+	_, _, err = totp.Validate("not base32!", "050471", now)
+	assert.ErrorIs(t, err, totp.ErrMalformedSecret)
+}
+
+func TestNewSecretAndURI(t *testing.T) {
+	secret, err := totp.NewSecret()
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Len(t, secret, 32)
+
+	other, _ := totp.NewSecret()
+	assert.NotEqual(t, secret, other)
+
+	uri, err := url.Parse(totp.URI("Form Hub", "user@example.com", secret))
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, "otpauth", uri.Scheme)
+	assert.Equal(t, "totp", uri.Host)
+	assert.Equal(t, "/Form Hub:user@example.com", uri.Path)
+	assert.Equal(t, secret, uri.Query().Get("secret"))
+	assert.Equal(t, "Form Hub", uri.Query().Get("issuer"))
+	assert.Equal(t, "6", uri.Query().Get("digits"))
+	assert.Equal(t, "30", uri.Query().Get("period"))
+}
@@ -0,0 +1,47 @@
+package webhook_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go-form-hub/internal/services/webhook"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckURL(t *testing.T) {
+	private := []string{
+		"http://127.0.0.1/hook",
+		"http://localhost:8080/hook",
+		"http://169.254.169.254/latest/meta-data",
+		"http://10.0.0.5/hook",
+		"http://172.16.1.1/hook",
+		"https://192.168.1.1/hook",
+		"http://100.64.0.1/hook",
+		"http://0.0.0.0/hook",
+		"http://[::1]/hook",
+		"http://[fe80::1]/hook",
+		"http://[fd00::1]/hook",
+		"http://[::ffff:127.0.0.1]/hook",
+	}
+	for _, rawURL := range private {
+		assert.ErrorIs(t, webhook.CheckURL(context.Background(), rawURL), webhook.ErrPrivateAddress, rawURL)
+	}
+
+	assert.Nil(t, webhook.CheckURL(context.Background(), "https://93.184.216.34/hook"))
+	assert.Nil(t, webhook.CheckURL(context.Background(), "https://[2606:2800:220:1:248:1893:25c8:1946]/hook"))
+}
+
+func TestNewClientRefusesPrivateAddresses(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	// a host that resolved to a public address when the webhook was saved may resolve to a
+	// loopback one by the time of the delivery
+	_, err := webhook.NewClient().Get(server.URL)
+	assert.ErrorIs(t, err, webhook.ErrPrivateAddress)
+}
@@ -0,0 +1,179 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature"
+	EventHeader     = "X-Webhook-Event"
+	DeliveryHeader  = "X-Webhook-Delivery"
+
+	sepSymbol = "#"
+
+	maxAttempts      = 10
+	retryBaseDelay   = 30 * time.Second
+	retryMaxDelay    = 6 * time.Hour
+	claimLease       = 2 * time.Minute
+	claimBatchSize   = 20
+	pollInterval     = 5 * time.Second
+	deliveryTimeout  = 10 * time.Second
+	maxErrorBodySize = 1024
+)
+
+// Sign returns the signature of a payload sent at timestamp. It has the same format as the
+// tokens of api.HashToken: the hex HMAC-SHA256 of "<payload>:<timestamp>", "#" and the timestamp.
+func Sign(secret string, payload []byte, timestamp int64) string {
+	h := hmac.New(sha256.New, []byte(secret))
+	h.Write(payload)
+	h.Write([]byte(":" + strconv.FormatInt(timestamp, 10)))
+	return hex.EncodeToString(h.Sum(nil)) + sepSymbol + strconv.FormatInt(timestamp, 10)
+}
+
+// RetryDelay is how long to wait after the given number of failed attempts: it doubles each time
+// starting from 30 seconds and is capped at 6 hours.
+func RetryDelay(attempts int) time.Duration {
+	delay := retryBaseDelay
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if delay >= retryMaxDelay {
+			return retryMaxDelay
+		}
+	}
+	return delay
+}
+
+// Dispatcher sends queued deliveries and records every attempt.
+type Dispatcher struct {
+	repository repository.WebhookRepository
+	client     *http.Client
+	now        func() time.Time
+}
+
+func NewDispatcher(webhookRepository repository.WebhookRepository, client *http.Client, now func() time.Time) *Dispatcher {
+	return &Dispatcher{
+		repository: webhookRepository,
+		client:     client,
+		now:        now,
+	}
+}
+
+// Run sends due deliveries until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			sent, err := d.DispatchDue(ctx)
+			if err != nil {
+				log.Error().Msgf("webhook_dispatcher dispatch error: %v", err)
+			}
+			if err != nil || sent < claimBatchSize {
+				break
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchDue sends one batch of due deliveries and returns how many were attempted.
+func (d *Dispatcher) DispatchDue(ctx context.Context) (int, error) {
+	dispatches, err := d.repository.ClaimDueDeliveries(ctx, d.now().UTC(), claimBatchSize, claimLease)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, dispatch := range dispatches {
+		if err = d.Deliver(ctx, dispatch); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(dispatches), nil
+}
+
+// Deliver makes one attempt to send the delivery and stores its outcome: delivered on a 2xx response,
+// otherwise pending until the next retry or failed after the last attempt.
+func (d *Dispatcher) Deliver(ctx context.Context, dispatch *repository.WebhookDispatch) error {
+	delivery := dispatch.Delivery
+	delivery.Attempts++
+	delivery.ResponseStatus = nil
+	delivery.LastError = nil
+
+	status, err := d.send(ctx, dispatch)
+	now := d.now().UTC()
+
+	switch {
+	case err == nil:
+		delivery.Status = model.DeliveryStatusDelivered
+		delivery.DeliveredAt = &now
+		delivery.NextAttemptAt = nil
+	case delivery.Attempts >= maxAttempts:
+		delivery.Status = model.DeliveryStatusFailed
+		delivery.NextAttemptAt = nil
+	default:
+		nextAttemptAt := now.Add(RetryDelay(delivery.Attempts))
+		delivery.Status = model.DeliveryStatusPending
+		delivery.NextAttemptAt = &nextAttemptAt
+	}
+
+	if status != 0 {
+		delivery.ResponseStatus = &status
+	}
+	if err != nil {
+		lastError := err.Error()
+		delivery.LastError = &lastError
+	}
+
+	return d.repository.UpdateDelivery(ctx, delivery)
+}
+
+func (d *Dispatcher) send(ctx context.Context, dispatch *repository.WebhookDispatch) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, deliveryTimeout)
+	defer cancel()
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, dispatch.URL, bytes.NewReader(dispatch.Delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", "go-form-hub-webhook")
+	request.Header.Set(EventHeader, dispatch.Delivery.Event)
+	request.Header.Set(DeliveryHeader, strconv.FormatInt(dispatch.Delivery.ID, 10))
+	request.Header.Set(SignatureHeader, Sign(dispatch.Secret, dispatch.Delivery.Payload, d.now().Unix()))
+
+	response, err := d.client.Do(request)
+	if err != nil {
+		return 0, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(response.Body, maxErrorBodySize))
+		return response.StatusCode, fmt.Errorf("unexpected response status %d: %s", response.StatusCode, body)
+	}
+
+	_, _ = io.Copy(io.Discard, response.Body)
+	return response.StatusCode, nil
+}
@@ -0,0 +1,172 @@
+package webhook_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/webhook"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const secret = "0123456789abcdef"
+
+// fakeWebhookRepository keeps deliveries in memory. Claimed deliveries are returned once.
+type fakeWebhookRepository struct {
+	repository.WebhookRepository
+
+	mu         sync.Mutex
+	dispatches []*repository.WebhookDispatch
+	updated    []model.WebhookDelivery
+}
+
+func (r *fakeWebhookRepository) ClaimDueDeliveries(_ context.Context, _ time.Time, limit int, _ time.Duration) ([]*repository.WebhookDispatch, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if limit > len(r.dispatches) {
+		limit = len(r.dispatches)
+	}
+	claimed := r.dispatches[:limit]
+	r.dispatches = r.dispatches[limit:]
+	return claimed, nil
+}
+
+func (r *fakeWebhookRepository) UpdateDelivery(_ context.Context, delivery *model.WebhookDelivery) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.updated = append(r.updated, *delivery)
+	return nil
+}
+
+func newDispatch(url string, attempts int) *repository.WebhookDispatch {
+	return &repository.WebhookDispatch{
+		Delivery: &model.WebhookDelivery{
+			ID:       7,
+			Event:    model.WebhookEventPassageCreated,
+			Payload:  []byte(`{"event":"passage.created","form_id":1}`),
+			Status:   model.DeliveryStatusPending,
+			Attempts: attempts,
+		},
+		URL:    url,
+		Secret: secret,
+	}
+}
+
+func TestDispatcherDispatchDue(t *testing.T) {
+	now := time.Date(2023, 12, 1, 10, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+
+	t.Run("Delivered", func(t *testing.T) {
+		t.Parallel()
+
+		var received *http.Request
+		var body []byte
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			received = r
+			body, _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusNoContent)
+		}))
+		defer receiver.Close()
+
+		repo := &fakeWebhookRepository{dispatches: []*repository.WebhookDispatch{newDispatch(receiver.URL, 0)}}
+		dispatcher := webhook.NewDispatcher(repo, receiver.Client(), clock)
+
+		sent, err := dispatcher.DispatchDue(context.Background())
+		assert.Nil(t, err)
+		assert.Equal(t, 1, sent)
+
+		assert.Equal(t, `{"event":"passage.created","form_id":1}`, string(body))
+		assert.Equal(t, "application/json", received.Header.Get("Content-Type"))
+		assert.Equal(t, model.WebhookEventPassageCreated, received.Header.Get(webhook.EventHeader))
+		assert.Equal(t, "7", received.Header.Get(webhook.DeliveryHeader))
+		assert.Equal(t, webhook.Sign(secret, body, now.Unix()), received.Header.Get(webhook.SignatureHeader))
+
+		assert.Equal(t, 1, len(repo.updated))
+		delivery := repo.updated[0]
+		assert.Equal(t, model.DeliveryStatusDelivered, delivery.Status)
+		assert.Equal(t, 1, delivery.Attempts)
+		assert.Equal(t, http.StatusNoContent, *delivery.ResponseStatus)
+		assert.Equal(t, now, *delivery.DeliveredAt)
+		assert.Nil(t, delivery.NextAttemptAt)
+		assert.Nil(t, delivery.LastError)
+	})
+
+	t.Run("Retried", func(t *testing.T) {
+		t.Parallel()
+
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("maintenance"))
+		}))
+		defer receiver.Close()
+
+		repo := &fakeWebhookRepository{dispatches: []*repository.WebhookDispatch{newDispatch(receiver.URL, 2)}}
+		dispatcher := webhook.NewDispatcher(repo, receiver.Client(), clock)
+
+		_, err := dispatcher.DispatchDue(context.Background())
+		assert.Nil(t, err)
+
+		delivery := repo.updated[0]
+		assert.Equal(t, model.DeliveryStatusPending, delivery.Status)
+		assert.Equal(t, 3, delivery.Attempts)
+		assert.Equal(t, http.StatusServiceUnavailable, *delivery.ResponseStatus)
+		assert.Equal(t, now.Add(2*time.Minute), *delivery.NextAttemptAt)
+		assert.Contains(t, *delivery.LastError, "maintenance")
+		assert.Nil(t, delivery.DeliveredAt)
+	})
+
+	t.Run("Failed", func(t *testing.T) {
+		t.Parallel()
+
+		receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer receiver.Close()
+
+		repo := &fakeWebhookRepository{dispatches: []*repository.WebhookDispatch{newDispatch(receiver.URL, 9)}}
+		dispatcher := webhook.NewDispatcher(repo, receiver.Client(), clock)
+
+		_, err := dispatcher.DispatchDue(context.Background())
+		assert.Nil(t, err)
+
+		delivery := repo.updated[0]
+		assert.Equal(t, model.DeliveryStatusFailed, delivery.Status)
+		assert.Equal(t, 10, delivery.Attempts)
+		assert.Nil(t, delivery.NextAttemptAt)
+	})
+
+	t.Run("Unreachable", func(t *testing.T) {
+		t.Parallel()
+
+		receiver := httptest.NewServer(http.NotFoundHandler())
+		receiver.Close()
+
+		repo := &fakeWebhookRepository{dispatches: []*repository.WebhookDispatch{newDispatch(receiver.URL, 0)}}
+		dispatcher := webhook.NewDispatcher(repo, http.DefaultClient, clock)
+
+		_, err := dispatcher.DispatchDue(context.Background())
+		assert.Nil(t, err)
+
+		delivery := repo.updated[0]
+		assert.Equal(t, model.DeliveryStatusPending, delivery.Status)
+		assert.Nil(t, delivery.ResponseStatus)
+		assert.NotNil(t, delivery.LastError)
+		assert.Equal(t, now.Add(30*time.Second), *delivery.NextAttemptAt)
+	})
+}
+
+func TestRetryDelay(t *testing.T) {
+	assert.Equal(t, 30*time.Second, webhook.RetryDelay(1))
+	assert.Equal(t, time.Minute, webhook.RetryDelay(2))
+	assert.Equal(t, 4*time.Minute, webhook.RetryDelay(4))
+	assert.Equal(t, 6*time.Hour, webhook.RetryDelay(20))
+}
@@ -0,0 +1,203 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+	resp "go-form-hub/internal/services/service_response"
+
+	validator "github.com/go-playground/validator/v10"
+)
+
+const (
+	DefaultDeliveryLimit = 50
+	MaxDeliveryLimit     = 100
+)
+
+type Service interface {
+	WebhookSave(ctx context.Context, formID int64, webhook *model.Webhook) (*resp.Response, error)
+	WebhookList(ctx context.Context, formID int64) (*resp.Response, error)
+	WebhookDelete(ctx context.Context, formID, webhookID int64) (*resp.Response, error)
+	WebhookDeliveries(ctx context.Context, formID, webhookID int64, limit uint64) (*resp.Response, error)
+	WebhookTest(ctx context.Context, formID, webhookID int64) (*resp.Response, error)
+}
+
+type webhookService struct {
+	formRepository    repository.FormRepository
+	webhookRepository repository.WebhookRepository
+	dispatcher        *Dispatcher
+	validate          *validator.Validate
+}
+
+func NewWebhookService(formRepository repository.FormRepository, webhookRepository repository.WebhookRepository, dispatcher *Dispatcher, validate *validator.Validate) Service {
+	return &webhookService{
+		formRepository:    formRepository,
+		webhookRepository: webhookRepository,
+		dispatcher:        dispatcher,
+		validate:          validate,
+	}
+}
+
+func (s *webhookService) WebhookSave(ctx context.Context, formID int64, webhook *model.Webhook) (*resp.Response, error) {
+	if err := s.validate.Struct(webhook); err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), err
+	}
+
+	if response, err := s.checkFormAuthor(ctx, formID); response != nil {
+		return response, err
+	}
+
+	if err := CheckURL(ctx, webhook.URL); err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), err
+	}
+
+	webhook.FormID = formID
+	webhook.CreatedAt = time.Now().UTC()
+	if err := s.webhookRepository.Insert(ctx, webhook); err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+	webhook.Secret = ""
+
+	return resp.NewResponse(http.StatusOK, webhook), nil
+}
+
+func (s *webhookService) WebhookList(ctx context.Context, formID int64) (*resp.Response, error) {
+	if response, err := s.checkFormAuthor(ctx, formID); response != nil {
+		return response, err
+	}
+
+	webhooks, err := s.webhookRepository.FindAllByForm(ctx, formID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	for _, webhook := range webhooks {
+		webhook.Secret = ""
+	}
+
+	return resp.NewResponse(http.StatusOK, &model.WebhookList{
+		CollectionResponse: model.CollectionResponse{
+			Count: len(webhooks),
+		},
+		Webhooks: webhooks,
+	}), nil
+}
+
+func (s *webhookService) WebhookDelete(ctx context.Context, formID, webhookID int64) (*resp.Response, error) {
+	if response, err := s.checkWebhook(ctx, formID, webhookID); response != nil {
+		return response, err
+	}
+
+	if err := s.webhookRepository.Delete(ctx, webhookID); err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	return resp.NewResponse(http.StatusOK, nil), nil
+}
+
+func (s *webhookService) WebhookDeliveries(ctx context.Context, formID, webhookID int64, limit uint64) (*resp.Response, error) {
+	if response, err := s.checkWebhook(ctx, formID, webhookID); response != nil {
+		return response, err
+	}
+
+	deliveries, err := s.webhookRepository.Deliveries(ctx, webhookID, limit)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	return resp.NewResponse(http.StatusOK, &model.WebhookDeliveryList{
+		CollectionResponse: model.CollectionResponse{
+			Count: len(deliveries),
+		},
+		Deliveries: deliveries,
+	}), nil
+}
+
+// WebhookTest sends a ping event right away and returns the delivery with the outcome of the attempt.
+// A failed ping is retried like any other delivery.
+func (s *webhookService) WebhookTest(ctx context.Context, formID, webhookID int64) (*resp.Response, error) {
+	webhook, response, err := s.findWebhook(ctx, formID, webhookID)
+	if response != nil {
+		return response, err
+	}
+
+	now := time.Now().UTC()
+	payload, err := json.Marshal(&model.WebhookPayload{
+		Event:      model.WebhookEventPing,
+		FormID:     formID,
+		OccurredAt: now,
+		Data:       struct{}{},
+	})
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	delivery := &model.WebhookDelivery{
+		WebhookID:     webhookID,
+		Event:         model.WebhookEventPing,
+		Payload:       payload,
+		Status:        model.DeliveryStatusPending,
+		NextAttemptAt: &now,
+		CreatedAt:     now,
+	}
+	if err = s.webhookRepository.InsertDelivery(ctx, delivery); err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	err = s.dispatcher.Deliver(ctx, &repository.WebhookDispatch{
+		Delivery: delivery,
+		URL:      webhook.URL,
+		Secret:   webhook.Secret,
+	})
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	return resp.NewResponse(http.StatusOK, delivery), nil
+}
+
+// checkFormAuthor returns a response if the form does not exist or the current user is not its author.
+func (s *webhookService) checkFormAuthor(ctx context.Context, formID int64) (*resp.Response, error) {
+	currentUser := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
+
+	form, err := s.formRepository.FindByID(ctx, formID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if form == nil {
+		return resp.NewResponse(http.StatusNotFound, nil), nil
+	}
+
+	if form.Author.ID != currentUser.ID {
+		return resp.NewResponse(http.StatusForbidden, nil), nil
+	}
+
+	return nil, nil
+}
+
+func (s *webhookService) checkWebhook(ctx context.Context, formID, webhookID int64) (*resp.Response, error) {
+	_, response, err := s.findWebhook(ctx, formID, webhookID)
+	return response, err
+}
+
+func (s *webhookService) findWebhook(ctx context.Context, formID, webhookID int64) (*model.Webhook, *resp.Response, error) {
+	if response, err := s.checkFormAuthor(ctx, formID); response != nil {
+		return nil, response, err
+	}
+
+	webhook, err := s.webhookRepository.FindByID(ctx, webhookID)
+	if err != nil {
+		return nil, resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if webhook == nil || webhook.FormID != formID {
+		return nil, resp.NewResponse(http.StatusNotFound, nil), nil
+	}
+
+	return webhook, nil, nil
+}
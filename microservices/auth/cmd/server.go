@@ -1,15 +1,22 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"go-form-hub/internal/config"
 	"go-form-hub/internal/database"
 	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/apitoken"
+	"go-form-hub/internal/services/authsession"
+	"go-form-hub/internal/services/mail"
+	"go-form-hub/internal/services/password"
+	"go-form-hub/internal/services/ratelimit"
 	"go-form-hub/microservices/auth/controller"
 	"go-form-hub/microservices/auth/session"
 	"go-form-hub/microservices/auth/usecase"
@@ -21,7 +28,11 @@ import (
 	"google.golang.org/grpc"
 )
 
-const defaultPort = ":8081"
+const (
+	defaultPort = ":8081"
+
+	loginAttemptPurgeInterval = time.Hour
+)
 
 func main() {
 	log.Info().Msg("Starting microservice...")
@@ -44,10 +55,37 @@ func main() {
 
 	validate := validator.New()
 
+	hasher := password.NewHasher(password.DefaultParams, cfg.EncryptionKey)
+
+	mailSender, err := mail.NewSender(cfg)
+	if err != nil {
+		log.Error().Msgf("failed to create mail sender: %s", err)
+		return
+	}
+
 	sessionRepository := repository.NewSessionDatabaseRepository(db, builder)
 	userRepository := repository.NewUserDatabaseRepository(db, builder)
-	authService := usecase.NewAuthUseCase(userRepository, sessionRepository, cfg, validate)
-	authController := controller.NewAuthController(authService, validate)
+	passwordResetRepository := repository.NewPasswordResetDatabaseRepository(db, builder)
+	emailVerificationRepository := repository.NewEmailVerificationDatabaseRepository(db, builder)
+	twoFactorRepository := repository.NewTwoFactorDatabaseRepository(db, builder)
+	identityRepository := repository.NewIdentityDatabaseRepository(db, builder)
+	apiTokenRepository := repository.NewAPITokenDatabaseRepository(db, builder)
+	sessions := authsession.NewManager(sessionRepository, userRepository, cfg.CookieExpiration, time.Now)
+
+	var loginAttemptStore ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.LoginAttemptStore == config.LoginAttemptStorePostgres {
+		loginAttemptStore = repository.NewLoginAttemptDatabaseRepository(db, builder)
+	}
+	throttle := usecase.NewLoginThrottle(loginAttemptStore, time.Now)
+
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	defer stopCleanup()
+	go usecase.PurgeLoginAttempts(cleanupCtx, throttle, loginAttemptPurgeInterval)
+
+	authService := usecase.NewAuthUseCase(userRepository, passwordResetRepository, emailVerificationRepository, twoFactorRepository,
+		identityRepository, sessions, throttle, hasher, mailSender, cfg, time.Now, validate)
+	apiTokenService := apitoken.NewAPITokenService(apiTokenRepository, userRepository, time.Now, validate)
+	authController := controller.NewAuthController(authService, apiTokenService, validate)
 
 	lis, err := net.Listen("tcp", defaultPort) // #nosec G102
 	if err != nil {
@@ -2,43 +2,100 @@ package controller
 
 import (
 	"context"
+	"errors"
+	"net/http"
 
 	"go-form-hub/internal/model"
+	"go-form-hub/internal/services/apitoken"
+	"go-form-hub/internal/services/authsession"
+	resp "go-form-hub/internal/services/service_response"
 	"go-form-hub/microservices/auth/session"
 	"go-form-hub/microservices/auth/usecase"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog/log"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 type AuthController struct {
 	session.UnimplementedAuthCheckerServer
 
 	authUseCase usecase.AuthUseCase
+	tokens      apitoken.Service
 	validator   *validator.Validate
 }
 
-func NewAuthController(authUsecase usecase.AuthUseCase, v *validator.Validate) *AuthController {
+func NewAuthController(authUsecase usecase.AuthUseCase, tokens apitoken.Service, v *validator.Validate) *AuthController {
 	return &AuthController{
 		authUseCase: authUsecase,
+		tokens:      tokens,
 		validator:   v,
 	}
 }
 
 func (m *AuthController) Login(ctx context.Context, userLogin *session.UserLogin) (*session.SessionInfo, error) {
 	user := model.UserLogin{
-		Email:    userLogin.Email,
-		Password: userLogin.Password,
+		Email:     userLogin.Email,
+		Password:  userLogin.Password,
+		UserAgent: userLogin.UserAgent,
+		IP:        userLogin.Ip,
 	}
 
 	response, sessionID, err := m.authUseCase.AuthLogin(ctx, &user)
 	if err != nil {
 		log.Error().Msgf("error logging in: %v", err)
-		return nil, status.Errorf(codes.NotFound, err.Error())
+		return nil, statusError(response.StatusCode, err)
 	}
 
+	if response.StatusCode == http.StatusAccepted {
+		return &session.SessionInfo{Session: sessionID, TwoFactorRequired: true}, nil
+	}
+
+	return sessionInfo(response, sessionID), nil
+}
+
+func (m *AuthController) LoginSecondFactor(ctx context.Context, secondFactor *session.SecondFactor) (*session.SessionInfo, error) {
+	response, sessionID, err := m.authUseCase.AuthLoginSecondFactor(ctx, &model.SecondFactor{
+		PendingToken: secondFactor.Session,
+		Code:         secondFactor.Code,
+	})
+	if err != nil {
+		log.Error().Msgf("error checking second factor: %v", err)
+		return nil, statusError(response.StatusCode, err)
+	}
+
+	return sessionInfo(response, sessionID), nil
+}
+
+// LoginOIDC logs in with the claims of an ID token the gateway verified.
+func (m *AuthController) LoginOIDC(ctx context.Context, login *session.OIDCLogin) (*session.SessionInfo, error) {
+	response, sessionID, err := m.authUseCase.AuthLoginOIDC(ctx, &model.OIDCIdentity{
+		Provider:      login.Provider,
+		Subject:       login.Subject,
+		Email:         login.Email,
+		EmailVerified: login.EmailVerified,
+		FirstName:     login.FirstName,
+		LastName:      login.LastName,
+		UserAgent:     login.UserAgent,
+		IP:            login.Ip,
+	})
+	if err != nil {
+		log.Error().Msgf("error logging in with oidc: %v", err)
+		return nil, statusError(response.StatusCode, err)
+	}
+
+	if response.StatusCode == http.StatusAccepted {
+		return &session.SessionInfo{Session: sessionID, TwoFactorRequired: true}, nil
+	}
+
+	return sessionInfo(response, sessionID), nil
+}
+
+// sessionInfo returns the session of a completed login with its user.
+func sessionInfo(response *resp.Response, sessionID string) *session.SessionInfo {
 	userInfo := response.Body.(*model.UserGet)
 	if userInfo.Avatar == nil {
 		userInfo.Avatar = new(string)
@@ -53,12 +110,10 @@ func (m *AuthController) Login(ctx context.Context, userLogin *session.UserLogin
 		Avatar:    *userInfo.Avatar,
 	}
 
-	res := &session.SessionInfo{
+	return &session.SessionInfo{
 		Session:     sessionID,
 		CurrentUser: userMsg,
 	}
-
-	return res, nil
 }
 
 func (m *AuthController) Signup(ctx context.Context, userSignup *session.UserSignup) (*session.SessionInfo, error) {
@@ -68,6 +123,8 @@ func (m *AuthController) Signup(ctx context.Context, userSignup *session.UserSig
 		FirstName: userSignup.FirstName,
 		LastName:  userSignup.LastName,
 		Username:  userSignup.Username,
+		UserAgent: userSignup.UserAgent,
+		IP:        userSignup.Ip,
 	}
 
 	response, sessionID, err := m.authUseCase.AuthSignUp(ctx, &user)
@@ -99,17 +156,55 @@ func (m *AuthController) Signup(ctx context.Context, userSignup *session.UserSig
 }
 
 func (m *AuthController) Check(ctx context.Context, sessionID *session.Session) (*session.CheckResult, error) {
-	valid, err := m.authUseCase.IsSessionValid(ctx, sessionID.Session)
-	if err != nil {
+	response, refreshed, err := m.authUseCase.AuthCheck(ctx, sessionID.Session)
+	switch {
+	case errors.Is(err, authsession.ErrNotFound):
+		return &session.CheckResult{Valid: false}, nil
+	case errors.Is(err, authsession.ErrExpired):
+		return &session.CheckResult{Valid: false, Expired: true}, nil
+	case err != nil:
 		log.Error().Msgf("error finding session: %v", err)
-		return nil, status.Errorf(codes.NotFound, err.Error())
+		return nil, statusError(response.StatusCode, err)
 	}
 
-	res := &session.CheckResult{
-		Valid: valid,
+	user := response.Body.(*model.UserGet)
+	return &session.CheckResult{
+		Valid: true,
+		CurrentUser: &session.User{
+			Id:        user.ID,
+			Username:  user.Username,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Email:     user.Email,
+		},
+		Refreshed: refreshed,
+	}, nil
+}
+
+// CheckToken checks a personal access token for the gateway, which keeps no access to the users.
+func (m *AuthController) CheckToken(ctx context.Context, token *session.Token) (*session.TokenCheckResult, error) {
+	user, scopes, err := m.tokens.Authenticate(ctx, token.Token)
+	switch {
+	case errors.Is(err, apitoken.ErrNotFound):
+		return &session.TokenCheckResult{Valid: false}, nil
+	case errors.Is(err, apitoken.ErrExpired):
+		return &session.TokenCheckResult{Valid: false, Expired: true}, nil
+	case err != nil:
+		log.Error().Msgf("error checking token: %v", err)
+		return nil, statusError(http.StatusInternalServerError, err)
 	}
 
-	return res, nil
+	return &session.TokenCheckResult{
+		Valid: true,
+		CurrentUser: &session.User{
+			Id:        user.ID,
+			Username:  user.Username,
+			FirstName: user.FirstName,
+			LastName:  user.LastName,
+			Email:     user.Email,
+		},
+		Scopes: scopes,
+	}, nil
 }
 
 func (m *AuthController) Delete(ctx context.Context, sessionID *session.Session) (*session.Nothing, error) {
@@ -120,3 +215,157 @@ func (m *AuthController) Delete(ctx context.Context, sessionID *session.Session)
 	}
 	return &session.Nothing{}, nil
 }
+
+func (m *AuthController) Rotate(ctx context.Context, sessionID *session.Session) (*session.Session, error) {
+	response, newSessionID, err := m.authUseCase.AuthRotate(ctx, sessionID.Session)
+	if err != nil {
+		log.Error().Msgf("error rotating session: %v", err)
+		return nil, statusError(response.StatusCode, err)
+	}
+
+	return &session.Session{Session: newSessionID}, nil
+}
+
+func (m *AuthController) ListSessions(ctx context.Context, sessionID *session.Session) (*session.SessionList, error) {
+	response, err := m.authUseCase.SessionList(ctx, sessionID.Session)
+	if err != nil {
+		log.Error().Msgf("error listing sessions: %v", err)
+		return nil, statusError(response.StatusCode, err)
+	}
+
+	sessions := response.Body.(*model.SessionList)
+	res := &session.SessionList{Sessions: make([]*session.SessionMeta, 0, len(sessions.Sessions))}
+	for _, s := range sessions.Sessions {
+		res.Sessions = append(res.Sessions, &session.SessionMeta{
+			Id:         s.ID,
+			CreatedAt:  s.CreatedAt.Unix(),
+			LastSeenAt: s.LastSeenAt.Unix(),
+			UserAgent:  s.UserAgent,
+			Ip:         s.IP,
+			Current:    s.Current,
+		})
+	}
+
+	return res, nil
+}
+
+func (m *AuthController) RevokeSession(ctx context.Context, revoke *session.SessionRevoke) (*session.Nothing, error) {
+	response, err := m.authUseCase.SessionRevoke(ctx, revoke.Session, revoke.Id)
+	if err != nil {
+		log.Error().Msgf("error revoking session: %v", err)
+		return nil, statusError(response.StatusCode, err)
+	}
+
+	return &session.Nothing{}, nil
+}
+
+func (m *AuthController) RevokeOtherSessions(ctx context.Context, sessionID *session.Session) (*session.Nothing, error) {
+	response, err := m.authUseCase.SessionRevokeOthers(ctx, sessionID.Session)
+	if err != nil {
+		log.Error().Msgf("error revoking other sessions: %v", err)
+		return nil, statusError(response.StatusCode, err)
+	}
+
+	return &session.Nothing{}, nil
+}
+
+func (m *AuthController) ForgotPassword(ctx context.Context, forgot *session.PasswordForgot) (*session.Nothing, error) {
+	response, err := m.authUseCase.PasswordForgot(ctx, &model.PasswordForgot{Email: forgot.Email, IP: forgot.Ip})
+	if err != nil {
+		log.Error().Msgf("error requesting password reset: %v", err)
+		return nil, statusError(response.StatusCode, err)
+	}
+
+	return &session.Nothing{}, nil
+}
+
+func (m *AuthController) ResetPassword(ctx context.Context, reset *session.PasswordReset) (*session.PasswordResetResult, error) {
+	response, userID, err := m.authUseCase.PasswordReset(ctx, &model.PasswordReset{Token: reset.Token, Password: reset.Password})
+	if err != nil {
+		log.Error().Msgf("error resetting password: %v", err)
+		return nil, statusError(response.StatusCode, err)
+	}
+
+	return &session.PasswordResetResult{UserId: userID}, nil
+}
+
+func (m *AuthController) VerifyEmail(ctx context.Context, verify *session.EmailVerify) (*session.Nothing, error) {
+	response, err := m.authUseCase.EmailVerify(ctx, &model.EmailVerify{Token: verify.Token})
+	if err != nil {
+		log.Error().Msgf("error verifying email: %v", err)
+		return nil, statusError(response.StatusCode, err)
+	}
+
+	return &session.Nothing{}, nil
+}
+
+func (m *AuthController) ResendVerification(ctx context.Context, sessionID *session.Session) (*session.Nothing, error) {
+	response, err := m.authUseCase.EmailVerifyResend(ctx, sessionID.Session)
+	if err != nil {
+		log.Error().Msgf("error resending email verification: %v", err)
+		return nil, statusError(response.StatusCode, err)
+	}
+
+	return &session.Nothing{}, nil
+}
+
+func (m *AuthController) EnrollTwoFactor(ctx context.Context, sessionID *session.Session) (*session.TwoFactorSetup, error) {
+	response, err := m.authUseCase.TwoFactorEnroll(ctx, sessionID.Session)
+	if err != nil {
+		log.Error().Msgf("error enrolling two-factor authentication: %v", err)
+		return nil, statusError(response.StatusCode, err)
+	}
+
+	setup := response.Body.(*model.TwoFactorSetup)
+	return &session.TwoFactorSetup{Secret: setup.Secret, Uri: setup.URI}, nil
+}
+
+func (m *AuthController) ConfirmTwoFactor(ctx context.Context, confirm *session.TwoFactorConfirm) (*session.RecoveryCodes, error) {
+	response, err := m.authUseCase.TwoFactorConfirm(ctx, confirm.Session, &model.TwoFactorConfirm{Code: confirm.Code})
+	if err != nil {
+		log.Error().Msgf("error confirming two-factor authentication: %v", err)
+		return nil, statusError(response.StatusCode, err)
+	}
+
+	return &session.RecoveryCodes{Codes: response.Body.(*model.RecoveryCodes).Codes}, nil
+}
+
+func (m *AuthController) DisableTwoFactor(ctx context.Context, disable *session.TwoFactorDisable) (*session.Nothing, error) {
+	response, err := m.authUseCase.TwoFactorDisable(ctx, disable.Session, &model.TwoFactorDisable{Password: disable.Password})
+	if err != nil {
+		log.Error().Msgf("error disabling two-factor authentication: %v", err)
+		return nil, statusError(response.StatusCode, err)
+	}
+
+	return &session.Nothing{}, nil
+}
+
+// statusError carries the HTTP status of a use case error over gRPC, the gateway maps it back.
+func statusError(httpStatus int, err error) error {
+	code := codes.Internal
+	switch httpStatus {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.AlreadyExists
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	}
+
+	// a throttled login tells the gateway how long to wait, so that it can set Retry-After
+	var tooMany *usecase.TooManyAttemptsError
+	if errors.As(err, &tooMany) {
+		st, detailErr := status.New(code, err.Error()).WithDetails(&errdetails.RetryInfo{RetryDelay: durationpb.New(tooMany.Wait)})
+		if detailErr == nil {
+			return st.Err()
+		}
+	}
+
+	return status.Error(code, err.Error())
+}
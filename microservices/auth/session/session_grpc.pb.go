@@ -21,7 +21,21 @@ type AuthCheckerClient interface {
 	Login(ctx context.Context, in *UserLogin, opts ...grpc.CallOption) (*SessionInfo, error)
 	Signup(ctx context.Context, in *UserSignup, opts ...grpc.CallOption) (*SessionInfo, error)
 	Check(ctx context.Context, in *Session, opts ...grpc.CallOption) (*CheckResult, error)
+	CheckToken(ctx context.Context, in *Token, opts ...grpc.CallOption) (*TokenCheckResult, error)
 	Delete(ctx context.Context, in *Session, opts ...grpc.CallOption) (*Nothing, error)
+	Rotate(ctx context.Context, in *Session, opts ...grpc.CallOption) (*Session, error)
+	ListSessions(ctx context.Context, in *Session, opts ...grpc.CallOption) (*SessionList, error)
+	RevokeSession(ctx context.Context, in *SessionRevoke, opts ...grpc.CallOption) (*Nothing, error)
+	RevokeOtherSessions(ctx context.Context, in *Session, opts ...grpc.CallOption) (*Nothing, error)
+	ForgotPassword(ctx context.Context, in *PasswordForgot, opts ...grpc.CallOption) (*Nothing, error)
+	ResetPassword(ctx context.Context, in *PasswordReset, opts ...grpc.CallOption) (*PasswordResetResult, error)
+	VerifyEmail(ctx context.Context, in *EmailVerify, opts ...grpc.CallOption) (*Nothing, error)
+	ResendVerification(ctx context.Context, in *Session, opts ...grpc.CallOption) (*Nothing, error)
+	LoginSecondFactor(ctx context.Context, in *SecondFactor, opts ...grpc.CallOption) (*SessionInfo, error)
+	EnrollTwoFactor(ctx context.Context, in *Session, opts ...grpc.CallOption) (*TwoFactorSetup, error)
+	ConfirmTwoFactor(ctx context.Context, in *TwoFactorConfirm, opts ...grpc.CallOption) (*RecoveryCodes, error)
+	DisableTwoFactor(ctx context.Context, in *TwoFactorDisable, opts ...grpc.CallOption) (*Nothing, error)
+	LoginOIDC(ctx context.Context, in *OIDCLogin, opts ...grpc.CallOption) (*SessionInfo, error)
 }
 
 type authCheckerClient struct {
@@ -59,6 +73,15 @@ func (c *authCheckerClient) Check(ctx context.Context, in *Session, opts ...grpc
 	return out, nil
 }
 
+func (c *authCheckerClient) CheckToken(ctx context.Context, in *Token, opts ...grpc.CallOption) (*TokenCheckResult, error) {
+	out := new(TokenCheckResult)
+	err := c.cc.Invoke(ctx, "/session.AuthChecker/CheckToken", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *authCheckerClient) Delete(ctx context.Context, in *Session, opts ...grpc.CallOption) (*Nothing, error) {
 	out := new(Nothing)
 	err := c.cc.Invoke(ctx, "/session.AuthChecker/Delete", in, out, opts...)
@@ -68,6 +91,123 @@ func (c *authCheckerClient) Delete(ctx context.Context, in *Session, opts ...grp
 	return out, nil
 }
 
+func (c *authCheckerClient) Rotate(ctx context.Context, in *Session, opts ...grpc.CallOption) (*Session, error) {
+	out := new(Session)
+	err := c.cc.Invoke(ctx, "/session.AuthChecker/Rotate", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authCheckerClient) ListSessions(ctx context.Context, in *Session, opts ...grpc.CallOption) (*SessionList, error) {
+	out := new(SessionList)
+	err := c.cc.Invoke(ctx, "/session.AuthChecker/ListSessions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authCheckerClient) RevokeSession(ctx context.Context, in *SessionRevoke, opts ...grpc.CallOption) (*Nothing, error) {
+	out := new(Nothing)
+	err := c.cc.Invoke(ctx, "/session.AuthChecker/RevokeSession", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authCheckerClient) RevokeOtherSessions(ctx context.Context, in *Session, opts ...grpc.CallOption) (*Nothing, error) {
+	out := new(Nothing)
+	err := c.cc.Invoke(ctx, "/session.AuthChecker/RevokeOtherSessions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authCheckerClient) ForgotPassword(ctx context.Context, in *PasswordForgot, opts ...grpc.CallOption) (*Nothing, error) {
+	out := new(Nothing)
+	err := c.cc.Invoke(ctx, "/session.AuthChecker/ForgotPassword", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authCheckerClient) ResetPassword(ctx context.Context, in *PasswordReset, opts ...grpc.CallOption) (*PasswordResetResult, error) {
+	out := new(PasswordResetResult)
+	err := c.cc.Invoke(ctx, "/session.AuthChecker/ResetPassword", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authCheckerClient) VerifyEmail(ctx context.Context, in *EmailVerify, opts ...grpc.CallOption) (*Nothing, error) {
+	out := new(Nothing)
+	err := c.cc.Invoke(ctx, "/session.AuthChecker/VerifyEmail", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authCheckerClient) ResendVerification(ctx context.Context, in *Session, opts ...grpc.CallOption) (*Nothing, error) {
+	out := new(Nothing)
+	err := c.cc.Invoke(ctx, "/session.AuthChecker/ResendVerification", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authCheckerClient) LoginSecondFactor(ctx context.Context, in *SecondFactor, opts ...grpc.CallOption) (*SessionInfo, error) {
+	out := new(SessionInfo)
+	err := c.cc.Invoke(ctx, "/session.AuthChecker/LoginSecondFactor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authCheckerClient) EnrollTwoFactor(ctx context.Context, in *Session, opts ...grpc.CallOption) (*TwoFactorSetup, error) {
+	out := new(TwoFactorSetup)
+	err := c.cc.Invoke(ctx, "/session.AuthChecker/EnrollTwoFactor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authCheckerClient) ConfirmTwoFactor(ctx context.Context, in *TwoFactorConfirm, opts ...grpc.CallOption) (*RecoveryCodes, error) {
+	out := new(RecoveryCodes)
+	err := c.cc.Invoke(ctx, "/session.AuthChecker/ConfirmTwoFactor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authCheckerClient) DisableTwoFactor(ctx context.Context, in *TwoFactorDisable, opts ...grpc.CallOption) (*Nothing, error) {
+	out := new(Nothing)
+	err := c.cc.Invoke(ctx, "/session.AuthChecker/DisableTwoFactor", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *authCheckerClient) LoginOIDC(ctx context.Context, in *OIDCLogin, opts ...grpc.CallOption) (*SessionInfo, error) {
+	out := new(SessionInfo)
+	err := c.cc.Invoke(ctx, "/session.AuthChecker/LoginOIDC", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // AuthCheckerServer is the server API for AuthChecker service.
 // All implementations must embed UnimplementedAuthCheckerServer
 // for forward compatibility
@@ -75,7 +215,21 @@ type AuthCheckerServer interface {
 	Login(context.Context, *UserLogin) (*SessionInfo, error)
 	Signup(context.Context, *UserSignup) (*SessionInfo, error)
 	Check(context.Context, *Session) (*CheckResult, error)
+	CheckToken(context.Context, *Token) (*TokenCheckResult, error)
 	Delete(context.Context, *Session) (*Nothing, error)
+	Rotate(context.Context, *Session) (*Session, error)
+	ListSessions(context.Context, *Session) (*SessionList, error)
+	RevokeSession(context.Context, *SessionRevoke) (*Nothing, error)
+	RevokeOtherSessions(context.Context, *Session) (*Nothing, error)
+	ForgotPassword(context.Context, *PasswordForgot) (*Nothing, error)
+	ResetPassword(context.Context, *PasswordReset) (*PasswordResetResult, error)
+	VerifyEmail(context.Context, *EmailVerify) (*Nothing, error)
+	ResendVerification(context.Context, *Session) (*Nothing, error)
+	LoginSecondFactor(context.Context, *SecondFactor) (*SessionInfo, error)
+	EnrollTwoFactor(context.Context, *Session) (*TwoFactorSetup, error)
+	ConfirmTwoFactor(context.Context, *TwoFactorConfirm) (*RecoveryCodes, error)
+	DisableTwoFactor(context.Context, *TwoFactorDisable) (*Nothing, error)
+	LoginOIDC(context.Context, *OIDCLogin) (*SessionInfo, error)
 	mustEmbedUnimplementedAuthCheckerServer()
 }
 
@@ -92,9 +246,51 @@ func (UnimplementedAuthCheckerServer) Signup(context.Context, *UserSignup) (*Ses
 func (UnimplementedAuthCheckerServer) Check(context.Context, *Session) (*CheckResult, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Check not implemented")
 }
+func (UnimplementedAuthCheckerServer) CheckToken(context.Context, *Token) (*TokenCheckResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckToken not implemented")
+}
 func (UnimplementedAuthCheckerServer) Delete(context.Context, *Session) (*Nothing, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
 }
+func (UnimplementedAuthCheckerServer) Rotate(context.Context, *Session) (*Session, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Rotate not implemented")
+}
+func (UnimplementedAuthCheckerServer) ListSessions(context.Context, *Session) (*SessionList, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListSessions not implemented")
+}
+func (UnimplementedAuthCheckerServer) RevokeSession(context.Context, *SessionRevoke) (*Nothing, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeSession not implemented")
+}
+func (UnimplementedAuthCheckerServer) RevokeOtherSessions(context.Context, *Session) (*Nothing, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeOtherSessions not implemented")
+}
+func (UnimplementedAuthCheckerServer) ForgotPassword(context.Context, *PasswordForgot) (*Nothing, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ForgotPassword not implemented")
+}
+func (UnimplementedAuthCheckerServer) ResetPassword(context.Context, *PasswordReset) (*PasswordResetResult, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResetPassword not implemented")
+}
+func (UnimplementedAuthCheckerServer) VerifyEmail(context.Context, *EmailVerify) (*Nothing, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method VerifyEmail not implemented")
+}
+func (UnimplementedAuthCheckerServer) ResendVerification(context.Context, *Session) (*Nothing, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResendVerification not implemented")
+}
+func (UnimplementedAuthCheckerServer) LoginSecondFactor(context.Context, *SecondFactor) (*SessionInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoginSecondFactor not implemented")
+}
+func (UnimplementedAuthCheckerServer) EnrollTwoFactor(context.Context, *Session) (*TwoFactorSetup, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EnrollTwoFactor not implemented")
+}
+func (UnimplementedAuthCheckerServer) ConfirmTwoFactor(context.Context, *TwoFactorConfirm) (*RecoveryCodes, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmTwoFactor not implemented")
+}
+func (UnimplementedAuthCheckerServer) DisableTwoFactor(context.Context, *TwoFactorDisable) (*Nothing, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DisableTwoFactor not implemented")
+}
+func (UnimplementedAuthCheckerServer) LoginOIDC(context.Context, *OIDCLogin) (*SessionInfo, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LoginOIDC not implemented")
+}
 func (UnimplementedAuthCheckerServer) mustEmbedUnimplementedAuthCheckerServer() {}
 
 // UnsafeAuthCheckerServer may be embedded to opt out of forward compatibility for this service.
@@ -162,6 +358,24 @@ func _AuthChecker_Check_Handler(srv interface{}, ctx context.Context, dec func(i
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AuthChecker_CheckToken_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Token)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthCheckerServer).CheckToken(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/session.AuthChecker/CheckToken",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthCheckerServer).CheckToken(ctx, req.(*Token))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _AuthChecker_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(Session)
 	if err := dec(in); err != nil {
@@ -180,6 +394,240 @@ func _AuthChecker_Delete_Handler(srv interface{}, ctx context.Context, dec func(
 	return interceptor(ctx, in, info, handler)
 }
 
+func _AuthChecker_Rotate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Session)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthCheckerServer).Rotate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/session.AuthChecker/Rotate",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthCheckerServer).Rotate(ctx, req.(*Session))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthChecker_ListSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Session)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthCheckerServer).ListSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/session.AuthChecker/ListSessions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthCheckerServer).ListSessions(ctx, req.(*Session))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthChecker_RevokeSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SessionRevoke)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthCheckerServer).RevokeSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/session.AuthChecker/RevokeSession",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthCheckerServer).RevokeSession(ctx, req.(*SessionRevoke))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthChecker_RevokeOtherSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Session)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthCheckerServer).RevokeOtherSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/session.AuthChecker/RevokeOtherSessions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthCheckerServer).RevokeOtherSessions(ctx, req.(*Session))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthChecker_ForgotPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PasswordForgot)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthCheckerServer).ForgotPassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/session.AuthChecker/ForgotPassword",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthCheckerServer).ForgotPassword(ctx, req.(*PasswordForgot))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthChecker_ResetPassword_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PasswordReset)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthCheckerServer).ResetPassword(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/session.AuthChecker/ResetPassword",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthCheckerServer).ResetPassword(ctx, req.(*PasswordReset))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthChecker_VerifyEmail_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EmailVerify)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthCheckerServer).VerifyEmail(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/session.AuthChecker/VerifyEmail",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthCheckerServer).VerifyEmail(ctx, req.(*EmailVerify))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthChecker_ResendVerification_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Session)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthCheckerServer).ResendVerification(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/session.AuthChecker/ResendVerification",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthCheckerServer).ResendVerification(ctx, req.(*Session))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthChecker_LoginSecondFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SecondFactor)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthCheckerServer).LoginSecondFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/session.AuthChecker/LoginSecondFactor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthCheckerServer).LoginSecondFactor(ctx, req.(*SecondFactor))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthChecker_EnrollTwoFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Session)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthCheckerServer).EnrollTwoFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/session.AuthChecker/EnrollTwoFactor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthCheckerServer).EnrollTwoFactor(ctx, req.(*Session))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthChecker_ConfirmTwoFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TwoFactorConfirm)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthCheckerServer).ConfirmTwoFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/session.AuthChecker/ConfirmTwoFactor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthCheckerServer).ConfirmTwoFactor(ctx, req.(*TwoFactorConfirm))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthChecker_DisableTwoFactor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TwoFactorDisable)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthCheckerServer).DisableTwoFactor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/session.AuthChecker/DisableTwoFactor",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthCheckerServer).DisableTwoFactor(ctx, req.(*TwoFactorDisable))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AuthChecker_LoginOIDC_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(OIDCLogin)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AuthCheckerServer).LoginOIDC(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/session.AuthChecker/LoginOIDC",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AuthCheckerServer).LoginOIDC(ctx, req.(*OIDCLogin))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // AuthChecker_ServiceDesc is the grpc.ServiceDesc for AuthChecker service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -199,10 +647,66 @@ var AuthChecker_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Check",
 			Handler:    _AuthChecker_Check_Handler,
 		},
+		{
+			MethodName: "CheckToken",
+			Handler:    _AuthChecker_CheckToken_Handler,
+		},
 		{
 			MethodName: "Delete",
 			Handler:    _AuthChecker_Delete_Handler,
 		},
+		{
+			MethodName: "Rotate",
+			Handler:    _AuthChecker_Rotate_Handler,
+		},
+		{
+			MethodName: "ListSessions",
+			Handler:    _AuthChecker_ListSessions_Handler,
+		},
+		{
+			MethodName: "RevokeSession",
+			Handler:    _AuthChecker_RevokeSession_Handler,
+		},
+		{
+			MethodName: "RevokeOtherSessions",
+			Handler:    _AuthChecker_RevokeOtherSessions_Handler,
+		},
+		{
+			MethodName: "ForgotPassword",
+			Handler:    _AuthChecker_ForgotPassword_Handler,
+		},
+		{
+			MethodName: "ResetPassword",
+			Handler:    _AuthChecker_ResetPassword_Handler,
+		},
+		{
+			MethodName: "VerifyEmail",
+			Handler:    _AuthChecker_VerifyEmail_Handler,
+		},
+		{
+			MethodName: "ResendVerification",
+			Handler:    _AuthChecker_ResendVerification_Handler,
+		},
+		{
+			MethodName: "LoginSecondFactor",
+			Handler:    _AuthChecker_LoginSecondFactor_Handler,
+		},
+		{
+			MethodName: "EnrollTwoFactor",
+			Handler:    _AuthChecker_EnrollTwoFactor_Handler,
+		},
+		{
+			MethodName: "ConfirmTwoFactor",
+			Handler:    _AuthChecker_ConfirmTwoFactor_Handler,
+		},
+		{
+			MethodName: "DisableTwoFactor",
+			Handler:    _AuthChecker_DisableTwoFactor_Handler,
+		},
+		{
+			MethodName: "LoginOIDC",
+			Handler:    _AuthChecker_LoginOIDC_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "session.proto",
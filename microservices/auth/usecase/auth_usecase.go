@@ -2,97 +2,97 @@ package usecase
 
 import (
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/md5" // nolint:gosec
-	"crypto/sha256"
-	"encoding/hex"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"go-form-hub/internal/config"
 	"go-form-hub/internal/model"
 	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/authsession"
+	"go-form-hub/internal/services/mail"
+	"go-form-hub/internal/services/password"
 	resp "go-form-hub/internal/services/service_response"
 
 	validator "github.com/go-playground/validator/v10"
 	"github.com/microcosm-cc/bluemonday"
+	"github.com/rs/zerolog/log"
 )
 
 var (
-	ErrUsernameTaken    = errors.New("username taken")
-	ErrEmailTaken       = errors.New("email taken")
-	ErrWrongCredentials = errors.New("login credentials are wrong")
+	ErrUsernameTaken     = errors.New("username taken")
+	ErrEmailTaken        = errors.New("email taken")
+	ErrWrongCredentials  = errors.New("login credentials are wrong")
+	ErrInvalidResetLink  = errors.New("password reset link is invalid or expired")
+	ErrInvalidVerifyLink = errors.New("email verification link is invalid or expired")
+	ErrEmailVerified     = errors.New("email is already verified")
 )
 
+const linkTokenSize = 32
+
 type AuthUseCase interface {
 	AuthSignUp(ctx context.Context, user *model.UserSignUp) (*resp.Response, string, error)
 	AuthLogin(ctx context.Context, user *model.UserLogin) (*resp.Response, string, error)
+	AuthLoginSecondFactor(ctx context.Context, request *model.SecondFactor) (*resp.Response, string, error)
+	AuthLoginOIDC(ctx context.Context, identity *model.OIDCIdentity) (*resp.Response, string, error)
 	AuthLogout(ctx context.Context, sessionID string) (*resp.Response, string, error)
-	IsSessionValid(ctx context.Context, sessionID string) (bool, error)
+	AuthRotate(ctx context.Context, sessionID string) (*resp.Response, string, error)
+	AuthCheck(ctx context.Context, sessionID string) (*resp.Response, bool, error)
+	SessionList(ctx context.Context, sessionID string) (*resp.Response, error)
+	SessionRevoke(ctx context.Context, sessionID, id string) (*resp.Response, error)
+	SessionRevokeOthers(ctx context.Context, sessionID string) (*resp.Response, error)
+	PasswordForgot(ctx context.Context, request *model.PasswordForgot) (*resp.Response, error)
+	PasswordReset(ctx context.Context, request *model.PasswordReset) (*resp.Response, int64, error)
+	EmailVerify(ctx context.Context, request *model.EmailVerify) (*resp.Response, error)
+	EmailVerifyResend(ctx context.Context, sessionID string) (*resp.Response, error)
+	TwoFactorEnroll(ctx context.Context, sessionID string) (*resp.Response, error)
+	TwoFactorConfirm(ctx context.Context, sessionID string, request *model.TwoFactorConfirm) (*resp.Response, error)
+	TwoFactorDisable(ctx context.Context, sessionID string, request *model.TwoFactorDisable) (*resp.Response, error)
 }
 
 type authUseCase struct {
-	userRepository    repository.UserRepository
-	sessionRepository repository.SessionRepository
-	cfg               *config.Config
-	sanitizer         *bluemonday.Policy
-	validate          *validator.Validate
+	userRepository              repository.UserRepository
+	passwordResetRepository     repository.PasswordResetRepository
+	emailVerificationRepository repository.EmailVerificationRepository
+	twoFactorRepository         repository.TwoFactorRepository
+	identityRepository          repository.IdentityRepository
+	sessions                    *authsession.Manager
+	throttle                    *LoginThrottle
+	hasher                      *password.Hasher
+	mailSender                  mail.Sender
+	cfg                         *config.Config
+	appURL                      string
+	now                         func() time.Time
+	sanitizer                   *bluemonday.Policy
+	validate                    *validator.Validate
 }
 
-func NewAuthUseCase(userRepository repository.UserRepository, sessionRepository repository.SessionRepository, cfg *config.Config, validate *validator.Validate) AuthUseCase {
+func NewAuthUseCase(userRepository repository.UserRepository, passwordResetRepository repository.PasswordResetRepository,
+	emailVerificationRepository repository.EmailVerificationRepository, twoFactorRepository repository.TwoFactorRepository,
+	identityRepository repository.IdentityRepository, sessions *authsession.Manager, throttle *LoginThrottle, hasher *password.Hasher,
+	mailSender mail.Sender, cfg *config.Config, now func() time.Time, validate *validator.Validate) AuthUseCase {
 	sanitizer := bluemonday.UGCPolicy()
 	return &authUseCase{
-		userRepository:    userRepository,
-		sessionRepository: sessionRepository,
-		cfg:               cfg,
-		sanitizer:         sanitizer,
-		validate:          validate,
-	}
-}
-
-func generateSessionID(username string) string {
-	s := fmt.Sprintf("%s-%d", username, time.Now().UnixMilli())
-	h := sha256.New()
-	h.Write([]byte(s))
-
-	return fmt.Sprintf("%x", h.Sum(nil))
-}
-
-func (s *authUseCase) encryptPassword(pass string) (string, error) {
-	keyBytes, err := hex.DecodeString(s.cfg.EncryptionKey)
-	if err != nil {
-		return "", fmt.Errorf("encrypt_password invalid hex-encoded key: %v", err)
-	}
-
-	if len(keyBytes) != 32 {
-		return "", fmt.Errorf("invalid key length: expected 32 bytes, got %d", len(keyBytes))
-	}
-
-	block, err := aes.NewCipher(keyBytes)
-	if err != nil {
-		return "", err
-	}
-
-	hasher := md5.New() // nolint:gosec
-	_, err = io.WriteString(hasher, pass)
-	if err != nil {
-		return "", err
-	}
-
-	nonce := hasher.Sum(nil)[:12]
-
-	aesgcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
+		userRepository:              userRepository,
+		passwordResetRepository:     passwordResetRepository,
+		emailVerificationRepository: emailVerificationRepository,
+		twoFactorRepository:         twoFactorRepository,
+		identityRepository:          identityRepository,
+		sessions:                    sessions,
+		throttle:                    throttle,
+		hasher:                      hasher,
+		mailSender:                  mailSender,
+		cfg:                         cfg,
+		appURL:                      strings.TrimRight(cfg.AppURL, "/"),
+		now:                         now,
+		sanitizer:                   sanitizer,
+		validate:                    validate,
 	}
-
-	ciphertext := aesgcm.Seal(nil, nonce, []byte(pass), nil)
-
-	return hex.EncodeToString(nonce) + hex.EncodeToString(ciphertext), nil
 }
 
 func (s *authUseCase) AuthSignUp(ctx context.Context, user *model.UserSignUp) (*resp.Response, string, error) {
@@ -118,7 +118,7 @@ func (s *authUseCase) AuthSignUp(ctx context.Context, user *model.UserSignUp) (*
 		return resp.NewResponse(http.StatusConflict, nil), "", ErrUsernameTaken
 	}
 
-	encPassword, err := s.encryptPassword(user.Password)
+	passwordHash, err := s.hasher.Hash(user.Password)
 	if err != nil {
 		return resp.NewResponse(http.StatusInternalServerError, nil), "", err
 	}
@@ -127,7 +127,7 @@ func (s *authUseCase) AuthSignUp(ctx context.Context, user *model.UserSignUp) (*
 		Username:  user.Username,
 		FirstName: user.FirstName,
 		LastName:  user.LastName,
-		Password:  encPassword,
+		Password:  passwordHash,
 		Email:     user.Email,
 		Avatar:    user.Avatar,
 	})
@@ -135,16 +135,17 @@ func (s *authUseCase) AuthSignUp(ctx context.Context, user *model.UserSignUp) (*
 		return resp.NewResponse(http.StatusInternalServerError, nil), "", err
 	}
 
-	sessionID := generateSessionID(user.Username)
-	err = s.sessionRepository.Insert(ctx, &repository.Session{
-		SessionID: sessionID,
-		UserID:    id,
-		CreatedAt: time.Now().UTC(),
-	})
+	sessionID, err := s.sessions.Create(ctx, id, authsession.Client{UserAgent: user.UserAgent, IP: user.IP})
 	if err != nil {
 		return resp.NewResponse(http.StatusInternalServerError, nil), "", err
 	}
 
+	// the account works without a verified email, the link can be sent again if this one gets lost
+	err = s.sendVerification(ctx, &repository.User{ID: id, Username: user.Username, Email: user.Email})
+	if err != nil {
+		log.Error().Msgf("auth_usecase signup error: %v", err)
+	}
+
 	userRepsonse := &model.UserGet{
 		ID:        id,
 		Username:  user.Username,
@@ -162,48 +163,166 @@ func (s *authUseCase) AuthLogin(ctx context.Context, user *model.UserLogin) (*re
 		return resp.NewResponse(http.StatusBadRequest, nil), "", err
 	}
 
+	// a login that has to wait is rejected before the password is checked, even the right one
+	wait, err := s.throttle.Wait(ctx, user.Email, user.IP)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), "", err
+	}
+
+	if wait > 0 {
+		return resp.NewResponse(http.StatusTooManyRequests, nil), "", &TooManyAttemptsError{Wait: wait}
+	}
+
+	// the attempt counts before the password is checked, a parallel burst of guesses that all got
+	// past Wait is stopped here, only a completed login clears it
+	wait, lockedOut, err := s.throttle.Attempt(ctx, user.Email)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), "", err
+	}
+
 	existing, err := s.userRepository.FindByEmail(ctx, user.Email)
 	if err != nil {
 		return resp.NewResponse(http.StatusInternalServerError, nil), "", err
 	}
 
+	if wait > 0 {
+		s.passwordFailed(ctx, user.IP, existing, lockedOut)
+		return resp.NewResponse(http.StatusTooManyRequests, nil), "", &TooManyAttemptsError{Wait: wait}
+	}
+
 	if existing == nil {
+		s.passwordFailed(ctx, user.IP, nil, lockedOut)
 		return resp.NewResponse(http.StatusUnauthorized, nil), "", ErrWrongCredentials
 	}
 
-	encPassword, err := s.encryptPassword(user.Password)
+	ok, rehash, err := s.hasher.Verify(user.Password, existing.Password)
 	if err != nil {
 		return resp.NewResponse(http.StatusInternalServerError, nil), "", err
 	}
 
-	if existing.Password != encPassword {
+	if !ok {
+		s.passwordFailed(ctx, user.IP, existing, lockedOut)
 		return resp.NewResponse(http.StatusUnauthorized, nil), "", fmt.Errorf("invalid username or password")
 	}
 
-	sessionID := generateSessionID(existing.Username)
-	err = s.sessionRepository.Insert(ctx, &repository.Session{
-		SessionID: sessionID,
-		UserID:    existing.ID,
-		CreatedAt: time.Now().UTC(),
-	})
+	if rehash {
+		s.upgradePassword(ctx, existing.ID, user.Password)
+	}
+
+	return s.startLogin(ctx, existing, authsession.Client{UserAgent: user.UserAgent, IP: user.IP})
+}
+
+// startLogin starts a session of a user who proved the first factor. With two-factor
+// authentication it is only a pending session, AuthLoginSecondFactor completes the login.
+func (s *authUseCase) startLogin(ctx context.Context, user *repository.User, client authsession.Client) (*resp.Response, string, error) {
+	twoFactor, err := s.twoFactorEnabled(ctx, user.ID)
 	if err != nil {
 		return resp.NewResponse(http.StatusInternalServerError, nil), "", err
 	}
 
+	if twoFactor {
+		pendingID, err := s.sessions.CreatePending(ctx, user.ID, client)
+		if err != nil {
+			return resp.NewResponse(http.StatusInternalServerError, nil), "", err
+		}
+
+		return resp.NewResponse(http.StatusAccepted, &model.TwoFactorChallenge{
+			TwoFactorRequired: true,
+			PendingToken:      pendingID,
+		}), pendingID, nil
+	}
+
+	// Every login gets a new session ID, an ID the client had before is never reused.
+	sessionID, err := s.sessions.Create(ctx, user.ID, client)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), "", err
+	}
+
+	s.loginSucceeded(ctx, user.Email)
+
 	userResponse := &model.UserGet{
-		ID:        existing.ID,
-		FirstName: existing.FirstName,
-		LastName:  existing.LastName,
-		Username:  existing.Username,
-		Email:     existing.Email,
+		ID:        user.ID,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Username:  user.Username,
+		Email:     user.Email,
 	}
 
 	userResponse.Sanitize(s.sanitizer)
 	return resp.NewResponse(http.StatusOK, userResponse), sessionID, nil
 }
 
+// loginFailed counts a failed login to the account with the email from the address, and tells
+// the user, if there is one, when the account gets locked out. The login fails either way, so
+// errors are only logged.
+func (s *authUseCase) loginFailed(ctx context.Context, email, ip string, user *repository.User) {
+	lockedOut, err := s.throttle.Fail(ctx, email, ip)
+	if err != nil {
+		log.Error().Msgf("auth_usecase login_failed error: %v", err)
+		return
+	}
+
+	if lockedOut {
+		s.notifyLockout(ctx, ip, user)
+	}
+}
+
+// passwordFailed finishes a failed password login, whose attempt the account counted already: it
+// counts the failure of the address and tells the user when the account gets locked out.
+func (s *authUseCase) passwordFailed(ctx context.Context, ip string, user *repository.User, lockedOut bool) {
+	if err := s.throttle.FailAddress(ctx, ip); err != nil {
+		log.Error().Msgf("auth_usecase password_failed error: %v", err)
+	}
+
+	if lockedOut {
+		s.notifyLockout(ctx, ip, user)
+	}
+}
+
+// notifyLockout tells the user, if there is one, that the account is locked out after failed logins.
+func (s *authUseCase) notifyLockout(ctx context.Context, ip string, user *repository.User) {
+	if user == nil {
+		return
+	}
+
+	if ip == "" {
+		ip = "an unknown address"
+	}
+
+	message, err := renderLoginLockout(user.Email, &lockoutData{
+		Username: user.Username,
+		IP:       ip,
+		TTL:      formatTTL(s.throttle.Lockout()),
+	})
+	if err == nil {
+		err = s.mailSender.Send(ctx, message)
+	}
+	if err != nil {
+		log.Error().Msgf("auth_usecase notify_lockout error: %v", err)
+	}
+}
+
+// loginSucceeded forgets the failed logins to the account once a login to it completes.
+func (s *authUseCase) loginSucceeded(ctx context.Context, email string) {
+	if err := s.throttle.Succeed(ctx, email); err != nil {
+		log.Error().Msgf("auth_usecase login_succeeded error: %v", err)
+	}
+}
+
+// upgradePassword replaces a hash in a legacy format with a current one. The login succeeds even if
+// it fails, the hash is upgraded on a later login then.
+func (s *authUseCase) upgradePassword(ctx context.Context, userID int64, plain string) {
+	passwordHash, err := s.hasher.Hash(plain)
+	if err == nil {
+		err = s.userRepository.UpdatePassword(ctx, userID, passwordHash)
+	}
+	if err != nil {
+		log.Error().Msgf("auth_usecase upgrade_password error: %v", err)
+	}
+}
+
 func (s *authUseCase) AuthLogout(ctx context.Context, sessionID string) (*resp.Response, string, error) {
-	err := s.sessionRepository.Delete(ctx, sessionID)
+	err := s.sessions.Delete(ctx, sessionID)
 	if err != nil {
 		return resp.NewResponse(http.StatusInternalServerError, nil), "", err
 	}
@@ -211,28 +330,268 @@ func (s *authUseCase) AuthLogout(ctx context.Context, sessionID string) (*resp.R
 	return resp.NewResponse(http.StatusNoContent, nil), sessionID, nil
 }
 
-func (s *authUseCase) IsSessionValid(ctx context.Context, sessionID string) (bool, error) {
-	sessionInDB, err := s.sessionRepository.FindByID(ctx, sessionID)
+func (s *authUseCase) AuthRotate(ctx context.Context, sessionID string) (*resp.Response, string, error) {
+	newSessionID, err := s.sessions.Rotate(ctx, sessionID)
 	if err != nil {
-		return false, err
+		return sessionErrorResponse(err), "", err
 	}
 
-	if sessionInDB == nil {
-		return false, nil
+	return resp.NewResponse(http.StatusOK, nil), newSessionID, nil
+}
+
+// AuthCheck returns the user of the session and extends the session, refreshed reports whether
+// the expiry moved. An unknown or expired session fails with authsession.ErrNotFound or
+// authsession.ErrExpired.
+func (s *authUseCase) AuthCheck(ctx context.Context, sessionID string) (*resp.Response, bool, error) {
+	user, refreshed, err := s.sessions.Validate(ctx, sessionID)
+	if err != nil {
+		return sessionErrorResponse(err), false, err
 	}
 
-	if sessionInDB.CreatedAt.UnixMilli()+s.cfg.CookieExpiration.Milliseconds() < time.Now().UTC().UnixMilli() {
-		return false, nil
+	return resp.NewResponse(http.StatusOK, &model.UserGet{
+		ID:        user.ID,
+		Username:  user.Username,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Email:     user.Email,
+	}), refreshed, nil
+}
+
+func (s *authUseCase) SessionList(ctx context.Context, sessionID string) (*resp.Response, error) {
+	sessions, err := s.sessions.List(ctx, sessionID)
+	if err != nil {
+		return sessionErrorResponse(err), err
+	}
+
+	current := authsession.Hash(sessionID)
+	response := &model.SessionList{Sessions: make([]*model.SessionGet, 0, len(sessions))}
+	for _, session := range sessions {
+		response.Sessions = append(response.Sessions, &model.SessionGet{
+			ID:         session.SessionID,
+			CreatedAt:  session.CreatedAt,
+			LastSeenAt: session.LastSeenAt,
+			UserAgent:  session.UserAgent,
+			IP:         session.IP,
+			Current:    session.SessionID == current,
+		})
+	}
+
+	response.Count = len(response.Sessions)
+	response.Sanitize(s.sanitizer)
+	return resp.NewResponse(http.StatusOK, response), nil
+}
+
+func (s *authUseCase) SessionRevoke(ctx context.Context, sessionID, id string) (*resp.Response, error) {
+	err := s.sessions.Revoke(ctx, sessionID, id)
+	if errors.Is(err, authsession.ErrUnknownSession) {
+		return resp.NewResponse(http.StatusNotFound, nil), err
+	}
+	if err != nil {
+		return sessionErrorResponse(err), err
+	}
+
+	return resp.NewResponse(http.StatusNoContent, nil), nil
+}
+
+func (s *authUseCase) SessionRevokeOthers(ctx context.Context, sessionID string) (*resp.Response, error) {
+	if _, err := s.sessions.RevokeOthers(ctx, sessionID); err != nil {
+		return sessionErrorResponse(err), err
+	}
+
+	return resp.NewResponse(http.StatusNoContent, nil), nil
+}
+
+// PasswordForgot emails a password reset link if a user has the email. The response is the same
+// either way, so that it does not tell which emails have an account: the link is made and sent in
+// the background, so the response does not take longer for them either. The requests are throttled
+// per email and per client address whether or not a user has the email.
+func (s *authUseCase) PasswordForgot(ctx context.Context, request *model.PasswordForgot) (*resp.Response, error) {
+	if err := s.validate.Struct(request); err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), err
+	}
+
+	wait, err := s.throttle.ResetAttempt(ctx, request.Email, request.IP)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if wait > 0 {
+		return resp.NewResponse(http.StatusTooManyRequests, nil), &TooManyAttemptsError{Wait: wait}
+	}
+
+	user, err := s.userRepository.FindByEmail(ctx, request.Email)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if user != nil {
+		go s.sendPasswordReset(context.WithoutCancel(ctx), user)
+	}
+
+	return resp.NewResponse(http.StatusNoContent, nil), nil
+}
+
+// sendPasswordReset makes a password reset link for the user and emails it. It runs after the
+// response is sent, so errors are only logged.
+func (s *authUseCase) sendPasswordReset(ctx context.Context, user *repository.User) {
+	token, err := newLinkToken()
+	if err != nil {
+		log.Error().Msgf("auth_usecase send_password_reset error: %v", err)
+		return
+	}
+
+	now := s.now().UTC()
+	err = s.passwordResetRepository.Insert(ctx, &repository.PasswordResetToken{
+		UserID:    user.ID,
+		TokenHash: authsession.Hash(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.cfg.PasswordResetTTL),
+	})
+	if err != nil {
+		log.Error().Msgf("auth_usecase send_password_reset error: %v", err)
+		return
+	}
+
+	message, err := renderPasswordReset(user.Email, &linkData{
+		Username: user.Username,
+		Email:    user.Email,
+		Link:     fmt.Sprintf("%s/reset_password?token=%s", s.appURL, url.QueryEscape(token)),
+		TTL:      formatTTL(s.cfg.PasswordResetTTL),
+	})
+	if err == nil {
+		err = s.mailSender.Send(ctx, message)
+	}
+	if err != nil {
+		log.Error().Msgf("auth_usecase send_password_reset error: %v", err)
+	}
+}
+
+// PasswordReset sets a new password with a link from PasswordForgot and ends every session of the
+// user, so that whoever knew the old password is logged out. It returns the ID of the user, so that
+// the gateway can drop the sessions it cached.
+func (s *authUseCase) PasswordReset(ctx context.Context, request *model.PasswordReset) (*resp.Response, int64, error) {
+	if err := s.validate.Struct(request); err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), 0, err
+	}
+
+	passwordHash, err := s.hasher.Hash(request.Password)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), 0, err
+	}
+
+	// the link is used up together with the password change and the sessions, so that it keeps
+	// working if any of them fails
+	userID, err := s.passwordResetRepository.Reset(ctx, authsession.Hash(request.Token), passwordHash, s.now().UTC())
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), 0, err
+	}
+
+	if userID == 0 {
+		return resp.NewResponse(http.StatusBadRequest, nil), 0, ErrInvalidResetLink
+	}
+
+	return resp.NewResponse(http.StatusNoContent, nil), userID, nil
+}
+
+// EmailVerify verifies the email of a user with a link from sendVerification. A link sent to an
+// earlier email of the user is refused.
+func (s *authUseCase) EmailVerify(ctx context.Context, request *model.EmailVerify) (*resp.Response, error) {
+	if err := s.validate.Struct(request); err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), err
+	}
+
+	now := s.now().UTC()
+	token, err := s.emailVerificationRepository.Consume(ctx, authsession.Hash(request.Token), now)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if token == nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), ErrInvalidVerifyLink
+	}
+
+	user, err := s.userRepository.FindByID(ctx, token.UserID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if user == nil || user.Email != token.Email {
+		return resp.NewResponse(http.StatusBadRequest, nil), ErrInvalidVerifyLink
+	}
+
+	if user.EmailVerifiedAt == nil {
+		if err = s.userRepository.MarkEmailVerified(ctx, user.ID, now); err != nil {
+			return resp.NewResponse(http.StatusInternalServerError, nil), err
+		}
+	}
+
+	return resp.NewResponse(http.StatusNoContent, nil), nil
+}
+
+// EmailVerifyResend sends a new verification link to the user of the session. Earlier links keep
+// working until they expire.
+func (s *authUseCase) EmailVerifyResend(ctx context.Context, sessionID string) (*resp.Response, error) {
+	user, _, err := s.sessions.Validate(ctx, sessionID)
+	if err != nil {
+		return sessionErrorResponse(err), err
+	}
+
+	if user.EmailVerifiedAt != nil {
+		return resp.NewResponse(http.StatusConflict, nil), ErrEmailVerified
 	}
 
-	currentUser, err := s.userRepository.FindByID(ctx, sessionInDB.UserID)
+	if err = s.sendVerification(ctx, user); err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	return resp.NewResponse(http.StatusNoContent, nil), nil
+}
+
+// sendVerification emails the user a link to verify the current email.
+func (s *authUseCase) sendVerification(ctx context.Context, user *repository.User) error {
+	token, err := newLinkToken()
 	if err != nil {
-		return false, err
+		return err
 	}
 
-	if currentUser == nil {
-		return false, nil
+	now := s.now().UTC()
+	err = s.emailVerificationRepository.Insert(ctx, &repository.EmailVerificationToken{
+		UserID:    user.ID,
+		Email:     user.Email,
+		TokenHash: authsession.Hash(token),
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.cfg.EmailVerificationTTL),
+	})
+	if err != nil {
+		return err
+	}
+
+	message, err := renderEmailVerification(user.Email, &linkData{
+		Username: user.Username,
+		Email:    user.Email,
+		Link:     fmt.Sprintf("%s/verify_email?token=%s", s.appURL, url.QueryEscape(token)),
+		TTL:      formatTTL(s.cfg.EmailVerificationTTL),
+	})
+	if err != nil {
+		return err
 	}
 
-	return true, nil
+	return s.mailSender.Send(ctx, message)
+}
+
+// newLinkToken returns a random token for a link sent by email. Only its hash is stored.
+func newLinkToken() (string, error) {
+	token := make([]byte, linkTokenSize)
+	if _, err := rand.Read(token); err != nil {
+		return "", fmt.Errorf("auth_usecase failed to read random bytes: %v", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// sessionErrorResponse answers an invalid session with 401 and anything else with 500.
+func sessionErrorResponse(err error) *resp.Response {
+	if errors.Is(err, authsession.ErrNotFound) || errors.Is(err, authsession.ErrExpired) {
+		return resp.NewResponse(http.StatusUnauthorized, nil)
+	}
+	return resp.NewResponse(http.StatusInternalServerError, nil)
 }
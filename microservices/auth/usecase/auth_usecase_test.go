@@ -0,0 +1,808 @@
+package usecase_test
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"go-form-hub/internal/config"
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/authsession"
+	"go-form-hub/internal/services/mail"
+	"go-form-hub/internal/services/password"
+	"go-form-hub/internal/services/ratelimit"
+	"go-form-hub/internal/services/totp"
+	"go-form-hub/microservices/auth/usecase"
+
+	validator "github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/bcrypt"
+)
+
+var testParams = password.Params{
+	Memory:      1024,
+	Iterations:  1,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+type fakeUserRepository struct {
+	repository.UserRepository
+
+	user *repository.User
+}
+
+func (r *fakeUserRepository) FindByEmail(_ context.Context, email string) (*repository.User, error) {
+	if r.user == nil || r.user.Email != email {
+		return nil, nil
+	}
+	user := *r.user
+	return &user, nil
+}
+
+func (r *fakeUserRepository) FindByUsername(_ context.Context, username string) (*repository.User, error) {
+	if r.user == nil || r.user.Username != username {
+		return nil, nil
+	}
+	user := *r.user
+	return &user, nil
+}
+
+func (r *fakeUserRepository) FindByID(_ context.Context, id int64) (*repository.User, error) {
+	if r.user == nil || r.user.ID != id {
+		return nil, nil
+	}
+	user := *r.user
+	return &user, nil
+}
+
+func (r *fakeUserRepository) Insert(_ context.Context, user *repository.User) (int64, error) {
+	inserted := *user
+	inserted.ID = 1
+	r.user = &inserted
+	return inserted.ID, nil
+}
+
+func (r *fakeUserRepository) MarkEmailVerified(_ context.Context, _ int64, verifiedAt time.Time) error {
+	r.user.EmailVerifiedAt = &verifiedAt
+	return nil
+}
+
+func (r *fakeUserRepository) UpdatePassword(_ context.Context, _ int64, password string) error {
+	r.user.Password = password
+	return nil
+}
+
+type fakeSessionRepository struct {
+	repository.SessionRepository
+
+	sessions      map[string]*repository.Session
+	deletedUserID int64
+}
+
+func (r *fakeSessionRepository) Insert(_ context.Context, session *repository.Session) error {
+	if r.sessions == nil {
+		r.sessions = map[string]*repository.Session{}
+	}
+	r.sessions[session.SessionID] = session
+	return nil
+}
+
+func (r *fakeSessionRepository) FindByID(_ context.Context, sessionID string) (*repository.Session, error) {
+	return r.sessions[sessionID], nil
+}
+
+func (r *fakeSessionRepository) Touch(_ context.Context, sessionID string, lastSeenAt time.Time) error {
+	r.sessions[sessionID].LastSeenAt = lastSeenAt
+	return nil
+}
+
+func (r *fakeSessionRepository) Activate(_ context.Context, sessionID, newSessionID string, now time.Time) (bool, error) {
+	session, ok := r.sessions[sessionID]
+	if !ok || !session.Pending {
+		return false, nil
+	}
+	delete(r.sessions, sessionID)
+	session.SessionID, session.Pending, session.CreatedAt, session.LastSeenAt = newSessionID, false, now, now
+	r.sessions[newSessionID] = session
+	return true, nil
+}
+
+func (r *fakeSessionRepository) Delete(_ context.Context, sessionID string) error {
+	delete(r.sessions, sessionID)
+	return nil
+}
+
+func (r *fakeSessionRepository) DeleteByUserID(_ context.Context, userID int64, _ string) (int64, error) {
+	r.deletedUserID = userID
+	return 1, nil
+}
+
+type fakePasswordResetRepository struct {
+	users    *fakeUserRepository
+	sessions *fakeSessionRepository
+	tokens   []*repository.PasswordResetToken
+}
+
+func (r *fakePasswordResetRepository) Insert(_ context.Context, token *repository.PasswordResetToken) error {
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *fakePasswordResetRepository) Reset(ctx context.Context, tokenHash, passwordHash string, now time.Time) (int64, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash && token.UsedAt == nil && token.ExpiresAt.After(now) {
+			for _, other := range r.tokens {
+				if other.UserID == token.UserID && other.UsedAt == nil {
+					other.UsedAt = &now
+				}
+			}
+			_ = r.users.UpdatePassword(ctx, token.UserID, passwordHash)
+			_, _ = r.sessions.DeleteByUserID(ctx, token.UserID, "")
+			return token.UserID, nil
+		}
+	}
+	return 0, nil
+}
+
+type fakeEmailVerificationRepository struct {
+	tokens []*repository.EmailVerificationToken
+}
+
+func (r *fakeEmailVerificationRepository) Insert(_ context.Context, token *repository.EmailVerificationToken) error {
+	r.tokens = append(r.tokens, token)
+	return nil
+}
+
+func (r *fakeEmailVerificationRepository) Consume(_ context.Context, tokenHash string, now time.Time) (*repository.EmailVerificationToken, error) {
+	for _, token := range r.tokens {
+		if token.TokenHash == tokenHash && token.UsedAt == nil && token.ExpiresAt.After(now) {
+			token.UsedAt = &now
+			return token, nil
+		}
+	}
+	return nil, nil
+}
+
+type fakeTwoFactorRepository struct {
+	twoFactor     *repository.TwoFactor
+	recoveryCodes map[string]bool
+}
+
+func (r *fakeTwoFactorRepository) FindByUserID(_ context.Context, userID int64) (*repository.TwoFactor, error) {
+	if r.twoFactor == nil || r.twoFactor.UserID != userID {
+		return nil, nil
+	}
+	twoFactor := *r.twoFactor
+	return &twoFactor, nil
+}
+
+func (r *fakeTwoFactorRepository) Upsert(_ context.Context, twoFactor *repository.TwoFactor) error {
+	if r.twoFactor == nil || r.twoFactor.ConfirmedAt == nil {
+		r.twoFactor = twoFactor
+	}
+	return nil
+}
+
+func (r *fakeTwoFactorRepository) Confirm(_ context.Context, _ int64, confirmedAt time.Time, step int64, codeHashes []string) (bool, error) {
+	if r.twoFactor == nil || r.twoFactor.ConfirmedAt != nil {
+		return false, nil
+	}
+	r.twoFactor.ConfirmedAt, r.twoFactor.LastUsedStep = &confirmedAt, step
+	r.recoveryCodes = map[string]bool{}
+	for _, codeHash := range codeHashes {
+		r.recoveryCodes[codeHash] = true
+	}
+	return true, nil
+}
+
+func (r *fakeTwoFactorRepository) UseStep(_ context.Context, _, step int64) (bool, error) {
+	if r.twoFactor == nil || r.twoFactor.LastUsedStep >= step {
+		return false, nil
+	}
+	r.twoFactor.LastUsedStep = step
+	return true, nil
+}
+
+func (r *fakeTwoFactorRepository) UseRecoveryCode(_ context.Context, _ int64, codeHash string, _ time.Time) (bool, error) {
+	if !r.recoveryCodes[codeHash] {
+		return false, nil
+	}
+	r.recoveryCodes[codeHash] = false
+	return true, nil
+}
+
+func (r *fakeTwoFactorRepository) Delete(_ context.Context, _ int64) error {
+	r.twoFactor, r.recoveryCodes = nil, nil
+	return nil
+}
+
+type fakeIdentityRepository struct {
+	identities []*repository.Identity
+}
+
+func (r *fakeIdentityRepository) FindUserID(_ context.Context, provider, subject string) (int64, error) {
+	for _, identity := range r.identities {
+		if identity.Provider == provider && identity.Subject == subject {
+			return identity.UserID, nil
+		}
+	}
+	return 0, nil
+}
+
+func (r *fakeIdentityRepository) Insert(_ context.Context, identity *repository.Identity) error {
+	r.identities = append(r.identities, identity)
+	return nil
+}
+
+type recordingSender struct {
+	mu       sync.Mutex
+	messages []*mail.Message
+}
+
+func (s *recordingSender) Send(_ context.Context, message *mail.Message) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.messages = append(s.messages, message)
+	return nil
+}
+
+// waitFor waits until count messages are sent, for the ones sent in the background.
+func (s *recordingSender) waitFor(t *testing.T, count int) []*mail.Message {
+	assert.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return len(s.messages) >= count
+	}, time.Second, time.Millisecond)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*mail.Message(nil), s.messages...)
+}
+
+var (
+	resetLink  = regexp.MustCompile(`https://forms\.example\.com/reset_password\?token=(\S+)`)
+	verifyLink = regexp.MustCompile(`https://forms\.example\.com/verify_email\?token=(\S+)`)
+)
+
+var testConfig = &config.Config{
+	AppURL:               "https://forms.example.com/",
+	PasswordResetTTL:     time.Hour,
+	EmailVerificationTTL: 24 * time.Hour,
+}
+
+func TestAuthLoginUpgradesPassword(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("correct horse"), bcrypt.MinCost)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	users := &fakeUserRepository{user: &repository.User{ID: 1, Email: "user@example.com", Password: string(bcryptHash)}}
+	hasher := password.NewHasher(testParams, "")
+	sessions := authsession.NewManager(&fakeSessionRepository{}, users, time.Hour, time.Now)
+	authUseCase := usecase.NewAuthUseCase(users, &fakePasswordResetRepository{}, &fakeEmailVerificationRepository{}, &fakeTwoFactorRepository{},
+		&fakeIdentityRepository{}, sessions, usecase.NewLoginThrottle(ratelimit.NewMemoryStore(), time.Now), hasher, &recordingSender{}, testConfig, time.Now, validator.New())
+
+	result, _, err := authUseCase.AuthLogin(context.Background(), &model.UserLogin{Email: "user@example.com", Password: "battery staple"})
+	assert.NotNil(t, err)
+	assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+	assert.Equal(t, string(bcryptHash), users.user.Password, "a failed login must not upgrade the hash")
+
+	result, sessionID, err := authUseCase.AuthLogin(context.Background(), &model.UserLogin{Email: "user@example.com", Password: "correct horse"})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.NotEmpty(t, sessionID)
+	assert.True(t, strings.HasPrefix(users.user.Password, "$argon2id$"))
+
+	upgraded := users.user.Password
+	_, _, err = authUseCase.AuthLogin(context.Background(), &model.UserLogin{Email: "user@example.com", Password: "correct horse"})
+	assert.Nil(t, err)
+	assert.Equal(t, upgraded, users.user.Password, "a current hash must not be replaced")
+}
+
+func TestAuthPasswordReset(t *testing.T) {
+	users := &fakeUserRepository{user: &repository.User{ID: 1, Username: "user", Email: "user@example.com", Password: "old"}}
+	sessionRepository := &fakeSessionRepository{}
+	resets := &fakePasswordResetRepository{users: users, sessions: sessionRepository}
+	sender := &recordingSender{}
+	now := time.Date(2023, 11, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	hasher := password.NewHasher(testParams, "")
+	sessions := authsession.NewManager(sessionRepository, users, time.Hour, clock)
+	authUseCase := usecase.NewAuthUseCase(users, resets, &fakeEmailVerificationRepository{}, &fakeTwoFactorRepository{},
+		&fakeIdentityRepository{}, sessions, usecase.NewLoginThrottle(ratelimit.NewMemoryStore(), clock), hasher, sender, testConfig, clock, validator.New())
+	ctx := context.Background()
+
+	result, err := authUseCase.PasswordForgot(ctx, &model.PasswordForgot{Email: "nobody@example.com"})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, result.StatusCode, "an unknown email gets the same answer")
+	assert.Empty(t, sender.messages)
+	assert.Empty(t, resets.tokens)
+
+	result, err = authUseCase.PasswordForgot(ctx, &model.PasswordForgot{Email: "user@example.com"})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, result.StatusCode)
+	messages := sender.waitFor(t, 1)
+	if !assert.Len(t, messages, 1) || !assert.Len(t, resets.tokens, 1) {
+		return
+	}
+	assert.Equal(t, []string{"user@example.com"}, messages[0].To)
+	assert.Contains(t, messages[0].Text, "1 hour")
+
+	match := resetLink.FindStringSubmatch(messages[0].Text)
+	if !assert.NotNil(t, match, messages[0].Text) {
+		return
+	}
+	token, err := url.QueryUnescape(match[1])
+	assert.Nil(t, err)
+	assert.NotEqual(t, token, resets.tokens[0].TokenHash, "the token itself must not be stored")
+	assert.Equal(t, authsession.Hash(token), resets.tokens[0].TokenHash)
+	assert.Equal(t, now.Add(time.Hour), resets.tokens[0].ExpiresAt)
+
+	result, userID, err := authUseCase.PasswordReset(ctx, &model.PasswordReset{Token: token, Password: "new password"})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, result.StatusCode)
+	assert.Equal(t, int64(1), userID)
+	ok, _, err := hasher.Verify("new password", users.user.Password)
+	assert.Nil(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), sessionRepository.deletedUserID, "a reset must end every session of the user")
+
+	result, _, err = authUseCase.PasswordReset(ctx, &model.PasswordReset{Token: token, Password: "another password"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidResetLink, "a link works once")
+	assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+
+	_, err = authUseCase.PasswordForgot(ctx, &model.PasswordForgot{Email: "user@example.com"})
+	assert.Nil(t, err)
+	messages = sender.waitFor(t, 2)
+	if !assert.Len(t, messages, 2) {
+		return
+	}
+	expired := resetLink.FindStringSubmatch(messages[1].Text)[1]
+	now = now.Add(time.Hour + time.Second)
+	result, _, err = authUseCase.PasswordReset(ctx, &model.PasswordReset{Token: expired, Password: "another password"})
+	assert.ErrorIs(t, err, usecase.ErrInvalidResetLink, "a link expires")
+	assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+}
+
+func TestAuthPasswordForgotThrottle(t *testing.T) {
+	users := &fakeUserRepository{}
+	now := time.Date(2023, 11, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	sessions := authsession.NewManager(&fakeSessionRepository{}, users, time.Hour, clock)
+	authUseCase := usecase.NewAuthUseCase(users, &fakePasswordResetRepository{}, &fakeEmailVerificationRepository{}, &fakeTwoFactorRepository{},
+		&fakeIdentityRepository{}, sessions, usecase.NewLoginThrottle(ratelimit.NewMemoryStore(), clock), password.NewHasher(testParams, ""),
+		&recordingSender{}, testConfig, clock, validator.New())
+	ctx := context.Background()
+
+	// an unknown email is throttled like any other, or the throttling would tell them apart
+	for i := 0; i <= usecase.ResetAccountPolicy.Free; i++ {
+		result, err := authUseCase.PasswordForgot(ctx, &model.PasswordForgot{Email: "nobody@example.com", IP: "192.0.2.1"})
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusNoContent, result.StatusCode)
+	}
+
+	result, err := authUseCase.PasswordForgot(ctx, &model.PasswordForgot{Email: "nobody@example.com", IP: "192.0.2.2"})
+	var tooMany *usecase.TooManyAttemptsError
+	if assert.ErrorAs(t, err, &tooMany) {
+		assert.Equal(t, usecase.ResetAccountPolicy.BaseDelay, tooMany.Wait)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, result.StatusCode, "the email is throttled from any address")
+
+	for i := 0; i < usecase.ResetAddressPolicy.Free-usecase.ResetAccountPolicy.Free; i++ {
+		result, err = authUseCase.PasswordForgot(ctx, &model.PasswordForgot{Email: fmt.Sprintf("user%d@example.com", i), IP: "192.0.2.1"})
+		assert.Nil(t, err)
+		assert.Equal(t, http.StatusNoContent, result.StatusCode)
+	}
+
+	result, err = authUseCase.PasswordForgot(ctx, &model.PasswordForgot{Email: "another@example.com", IP: "192.0.2.1"})
+	assert.ErrorAs(t, err, &tooMany)
+	assert.Equal(t, http.StatusTooManyRequests, result.StatusCode, "the address is throttled for any email")
+
+	now = now.Add(time.Hour + time.Second)
+	result, err = authUseCase.PasswordForgot(ctx, &model.PasswordForgot{Email: "nobody@example.com", IP: "192.0.2.1"})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, result.StatusCode, "the requests are forgotten after the window")
+}
+
+func TestAuthEmailVerification(t *testing.T) {
+	users := &fakeUserRepository{}
+	verifications := &fakeEmailVerificationRepository{}
+	sender := &recordingSender{}
+	now := time.Date(2023, 11, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	hasher := password.NewHasher(testParams, "")
+	sessions := authsession.NewManager(&fakeSessionRepository{}, users, time.Hour, clock)
+	authUseCase := usecase.NewAuthUseCase(users, &fakePasswordResetRepository{}, verifications, &fakeTwoFactorRepository{},
+		&fakeIdentityRepository{}, sessions, usecase.NewLoginThrottle(ratelimit.NewMemoryStore(), clock), hasher, sender, testConfig, clock, validator.New())
+	ctx := context.Background()
+
+	_, sessionID, err := authUseCase.AuthSignUp(ctx, &model.UserSignUp{Username: "user", Email: "user@example.com", Password: "correct horse"})
+	if !assert.Nil(t, err) || !assert.Len(t, sender.messages, 1) {
+		return
+	}
+	assert.Equal(t, []string{"user@example.com"}, sender.messages[0].To)
+	assert.Contains(t, sender.messages[0].Text, "24 hours")
+	assert.Nil(t, users.user.EmailVerifiedAt, "signing up does not verify the email")
+
+	match := verifyLink.FindStringSubmatch(sender.messages[0].Text)
+	if !assert.NotNil(t, match, sender.messages[0].Text) {
+		return
+	}
+	assert.Equal(t, authsession.Hash(match[1]), verifications.tokens[0].TokenHash)
+
+	result, err := authUseCase.EmailVerifyResend(ctx, sessionID)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, result.StatusCode)
+	if !assert.Len(t, sender.messages, 2) {
+		return
+	}
+	resent := verifyLink.FindStringSubmatch(sender.messages[1].Text)[1]
+
+	// the user changes the email before opening the first link
+	users.user.Email = "other@example.com"
+	result, err = authUseCase.EmailVerify(ctx, &model.EmailVerify{Token: match[1]})
+	assert.ErrorIs(t, err, usecase.ErrInvalidVerifyLink, "a link verifies only the email it was sent to")
+	assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+	assert.Nil(t, users.user.EmailVerifiedAt)
+
+	users.user.Email = "user@example.com"
+	now = now.Add(time.Hour)
+	result, err = authUseCase.EmailVerify(ctx, &model.EmailVerify{Token: resent})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, result.StatusCode)
+	if assert.NotNil(t, users.user.EmailVerifiedAt) {
+		assert.Equal(t, now, *users.user.EmailVerifiedAt)
+	}
+
+	result, err = authUseCase.EmailVerify(ctx, &model.EmailVerify{Token: resent})
+	assert.ErrorIs(t, err, usecase.ErrInvalidVerifyLink, "a link works once")
+	assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+
+	result, err = authUseCase.EmailVerifyResend(ctx, sessionID)
+	assert.ErrorIs(t, err, usecase.ErrEmailVerified)
+	assert.Equal(t, http.StatusConflict, result.StatusCode)
+}
+
+func TestAuthTwoFactor(t *testing.T) {
+	hasher := password.NewHasher(testParams, "")
+	passwordHash, err := hasher.Hash("correct horse")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	users := &fakeUserRepository{user: &repository.User{ID: 1, Username: "user", Email: "user@example.com", Password: passwordHash}}
+	twoFactors := &fakeTwoFactorRepository{}
+	sessionRepository := &fakeSessionRepository{}
+	now := time.Date(2023, 11, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	sessions := authsession.NewManager(sessionRepository, users, time.Hour, clock)
+	authUseCase := usecase.NewAuthUseCase(users, &fakePasswordResetRepository{}, &fakeEmailVerificationRepository{}, twoFactors, &fakeIdentityRepository{},
+		sessions, usecase.NewLoginThrottle(ratelimit.NewMemoryStore(), clock), hasher, &recordingSender{}, testConfig, clock, validator.New())
+	ctx := context.Background()
+	login := &model.UserLogin{Email: "user@example.com", Password: "correct horse"}
+
+	_, sessionID, err := authUseCase.AuthLogin(ctx, login)
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	result, err := authUseCase.TwoFactorEnroll(ctx, sessionID)
+	if !assert.Nil(t, err) {
+		return
+	}
+	setup := result.Body.(*model.TwoFactorSetup)
+	assert.Contains(t, setup.URI, "secret="+setup.Secret)
+
+	result, _, err = authUseCase.AuthLogin(ctx, login)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.StatusCode, "an unconfirmed secret does not protect the login")
+
+	result, err = authUseCase.TwoFactorConfirm(ctx, sessionID, &model.TwoFactorConfirm{Code: "000000"})
+	assert.ErrorIs(t, err, usecase.ErrWrongCode)
+	assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+
+	code, _ := totp.Code(setup.Secret, now)
+	result, err = authUseCase.TwoFactorConfirm(ctx, sessionID, &model.TwoFactorConfirm{Code: code})
+	if !assert.Nil(t, err) {
+		return
+	}
+	recoveryCodes := result.Body.(*model.RecoveryCodes).Codes
+	assert.Len(t, recoveryCodes, 10)
+
+	result, err = authUseCase.TwoFactorEnroll(ctx, sessionID)
+	assert.ErrorIs(t, err, usecase.ErrTwoFactorEnabled)
+	assert.Equal(t, http.StatusConflict, result.StatusCode)
+
+	result, pending, err := authUseCase.AuthLogin(ctx, login)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusAccepted, result.StatusCode)
+	assert.Equal(t, pending, result.Body.(*model.TwoFactorChallenge).PendingToken)
+	_, _, err = sessions.Validate(ctx, pending)
+	assert.ErrorIs(t, err, authsession.ErrNotFound, "the password alone does not log in")
+
+	result, _, err = authUseCase.AuthLoginSecondFactor(ctx, &model.SecondFactor{PendingToken: pending, Code: code})
+	assert.ErrorIs(t, err, usecase.ErrWrongCode, "the code used to confirm cannot be replayed")
+	assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+
+	_, _, err = authUseCase.AuthLoginSecondFactor(ctx, &model.SecondFactor{PendingToken: pending, Code: code})
+	assert.ErrorIs(t, err, authsession.ErrNotFound, "a wrong code ends the pending login")
+
+	now = now.Add(totp.Period)
+	code, _ = totp.Code(setup.Secret, now)
+	_, pending, _ = authUseCase.AuthLogin(ctx, login)
+	result, loggedIn, err := authUseCase.AuthLoginSecondFactor(ctx, &model.SecondFactor{PendingToken: pending, Code: code[:3] + " " + code[3:]})
+	if assert.Nil(t, err) {
+		assert.Equal(t, http.StatusOK, result.StatusCode)
+		assert.Equal(t, "user", result.Body.(*model.UserGet).Username)
+		user, _, err := sessions.Validate(ctx, loggedIn)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(1), user.ID)
+	}
+
+	_, pending, _ = authUseCase.AuthLogin(ctx, login)
+	now = now.Add(authsession.PendingTTL + time.Second)
+	_, _, err = authUseCase.AuthLoginSecondFactor(ctx, &model.SecondFactor{PendingToken: pending, Code: recoveryCodes[0]})
+	assert.ErrorIs(t, err, authsession.ErrExpired, "a pending login expires")
+
+	_, pending, _ = authUseCase.AuthLogin(ctx, login)
+	result, _, err = authUseCase.AuthLoginSecondFactor(ctx, &model.SecondFactor{PendingToken: pending, Code: strings.ToUpper(recoveryCodes[0])})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.StatusCode, "a recovery code replaces the code of the app")
+
+	_, pending, _ = authUseCase.AuthLogin(ctx, login)
+	_, _, err = authUseCase.AuthLoginSecondFactor(ctx, &model.SecondFactor{PendingToken: pending, Code: recoveryCodes[0]})
+	assert.ErrorIs(t, err, usecase.ErrWrongCode, "a recovery code works once")
+
+	result, err = authUseCase.TwoFactorDisable(ctx, loggedIn, &model.TwoFactorDisable{Password: "battery staple"})
+	assert.ErrorIs(t, err, usecase.ErrWrongCredentials)
+	assert.Equal(t, http.StatusForbidden, result.StatusCode)
+
+	result, err = authUseCase.TwoFactorDisable(ctx, loggedIn, &model.TwoFactorDisable{Password: "correct horse"})
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusNoContent, result.StatusCode)
+
+	result, _, err = authUseCase.AuthLogin(ctx, login)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+}
+
+func TestAuthCheck(t *testing.T) {
+	hasher := password.NewHasher(testParams, "")
+	passwordHash, err := hasher.Hash("correct horse")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	users := &fakeUserRepository{user: &repository.User{ID: 1, Username: "user", Email: "user@example.com", Password: passwordHash}}
+	now := time.Date(2023, 12, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	sessions := authsession.NewManager(&fakeSessionRepository{}, users, time.Hour, clock)
+	authUseCase := usecase.NewAuthUseCase(users, &fakePasswordResetRepository{}, &fakeEmailVerificationRepository{}, &fakeTwoFactorRepository{},
+		&fakeIdentityRepository{}, sessions, usecase.NewLoginThrottle(ratelimit.NewMemoryStore(), clock), hasher, &recordingSender{}, testConfig, clock, validator.New())
+	ctx := context.Background()
+
+	_, sessionID, err := authUseCase.AuthLogin(ctx, &model.UserLogin{Email: "user@example.com", Password: "correct horse"})
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	result, _, err := authUseCase.AuthCheck(ctx, sessionID)
+	if assert.Nil(t, err) {
+		assert.Equal(t, http.StatusOK, result.StatusCode)
+		assert.Equal(t, &model.UserGet{ID: 1, Username: "user", Email: "user@example.com"}, result.Body)
+	}
+
+	result, _, err = authUseCase.AuthCheck(ctx, "unknown")
+	assert.ErrorIs(t, err, authsession.ErrNotFound)
+	assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+
+	now = now.Add(2 * time.Hour)
+	_, _, err = authUseCase.AuthCheck(ctx, sessionID)
+	assert.ErrorIs(t, err, authsession.ErrExpired)
+}
+
+func TestAuthLoginThrottle(t *testing.T) {
+	hasher := password.NewHasher(testParams, "")
+	passwordHash, err := hasher.Hash("correct horse")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	users := &fakeUserRepository{user: &repository.User{ID: 1, Username: "user", Email: "user@example.com", Password: passwordHash}}
+	sender := &recordingSender{}
+	now := time.Date(2023, 12, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	sessions := authsession.NewManager(&fakeSessionRepository{}, users, time.Hour, clock)
+	authUseCase := usecase.NewAuthUseCase(users, &fakePasswordResetRepository{}, &fakeEmailVerificationRepository{}, &fakeTwoFactorRepository{},
+		&fakeIdentityRepository{}, sessions, usecase.NewLoginThrottle(ratelimit.NewMemoryStore(), clock), hasher, sender, testConfig, clock, validator.New())
+	ctx := context.Background()
+	wrong := &model.UserLogin{Email: "user@example.com", Password: "battery staple", IP: "192.0.2.1"}
+	right := &model.UserLogin{Email: "user@example.com", Password: "correct horse", IP: "192.0.2.1"}
+
+	for i := 0; i < usecase.AccountPolicy.Free+1; i++ {
+		result, _, err := authUseCase.AuthLogin(ctx, wrong)
+		assert.NotNil(t, err)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+	}
+
+	result, _, err := authUseCase.AuthLogin(ctx, right)
+	var tooMany *usecase.TooManyAttemptsError
+	if assert.ErrorAs(t, err, &tooMany, "even the right password waits") {
+		assert.Equal(t, http.StatusTooManyRequests, result.StatusCode)
+		assert.Equal(t, time.Second, tooMany.Wait)
+	}
+
+	result, _, err = authUseCase.AuthLogin(ctx, &model.UserLogin{Email: "other@example.com", Password: "correct horse", IP: "192.0.2.1"})
+	assert.Equal(t, http.StatusUnauthorized, result.StatusCode, "other accounts from the address do not wait")
+	assert.ErrorIs(t, err, usecase.ErrWrongCredentials)
+
+	now = now.Add(time.Second)
+	result, _, err = authUseCase.AuthLogin(ctx, right)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+
+	for i := 0; i < usecase.AccountPolicy.Free; i++ {
+		result, _, _ = authUseCase.AuthLogin(ctx, wrong)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode, "a login forgets the failures of the account")
+	}
+
+	for i := usecase.AccountPolicy.Free; i < usecase.AccountPolicy.LockoutAfter; i++ {
+		now = now.Add(usecase.AccountPolicy.MaxDelay)
+		result, _, _ = authUseCase.AuthLogin(ctx, wrong)
+		assert.Equal(t, http.StatusUnauthorized, result.StatusCode)
+	}
+	if assert.Len(t, sender.messages, 1, "the lockout is reported once") {
+		assert.Equal(t, []string{"user@example.com"}, sender.messages[0].To)
+		assert.Contains(t, sender.messages[0].Text, "192.0.2.1")
+		assert.Contains(t, sender.messages[0].Text, "15 minutes")
+	}
+
+	now = now.Add(usecase.AccountPolicy.MaxDelay)
+	_, _, err = authUseCase.AuthLogin(ctx, right)
+	if assert.ErrorAs(t, err, &tooMany) {
+		assert.Equal(t, usecase.AccountPolicy.Lockout-usecase.AccountPolicy.MaxDelay, tooMany.Wait)
+	}
+
+	now = now.Add(usecase.AccountPolicy.Lockout)
+	_, _, err = authUseCase.AuthLogin(ctx, right)
+	assert.Nil(t, err, "the lockout ends")
+}
+
+func TestAuthLoginThrottleParallel(t *testing.T) {
+	hasher := password.NewHasher(testParams, "")
+	passwordHash, err := hasher.Hash("correct horse")
+	if !assert.Nil(t, err) {
+		return
+	}
+
+	users := &fakeUserRepository{user: &repository.User{ID: 1, Username: "user", Email: "user@example.com", Password: passwordHash}}
+	now := time.Date(2023, 12, 1, 9, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	sessions := authsession.NewManager(&fakeSessionRepository{}, users, time.Hour, clock)
+	authUseCase := usecase.NewAuthUseCase(users, &fakePasswordResetRepository{}, &fakeEmailVerificationRepository{}, &fakeTwoFactorRepository{},
+		&fakeIdentityRepository{}, sessions, usecase.NewLoginThrottle(ratelimit.NewMemoryStore(), clock), hasher, &recordingSender{}, testConfig,
+		clock, validator.New())
+
+	const guesses = 20
+	var (
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+		unauthorized int
+		tooMany      int
+	)
+	start := make(chan struct{})
+	for i := 0; i < guesses; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+
+			wrong := &model.UserLogin{Email: "user@example.com", Password: "battery staple"}
+			result, _, _ := authUseCase.AuthLogin(context.Background(), wrong)
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch result.StatusCode {
+			case http.StatusUnauthorized:
+				unauthorized++
+			case http.StatusTooManyRequests:
+				tooMany++
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	// the password of only the free attempts and the first delayed one is checked
+	assert.Equal(t, usecase.AccountPolicy.Free+1, unauthorized)
+	assert.Equal(t, guesses-usecase.AccountPolicy.Free-1, tooMany)
+}
+
+func TestAuthLoginOIDC(t *testing.T) {
+	users := &fakeUserRepository{}
+	identities := &fakeIdentityRepository{}
+	sender := &recordingSender{}
+	now := time.Date(2023, 11, 1, 12, 0, 0, 0, time.UTC)
+	clock := func() time.Time { return now }
+	hasher := password.NewHasher(testParams, "")
+	sessions := authsession.NewManager(&fakeSessionRepository{}, users, time.Hour, clock)
+	authUseCase := usecase.NewAuthUseCase(users, &fakePasswordResetRepository{}, &fakeEmailVerificationRepository{}, &fakeTwoFactorRepository{},
+		identities, sessions, usecase.NewLoginThrottle(ratelimit.NewMemoryStore(), clock), hasher, sender, testConfig, clock, validator.New())
+	ctx := context.Background()
+
+	identity := &model.OIDCIdentity{
+		Provider:      "corp",
+		Subject:       "subject-1",
+		Email:         "ada.lovelace@example.com",
+		EmailVerified: true,
+		FirstName:     "Ada",
+		LastName:      "Lovelace",
+	}
+
+	result, sessionID, err := authUseCase.AuthLoginOIDC(ctx, identity)
+	if !assert.Nil(t, err) {
+		return
+	}
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, "adalovelace", users.user.Username, "a new user is provisioned")
+	assert.Equal(t, "Ada", users.user.FirstName)
+	assert.Equal(t, &now, users.user.EmailVerifiedAt, "the provider verified the email")
+	assert.Empty(t, sender.messages)
+	if assert.Len(t, identities.identities, 1) {
+		assert.Equal(t, users.user.ID, identities.identities[0].UserID)
+	}
+	user, _, err := sessions.Validate(ctx, sessionID)
+	if assert.Nil(t, err) {
+		assert.Equal(t, users.user.ID, user.ID)
+	}
+
+	// the email at the provider changed, the account stays linked by its subject
+	identity.Email = "ada@example.com"
+	_, _, err = authUseCase.AuthLoginOIDC(ctx, identity)
+	assert.Nil(t, err)
+	assert.Len(t, identities.identities, 1)
+
+	// an existing user who has not verified the email is not taken over
+	users.user = &repository.User{ID: 5, Username: "ada", Email: "ada@example.com", Password: "hash"}
+	other := &model.OIDCIdentity{Provider: "other", Subject: "subject-2", Email: "ada@example.com", EmailVerified: true}
+	result, _, err = authUseCase.AuthLoginOIDC(ctx, other)
+	assert.ErrorIs(t, err, usecase.ErrLocalEmailNotVerified)
+	assert.Equal(t, http.StatusConflict, result.StatusCode)
+	assert.Len(t, identities.identities, 1)
+
+	// an account at another provider with the verified email of an existing user is linked to it
+	verifiedAt := now.Add(-time.Hour)
+	users.user.EmailVerifiedAt = &verifiedAt
+	result, _, err = authUseCase.AuthLoginOIDC(ctx, other)
+	assert.Nil(t, err)
+	assert.Equal(t, "ada", result.Body.(*model.UserGet).Username)
+	if assert.Len(t, identities.identities, 2) {
+		assert.Equal(t, int64(5), identities.identities[1].UserID)
+	}
+
+	// an unverified email does not take over an existing user
+	unverified := &model.OIDCIdentity{Provider: "other", Subject: "subject-3", Email: "ada@example.com"}
+	result, _, err = authUseCase.AuthLoginOIDC(ctx, unverified)
+	assert.ErrorIs(t, err, usecase.ErrEmailNotVerified)
+	assert.Equal(t, http.StatusConflict, result.StatusCode)
+	assert.Len(t, identities.identities, 2)
+
+	// nor does it stop a new user, who is asked to verify the email
+	unverified.Email = "ada@mail.example.com"
+	result, _, err = authUseCase.AuthLoginOIDC(ctx, unverified)
+	assert.Nil(t, err)
+	assert.Equal(t, http.StatusOK, result.StatusCode)
+	assert.Equal(t, "ada2", users.user.Username, "a taken username gets a number")
+	assert.Nil(t, users.user.EmailVerifiedAt)
+	assert.Len(t, sender.messages, 1)
+}
@@ -0,0 +1,204 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"go-form-hub/internal/services/authsession"
+	"go-form-hub/internal/services/ratelimit"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	// AccountPolicy slows down the guessing of the password of one account.
+	AccountPolicy = ratelimit.Policy{
+		Free:         3,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 10,
+		Lockout:      15 * time.Minute,
+		Window:       time.Hour,
+	}
+	// AddressPolicy slows down a client trying many accounts. It allows more failures than
+	// AccountPolicy, since many users may share an address.
+	AddressPolicy = ratelimit.Policy{
+		Free:         20,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 100,
+		Lockout:      15 * time.Minute,
+		Window:       time.Hour,
+	}
+	// ResetAccountPolicy slows down the password reset links sent to one email, so that its owner
+	// is not flooded with them.
+	ResetAccountPolicy = ratelimit.Policy{
+		Free:         3,
+		BaseDelay:    time.Minute,
+		MaxDelay:     15 * time.Minute,
+		LockoutAfter: 10,
+		Lockout:      time.Hour,
+		Window:       time.Hour,
+	}
+	// ResetAddressPolicy slows down a client asking for links to many emails.
+	ResetAddressPolicy = ratelimit.Policy{
+		Free:         20,
+		BaseDelay:    time.Second,
+		MaxDelay:     time.Minute,
+		LockoutAfter: 100,
+		Lockout:      15 * time.Minute,
+		Window:       time.Hour,
+	}
+)
+
+// TooManyAttemptsError rejects a login without checking the password, or a password reset request
+// without looking up the email, while the account or the client address has to wait.
+type TooManyAttemptsError struct {
+	Wait time.Duration
+}
+
+func (e *TooManyAttemptsError) Error() string {
+	return fmt.Sprintf("too many attempts, try again in %d seconds", int64(math.Ceil(e.Wait.Seconds())))
+}
+
+// LoginThrottle tracks the failed logins and the password reset requests per account and per
+// client address. With a store in the database every replica of the microservice sees the same
+// failures.
+type LoginThrottle struct {
+	accounts       *ratelimit.Backoff
+	addresses      *ratelimit.Backoff
+	resetAccounts  *ratelimit.Backoff
+	resetAddresses *ratelimit.Backoff
+}
+
+func NewLoginThrottle(store ratelimit.Store, now func() time.Time) *LoginThrottle {
+	return &LoginThrottle{
+		accounts:       ratelimit.NewBackoff(store, AccountPolicy, now),
+		addresses:      ratelimit.NewBackoff(store, AddressPolicy, now),
+		resetAccounts:  ratelimit.NewBackoff(store, ResetAccountPolicy, now),
+		resetAddresses: ratelimit.NewBackoff(store, ResetAddressPolicy, now),
+	}
+}
+
+// the keys are hashed, so the store holds neither the emails that were tried nor the addresses
+func accountKey(email string) string {
+	return authsession.Hash("account:" + strings.ToLower(strings.TrimSpace(email)))
+}
+
+func addressKey(ip string) string {
+	return authsession.Hash("address:" + ip)
+}
+
+func resetAccountKey(email string) string {
+	return authsession.Hash("reset_account:" + strings.ToLower(strings.TrimSpace(email)))
+}
+
+func resetAddressKey(ip string) string {
+	return authsession.Hash("reset_address:" + ip)
+}
+
+// Wait returns how long a login to the account from the address has to wait, 0 if it may go ahead.
+func (t *LoginThrottle) Wait(ctx context.Context, email, ip string) (time.Duration, error) {
+	wait, err := t.accounts.Wait(ctx, accountKey(email))
+	if err != nil || ip == "" {
+		return wait, err
+	}
+
+	addressWait, err := t.addresses.Wait(ctx, addressKey(ip))
+	if err != nil {
+		return 0, err
+	}
+
+	if addressWait > wait {
+		return addressWait, nil
+	}
+	return wait, nil
+}
+
+// Attempt records a login to the account as failed before its password is checked, so that
+// parallel guesses cannot all get past Wait, and returns how long the login has to wait, 0 if it
+// may go ahead. Succeed forgets it. lockedOut reports whether the login locks the account out,
+// should it fail.
+func (t *LoginThrottle) Attempt(ctx context.Context, email string) (wait time.Duration, lockedOut bool, err error) {
+	return t.accounts.Attempt(ctx, accountKey(email))
+}
+
+// FailAddress records a failed login from the address, the address may be empty. It is recorded
+// after the password is checked, or a client could not log in to its own accounts, parallel guesses
+// at one account are stopped by Attempt.
+func (t *LoginThrottle) FailAddress(ctx context.Context, ip string) error {
+	if ip == "" {
+		return nil
+	}
+
+	_, err := t.addresses.Fail(ctx, addressKey(ip))
+	return err
+}
+
+// Fail records a failed login to the account from the address, the address may be empty. It
+// reports whether the failure locked the account out.
+func (t *LoginThrottle) Fail(ctx context.Context, email, ip string) (bool, error) {
+	lockedOut, err := t.accounts.Fail(ctx, accountKey(email))
+	if err != nil || ip == "" {
+		return lockedOut, err
+	}
+
+	_, err = t.addresses.Fail(ctx, addressKey(ip))
+	return lockedOut, err
+}
+
+// Succeed forgets the failures of the account once a login to it completes. The failures of the
+// address stay, or a client could clear them by logging in to an account of its own.
+func (t *LoginThrottle) Succeed(ctx context.Context, email string) error {
+	return t.accounts.Reset(ctx, accountKey(email))
+}
+
+// ResetAttempt records a password reset request for the email from the address, the address may be
+// empty, and returns how long the request has to wait, 0 if it may go ahead. Every request counts,
+// whether or not a user has the email, so the delay does not tell which emails have an account.
+func (t *LoginThrottle) ResetAttempt(ctx context.Context, email, ip string) (time.Duration, error) {
+	wait, _, err := t.resetAccounts.Attempt(ctx, resetAccountKey(email))
+	if err != nil || ip == "" {
+		return wait, err
+	}
+
+	addressWait, _, err := t.resetAddresses.Attempt(ctx, resetAddressKey(ip))
+	if err != nil {
+		return 0, err
+	}
+
+	if addressWait > wait {
+		return addressWait, nil
+	}
+	return wait, nil
+}
+
+// Lockout is how long an account stays locked out.
+func (t *LoginThrottle) Lockout() time.Duration {
+	return t.accounts.Lockout()
+}
+
+// PurgeLoginAttempts forgets the failures that no longer count every interval until ctx is done.
+func PurgeLoginAttempts(ctx context.Context, throttle *LoginThrottle, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		// all the policies have the same window, the keys of accounts and addresses go together
+		deleted, err := throttle.accounts.Purge(ctx)
+		if err != nil {
+			log.Error().Msgf("auth_usecase purge_login_attempts error: %v", err)
+		} else if deleted > 0 {
+			log.Info().Msgf("auth_usecase purged %d login attempts", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/authsession"
+	resp "go-form-hub/internal/services/service_response"
+
+	"github.com/rs/zerolog/log"
+)
+
+var (
+	ErrEmailNotVerified      = errors.New("the provider did not verify the email, log in with the password to link the account")
+	ErrLocalEmailNotVerified = errors.New("the email of the existing user is not verified, verify it to link the account")
+)
+
+const (
+	defaultUsername   = "user"
+	maxUsernameTries  = 100
+	maxUsernameLength = 64
+)
+
+// AuthLoginOIDC logs in the user linked to the account at the provider. An account seen for the
+// first time is linked to the user with the same email if both the provider and the user verified
+// the email, or gets a new user if nobody has the email.
+func (s *authUseCase) AuthLoginOIDC(ctx context.Context, identity *model.OIDCIdentity) (*resp.Response, string, error) {
+	if err := s.validate.Struct(identity); err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), "", err
+	}
+
+	userID, err := s.identityRepository.FindUserID(ctx, identity.Provider, identity.Subject)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), "", err
+	}
+
+	if userID == 0 {
+		var response *resp.Response
+		response, userID, err = s.linkIdentity(ctx, identity)
+		if err != nil {
+			return response, "", err
+		}
+	}
+
+	user, err := s.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), "", err
+	}
+
+	if user == nil {
+		return resp.NewResponse(http.StatusUnauthorized, nil), "", ErrWrongCredentials
+	}
+
+	return s.startLogin(ctx, user, authsession.Client{UserAgent: identity.UserAgent, IP: identity.IP})
+}
+
+// linkIdentity links the account at the provider to the user with its email, or to a new user, and
+// returns the ID of the user.
+func (s *authUseCase) linkIdentity(ctx context.Context, identity *model.OIDCIdentity) (*resp.Response, int64, error) {
+	existing, err := s.userRepository.FindByEmail(ctx, identity.Email)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), 0, err
+	}
+
+	var userID int64
+	switch {
+	case existing != nil && !identity.EmailVerified:
+		// whoever controls the account at the provider does not necessarily own the email
+		return resp.NewResponse(http.StatusConflict, nil), 0, ErrEmailNotVerified
+	case existing != nil && existing.EmailVerifiedAt == nil:
+		// the user may have been registered with the email by someone else, who still knows the password
+		return resp.NewResponse(http.StatusConflict, nil), 0, ErrLocalEmailNotVerified
+	case existing != nil:
+		userID = existing.ID
+	default:
+		userID, err = s.provisionUser(ctx, identity)
+		if err != nil {
+			return resp.NewResponse(http.StatusInternalServerError, nil), 0, err
+		}
+	}
+
+	err = s.identityRepository.Insert(ctx, &repository.Identity{
+		UserID:    userID,
+		Provider:  identity.Provider,
+		Subject:   identity.Subject,
+		Email:     identity.Email,
+		CreatedAt: s.now().UTC(),
+	})
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), 0, err
+	}
+
+	return nil, userID, nil
+}
+
+// provisionUser creates a user for an account at a provider. The user has a random password, a
+// password of their own can be set with the password reset.
+func (s *authUseCase) provisionUser(ctx context.Context, identity *model.OIDCIdentity) (int64, error) {
+	username, err := s.freeUsername(ctx, identity.Email)
+	if err != nil {
+		return 0, err
+	}
+
+	randomPassword, err := newLinkToken()
+	if err != nil {
+		return 0, err
+	}
+
+	passwordHash, err := s.hasher.Hash(randomPassword)
+	if err != nil {
+		return 0, err
+	}
+
+	user := &repository.User{
+		Username:  username,
+		FirstName: identity.FirstName,
+		LastName:  identity.LastName,
+		Password:  passwordHash,
+		Email:     identity.Email,
+		Avatar:    new(string),
+	}
+
+	user.ID, err = s.userRepository.Insert(ctx, user)
+	if err != nil {
+		return 0, err
+	}
+
+	if identity.EmailVerified {
+		return user.ID, s.userRepository.MarkEmailVerified(ctx, user.ID, s.now().UTC())
+	}
+
+	if err = s.sendVerification(ctx, user); err != nil {
+		log.Error().Msgf("auth_usecase provision_user error: %v", err)
+	}
+
+	return user.ID, nil
+}
+
+// freeUsername derives an unused alphanumeric username from the email, user@example.com becomes
+// user, then user2, user3 and so on.
+func (s *authUseCase) freeUsername(ctx context.Context, email string) (string, error) {
+	local, _, _ := strings.Cut(email, "@")
+	base := strings.Map(func(r rune) rune {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return -1
+	}, local)
+	if base == "" {
+		base = defaultUsername
+	}
+	if len(base) > maxUsernameLength {
+		base = base[:maxUsernameLength]
+	}
+
+	for i := 1; i <= maxUsernameTries; i++ {
+		username := base
+		if i > 1 {
+			username += strconv.Itoa(i)
+		}
+
+		existing, err := s.userRepository.FindByUsername(ctx, username)
+		if err != nil {
+			return "", err
+		}
+
+		if existing == nil {
+			return username, nil
+		}
+	}
+
+	return "", ErrUsernameTaken
+}
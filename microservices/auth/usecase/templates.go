@@ -0,0 +1,82 @@
+package usecase
+
+import (
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"go-form-hub/internal/services/mail"
+)
+
+//go:embed templates
+var templateFiles embed.FS
+
+var (
+	passwordResetText     = texttemplate.Must(texttemplate.ParseFS(templateFiles, "templates/password_reset.txt"))
+	passwordResetHTML     = htmltemplate.Must(htmltemplate.ParseFS(templateFiles, "templates/password_reset.html"))
+	emailVerificationText = texttemplate.Must(texttemplate.ParseFS(templateFiles, "templates/email_verification.txt"))
+	emailVerificationHTML = htmltemplate.Must(htmltemplate.ParseFS(templateFiles, "templates/email_verification.html"))
+	loginLockoutText      = texttemplate.Must(texttemplate.ParseFS(templateFiles, "templates/login_lockout.txt"))
+	loginLockoutHTML      = htmltemplate.Must(htmltemplate.ParseFS(templateFiles, "templates/login_lockout.html"))
+)
+
+// linkData is what the emails with a single-use link show.
+type linkData struct {
+	Username string
+	Email    string
+	Link     string
+	TTL      string
+}
+
+// lockoutData is what the email about a locked out account shows.
+type lockoutData struct {
+	Username string
+	IP       string
+	TTL      string
+}
+
+func renderPasswordReset(to string, data *linkData) (*mail.Message, error) {
+	return render(to, "Reset your password", passwordResetText, passwordResetHTML, data)
+}
+
+func renderEmailVerification(to string, data *linkData) (*mail.Message, error) {
+	return render(to, "Verify your email", emailVerificationText, emailVerificationHTML, data)
+}
+
+func renderLoginLockout(to string, data *lockoutData) (*mail.Message, error) {
+	return render(to, "Too many failed logins", loginLockoutText, loginLockoutHTML, data)
+}
+
+func render(to, subject string, textTemplate *texttemplate.Template, htmlTemplate *htmltemplate.Template, data interface{}) (*mail.Message, error) {
+	var text, html strings.Builder
+	if err := textTemplate.Execute(&text, data); err != nil {
+		return nil, err
+	}
+
+	if err := htmlTemplate.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	return &mail.Message{
+		To:      []string{to},
+		Subject: subject,
+		Text:    text.String(),
+		HTML:    html.String(),
+	}, nil
+}
+
+// formatTTL writes a link lifetime the way a person would, "1 hour" rather than "1h0m0s".
+func formatTTL(ttl time.Duration) string {
+	value, unit := int64(ttl/time.Minute), "minute"
+	if ttl >= time.Hour && ttl%time.Hour == 0 {
+		value, unit = int64(ttl/time.Hour), "hour"
+	}
+
+	if value == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", value, unit)
+}
@@ -0,0 +1,282 @@
+package usecase
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/authsession"
+	resp "go-form-hub/internal/services/service_response"
+	"go-form-hub/internal/services/totp"
+)
+
+var (
+	ErrWrongCode           = errors.New("the code is wrong or was already used")
+	ErrTwoFactorEnabled    = errors.New("two-factor authentication is already enabled")
+	ErrTwoFactorNotEnabled = errors.New("two-factor authentication is not enabled")
+	ErrTwoFactorNotStarted = errors.New("two-factor authentication setup was not started")
+)
+
+const (
+	twoFactorIssuer = "Form Hub"
+
+	recoveryCodeCount = 10
+	recoveryCodeSize  = 5
+)
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// AuthLoginSecondFactor completes a login that AuthLogin left pending. A wrong code ends the
+// pending session, so that every guess costs the password again.
+func (s *authUseCase) AuthLoginSecondFactor(ctx context.Context, request *model.SecondFactor) (*resp.Response, string, error) {
+	if err := s.validate.Struct(request); err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), "", err
+	}
+
+	userID, err := s.sessions.PendingUser(ctx, request.PendingToken)
+	if err != nil {
+		return sessionErrorResponse(err), "", err
+	}
+
+	user, err := s.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), "", err
+	}
+
+	if user == nil {
+		return resp.NewResponse(http.StatusUnauthorized, nil), "", ErrWrongCredentials
+	}
+
+	twoFactor, err := s.twoFactorRepository.FindByUserID(ctx, userID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), "", err
+	}
+
+	ok := false
+	if twoFactor != nil && twoFactor.ConfirmedAt != nil {
+		ok, err = s.checkSecondFactor(ctx, twoFactor, request.Code)
+		if err != nil {
+			return resp.NewResponse(http.StatusInternalServerError, nil), "", err
+		}
+	}
+
+	if !ok {
+		// a wrong code counts against the account like a wrong password, the right password
+		// alone does not clear the failures
+		s.loginFailed(ctx, user.Email, "", user)
+		if err = s.sessions.Delete(ctx, request.PendingToken); err != nil {
+			return resp.NewResponse(http.StatusInternalServerError, nil), "", err
+		}
+		return resp.NewResponse(http.StatusUnauthorized, nil), "", ErrWrongCode
+	}
+
+	sessionID, err := s.sessions.Activate(ctx, request.PendingToken)
+	if err != nil {
+		return sessionErrorResponse(err), "", err
+	}
+
+	s.loginSucceeded(ctx, user.Email)
+
+	userResponse := &model.UserGet{
+		ID:        user.ID,
+		FirstName: user.FirstName,
+		LastName:  user.LastName,
+		Username:  user.Username,
+		Email:     user.Email,
+	}
+
+	userResponse.Sanitize(s.sanitizer)
+	return resp.NewResponse(http.StatusOK, userResponse), sessionID, nil
+}
+
+// checkSecondFactor accepts a current code of the authenticator app that was not used before, or
+// an unused recovery code.
+func (s *authUseCase) checkSecondFactor(ctx context.Context, twoFactor *repository.TwoFactor, code string) (bool, error) {
+	code = normalizeCode(code)
+	if !isTOTPCode(code) {
+		return s.twoFactorRepository.UseRecoveryCode(ctx, twoFactor.UserID, authsession.Hash(code), s.now().UTC())
+	}
+
+	step, ok, err := totp.Validate(twoFactor.Secret, code, s.now())
+	if err != nil || !ok {
+		return false, err
+	}
+
+	return s.twoFactorRepository.UseStep(ctx, twoFactor.UserID, step)
+}
+
+// TwoFactorEnroll starts the setup of two-factor authentication for the user of the session with
+// a new secret. Nothing changes for the login before TwoFactorConfirm.
+func (s *authUseCase) TwoFactorEnroll(ctx context.Context, sessionID string) (*resp.Response, error) {
+	user, _, err := s.sessions.Validate(ctx, sessionID)
+	if err != nil {
+		return sessionErrorResponse(err), err
+	}
+
+	twoFactor, err := s.twoFactorRepository.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if twoFactor != nil && twoFactor.ConfirmedAt != nil {
+		return resp.NewResponse(http.StatusConflict, nil), ErrTwoFactorEnabled
+	}
+
+	secret, err := totp.NewSecret()
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	err = s.twoFactorRepository.Upsert(ctx, &repository.TwoFactor{
+		UserID:    user.ID,
+		Secret:    secret,
+		CreatedAt: s.now().UTC(),
+	})
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	return resp.NewResponse(http.StatusOK, &model.TwoFactorSetup{
+		Secret: secret,
+		URI:    totp.URI(twoFactorIssuer, user.Email, secret),
+	}), nil
+}
+
+// TwoFactorConfirm enables two-factor authentication once the user proves the authenticator app
+// has the secret, and returns the recovery codes. They are only shown this once.
+func (s *authUseCase) TwoFactorConfirm(ctx context.Context, sessionID string, request *model.TwoFactorConfirm) (*resp.Response, error) {
+	if err := s.validate.Struct(request); err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), err
+	}
+
+	user, _, err := s.sessions.Validate(ctx, sessionID)
+	if err != nil {
+		return sessionErrorResponse(err), err
+	}
+
+	twoFactor, err := s.twoFactorRepository.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if twoFactor == nil {
+		return resp.NewResponse(http.StatusNotFound, nil), ErrTwoFactorNotStarted
+	}
+
+	if twoFactor.ConfirmedAt != nil {
+		return resp.NewResponse(http.StatusConflict, nil), ErrTwoFactorEnabled
+	}
+
+	now := s.now()
+	step, ok, err := totp.Validate(twoFactor.Secret, request.Code, now)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if !ok {
+		return resp.NewResponse(http.StatusBadRequest, nil), ErrWrongCode
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	confirmed, err := s.twoFactorRepository.Confirm(ctx, user.ID, now.UTC(), step, hashes)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if !confirmed {
+		return resp.NewResponse(http.StatusConflict, nil), ErrTwoFactorEnabled
+	}
+
+	return resp.NewResponse(http.StatusOK, &model.RecoveryCodes{Codes: codes}), nil
+}
+
+// TwoFactorDisable turns two-factor authentication off after checking the password of the user.
+func (s *authUseCase) TwoFactorDisable(ctx context.Context, sessionID string, request *model.TwoFactorDisable) (*resp.Response, error) {
+	if err := s.validate.Struct(request); err != nil {
+		return resp.NewResponse(http.StatusBadRequest, nil), err
+	}
+
+	user, _, err := s.sessions.Validate(ctx, sessionID)
+	if err != nil {
+		return sessionErrorResponse(err), err
+	}
+
+	ok, _, err := s.hasher.Verify(request.Password, user.Password)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if !ok {
+		return resp.NewResponse(http.StatusForbidden, nil), ErrWrongCredentials
+	}
+
+	twoFactor, err := s.twoFactorRepository.FindByUserID(ctx, user.ID)
+	if err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	if twoFactor == nil {
+		return resp.NewResponse(http.StatusNotFound, nil), ErrTwoFactorNotEnabled
+	}
+
+	if err = s.twoFactorRepository.Delete(ctx, user.ID); err != nil {
+		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	}
+
+	return resp.NewResponse(http.StatusNoContent, nil), nil
+}
+
+// twoFactorEnabled reports whether the login of the user needs a second factor.
+func (s *authUseCase) twoFactorEnabled(ctx context.Context, userID int64) (bool, error) {
+	twoFactor, err := s.twoFactorRepository.FindByUserID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return twoFactor != nil && twoFactor.ConfirmedAt != nil, nil
+}
+
+// newRecoveryCodes returns recovery codes formatted like abcd-efgh along with the hashes to store.
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	codes = make([]string, 0, recoveryCodeCount)
+	hashes = make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code := make([]byte, recoveryCodeSize)
+		if _, err = rand.Read(code); err != nil {
+			return nil, nil, fmt.Errorf("auth_usecase failed to read random bytes: %v", err)
+		}
+
+		encoded := strings.ToLower(recoveryCodeEncoding.EncodeToString(code))
+		codes = append(codes, encoded[:4]+"-"+encoded[4:])
+		hashes = append(hashes, authsession.Hash(encoded))
+	}
+
+	return codes, hashes, nil
+}
+
+// normalizeCode drops the separators users type or copy along with a code.
+func normalizeCode(code string) string {
+	return strings.ToLower(strings.NewReplacer(" ", "", "-", "").Replace(code))
+}
+
+func isTOTPCode(code string) bool {
+	if len(code) != 6 {
+		return false
+	}
+	for _, r := range code {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"go-form-hub/internal/api"
 	"go-form-hub/internal/config"
 	"go-form-hub/internal/database"
 	"go-form-hub/internal/repository"
@@ -21,7 +24,10 @@ import (
 	"google.golang.org/grpc"
 )
 
-const defaultPort = ":8083"
+const (
+	defaultPort              = ":8083"
+	fingerprintPurgeInterval = time.Hour
+)
 
 func main() {
 	log.Info().Msg("Starting microservice...")
@@ -45,9 +51,17 @@ func main() {
 	validate := validator.New()
 
 	formRepository := repository.NewFormDatabaseRepository(db, builder)
-	passageService := usecase.NewformPasageUseCase(formRepository, validate)
+	recipientRepository := repository.NewRecipientDatabaseRepository(db, builder)
+	userRepository := repository.NewUserDatabaseRepository(db, builder)
+	grants := api.NewHMACHashToken(cfg.Secret)
+	passageService := usecase.NewformPasageUseCase(formRepository, recipientRepository, userRepository, grants,
+		[]byte(cfg.Secret), cfg.AnonFingerprintRetention, validate)
 	passageController := controller.NewPassageController(passageService, validate)
 
+	cleanupCtx, stopCleanup := context.WithCancel(context.Background())
+	defer stopCleanup()
+	go usecase.PurgeFingerprints(cleanupCtx, formRepository, cfg.AnonFingerprintRetention, fingerprintPurgeInterval)
+
 	lis, err := net.Listen("tcp", defaultPort) // #nosec G102
 	if err != nil {
 		log.Error().Msgf("failed to listen to port: %v", err)
@@ -2,6 +2,7 @@ package controller
 
 import (
 	"context"
+	"time"
 
 	"go-form-hub/internal/model"
 	passage "go-form-hub/microservices/passage/passage_client"
@@ -36,6 +37,15 @@ func (controller *PassageController) Pass(ctx context.Context, passageMsg *passa
 	passageModel := &model.FormPassage{
 		FormID:         &passageMsg.FormID,
 		PassageAnswers: passageAnswers,
+		AccessToken:    passageMsg.AccessToken,
+		AccessGrant:    passageMsg.AccessGrant,
+		RespondentID:   passageMsg.RespondentID,
+		Fingerprint:    passageMsg.Fingerprint,
+		IdempotencyKey: passageMsg.IdempotencyKey,
+	}
+	if passageMsg.StartedAt != 0 {
+		startedAt := time.Unix(passageMsg.StartedAt, 0).UTC()
+		passageModel.StartedAt = &startedAt
 	}
 	ctx = context.WithValue(ctx, model.ContextCurrentUser, &model.UserGet{
 		ID: passageMsg.UserID,
@@ -2,10 +2,17 @@ package usecase
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
 	"net/http"
+	"time"
 
 	"go-form-hub/internal/model"
 	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/passage"
 	resp "go-form-hub/internal/services/service_response"
 
 	"github.com/go-playground/validator/v10"
@@ -17,15 +24,31 @@ type FormPassageUseCase interface {
 	FormPass(ctx context.Context, formPassage *model.FormPassage) (*resp.Response, error)
 }
 
+// GrantChecker verifies access grants of password-protected forms, api.HashToken implements it.
+type GrantChecker interface {
+	Check(subject, token string) (bool, error)
+}
+
 type formPasageUseCase struct {
-	formRepository repository.FormRepository
-	validate       *validator.Validate
+	formRepository       repository.FormRepository
+	recipientRepository  repository.RecipientRepository
+	userRepository       repository.UserRepository
+	grants               GrantChecker
+	secret               []byte
+	fingerprintRetention time.Duration
+	validate             *validator.Validate
 }
 
-func NewformPasageUseCase(formRepository repository.FormRepository, validate *validator.Validate) FormPassageUseCase {
+func NewformPasageUseCase(formRepository repository.FormRepository, recipientRepository repository.RecipientRepository,
+	userRepository repository.UserRepository, grants GrantChecker, secret []byte, fingerprintRetention time.Duration, validate *validator.Validate) FormPassageUseCase {
 	return &formPasageUseCase{
-		formRepository: formRepository,
-		validate:       validate,
+		formRepository:       formRepository,
+		recipientRepository:  recipientRepository,
+		userRepository:       userRepository,
+		grants:               grants,
+		secret:               secret,
+		fingerprintRetention: fingerprintRetention,
+		validate:             validate,
 	}
 }
 
@@ -45,35 +68,146 @@ func (s *formPasageUseCase) FormPass(ctx context.Context, formPassage *model.For
 		return resp.NewResponse(http.StatusNotFound, nil), nil
 	}
 
-	if !existingForm.Anonymous {
-		value := ctx.Value(model.ContextCurrentUser)
-		if value == nil {
+	if formPassage.IdempotencyKey != "" {
+		formPassage.IdempotencyHash = s.idempotencyHash(ctx, formPassage)
+
+		// a replay is answered before the checks, a used up invitation or limit must not fail the retry
+		replayed, err := s.formRepository.FormPassageReplayed(ctx, *existingForm.ID, formPassage.IdempotencyKey, formPassage.IdempotencyHash)
+		if errors.Is(err, repository.ErrIdempotencyKeyReused) {
+			return resp.NewResponse(http.StatusConflict, nil), nil
+		}
+		if err != nil {
+			return resp.NewResponse(http.StatusInternalServerError, nil), err
+		}
+
+		if replayed {
+			return resp.NewResponse(http.StatusNoContent, nil), nil
+		}
+	}
+
+	if existingForm.ClosedAt != nil {
+		return resp.NewResponse(http.StatusForbidden, nil), nil
+	}
+
+	if existingForm.PasswordProtected && !s.isAuthor(ctx, existingForm) {
+		valid, err := s.grants.Check(existingForm.AccessGrantSubject(), formPassage.AccessGrant)
+		if err != nil || !valid {
+			return resp.NewResponse(http.StatusForbidden, nil), nil
+		}
+	}
+
+	if existingForm.InviteOnly {
+		// the invitation replaces the account check, the passage is attributed to the recipient and
+		// to a logged in respondent unless the form is anonymous
+		if formPassage.AccessToken == "" {
+			return resp.NewResponse(http.StatusForbidden, nil), nil
+		}
+
+		recipient, err := s.recipientRepository.FindByToken(ctx, *existingForm.ID, formPassage.AccessToken)
+		if err != nil {
+			return resp.NewResponse(http.StatusInternalServerError, nil), err
+		}
+
+		if recipient == nil || recipient.Uses >= recipient.MaxUses {
+			return resp.NewResponse(http.StatusForbidden, nil), nil
+		}
+		formPassage.RecipientID = recipient.ID
+
+		if currentUser, ok := ctx.Value(model.ContextCurrentUser).(*model.UserGet); ok && !existingForm.Anonymous {
+			userID = int(currentUser.ID)
+		}
+	} else if !existingForm.Anonymous {
+		currentUser, ok := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
+		if !ok || currentUser.ID == model.AnonUserID {
 			return resp.NewResponse(http.StatusUnauthorized, nil), nil
 		}
 
-		currentUser := value.(*model.UserGet)
+		// passage_max is checked by FormPassageSave under a lock, counting here would race with parallel submissions
 		userID = int(currentUser.ID)
+	} else if existingForm.PassageMax != noLimit {
+		// anonymous passages are not linked to the respondent, so the repository counts them by marks
+		formPassage.RespondentMarks = s.respondentMarks(ctx, existingForm, formPassage)
+		formPassage.PassageMax = existingForm.PassageMax
+	}
+
+	if existingForm.VerifiedOnly {
+		// an invitation does not replace the verified email
+		currentUser, ok := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
+		if !ok || currentUser.ID == model.AnonUserID {
+			return resp.NewResponse(http.StatusUnauthorized, nil), nil
+		}
 
-		totalPassages, err := s.formRepository.UserFormPassageCount(ctx, *existingForm.ID, currentUser.ID)
+		verified, err := s.isEmailVerified(ctx, currentUser.ID)
 		if err != nil {
-			return resp.NewResponse(http.StatusInternalServerError, nil), nil
+			return resp.NewResponse(http.StatusInternalServerError, nil), err
+		}
+
+		if !verified {
+			return resp.NewResponse(http.StatusForbidden, nil), nil
 		}
+	}
 
-		if existingForm.PassageMax != noLimit && totalPassages >= int64(existingForm.PassageMax) {
-			return resp.NewResponse(http.StatusBadRequest, nil), nil
+	if formPassage.StartedAt != nil {
+		// the start time comes from the client, so keep it only when it is plausible
+		if formPassage.StartedAt.Before(existingForm.CreatedAt) || formPassage.StartedAt.After(time.Now().UTC()) {
+			formPassage.StartedAt = nil
 		}
 	}
 
-	var formValidator passageValidator
-	err = formValidator.validateFormPassage(formPassage, existingForm)
+	var formValidator passage.Validator
+	err = formValidator.Validate(formPassage, existingForm)
 	if err != nil {
 		return resp.NewResponse(http.StatusBadRequest, nil), err
 	}
 
 	err = s.formRepository.FormPassageSave(ctx, formPassage, uint64(userID))
+	if errors.Is(err, repository.ErrPassageReplayed) {
+		// a concurrent request with the same key saved the passage first
+		return resp.NewResponse(http.StatusNoContent, nil), nil
+	}
+	if errors.Is(err, repository.ErrIdempotencyKeyReused) {
+		return resp.NewResponse(http.StatusConflict, nil), nil
+	}
+	if errors.Is(err, repository.ErrRecipientUsedUp) {
+		return resp.NewResponse(http.StatusForbidden, nil), nil
+	}
+	if errors.Is(err, repository.ErrPassageLimitReached) {
+		return resp.NewResponse(http.StatusBadRequest, nil), nil
+	}
 	if err != nil {
 		return resp.NewResponse(http.StatusInternalServerError, nil), err
 	}
 
 	return resp.NewResponse(http.StatusNoContent, nil), nil
 }
+
+// idempotencyHash covers the account, the invitation and the answers of a passage, so that a key
+// sent again by another respondent or with other answers is told apart from a retry. It is keyed
+// with the server secret, it would otherwise link passages of anonymous forms to accounts.
+func (s *formPasageUseCase) idempotencyHash(ctx context.Context, formPassage *model.FormPassage) string {
+	userID := int64(model.AnonUserID)
+	if currentUser, ok := ctx.Value(model.ContextCurrentUser).(*model.UserGet); ok {
+		userID = currentUser.ID
+	}
+
+	h := hmac.New(sha256.New, s.secret)
+	fmt.Fprintf(h, "%d\n%q\n", userID, formPassage.AccessToken)
+	for _, answer := range formPassage.PassageAnswers {
+		fmt.Fprintf(h, "%d:%q\n", *answer.QuestionID, answer.Text)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (s *formPasageUseCase) isAuthor(ctx context.Context, form *model.Form) bool {
+	currentUser, ok := ctx.Value(model.ContextCurrentUser).(*model.UserGet)
+	return ok && currentUser.ID != model.AnonUserID && form.Author.ID == currentUser.ID
+}
+
+func (s *formPasageUseCase) isEmailVerified(ctx context.Context, userID int64) (bool, error) {
+	user, err := s.userRepository.FindByID(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+
+	return user != nil && user.EmailVerifiedAt != nil, nil
+}
@@ -0,0 +1,71 @@
+package usecase
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"go-form-hub/internal/model"
+	"go-form-hub/internal/repository"
+
+	"github.com/rs/zerolog/log"
+)
+
+// respondentMarks recognizes the respondent of an anonymous form by whatever the gateway sent:
+// the respondent cookie, the client fingerprint and the account of a logged in respondent.
+// Only hashes are stored, the passage itself stays anonymous.
+func (s *formPasageUseCase) respondentMarks(ctx context.Context, form *model.Form, formPassage *model.FormPassage) []*model.RespondentMark {
+	marks := make([]*model.RespondentMark, 0, 3)
+
+	if formPassage.RespondentID != "" {
+		marks = append(marks, s.newRespondentMark(*form.ID, model.RespondentMarkCookie, formPassage.RespondentID))
+	}
+
+	if formPassage.Fingerprint != "" {
+		mark := s.newRespondentMark(*form.ID, model.RespondentMarkFingerprint, formPassage.Fingerprint)
+		// fingerprints are shared by clients behind one address, so they only count recent passages
+		mark.ForgetBefore = time.Now().UTC().Add(-s.fingerprintRetention)
+		marks = append(marks, mark)
+	}
+
+	if currentUser, ok := ctx.Value(model.ContextCurrentUser).(*model.UserGet); ok && currentUser.ID != model.AnonUserID {
+		marks = append(marks, s.newRespondentMark(*form.ID, model.RespondentMarkUser, fmt.Sprint(currentUser.ID)))
+	}
+
+	return marks
+}
+
+// newRespondentMark keys the mark with an HMAC of the server secret, user IDs are few enough
+// to be found from a plain hash by trying them all.
+func (s *formPasageUseCase) newRespondentMark(formID int64, kind, value string) *model.RespondentMark {
+	h := hmac.New(sha256.New, s.secret)
+	h.Write([]byte(fmt.Sprintf("%d:%s:%s", formID, kind, value)))
+	return &model.RespondentMark{
+		Kind:    kind,
+		KeyHash: hex.EncodeToString(h.Sum(nil)),
+	}
+}
+
+// PurgeFingerprints deletes the fingerprints older than retention every interval until ctx is done.
+func PurgeFingerprints(ctx context.Context, formRepository repository.FormRepository, retention, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		deleted, err := formRepository.PurgeRespondentMarks(ctx, model.RespondentMarkFingerprint, time.Now().UTC().Add(-retention))
+		if err != nil {
+			log.Error().Msgf("passage_usecase purge_fingerprints error: %v", err)
+		} else if deleted > 0 {
+			log.Info().Msgf("passage_usecase purged %d fingerprints", deleted)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -10,6 +10,7 @@ import (
 	"go-form-hub/internal/config"
 	"go-form-hub/internal/database"
 	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/password"
 	"go-form-hub/microservices/user/controller"
 	"go-form-hub/microservices/user/profile"
 	"go-form-hub/microservices/user/usecase"
@@ -44,8 +45,10 @@ func main() {
 
 	validate := validator.New()
 
+	hasher := password.NewHasher(password.DefaultParams, cfg.EncryptionKey)
+
 	userRepository := repository.NewUserDatabaseRepository(db, builder)
-	userService := usecase.NewUserUseCase(userRepository, cfg, validate)
+	userService := usecase.NewUserUseCase(userRepository, hasher, validate)
 	userController := controller.NewProfileController(userService, validate)
 
 	lis, err := net.Listen("tcp", defaultPort) // #nosec G102
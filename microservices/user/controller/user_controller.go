@@ -39,12 +39,13 @@ func (pm *ProfileController) UserGet(ctx context.Context, userID *profile.Curren
 		userGet.Avatar = &empty
 	}
 	userMsg := &profile.User{
-		Email:     userGet.Email,
-		FirstName: userGet.FirstName,
-		LastName:  userGet.LastName,
-		Username:  userGet.Username,
-		Id:        userGet.ID,
-		Avatar:    *userGet.Avatar,
+		Email:         userGet.Email,
+		FirstName:     userGet.FirstName,
+		LastName:      userGet.LastName,
+		Username:      userGet.Username,
+		Id:            userGet.ID,
+		Avatar:        *userGet.Avatar,
+		EmailVerified: userGet.EmailVerified != nil && *userGet.EmailVerified,
 	}
 	body, err := anypb.New(userMsg)
 	if err != nil {
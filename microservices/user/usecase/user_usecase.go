@@ -2,18 +2,13 @@ package usecase
 
 import (
 	"context"
-	"crypto/aes"
-	"crypto/cipher"
-	"crypto/md5" // nolint:gosec
-	"encoding/hex"
 	"errors"
 	"fmt"
-	"io"
 	"net/http"
 
-	"go-form-hub/internal/config"
 	"go-form-hub/internal/model"
 	"go-form-hub/internal/repository"
+	"go-form-hub/internal/services/password"
 	resp "go-form-hub/internal/services/service_response"
 
 	validator "github.com/go-playground/validator/v10"
@@ -34,53 +29,21 @@ var (
 
 type userUseCase struct {
 	userRepository repository.UserRepository
-	cfg            *config.Config
+	hasher         *password.Hasher
 	validate       *validator.Validate
 	sanitizer      *bluemonday.Policy
 }
 
-func NewUserUseCase(userRepository repository.UserRepository, cfg *config.Config, validate *validator.Validate) UserUseCase {
+func NewUserUseCase(userRepository repository.UserRepository, hasher *password.Hasher, validate *validator.Validate) UserUseCase {
 	sanitizer := bluemonday.UGCPolicy()
 	return &userUseCase{
 		userRepository: userRepository,
-		cfg:            cfg,
+		hasher:         hasher,
 		validate:       validate,
 		sanitizer:      sanitizer,
 	}
 }
 
-func (s *userUseCase) encryptPassword(pass string) (string, error) {
-	keyBytes, err := hex.DecodeString(s.cfg.EncryptionKey)
-	if err != nil {
-		return "", fmt.Errorf("encrypt_password invalid hex-encoded key: %v", err)
-	}
-
-	if len(keyBytes) != 32 {
-		return "", fmt.Errorf("invalid key length: expected 32 bytes, got %d", len(keyBytes))
-	}
-
-	block, err := aes.NewCipher(keyBytes)
-	if err != nil {
-		return "", err
-	}
-
-	hasher := md5.New() // nolint:gosec
-	_, err = io.WriteString(hasher, pass)
-	if err != nil {
-		return "", err
-	}
-	nonce := hasher.Sum(nil)[:12]
-
-	aesgcm, err := cipher.NewGCM(block)
-	if err != nil {
-		return "", err
-	}
-
-	ciphertext := aesgcm.Seal(nil, nonce, []byte(pass), nil)
-
-	return hex.EncodeToString(nonce) + hex.EncodeToString(ciphertext), nil
-}
-
 func (s *userUseCase) UserList(ctx context.Context) (*resp.Response, error) {
 	var response model.UserList
 	response.Users = make([]*model.UserGet, 0)
@@ -116,13 +79,15 @@ func (s *userUseCase) UserGet(ctx context.Context, id int64) (*resp.Response, er
 		return resp.NewResponse(http.StatusNotFound, nil), ErrCouldntFindUser
 	}
 
+	emailVerified := user.EmailVerifiedAt != nil
 	modelUser := &model.UserGet{
-		ID:        user.ID,
-		Username:  user.Username,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Email:     user.Email,
-		Avatar:    user.Avatar,
+		ID:            user.ID,
+		Username:      user.Username,
+		FirstName:     user.FirstName,
+		LastName:      user.LastName,
+		Email:         user.Email,
+		Avatar:        user.Avatar,
+		EmailVerified: &emailVerified,
 	}
 
 	modelUser.Sanitize(s.sanitizer)
@@ -181,32 +146,46 @@ func (s *userUseCase) UserUpdate(ctx context.Context, user *model.UserUpdate) (*
 		return resp.NewResponse(http.StatusNotFound, nil), ErrCouldntFindUser
 	}
 
+	passwordHash := existing.Password
 	if user.Username != existing.Username || user.Email != existing.Email || user.NewPassword != "" {
-		encPassword, err := s.encryptPassword(user.Password)
+		ok, rehash, err := s.hasher.Verify(user.Password, existing.Password)
 		if err != nil {
 			return resp.NewResponse(http.StatusInternalServerError, nil), err
 		}
 
-		if existing.Password != encPassword {
+		if !ok {
 			return resp.NewResponse(http.StatusForbidden, nil), fmt.Errorf("invalid password")
 		}
+
+		if rehash {
+			passwordHash, err = s.hasher.Hash(user.Password)
+			if err != nil {
+				return resp.NewResponse(http.StatusInternalServerError, nil), err
+			}
+		}
 	}
 
-	encNewPassword, err := s.encryptPassword(user.NewPassword)
-	if err != nil {
-		return resp.NewResponse(http.StatusInternalServerError, nil), err
+	if user.NewPassword != "" {
+		passwordHash, err = s.hasher.Hash(user.NewPassword)
+		if err != nil {
+			return resp.NewResponse(http.StatusInternalServerError, nil), err
+		}
 	}
-	if user.NewPassword == "" {
-		encNewPassword = existing.Password
+
+	// a new email has to be verified again
+	emailVerifiedAt := existing.EmailVerifiedAt
+	if user.Email != existing.Email {
+		emailVerifiedAt = nil
 	}
 
 	err = s.userRepository.Update(ctx, existing.ID, &repository.User{
-		Username:  user.Username,
-		FirstName: user.FirstName,
-		LastName:  user.LastName,
-		Password:  encNewPassword,
-		Email:     user.Email,
-		Avatar:    user.Avatar,
+		Username:        user.Username,
+		FirstName:       user.FirstName,
+		LastName:        user.LastName,
+		Password:        passwordHash,
+		Email:           user.Email,
+		Avatar:          user.Avatar,
+		EmailVerifiedAt: emailVerifiedAt,
 	})
 	if err != nil {
 		return resp.NewResponse(http.StatusInternalServerError, nil), err